@@ -0,0 +1,8 @@
+// Package config embeds the shipped PII config, which the service falls back
+// to when config/regexpii.json is missing from the working directory.
+package config
+
+import _ "embed"
+
+//go:embed regexpii.json
+var DefaultPIIConfig []byte
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry records an administrative action taken on stored data.
+type AuditEntry struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty"`
+	Action    string                 `bson:"action"`
+	Actor     string                 `bson:"actor"`
+	Details   map[string]interface{} `bson:"details,omitempty"`
+	Timestamp time.Time              `bson:"timestamp"`
+}
+
+func (mi *MongoInstance) SaveAuditEntry(ctx context.Context, entry AuditEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := mi.InsertOne(ctx, "audit_log", entry); err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	return nil
+}
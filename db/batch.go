@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	batchRetryAttempts = 3
+	batchRetryBackoff  = 200 * time.Millisecond
+)
+
+// BatchResult summarizes a batch insert.
+type BatchResult struct {
+	Inserted     int `json:"inserted"`
+	Failed       int `json:"failed"`
+	DeadLettered int `json:"dead_lettered"`
+	// FailedIndexes are the positions in the batch of the documents that
+	// were not stored.
+	FailedIndexes []int `json:"-"`
+}
+
+// transientWriteCodes are server error codes worth retrying: interrupted
+// operations, elections and exceeded time limits.
+var transientWriteCodes = map[int]bool{
+	6: true, 7: true, 50: true, 89: true, 91: true, 189: true, 262: true,
+	9001: true, 10107: true, 11600: true, 11602: true, 13435: true, 13436: true,
+}
+
+// SaveUserAPIDataBatch inserts documents unordered, so one bad document does
+// not stop the rest. Documents that fail transiently are retried one at a time
+// with backoff; documents that still fail, or fail permanently, are written to
+// the dead-letter collection.
+func (mi *MongoInstance) SaveUserAPIDataBatch(ctx context.Context, batch []UserAPIData) (BatchResult, error) {
+	var result BatchResult
+	if len(batch) == 0 {
+		return result, nil
+	}
+	docs := make([]interface{}, len(batch))
+	for i := range batch {
+		if batch[i].Timestamp.IsZero() {
+			batch[i].Timestamp = time.Now()
+		}
+		batch[i].Timestamp = batch[i].Timestamp.UTC()
+		// Ids are assigned up front so a retried document that was in fact
+		// inserted shows up as a duplicate key rather than a second copy.
+		if batch[i].ID.IsZero() {
+			batch[i].ID = primitive.NewObjectID()
+		}
+		docs[i] = batch[i]
+	}
+
+	collection := mi.GetCollection("user_api_data")
+	insertCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	res, err := collection.InsertMany(insertCtx, docs, options.InsertMany().SetOrdered(false))
+	cancel()
+	if res != nil {
+		result.Inserted = len(res.InsertedIDs)
+	}
+	if err == nil {
+		return result, nil
+	}
+
+	var retry []int
+	permanent := map[int]error{}
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		result.Inserted, retry, permanent = classifyWriteErrors(len(batch), bulkErr.WriteErrors)
+	} else if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		// Which documents made it is unknown; retrying each is safe because
+		// a document already inserted fails with a duplicate key.
+		result.Inserted = 0
+		for i := range batch {
+			retry = append(retry, i)
+		}
+	} else {
+		return result, fmt.Errorf("failed to insert API data batch: %w", err)
+	}
+
+	for _, i := range retry {
+		if err := mi.insertWithRetry(ctx, collection, batch[i]); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				result.Inserted++
+				continue
+			}
+			permanent[i] = err
+			continue
+		}
+		result.Inserted++
+	}
+
+	for i, insertErr := range permanent {
+		result.Failed++
+		result.FailedIndexes = append(result.FailedIndexes, i)
+		payload, err := json.Marshal(batch[i])
+		if err != nil {
+			log.Printf("Failed to encode batch document %d for dead-lettering: %v", i, err)
+			continue
+		}
+		deadLetter := DeadLetter{Source: "batch_insert", Payload: string(payload), Error: insertErr.Error()}
+		if err := mi.SaveDeadLetter(ctx, deadLetter); err != nil {
+			log.Printf("Failed to dead-letter batch document %d: %v", i, err)
+			continue
+		}
+		result.DeadLettered++
+	}
+	sort.Ints(result.FailedIndexes)
+	log.Printf("Batch insert: %d inserted, %d failed, %d dead-lettered", result.Inserted, result.Failed, result.DeadLettered)
+	return result, nil
+}
+
+// classifyWriteErrors sorts the write errors of an unordered insert of n
+// documents, which reports every failed document; the rest went in. A
+// duplicate key means the document is already stored, e.g. by a replay, so it
+// counts as inserted. Transient errors are retried and the others are final.
+func classifyWriteErrors(n int, writeErrors []mongo.BulkWriteError) (inserted int, retry []int, permanent map[int]error) {
+	inserted = n
+	permanent = map[int]error{}
+	for _, we := range writeErrors {
+		switch {
+		case isDuplicateKeyCode(we.Code):
+		case transientWriteCodes[we.Code]:
+			inserted--
+			retry = append(retry, we.Index)
+		default:
+			inserted--
+			permanent[we.Index] = we
+		}
+	}
+	return inserted, retry, permanent
+}
+
+// isDuplicateKeyCode reports whether code is one of the server's duplicate
+// key error codes, as checked by mongo.IsDuplicateKeyError.
+func isDuplicateKeyCode(code int) bool {
+	return code == 11000 || code == 11001 || code == 12582
+}
+
+func (mi *MongoInstance) insertWithRetry(ctx context.Context, collection *mongo.Collection, doc UserAPIData) error {
+	backoff := batchRetryBackoff
+	var err error
+	for attempt := 1; attempt <= batchRetryAttempts; attempt++ {
+		insertCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		_, err = collection.InsertOne(insertCtx, doc)
+		cancel()
+		if err == nil || mongo.IsDuplicateKeyError(err) || !isTransient(err) {
+			return err
+		}
+		if attempt < batchRetryAttempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+func isTransient(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var we mongo.WriteException
+	if errors.As(err, &we) {
+		for _, writeErr := range we.WriteErrors {
+			if transientWriteCodes[writeErr.Code] {
+				return true
+			}
+		}
+	}
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		for code := range transientWriteCodes {
+			if se.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
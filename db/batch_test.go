@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSaveUserAPIDataBatch(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		want      BatchResult
+	}{
+		{
+			name:      "all inserted",
+			responses: []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 10})},
+			want:      BatchResult{Inserted: 10},
+		},
+		{
+			name: "one poison document",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 4, Code: 2, Message: "poison document"}),
+				mtest.CreateSuccessResponse(), // dead letter
+			},
+			want: BatchResult{Inserted: 9, Failed: 1, DeadLettered: 1, FailedIndexes: []int{4}},
+		},
+		{
+			name: "already stored document",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 7, Code: 11000, Message: "duplicate key"}),
+			},
+			want: BatchResult{Inserted: 10},
+		},
+		{
+			name: "transient failure retried",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 2, Code: 91, Message: "shutdown in progress"}),
+				mtest.CreateSuccessResponse(), // retry
+			},
+			want: BatchResult{Inserted: 10},
+		},
+		{
+			name: "transient failure then duplicate on retry",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 2, Code: 91, Message: "shutdown in progress"}),
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}),
+			},
+			want: BatchResult{Inserted: 10},
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+			mi := &MongoInstance{Client: mt.Client, DB: mt.DB}
+			batch := make([]UserAPIData, 10)
+			for i := range batch {
+				batch[i] = UserAPIData{APIEndpoint: "/api/users", Method: "GET"}
+			}
+			got, err := mi.SaveUserAPIDataBatch(context.Background(), batch)
+			if err != nil {
+				mt.Fatalf("SaveUserAPIDataBatch() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				mt.Errorf("SaveUserAPIDataBatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
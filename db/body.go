@@ -0,0 +1,38 @@
+package db
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PlainBody converts the BSON documents and arrays a stored body decodes to
+// into maps and slices, which encode as regular JSON objects and arrays.
+func PlainBody(body interface{}) interface{} {
+	switch v := body.(type) {
+	case primitive.D:
+		m := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			m[e.Key] = PlainBody(e.Value)
+		}
+		return m
+	case primitive.M:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = PlainBody(item)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = PlainBody(item)
+		}
+		return m
+	case primitive.A:
+		return PlainBody([]interface{}(v))
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = PlainBody(item)
+		}
+		return items
+	default:
+		return body
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CompressBodies gzips the request and response bodies of a document in place
+// and sets BodyCompressed. Bodies are JSON-encoded first so both string and
+// structured bodies come back with their original shape.
+func (d *UserAPIData) CompressBodies() error {
+	if d.BodyCompressed {
+		return nil
+	}
+	requestBody, err := compressBody(d.RequestBody)
+	if err != nil {
+		return fmt.Errorf("failed to compress request body: %w", err)
+	}
+	responseBody, err := compressBody(d.ResponseBody)
+	if err != nil {
+		return fmt.Errorf("failed to compress response body: %w", err)
+	}
+	d.RequestBody, d.ResponseBody = requestBody, responseBody
+	d.BodyCompressed = true
+	return nil
+}
+
+// DecompressBodies reverses CompressBodies.
+func (d *UserAPIData) DecompressBodies() error {
+	if !d.BodyCompressed {
+		return nil
+	}
+	requestBody, err := DecompressBody(d.RequestBody)
+	if err != nil {
+		return fmt.Errorf("failed to decompress request body: %w", err)
+	}
+	responseBody, err := DecompressBody(d.ResponseBody)
+	if err != nil {
+		return fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	d.RequestBody, d.ResponseBody = requestBody, responseBody
+	d.BodyCompressed = false
+	return nil
+}
+
+func compressBody(body interface{}) (interface{}, error) {
+	if body == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(encoded); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressBody decodes a body stored by CompressBodies, as read back from
+// MongoDB into an interface{} field.
+func DecompressBody(stored interface{}) (interface{}, error) {
+	var compressed []byte
+	switch v := stored.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		compressed = v
+	case primitive.Binary:
+		compressed = v.Data
+	default:
+		return nil, fmt.Errorf("unexpected compressed body type %T", stored)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	encoded, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	var body interface{}
+	if err := json.Unmarshal(encoded, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
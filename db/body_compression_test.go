@@ -0,0 +1,58 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompressBodiesRoundTrip(t *testing.T) {
+	users := make([]interface{}, 5000)
+	for i := range users {
+		users[i] = map[string]interface{}{
+			"email": fmt.Sprintf("user%d@example.com", i),
+			"phone": fmt.Sprintf("+1 415 555 %04d", i),
+			"tags":  []interface{}{"a", "b"},
+		}
+	}
+	requestBody := map[string]interface{}{"users": users}
+	responseBody := strings.Repeat("line of plain text with user@example.com\n", 50000)
+	doc := UserAPIData{APIEndpoint: "/api/users", RequestBody: requestBody, ResponseBody: responseBody}
+
+	if err := doc.CompressBodies(); err != nil {
+		t.Fatalf("CompressBodies: %v", err)
+	}
+	if !doc.BodyCompressed {
+		t.Fatal("BodyCompressed = false after CompressBodies")
+	}
+	compressed, ok := doc.ResponseBody.([]byte)
+	if !ok || len(compressed) >= len(responseBody)/10 {
+		t.Fatalf("compressed response body is %T of %d bytes, want well under %d", doc.ResponseBody, len(compressed), len(responseBody))
+	}
+
+	// Store and read back the document as MongoDB would, so bodies come back
+	// as primitive.Binary.
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("bson.Marshal: %v", err)
+	}
+	var stored UserAPIData
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		t.Fatalf("bson.Unmarshal: %v", err)
+	}
+	if err := stored.DecompressBodies(); err != nil {
+		t.Fatalf("DecompressBodies: %v", err)
+	}
+	if stored.BodyCompressed {
+		t.Error("BodyCompressed = true after DecompressBodies")
+	}
+	if !reflect.DeepEqual(stored.RequestBody, requestBody) {
+		t.Error("request body changed in the round trip")
+	}
+	if stored.ResponseBody != responseBody {
+		t.Errorf("response body is %d bytes after the round trip, want %d", len(fmt.Sprint(stored.ResponseBody)), len(responseBody))
+	}
+}
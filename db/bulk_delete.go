@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CountUserAPIData counts the documents matching filter.
+func (mi *MongoInstance) CountUserAPIData(ctx context.Context, filter bson.M) (int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count API data: %w", err)
+	}
+	return count, nil
+}
+
+// SoftDeleteUserAPIDataMatching marks every live document matching filter
+// deleted, leaving them restorable until the TTL index purges them.
+func (mi *MongoInstance) SoftDeleteUserAPIDataMatching(ctx context.Context, filter bson.M) (int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	result, err := collection.UpdateMany(ctx, ExcludeDeleted(filter), bson.M{"$set": bson.M{"deleted_at": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to soft-delete API data: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteUserAPIDataMatching permanently removes every document matching filter.
+func (mi *MongoInstance) DeleteUserAPIDataMatching(ctx context.Context, filter bson.M) (int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete API data: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetPIIComplianceStatsRiskLevels(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name                   string
+		critical, high         string
+		wantCritical, wantHigh string // level counted, "" for none
+	}{
+		{name: "custom levels", critical: "SEVERE", high: "ELEVATED", wantCritical: "SEVERE", wantHigh: "ELEVATED"},
+		{name: "single level", critical: "SEVERE", wantCritical: "SEVERE"},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, bson.D{
+				{Key: "total_apis", Value: int32(10)},
+				{Key: "apis_with_pii", Value: int32(4)},
+				{Key: "critical_risk_apis", Value: int32(1)},
+				{Key: "high_risk_apis", Value: int32(2)},
+			}))
+			mi := &MongoInstance{Client: mt.Client, DB: mt.DB}
+			stats, err := mi.GetPIIComplianceStats(tt.critical, tt.high)
+			if err != nil {
+				mt.Fatalf("GetPIIComplianceStats: %v", err)
+			}
+			if stats["compliance_percentage"] != float64(60) {
+				mt.Errorf("compliance_percentage = %v, want 60", stats["compliance_percentage"])
+			}
+
+			event := mt.GetStartedEvent()
+			if event == nil || event.CommandName != "aggregate" {
+				mt.Fatalf("sent %v, want an aggregate", event)
+			}
+			var command struct {
+				Pipeline []bson.M `bson:"pipeline"`
+			}
+			if err := bson.Unmarshal(event.Command, &command); err != nil {
+				mt.Fatal(err)
+			}
+			group := command.Pipeline[1]["$group"].(bson.M)
+			for field, want := range map[string]string{"critical_risk_apis": tt.wantCritical, "high_risk_apis": tt.wantHigh} {
+				if got := countedRiskLevel(group[field].(bson.M)); got != want {
+					mt.Errorf("%s counts level %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+// countedRiskLevel returns the level a countRiskLevel accumulator counts, or
+// "" for one counting nothing.
+func countedRiskLevel(accumulator bson.M) string {
+	cond, ok := accumulator["$sum"].(bson.M)
+	if !ok {
+		return ""
+	}
+	eq := cond["$cond"].(bson.M)["if"].(bson.M)["$eq"].(bson.A)
+	return eq[1].(string)
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateConfigVersion is returned when a config version's name is already taken.
+var ErrDuplicateConfigVersion = errors.New("a config version with this name already exists")
+
+// ConfigVersion is a named snapshot of a PII config, kept for comparisons.
+// Config holds the config JSON as text, since pattern names aren't safe
+// document keys.
+type ConfigVersion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Config      string             `bson:"config,omitempty" json:"-"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func (mi *MongoInstance) CreateConfigVersion(ctx context.Context, version ConfigVersion) (ConfigVersion, error) {
+	collection := mi.GetCollection("config_versions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	version.ID = primitive.NewObjectID()
+	version.CreatedAt = time.Now()
+	if _, err := collection.InsertOne(ctx, version); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ConfigVersion{}, ErrDuplicateConfigVersion
+		}
+		return ConfigVersion{}, fmt.Errorf("failed to insert config version: %w", err)
+	}
+	return version, nil
+}
+
+// ListConfigVersions returns the stored versions newest first, without their configs.
+func (mi *MongoInstance) ListConfigVersions(ctx context.Context) ([]ConfigVersion, error) {
+	collection := mi.GetCollection("config_versions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"config": 0})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config versions: %w", err)
+	}
+	defer cursor.Close(ctx)
+	versions := []ConfigVersion{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("failed to decode config versions: %w", err)
+	}
+	return versions, nil
+}
+
+// FindConfigVersion returns the named version. The error wraps
+// mongo.ErrNoDocuments when there is none.
+func (mi *MongoInstance) FindConfigVersion(ctx context.Context, name string) (ConfigVersion, error) {
+	collection := mi.GetCollection("config_versions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var version ConfigVersion
+	if err := collection.FindOne(ctx, bson.M{"name": name}).Decode(&version); err != nil {
+		return ConfigVersion{}, fmt.Errorf("failed to find config version '%s': %w", name, err)
+	}
+	return version, nil
+}
+
+func (mi *MongoInstance) DeleteConfigVersion(ctx context.Context, name string) (bool, error) {
+	collection := mi.GetCollection("config_versions")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	result, err := collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete config version: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -26,6 +27,7 @@ func ConnectDB() (MongoInstance, error) {
 	if err != nil {
 		log.Println("Warning: No .env file found. Using system environment variables.")
 	}
+	loadDebugLogging()
 
 	// Get DATABASE_URL from environment
 	mongoURI := os.Getenv("DATABASE_URL")
@@ -69,8 +71,7 @@ func ConnectDB() (MongoInstance, error) {
 		DB:     db,
 	}
 	// Create indexes (optional)
-	err = mi.setupIndexes(ctx)
-	if err != nil {
+	if _, err := mi.EnsureIndexes(ctx); err != nil {
 		log.Printf("Error setting up indexes: %v", err)
 	}
 
@@ -82,8 +83,11 @@ func (mi *MongoInstance) GetCollection(collectionName string) *mongo.Collection
 	return mi.DB.Collection(collectionName)
 }
 
-// setupIndexes creates necessary indexes
-func (mi *MongoInstance) setupIndexes(ctx context.Context) error {
+// EnsureIndexes creates the indexes RAVEN relies on and reports, per index,
+// whether it was created or already present. It carries on past failures,
+// such as an existing index whose options changed, and returns them joined.
+func (mi *MongoInstance) EnsureIndexes(ctx context.Context) ([]IndexResult, error) {
+	setup := &indexSetup{existing: map[string]map[string]bool{}}
 	collection := mi.GetCollection("user_api_data")
 	indexModel := mongo.IndexModel{
 		Keys: bson.D{ // Corrected: Use keyed fields
@@ -92,12 +96,86 @@ func (mi *MongoInstance) setupIndexes(ctx context.Context) error {
 		}, // Index on APIEndpoint and Timestamp
 		Options: nil,
 	}
-	_, err := collection.Indexes().CreateOne(ctx, indexModel)
-	if err != nil {
-		return fmt.Errorf("failed to create index: %w", err)
+	setup.ensure(ctx, collection, indexModel)
+
+	// Serves the has_pii/highest_risk filters of the log listing, which sorts by timestamp.
+	riskIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "has_pii", Value: 1},
+			{Key: "highest_risk", Value: 1},
+			{Key: "timestamp", Value: -1},
+		},
+		Options: options.Index().SetName("has_pii_highest_risk_timestamp"),
 	}
-	log.Println("Created index on api_endpoint and timestamp")
-	return nil
+	setup.ensure(ctx, collection, riskIndex)
+
+	patternIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "mode", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("pii_patterns"), patternIndex)
+
+	offsetIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "group_id", Value: 1}, {Key: "topic", Value: 1}, {Key: "partition", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("kafka_offsets"), offsetIndex)
+
+	ownerIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "pattern", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("endpoint_owners"), ownerIndex)
+
+	configVersionIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("config_versions"), configVersionIndex)
+
+	baselineIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "api_endpoint", Value: 1}, {Key: "method", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("finding_baselines"), baselineIndex)
+
+	// Serves the owner filter of the log listing and the owner rollup.
+	ownerTimestampIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "owner", Value: 1},
+			{Key: "timestamp", Value: -1},
+		},
+	}
+	setup.ensure(ctx, collection, ownerTimestampIndex)
+
+	// Serves the finding_value filter of the log listing, which correlates
+	// one masked value across endpoints.
+	findingValueIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "pii_findings.detected_value", Value: 1}},
+	}
+	setup.ensure(ctx, collection, findingValueIndex)
+
+	occurrenceIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "api_endpoint", Value: 1}, {Key: "method", Value: 1}, {Key: "finding_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	setup.ensure(ctx, mi.GetCollection("finding_occurrences"), occurrenceIndex)
+
+	gracePeriod := 30 * 24 * time.Hour
+	if v := os.Getenv("SOFT_DELETE_GRACE_PERIOD"); v != "" {
+		if d, parseErr := time.ParseDuration(v); parseErr == nil {
+			gracePeriod = d
+		} else {
+			log.Printf("Invalid SOFT_DELETE_GRACE_PERIOD '%s', using default %s", v, gracePeriod)
+		}
+	}
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(gracePeriod.Seconds())),
+	}
+	setup.ensure(ctx, collection, ttlIndex)
+	log.Printf("Soft-deleted documents are purged after %s", gracePeriod)
+	return setup.results, errors.Join(setup.errs...)
 }
 
 func (mi *MongoInstance) CloseDB(ctx context.Context) {
@@ -117,4 +195,13 @@ func (mi *MongoInstance) InsertOne(ctx context.Context, collectionName string, d
 		return fmt.Errorf("failed to insert document into collection '%s': %w", collectionName, err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+// Ping checks that the primary is reachable.
+func (mi *MongoInstance) Ping(ctx context.Context) error {
+	if mi.Client == nil {
+		return fmt.Errorf("not connected")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return mi.Client.Ping(ctx, readpref.Primary())
+}
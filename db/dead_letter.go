@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetter records an ingest payload that could not be processed.
+type DeadLetter struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Source    string             `bson:"source"`
+	Payload   string             `bson:"payload"`
+	Error     string             `bson:"error"`
+	Timestamp time.Time          `bson:"timestamp"`
+}
+
+func (mi *MongoInstance) SaveDeadLetter(ctx context.Context, deadLetter DeadLetter) error {
+	if deadLetter.Timestamp.IsZero() {
+		deadLetter.Timestamp = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := mi.InsertOne(ctx, "dead_letters", deadLetter); err != nil {
+		return fmt.Errorf("failed to save dead letter: %w", err)
+	}
+	return nil
+}
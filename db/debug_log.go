@@ -0,0 +1,23 @@
+package db
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// debugLogging enables debugf output. ConnectDB sets it from DEBUG_LOG once
+// .env is loaded.
+var debugLogging bool
+
+// loadDebugLogging reads DEBUG_LOG into debugLogging.
+func loadDebugLogging() {
+	debugLogging, _ = strconv.ParseBool(os.Getenv("DEBUG_LOG"))
+}
+
+// debugf logs routine events that are only of interest while debugging.
+func debugf(format string, args ...interface{}) {
+	if debugLogging {
+		log.Printf("DEBUG: "+format, args...)
+	}
+}
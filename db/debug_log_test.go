@@ -0,0 +1,80 @@
+package db
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectDBLoadsDebugLogging(t *testing.T) {
+	tests := []struct {
+		name   string
+		dotenv string
+		env    string
+		want   bool
+	}{
+		{name: "set in .env", dotenv: "DEBUG_LOG=true\n", want: true},
+		{name: "set in the environment", env: "1", want: true},
+		{name: "environment wins over .env", dotenv: "DEBUG_LOG=true\n", env: "false", want: false},
+		{name: "unset", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.dotenv != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.dotenv), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			chdir(t, dir)
+			// DATABASE_URL is unset so ConnectDB stops after loading the
+			// environment, without dialing.
+			t.Setenv("DATABASE_URL", "")
+			if tt.env != "" {
+				t.Setenv("DEBUG_LOG", tt.env)
+			} else {
+				unsetenv(t, "DEBUG_LOG")
+			}
+			t.Cleanup(func() { debugLogging = false })
+
+			if _, err := ConnectDB(); err == nil {
+				t.Fatal("ConnectDB succeeded without DATABASE_URL")
+			}
+			if debugLogging != tt.want {
+				t.Errorf("debugLogging = %v, want %v", debugLogging, tt.want)
+			}
+
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(io.Discard)
+			debugf("stored %d", 1)
+			if logged := buf.Len() > 0; logged != tt.want {
+				t.Errorf("debugf logged = %v, want %v", logged, tt.want)
+			}
+		})
+	}
+}
+
+// chdir changes into dir for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// unsetenv unsets key for the rest of the test. godotenv does not override
+// variables that are set, even to "", so the .env cases need it gone.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
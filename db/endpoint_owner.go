@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateOwnerPattern is returned when an endpoint glob is already mapped to an owner.
+var ErrDuplicateOwnerPattern = errors.New("an owner mapping for this endpoint pattern already exists")
+
+// EndpointOwner maps an endpoint glob (e.g. "/api/billing/*") to the team that owns it.
+type EndpointOwner struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Pattern   string             `bson:"pattern" json:"pattern"`
+	Owner     string             `bson:"owner" json:"owner"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func (mi *MongoInstance) ListEndpointOwners(ctx context.Context) ([]EndpointOwner, error) {
+	collection := mi.GetCollection("endpoint_owners")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find endpoint owners: %w", err)
+	}
+	defer cursor.Close(ctx)
+	owners := []EndpointOwner{}
+	if err := cursor.All(ctx, &owners); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoint owners: %w", err)
+	}
+	return owners, nil
+}
+
+func (mi *MongoInstance) CreateEndpointOwner(ctx context.Context, owner EndpointOwner) (EndpointOwner, error) {
+	collection := mi.GetCollection("endpoint_owners")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	now := time.Now()
+	owner.ID = primitive.NewObjectID()
+	owner.CreatedAt = now
+	owner.UpdatedAt = now
+	if _, err := collection.InsertOne(ctx, owner); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return EndpointOwner{}, ErrDuplicateOwnerPattern
+		}
+		return EndpointOwner{}, fmt.Errorf("failed to insert endpoint owner: %w", err)
+	}
+	return owner, nil
+}
+
+func (mi *MongoInstance) UpdateEndpointOwner(ctx context.Context, id primitive.ObjectID, owner EndpointOwner) (bool, error) {
+	collection := mi.GetCollection("endpoint_owners")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{
+		"pattern":    owner.Pattern,
+		"owner":      owner.Owner,
+		"updated_at": time.Now(),
+	}}
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, ErrDuplicateOwnerPattern
+		}
+		return false, fmt.Errorf("failed to update endpoint owner: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (mi *MongoInstance) DeleteEndpointOwner(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection := mi.GetCollection("endpoint_owners")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete endpoint owner: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
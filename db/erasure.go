@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EachAPIDataForErasure passes every document, soft-deleted or not, that may
+// hold value to fn in batches of up to batchSize: those matched by
+// subjectFilter, plus those with compressed bodies, which the query cannot
+// search and the caller has to check once decompressed.
+func (mi *MongoInstance) EachAPIDataForErasure(ctx context.Context, value string, maskedForms []string, batchSize int, fn func([]UserAPIData) error) error {
+	collection := mi.GetCollection("user_api_data")
+	filter := bson.M{"$or": []bson.M{
+		subjectFilter(value, maskedForms),
+		{"body_compressed": true},
+	}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetBatchSize(int32(batchSize)))
+	if err != nil {
+		return fmt.Errorf("failed to find API data for erasure: %w", err)
+	}
+	defer cursor.Close(ctx)
+	batch := make([]UserAPIData, 0, batchSize)
+	for cursor.Next(ctx) {
+		var data UserAPIData
+		if err := cursor.Decode(&data); err != nil {
+			return fmt.Errorf("failed to decode API data for erasure: %w", err)
+		}
+		batch = append(batch, data)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]UserAPIData, 0, batchSize)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate API data for erasure: %w", err)
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// FindAPIDataForSubject returns a page of the live documents holding value,
+// matched by subjectFilter, newest first, and the number of
+// matching documents.
+func (mi *MongoInstance) FindAPIDataForSubject(ctx context.Context, value string, maskedForms []string, skip, limit int) ([]UserAPIData, int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	filter := ExcludeDeleted(subjectFilter(value, maskedForms))
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count API data for subject: %w", err)
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find API data for subject: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var results []UserAPIData
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode API data for subject: %w", err)
+	}
+	return results, total, nil
+}
+
+// subjectFilter matches documents with a finding whose masked value is one of
+// maskedForms, or whose URL or string body contains value.
+func subjectFilter(value string, maskedForms []string) bson.M {
+	contains := primitive.Regex{Pattern: regexp.QuoteMeta(value)}
+	return bson.M{"$or": []bson.M{
+		{"pii_findings.detected_value": bson.M{"$in": maskedForms}},
+		{"url": contains},
+		{"request_body": contains},
+		{"response_body": contains},
+	}}
+}
+
+// DeleteUserAPIDataByIDs permanently removes documents.
+func (mi *MongoInstance) DeleteUserAPIDataByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete API data: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// ReplaceUserAPIData overwrites a stored document with data.
+func (mi *MongoInstance) ReplaceUserAPIData(ctx context.Context, data UserAPIData) error {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := collection.ReplaceOne(ctx, bson.M{"_id": data.ID}, data); err != nil {
+		return fmt.Errorf("failed to replace API data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindingBaseline is the rolling average number of findings an endpoint
+// produces per spike check interval. Intervals counts the intervals it
+// averages over, so a fresh baseline can be told from an established one.
+type FindingBaseline struct {
+	APIEndpoint string    `bson:"api_endpoint" json:"api_endpoint"`
+	Method      string    `bson:"method" json:"method"`
+	Rate        float64   `bson:"rate" json:"rate"`
+	Intervals   int       `bson:"intervals" json:"intervals"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// EndpointFindingCount is the number of findings stored for an endpoint in
+// a time window.
+type EndpointFindingCount struct {
+	APIEndpoint string `bson:"api_endpoint"`
+	Method      string `bson:"method"`
+	Findings    int    `bson:"findings"`
+}
+
+// CountFindingsByEndpoint sums the findings of the documents stored for each
+// endpoint with a timestamp in [from, to). Endpoints are normalized like
+// finding occurrences, and endpoints without findings are left out.
+func (mi *MongoInstance) CountFindingsByEndpoint(ctx context.Context, from, to time.Time) ([]EndpointFindingCount, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	pipeline := []bson.M{
+		{"$match": ExcludeDeleted(bson.M{
+			"has_pii":   true,
+			"timestamp": bson.M{"$gte": from, "$lt": to},
+		})},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"api_endpoint": bson.M{"$toLower": "$api_endpoint"},
+				"method":       bson.M{"$toUpper": "$method"},
+			},
+			"findings": bson.M{"$sum": "$pii_count"},
+		}},
+		{"$project": bson.M{
+			"_id":          0,
+			"api_endpoint": "$_id.api_endpoint",
+			"method":       "$_id.method",
+			"findings":     1,
+		}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count findings by endpoint: %w", err)
+	}
+	defer cursor.Close(ctx)
+	counts := []EndpointFindingCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode finding counts: %w", err)
+	}
+	return counts, nil
+}
+
+func (mi *MongoInstance) ListFindingBaselines(ctx context.Context) ([]FindingBaseline, error) {
+	collection := mi.GetCollection("finding_baselines")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find finding baselines: %w", err)
+	}
+	defer cursor.Close(ctx)
+	baselines := []FindingBaseline{}
+	if err := cursor.All(ctx, &baselines); err != nil {
+		return nil, fmt.Errorf("failed to decode finding baselines: %w", err)
+	}
+	return baselines, nil
+}
+
+// SaveFindingBaselines replaces the stored baselines of the given endpoints.
+func (mi *MongoInstance) SaveFindingBaselines(ctx context.Context, baselines []FindingBaseline) error {
+	if len(baselines) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(baselines))
+	for _, b := range baselines {
+		b.APIEndpoint, b.Method = strings.ToLower(b.APIEndpoint), strings.ToUpper(b.Method)
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"api_endpoint": b.APIEndpoint, "method": b.Method}).
+			SetReplacement(b).
+			SetUpsert(true))
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := mi.GetCollection("finding_baselines").BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to save finding baselines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FindingHistory is when a finding was first and last observed on an
+// endpoint, and in how many ingested documents.
+type FindingHistory struct {
+	FirstSeen   time.Time `bson:"first_seen" json:"first_seen"`
+	LastSeen    time.Time `bson:"last_seen" json:"last_seen"`
+	Occurrences int       `bson:"occurrences" json:"occurrences"`
+}
+
+// RecordFindingOccurrences counts one observation of each finding on an
+// endpoint at observedAt. The endpoint is normalized to lower case and the
+// method to upper case, so one finding id has one history per endpoint.
+func (mi *MongoInstance) RecordFindingOccurrences(ctx context.Context, apiEndpoint, method string, observedAt time.Time, findings []PIIFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	apiEndpoint, method = strings.ToLower(apiEndpoint), strings.ToUpper(method)
+	seen := make(map[string]bool, len(findings))
+	var models []mongo.WriteModel
+	for _, finding := range findings {
+		if finding.ID == "" || seen[finding.ID] {
+			continue
+		}
+		seen[finding.ID] = true
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"api_endpoint": apiEndpoint, "method": method, "finding_id": finding.ID}).
+			SetUpdate(bson.M{
+				"$min": bson.M{"first_seen": observedAt},
+				"$max": bson.M{"last_seen": observedAt},
+				"$inc": bson.M{"occurrences": 1},
+				"$set": bson.M{"pii_type": finding.PIIType, "location": finding.Location},
+			}).
+			SetUpsert(true))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := mi.GetCollection("finding_occurrences").BulkWrite(ctx, models); err != nil {
+		return fmt.Errorf("failed to record finding occurrences: %w", err)
+	}
+	return nil
+}
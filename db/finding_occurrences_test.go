@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// occurrenceStore applies the upserts RecordFindingOccurrences sends the way
+// the server would, keyed like the unique index.
+type occurrenceStore map[[3]string]FindingHistory
+
+func (s occurrenceStore) apply(t *testing.T, update bson.Raw) {
+	t.Helper()
+	var op struct {
+		Q struct {
+			Endpoint  string `bson:"api_endpoint"`
+			Method    string `bson:"method"`
+			FindingID string `bson:"finding_id"`
+		} `bson:"q"`
+		U struct {
+			Min struct {
+				FirstSeen time.Time `bson:"first_seen"`
+			} `bson:"$min"`
+			Max struct {
+				LastSeen time.Time `bson:"last_seen"`
+			} `bson:"$max"`
+			Inc struct {
+				Occurrences int `bson:"occurrences"`
+			} `bson:"$inc"`
+		} `bson:"u"`
+		Upsert bool `bson:"upsert"`
+	}
+	if err := bson.Unmarshal(update, &op); err != nil {
+		t.Fatalf("decoding update: %v", err)
+	}
+	if !op.Upsert {
+		t.Fatal("update is not an upsert")
+	}
+	key := [3]string{op.Q.Endpoint, op.Q.Method, op.Q.FindingID}
+	entry, ok := s[key]
+	if !ok || op.U.Min.FirstSeen.Before(entry.FirstSeen) {
+		entry.FirstSeen = op.U.Min.FirstSeen
+	}
+	if op.U.Max.LastSeen.After(entry.LastSeen) {
+		entry.LastSeen = op.U.Max.LastSeen
+	}
+	entry.Occurrences += op.U.Inc.Occurrences
+	s[key] = entry
+}
+
+func TestRecordFindingOccurrences(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	email := PIIFinding{ID: "email-1", PIIType: "EMAIL", Location: "response_body"}
+	ssn := PIIFinding{ID: "ssn-1", PIIType: "US_SSN", Location: "request_body"}
+
+	type observation struct {
+		endpoint, method string
+		at               time.Time
+		findings         []PIIFinding
+	}
+	tests := []struct {
+		name         string
+		observations []observation
+		want         map[[3]string]FindingHistory
+	}{
+		{
+			name: "repeated observations",
+			observations: []observation{
+				{"/users", "GET", day(2), []PIIFinding{email}},
+				{"/users", "GET", day(5), []PIIFinding{email, ssn}},
+				{"/users", "GET", day(9), []PIIFinding{email}},
+			},
+			want: map[[3]string]FindingHistory{
+				{"/users", "GET", "email-1"}: {FirstSeen: day(2), LastSeen: day(9), Occurrences: 3},
+				{"/users", "GET", "ssn-1"}:   {FirstSeen: day(5), LastSeen: day(5), Occurrences: 1},
+			},
+		},
+		{
+			name: "observed out of order",
+			observations: []observation{
+				{"/users", "GET", day(9), []PIIFinding{email}},
+				{"/users", "GET", day(2), []PIIFinding{email}},
+				{"/users", "GET", day(5), []PIIFinding{email}},
+			},
+			want: map[[3]string]FindingHistory{
+				{"/users", "GET", "email-1"}: {FirstSeen: day(2), LastSeen: day(9), Occurrences: 3},
+			},
+		},
+		{
+			name: "endpoint and method normalized",
+			observations: []observation{
+				{"/Users", "get", day(2), []PIIFinding{email}},
+				{"/users", "GET", day(3), []PIIFinding{email}},
+				{"/users", "POST", day(4), []PIIFinding{email}},
+			},
+			want: map[[3]string]FindingHistory{
+				{"/users", "GET", "email-1"}:  {FirstSeen: day(2), LastSeen: day(3), Occurrences: 2},
+				{"/users", "POST", "email-1"}: {FirstSeen: day(4), LastSeen: day(4), Occurrences: 1},
+			},
+		},
+		{
+			name: "one document counts a finding once",
+			observations: []observation{
+				{"/users", "GET", day(2), []PIIFinding{email, email, {PIIType: "EMAIL"}}},
+			},
+			want: map[[3]string]FindingHistory{
+				{"/users", "GET", "email-1"}: {FirstSeen: day(2), LastSeen: day(2), Occurrences: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mi := &MongoInstance{Client: mt.Client, DB: mt.DB}
+			store := occurrenceStore{}
+			for _, obs := range tt.observations {
+				mt.ClearEvents()
+				mt.AddMockResponses(mtest.CreateSuccessResponse())
+				if err := mi.RecordFindingOccurrences(context.Background(), obs.endpoint, obs.method, obs.at, obs.findings); err != nil {
+					mt.Fatalf("RecordFindingOccurrences: %v", err)
+				}
+				event := mt.GetStartedEvent()
+				if event == nil || event.CommandName != "update" {
+					mt.Fatalf("sent %v, want an update", event)
+				}
+				updates, err := event.Command.LookupErr("updates")
+				if err != nil {
+					mt.Fatal(err)
+				}
+				values, err := updates.Array().Values()
+				if err != nil {
+					mt.Fatal(err)
+				}
+				for _, v := range values {
+					store.apply(mt.T, v.Document())
+				}
+			}
+			if len(store) != len(tt.want) {
+				mt.Errorf("got %d histories, want %d: %v", len(store), len(tt.want), store)
+			}
+			for key, want := range tt.want {
+				got, ok := store[key]
+				if !ok {
+					mt.Errorf("no history for %v", key)
+					continue
+				}
+				if !got.FirstSeen.Equal(want.FirstSeen) || !got.LastSeen.Equal(want.LastSeen) || got.Occurrences != want.Occurrences {
+					mt.Errorf("history of %v = %+v, want %+v", key, got, want)
+				}
+			}
+		})
+	}
+}
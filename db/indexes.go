@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IndexResult is the outcome of ensuring one index: "created", "existing"
+// or "failed", with the error of a failure.
+type IndexResult struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// IndexKey is one field of an index key, with its direction (1 or -1) or
+// index type, such as "text".
+type IndexKey struct {
+	Field string      `json:"field"`
+	Order interface{} `json:"order"`
+}
+
+// IndexSpec describes an index present on a collection.
+type IndexSpec struct {
+	Collection         string     `json:"collection"`
+	Name               string     `json:"name"`
+	Keys               []IndexKey `json:"keys"`
+	Unique             bool       `json:"unique,omitempty"`
+	ExpireAfterSeconds *int32     `json:"expire_after_seconds,omitempty"`
+}
+
+// indexSetup ensures indexes one after another, recording each outcome.
+type indexSetup struct {
+	results []IndexResult
+	errs    []error
+	// existing holds the index names present on each collection before
+	// the setup touched it.
+	existing map[string]map[string]bool
+}
+
+// ensure creates an index unless an identical one already exists, in which
+// case MongoDB treats the request as a no-op.
+func (s *indexSetup) ensure(ctx context.Context, collection *mongo.Collection, model mongo.IndexModel) {
+	existing, err := s.existingIndexes(ctx, collection)
+	if err != nil {
+		s.fail(collection.Name(), err)
+		return
+	}
+	name, err := collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		s.fail(collection.Name(), fmt.Errorf("failed to create index on %s: %w", collection.Name(), err))
+		return
+	}
+	status := "created"
+	if existing[name] {
+		status = "existing"
+	}
+	log.Printf("Ensured index %s on %s (%s)", name, collection.Name(), status)
+	s.results = append(s.results, IndexResult{Collection: collection.Name(), Name: name, Status: status})
+}
+
+func (s *indexSetup) fail(collection string, err error) {
+	s.errs = append(s.errs, err)
+	s.results = append(s.results, IndexResult{Collection: collection, Status: "failed", Error: err.Error()})
+}
+
+func (s *indexSetup) existingIndexes(ctx context.Context, collection *mongo.Collection) (map[string]bool, error) {
+	if names, ok := s.existing[collection.Name()]; ok {
+		return names, nil
+	}
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes on %s: %w", collection.Name(), err)
+	}
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+	s.existing[collection.Name()] = names
+	return names, nil
+}
+
+// ListIndexSpecs returns the indexes present on the given collections.
+func (mi *MongoInstance) ListIndexSpecs(ctx context.Context, collections []string) ([]IndexSpec, error) {
+	indexes := []IndexSpec{}
+	for _, name := range collections {
+		specs, err := mi.GetCollection(name).Indexes().ListSpecifications(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list indexes on %s: %w", name, err)
+		}
+		for _, spec := range specs {
+			var keys bson.D
+			if err := bson.Unmarshal(spec.KeysDocument, &keys); err != nil {
+				return nil, fmt.Errorf("failed to decode keys of index %s on %s: %w", spec.Name, name, err)
+			}
+			index := IndexSpec{Collection: name, Name: spec.Name, Keys: make([]IndexKey, 0, len(keys)), ExpireAfterSeconds: spec.ExpireAfterSeconds}
+			for _, key := range keys {
+				index.Keys = append(index.Keys, IndexKey{Field: key.Key, Order: key.Value})
+			}
+			if spec.Unique != nil {
+				index.Unique = *spec.Unique
+			}
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func indexDoc(name string, keys bson.D) bson.D {
+	return bson.D{{Key: "v", Value: int32(2)}, {Key: "key", Value: keys}, {Key: "name", Value: name}}
+}
+
+func TestIndexSetup(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("outcomes", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			// user_api_data is listed once, before its first index.
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+				indexDoc("_id_", bson.D{{Key: "_id", Value: int32(1)}}),
+				indexDoc("api_endpoint_1_timestamp_-1", bson.D{{Key: "api_endpoint", Value: int32(1)}, {Key: "timestamp", Value: int32(-1)}}),
+			),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 85, Name: "IndexOptionsConflict", Message: "index already exists with different options"}),
+			mtest.CreateCursorResponse(0, "raven.kafka_offsets", mtest.FirstBatch, indexDoc("_id_", bson.D{{Key: "_id", Value: int32(1)}})),
+			mtest.CreateSuccessResponse(),
+		)
+		mi := MongoInstance{Client: mt.Client, DB: mt.DB}
+		setup := &indexSetup{existing: map[string]map[string]bool{}}
+		ctx := context.Background()
+		apiData := mi.GetCollection("user_api_data")
+		setup.ensure(ctx, apiData, mongo.IndexModel{Keys: bson.D{{Key: "api_endpoint", Value: 1}, {Key: "timestamp", Value: -1}}})
+		setup.ensure(ctx, apiData, mongo.IndexModel{Keys: bson.D{{Key: "risk_score", Value: -1}}})
+		setup.ensure(ctx, apiData, mongo.IndexModel{Keys: bson.D{{Key: "owner", Value: 1}}})
+		setup.ensure(ctx, mi.GetCollection("kafka_offsets"), mongo.IndexModel{Keys: bson.D{{Key: "topic", Value: 1}, {Key: "partition", Value: 1}}})
+
+		want := []IndexResult{
+			{Collection: "user_api_data", Name: "api_endpoint_1_timestamp_-1", Status: "existing"},
+			{Collection: "user_api_data", Name: "risk_score_-1", Status: "created"},
+			{Collection: "user_api_data", Status: "failed"},
+			{Collection: "kafka_offsets", Name: "topic_1_partition_1", Status: "created"},
+		}
+		if len(setup.results) != len(want) {
+			mt.Fatalf("results = %+v, want %+v", setup.results, want)
+		}
+		for i := range want {
+			got := setup.results[i]
+			if got.Status == "failed" && got.Error == "" {
+				mt.Errorf("result %d failed without an error", i)
+			}
+			got.Error = ""
+			if got != want[i] {
+				mt.Errorf("result %d = %+v, want %+v", i, got, want[i])
+			}
+		}
+		if len(setup.errs) != 1 {
+			mt.Errorf("errors = %v, want the one conflict", setup.errs)
+		}
+		var commands []string
+		for evt := mt.GetStartedEvent(); evt != nil; evt = mt.GetStartedEvent() {
+			commands = append(commands, evt.CommandName)
+		}
+		wantCommands := []string{"listIndexes", "createIndexes", "createIndexes", "createIndexes", "listIndexes", "createIndexes"}
+		if !reflect.DeepEqual(commands, wantCommands) {
+			mt.Errorf("commands = %v, want %v", commands, wantCommands)
+		}
+	})
+}
+
+func TestListIndexSpecs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("specs", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+			indexDoc("_id_", bson.D{{Key: "_id", Value: int32(1)}}),
+			append(indexDoc("timestamp_1", bson.D{{Key: "timestamp", Value: int32(1)}}), bson.E{Key: "expireAfterSeconds", Value: int32(86400)}),
+			append(indexDoc("detected_value_text", bson.D{{Key: "_fts", Value: "text"}}), bson.E{Key: "unique", Value: true}),
+		))
+		mi := MongoInstance{Client: mt.Client, DB: mt.DB}
+		specs, err := mi.ListIndexSpecs(context.Background(), []string{"user_api_data"})
+		if err != nil {
+			mt.Fatalf("ListIndexSpecs: %v", err)
+		}
+		if len(specs) != 3 {
+			mt.Fatalf("specs = %+v, want 3", specs)
+		}
+		if ttl := specs[1].ExpireAfterSeconds; ttl == nil || *ttl != 86400 {
+			mt.Errorf("ttl index expire_after_seconds = %v, want 86400", ttl)
+		}
+		if text := specs[2]; !text.Unique || len(text.Keys) != 1 || text.Keys[0].Field != "_fts" || text.Keys[0].Order != "text" {
+			mt.Errorf("text index = %+v, want a unique text key", text)
+		}
+		if specs[0].Collection != "user_api_data" || specs[0].Name != "_id_" {
+			mt.Errorf("first index = %+v, want _id_ on user_api_data", specs[0])
+		}
+	})
+}
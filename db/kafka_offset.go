@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// KafkaOffset is the durable checkpoint of a consumer group's position in one
+// partition. Offset is the next offset to consume, matching Kafka's committed
+// offset semantics.
+type KafkaOffset struct {
+	GroupID   string    `bson:"group_id" json:"group_id"`
+	Topic     string    `bson:"topic" json:"topic"`
+	Partition int       `bson:"partition" json:"partition"`
+	Offset    int64     `bson:"offset" json:"offset"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SaveKafkaOffset records a checkpoint. Offsets only move forward, so a late
+// write from an earlier commit can't rewind the checkpoint.
+func (mi *MongoInstance) SaveKafkaOffset(ctx context.Context, checkpoint KafkaOffset) error {
+	collection := mi.GetCollection("kafka_offsets")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := bson.M{"group_id": checkpoint.GroupID, "topic": checkpoint.Topic, "partition": checkpoint.Partition}
+	update := bson.M{
+		"$max": bson.M{"offset": checkpoint.Offset},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	if _, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to save kafka offset checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (mi *MongoInstance) LoadKafkaOffsets(ctx context.Context, groupID, topic string) ([]KafkaOffset, error) {
+	collection := mi.GetCollection("kafka_offsets")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	cursor, err := collection.Find(ctx, bson.M{"group_id": groupID, "topic": topic}, options.Find().SetSort(bson.D{{Key: "partition", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find kafka offset checkpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+	offsets := []KafkaOffset{}
+	if err := cursor.All(ctx, &offsets); err != nil {
+		return nil, fmt.Errorf("failed to decode kafka offset checkpoints: %w", err)
+	}
+	return offsets, nil
+}
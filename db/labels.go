@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LabelCount is how many documents and findings carry a triage label.
+type LabelCount struct {
+	Label     string `json:"label"`
+	Documents int    `json:"documents"`
+	Findings  int    `json:"findings"`
+}
+
+// UpdateLabels adds and removes triage labels on a document, or on the given
+// findings of it when findingIDs is not empty. It reports whether the document exists.
+func (mi *MongoInstance) UpdateLabels(ctx context.Context, id primitive.ObjectID, findingIDs, add, remove []string) (bool, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	field := "labels"
+	opts := options.Update()
+	if len(findingIDs) > 0 {
+		field = "pii_findings.$[f].labels"
+		opts.SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"f.finding_id": bson.M{"$in": findingIDs}}}})
+	}
+	filter := ExcludeDeleted(bson.M{"_id": id})
+	// A field can't be both added to and pulled from in a single update.
+	updates := []bson.M{}
+	if len(add) > 0 {
+		updates = append(updates, bson.M{"$addToSet": bson.M{field: bson.M{"$each": add}}})
+	}
+	if len(remove) > 0 {
+		updates = append(updates, bson.M{"$pull": bson.M{field: bson.M{"$in": remove}}})
+	}
+	found := false
+	for _, update := range updates {
+		result, err := collection.UpdateOne(ctx, filter, update, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to update labels: %w", err)
+		}
+		found = result.MatchedCount > 0
+		if !found {
+			break
+		}
+	}
+	return found, nil
+}
+
+// LabelCounts lists every label in use on stored documents and findings.
+func (mi *MongoInstance) LabelCounts(ctx context.Context) ([]LabelCount, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	documents, err := aggregateLabels(ctx, collection, []bson.M{
+		{"$match": ExcludeDeleted(bson.M{"labels.0": bson.M{"$exists": true}})},
+		{"$unwind": "$labels"},
+		{"$group": bson.M{"_id": "$labels", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	findings, err := aggregateLabels(ctx, collection, []bson.M{
+		{"$match": ExcludeDeleted(bson.M{"pii_findings.labels.0": bson.M{"$exists": true}})},
+		{"$unwind": "$pii_findings"},
+		{"$unwind": "$pii_findings.labels"},
+		{"$group": bson.M{"_id": "$pii_findings.labels", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []LabelCount{}
+	for label, n := range documents {
+		result = append(result, LabelCount{Label: label, Documents: n, Findings: findings[label]})
+	}
+	for label, n := range findings {
+		if _, ok := documents[label]; !ok {
+			result = append(result, LabelCount{Label: label, Findings: n})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+	return result, nil
+}
+
+// aggregateLabels runs a pipeline grouping labels into {_id: label, count: n}.
+func aggregateLabels(ctx context.Context, collection *mongo.Collection, pipeline []bson.M) (map[string]int, error) {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate labels: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var rows []struct {
+		Label string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode labels: %w", err)
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Label] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PatternFeedback aggregates the reviewed findings of one pattern, identified
+// by the PII type and detection mode it reports.
+type PatternFeedback struct {
+	PIIType        string    `bson:"pii_type" json:"pii_type"`
+	DetectionMode  string    `bson:"detection_mode" json:"detection_mode"`
+	TruePositives  int       `bson:"true_positives" json:"true_positives"`
+	FalsePositives int       `bson:"false_positives" json:"false_positives"`
+	Precision      float64   `bson:"precision" json:"precision"`
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// AggregatePatternFeedback counts reviewed findings per pattern. A finding is
+// a false positive when it is marked false_positive or carries one of
+// falsePositiveLabels, and a true positive when it instead carries one of
+// truePositiveLabels. Unreviewed findings are not counted. Precision is left
+// for the caller to compute.
+func (mi *MongoInstance) AggregatePatternFeedback(ctx context.Context, truePositiveLabels, falsePositiveLabels []string) ([]PatternFeedback, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	hasLabel := func(labels []string) bson.M {
+		return bson.M{"$gt": bson.A{
+			bson.M{"$size": bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$pii_findings.labels", bson.A{}}}, labels}}},
+			0,
+		}}
+	}
+	falsePositive := bson.M{"$or": bson.A{bson.M{"$eq": bson.A{"$pii_findings.false_positive", true}}, hasLabel(falsePositiveLabels)}}
+	pipeline := []bson.M{
+		{"$match": ExcludeDeleted(bson.M{"pii_findings.0": bson.M{"$exists": true}})},
+		{"$unwind": "$pii_findings"},
+		{"$group": bson.M{
+			"_id":             bson.M{"pii_type": "$pii_findings.pii_type", "detection_mode": "$pii_findings.detection_mode"},
+			"false_positives": bson.M{"$sum": bson.M{"$cond": bson.A{falsePositive, 1, 0}}},
+			"true_positives": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{bson.M{"$not": bson.A{falsePositive}}, hasLabel(truePositiveLabels)}}, 1, 0,
+			}}},
+		}},
+		{"$match": bson.M{"$or": bson.A{bson.M{"true_positives": bson.M{"$gt": 0}}, bson.M{"false_positives": bson.M{"$gt": 0}}}}},
+		{"$project": bson.M{
+			"_id":             0,
+			"pii_type":        "$_id.pii_type",
+			"detection_mode":  "$_id.detection_mode",
+			"true_positives":  1,
+			"false_positives": 1,
+		}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate pattern feedback: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var feedback []PatternFeedback
+	if err := cursor.All(ctx, &feedback); err != nil {
+		return nil, fmt.Errorf("failed to decode pattern feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// SavePatternFeedback replaces the stored aggregates with feedback.
+func (mi *MongoInstance) SavePatternFeedback(ctx context.Context, feedback []PatternFeedback) error {
+	collection := mi.GetCollection("pattern_feedback")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := collection.DeleteMany(ctx, bson.M{}); err != nil {
+		return fmt.Errorf("failed to clear pattern feedback: %w", err)
+	}
+	if len(feedback) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(feedback))
+	for _, f := range feedback {
+		models = append(models, mongo.NewInsertOneModel().SetDocument(f))
+	}
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to save pattern feedback: %w", err)
+	}
+	return nil
+}
+
+// ListPatternFeedback returns the stored aggregates, lowest precision first.
+func (mi *MongoInstance) ListPatternFeedback(ctx context.Context) ([]PatternFeedback, error) {
+	collection := mi.GetCollection("pattern_feedback")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "precision", Value: 1}, {Key: "pii_type", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pattern feedback: %w", err)
+	}
+	defer cursor.Close(ctx)
+	feedback := []PatternFeedback{}
+	if err := cursor.All(ctx, &feedback); err != nil {
+		return nil, fmt.Errorf("failed to decode pattern feedback: %w", err)
+	}
+	return feedback, nil
+}
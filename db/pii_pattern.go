@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicatePattern is returned when a stored pattern's mode and name are already taken.
+var ErrDuplicatePattern = errors.New("a pattern with this name already exists for the mode")
+
+// StoredPIIPattern is a user-defined detection pattern managed through the API.
+// It is merged into the file-based config when the PII service loads.
+type StoredPIIPattern struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name         string             `bson:"name" json:"name"`
+	Mode         string             `bson:"mode" json:"mode"`
+	Pattern      string             `bson:"pattern" json:"pattern"`
+	FieldNames   []string           `bson:"field_names,omitempty" json:"field_names,omitempty"`
+	RiskLevel    string             `bson:"risk_level" json:"risk_level"`
+	Category     string             `bson:"category" json:"category"`
+	Tags         []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Frameworks   []string           `bson:"frameworks,omitempty" json:"frameworks,omitempty"`
+	MaskStrategy string             `bson:"mask_strategy,omitempty" json:"mask_strategy,omitempty"`
+	Validate     string             `bson:"validate,omitempty" json:"validate,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func (mi *MongoInstance) ListPIIPatterns(ctx context.Context) ([]StoredPIIPattern, error) {
+	collection := mi.GetCollection("pii_patterns")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "mode", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PII patterns: %w", err)
+	}
+	defer cursor.Close(ctx)
+	patterns := []StoredPIIPattern{}
+	if err := cursor.All(ctx, &patterns); err != nil {
+		return nil, fmt.Errorf("failed to decode PII patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+func (mi *MongoInstance) CreatePIIPattern(ctx context.Context, pattern StoredPIIPattern) (StoredPIIPattern, error) {
+	collection := mi.GetCollection("pii_patterns")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	now := time.Now()
+	pattern.ID = primitive.NewObjectID()
+	pattern.CreatedAt = now
+	pattern.UpdatedAt = now
+	if _, err := collection.InsertOne(ctx, pattern); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return StoredPIIPattern{}, ErrDuplicatePattern
+		}
+		return StoredPIIPattern{}, fmt.Errorf("failed to insert PII pattern: %w", err)
+	}
+	return pattern, nil
+}
+
+// UpdatePIIPattern replaces a stored pattern, keeping its creation time.
+func (mi *MongoInstance) UpdatePIIPattern(ctx context.Context, id primitive.ObjectID, pattern StoredPIIPattern) (bool, error) {
+	collection := mi.GetCollection("pii_patterns")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{
+		"name":          pattern.Name,
+		"mode":          pattern.Mode,
+		"pattern":       pattern.Pattern,
+		"field_names":   pattern.FieldNames,
+		"risk_level":    pattern.RiskLevel,
+		"category":      pattern.Category,
+		"tags":          pattern.Tags,
+		"frameworks":    pattern.Frameworks,
+		"mask_strategy": pattern.MaskStrategy,
+		"validate":      pattern.Validate,
+		"updated_at":    time.Now(),
+	}}
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, ErrDuplicatePattern
+		}
+		return false, fmt.Errorf("failed to update PII pattern: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (mi *MongoInstance) DeletePIIPattern(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection := mi.GetCollection("pii_patterns")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete PII pattern: %w", err)
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindPIIAnalysisReportsBefore returns the reports and rollups dated before
+// before, oldest first.
+func (mi *MongoInstance) FindPIIAnalysisReportsBefore(ctx context.Context, before time.Time) ([]PIIAnalysisReport, error) {
+	collection := mi.GetCollection("pii_analysis_reports")
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "report_date", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"report_date": bson.M{"$lt": before}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PII analysis reports: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var reports []PIIAnalysisReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("failed to decode PII analysis reports: %w", err)
+	}
+	return reports, nil
+}
+
+// ReplacePIIAnalysisReports stores a rollup and then deletes the reports it
+// replaces, returning how many were deleted. Should the delete fail, the
+// replaced reports are kept next to the rollup rather than lost.
+func (mi *MongoInstance) ReplacePIIAnalysisReports(ctx context.Context, rollup PIIAnalysisReport, replaced []primitive.ObjectID) (int64, error) {
+	collection := mi.GetCollection("pii_analysis_reports")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := collection.InsertOne(ctx, rollup); err != nil {
+		return 0, fmt.Errorf("failed to save PII analysis report rollup: %w", err)
+	}
+	result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": replaced}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rolled-up PII analysis reports: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindAPIDataBelowSchemaVersion returns up to limit documents after afterID,
+// in id order, stamped with a schema version below version or with none.
+// Soft-deleted documents are included, as they can still be restored. The
+// bodies are left out; migrations don't touch them.
+func (mi *MongoInstance) FindAPIDataBelowSchemaVersion(ctx context.Context, version int, afterID primitive.ObjectID, limit int) ([]UserAPIData, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := bson.M{
+		"_id":            bson.M{"$gt": afterID},
+		"schema_version": bson.M{"$not": bson.M{"$gte": version}},
+	}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"request_body": 0, "response_body": 0})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API data below schema version %d: %w", version, err)
+	}
+	defer cursor.Close(ctx)
+	var results []UserAPIData
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode API data below schema version %d: %w", version, err)
+	}
+	return results, nil
+}
+
+// SaveSchemaMigration stores the fields schema migrations rewrite together
+// with the document's new schema version. A migration that rewrites other
+// fields must be added here.
+func (mi *MongoInstance) SaveSchemaMigration(ctx context.Context, data UserAPIData) error {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	set := bson.M{"schema_version": data.SchemaVersion}
+	if len(data.PIIFindings) > 0 {
+		set["pii_findings"] = data.PIIFindings
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": data.ID}, bson.M{"$set": set}); err != nil {
+		return fmt.Errorf("failed to save schema migration: %w", err)
+	}
+	return nil
+}
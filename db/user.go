@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,6 +14,7 @@ import (
 )
 
 type PIIFinding struct {
+	ID            string    `bson:"finding_id,omitempty"`
 	PIIType       string    `bson:"pii_type"`
 	DetectedValue string    `bson:"detected_value"`
 	FieldName     string    `bson:"field_name,omitempty"`
@@ -21,27 +23,48 @@ type PIIFinding struct {
 	RiskLevel     string    `bson:"risk_level"`
 	Category      string    `bson:"category"`
 	Tags          []string  `bson:"tags"`
+	Frameworks    []string  `bson:"frameworks,omitempty"`
+	Confidence    float64   `bson:"confidence,omitempty"`
 	Timestamp     time.Time `bson:"timestamp"`
+	FirstSeen     time.Time `bson:"first_seen,omitempty"`
+	FalsePositive bool      `bson:"false_positive,omitempty"`
+	Labels        []string  `bson:"labels,omitempty"`
 }
 
 type UserAPIData struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty"`
-	APIEndpoint     string             `bson:"api_endpoint"`
-	Method          string             `bson:"method"`
-	URL             string             `bson:"url"`
-	RequestHeaders  map[string]string  `bson:"request_headers,omitempty"`
-	ResponseHeaders map[string]string  `bson:"response_headers,omitempty"`
-	RequestBody     interface{}        `bson:"request_body,omitempty"`
-	ResponseBody    interface{}        `bson:"response_body,omitempty"`
-	Source          string             `bson:"source"`
-	Timestamp       time.Time          `bson:"timestamp"`
-	HasPII          bool               `bson:"has_pii"`
-	PIICount        int                `bson:"pii_count"`
-	RiskScore       int                `bson:"risk_score"`
-	HighestRisk     string             `bson:"highest_risk,omitempty"`
-	SensitiveFields []string           `bson:"sensitive_fields,omitempty"`
-	PIIFindings     []PIIFinding       `bson:"pii_findings,omitempty"`
-	LastPIIAnalysis time.Time          `bson:"last_pii_analysis,omitempty"`
+	ID                 primitive.ObjectID `bson:"_id,omitempty"`
+	APIEndpoint        string             `bson:"api_endpoint"`
+	Method             string             `bson:"method"`
+	StatusCode         int                `bson:"status_code,omitempty"`
+	URL                string             `bson:"url"`
+	RequestHeaders     map[string]string  `bson:"request_headers,omitempty"`
+	ResponseHeaders    map[string]string  `bson:"response_headers,omitempty"`
+	RequestTrailers    map[string]string  `bson:"request_trailers,omitempty"`
+	ResponseTrailers   map[string]string  `bson:"response_trailers,omitempty"`
+	RequestBody        interface{}        `bson:"request_body,omitempty"`
+	ResponseBody       interface{}        `bson:"response_body,omitempty"`
+	Source             string             `bson:"source"`
+	Owner              string             `bson:"owner,omitempty"`
+	Labels             []string           `bson:"labels,omitempty"`
+	Timestamp          time.Time          `bson:"timestamp"`
+	HasPII             bool               `bson:"has_pii"`
+	PIICount           int                `bson:"pii_count"`
+	RiskScore          int                `bson:"risk_score"`
+	HighestRisk        string             `bson:"highest_risk,omitempty"`
+	SensitiveFields    []string           `bson:"sensitive_fields,omitempty"`
+	PIIFindings        []PIIFinding       `bson:"pii_findings,omitempty"`
+	FindingsTruncated  bool               `bson:"findings_truncated,omitempty"`
+	LastPIIAnalysis    time.Time          `bson:"last_pii_analysis,omitempty"`
+	BodyTruncated      bool               `bson:"body_truncated,omitempty"`
+	HeadersStripped    int                `bson:"headers_stripped,omitempty"`
+	BodyCompressed     bool               `bson:"body_compressed,omitempty"`
+	AnalysisPartial    bool               `bson:"analysis_partial,omitempty"`
+	AnalysisTimedOut   bool               `bson:"analysis_timed_out,omitempty"`
+	AnalysisDeferred   bool               `bson:"analysis_deferred,omitempty"`
+	ResponseSampledOut bool               `bson:"response_sampled_out,omitempty"`
+	BodySkippedBinary  bool               `bson:"body_skipped_binary,omitempty"`
+	SchemaVersion      int                `bson:"schema_version,omitempty"`
+	DeletedAt          *time.Time         `bson:"deleted_at,omitempty"`
 }
 
 type PIIAnalysisReport struct {
@@ -56,6 +79,10 @@ type PIIAnalysisReport struct {
 	TopRiskyEndpoints      []RiskyEndpoint    `bson:"top_risky_endpoints"`
 	ComplianceStatus       string             `bson:"compliance_status"`
 	CreatedAt              time.Time          `bson:"created_at"`
+	// Period is "week" or "month" for a rollup of older reports, whose
+	// ReportDate is the start of the period, and empty for a daily report.
+	Period   string `bson:"period,omitempty"`
+	RolledUp int    `bson:"rolled_up,omitempty"`
 }
 
 type RiskyEndpoint struct {
@@ -66,54 +93,142 @@ type RiskyEndpoint struct {
 	HighestRisk string `bson:"highest_risk"`
 }
 
-func (mi *MongoInstance) SaveUserAPIData(data UserAPIData) error {
+// ExcludeDeleted restricts a filter to documents that haven't been soft-deleted.
+func ExcludeDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
+
+func (mi *MongoInstance) SaveUserAPIData(ctx context.Context, data UserAPIData) error {
 	collection := mi.GetCollection("user_api_data")
 	if data.Timestamp.IsZero() {
 		log.Println("Warning: UserAPIData timestamp is zero, setting to current time.")
 		data.Timestamp = time.Now()
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	data.Timestamp = data.Timestamp.UTC()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	_, err := collection.InsertOne(ctx, data)
+	result, err := collection.InsertOne(ctx, data)
+	if mongo.IsDuplicateKeyError(err) {
+		// The document is already stored, e.g. a replayed message: inserting
+		// is idempotent, so this counts as saved.
+		debugf("API data already stored (%s, id: %v)", data.Method, data.ID)
+		return nil
+	}
 	if err != nil {
-		log.Printf("Failed to insert API data for endpoint %s (%s): %v\n", data.APIEndpoint, data.Method, err)
+		log.Printf("Failed to insert API data (%s): %v\n", data.Method, err)
 		return fmt.Errorf("failed to insert API data: %w", err)
 	}
-	log.Printf("API Data Inserted Successfully for %s (%s)", data.APIEndpoint, data.Method)
+	log.Printf("API Data Inserted Successfully (%s, id: %v)", data.Method, result.InsertedID)
 	return nil
 }
 
+// UpdateUserAPIDataWithPII stores a fresh analysis on every document of an
+// endpoint, merging findings by id so re-analysis keeps first-seen times and
+// false-positive flags and labels instead of replacing them.
 func (mi *MongoInstance) UpdateUserAPIDataWithPII(apiEndpoint, method string, findings []PIIFinding, riskScore int, highestRisk string) error {
 	collection := mi.GetCollection("user_api_data")
 	filter := bson.M{
 		"api_endpoint": apiEndpoint,
 		"method":       method,
 	}
-	update := bson.M{
-		"$set": bson.M{
-			"pii_findings":      findings,
-			"risk_score":        riskScore,
-			"highest_risk":      highestRisk,
-			"has_pii":           len(findings) > 0,
-			"pii_count":         len(findings),
-			"last_pii_analysis": time.Now(),
-		},
-	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	result, err := collection.UpdateMany(ctx, filter, update)
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"pii_findings": 1}))
 	if err != nil {
-		return fmt.Errorf("failed to update API data with PII findings: %w", err)
+		return fmt.Errorf("failed to find API data to update: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	modified := 0
+	for cursor.Next(ctx) {
+		var existing UserAPIData
+		if err := cursor.Decode(&existing); err != nil {
+			return fmt.Errorf("failed to decode API data: %w", err)
+		}
+		merged := MergePIIFindings(existing.PIIFindings, findings)
+		update := bson.M{
+			"$set": bson.M{
+				"pii_findings":      merged,
+				"risk_score":        riskScore,
+				"highest_risk":      highestRisk,
+				"has_pii":           len(merged) > 0,
+				"pii_count":         len(merged),
+				"last_pii_analysis": time.Now(),
+			},
+		}
+		result, err := collection.UpdateOne(ctx, bson.M{"_id": existing.ID}, update)
+		if err != nil {
+			return fmt.Errorf("failed to update API data with PII findings: %w", err)
+		}
+		modified += int(result.ModifiedCount)
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate API data: %w", err)
 	}
-	log.Printf("Updated %d documents with PII analysis for %s %s", result.ModifiedCount, method, apiEndpoint)
+	log.Printf("Updated %d %s documents with PII analysis", modified, method)
 	return nil
 }
 
+// MergePIIFindings returns the current findings, carrying over the first-seen
+// time, false-positive flag and labels of any previous finding with the same id.
+func MergePIIFindings(previous, current []PIIFinding) []PIIFinding {
+	byID := make(map[string]PIIFinding, len(previous))
+	for _, finding := range previous {
+		if finding.ID != "" {
+			byID[finding.ID] = finding
+		}
+	}
+	merged := make([]PIIFinding, 0, len(current))
+	for _, finding := range current {
+		if prior, ok := byID[finding.ID]; ok && finding.ID != "" {
+			finding.FalsePositive = prior.FalsePositive
+			finding.Labels = prior.Labels
+			if !prior.FirstSeen.IsZero() {
+				finding.FirstSeen = prior.FirstSeen
+			}
+		}
+		if finding.FirstSeen.IsZero() {
+			finding.FirstSeen = finding.Timestamp
+		}
+		merged = append(merged, finding)
+	}
+	return merged
+}
+
+// SoftDeleteUserAPIData marks a document deleted; it is purged by the TTL index after the grace period.
+func (mi *MongoInstance) SoftDeleteUserAPIData(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := ExcludeDeleted(bson.M{"_id": id})
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now()}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to soft-delete API data: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RestoreUserAPIData clears the soft-delete marker of a document that hasn't been purged yet.
+func (mi *MongoInstance) RestoreUserAPIData(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore API data: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
 func (mi *MongoInstance) FindAllAPIData() ([]UserAPIData, error) {
 	collection := mi.GetCollection("user_api_data")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	cursor, err := collection.Find(ctx, primitive.D{})
+	cursor, err := collection.Find(ctx, ExcludeDeleted(bson.M{}))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find API data: %w", err)
 	}
@@ -129,7 +244,7 @@ func (mi *MongoInstance) FindAPIDataWithPII() ([]UserAPIData, error) {
 	collection := mi.GetCollection("user_api_data")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	filter := bson.M{"has_pii": true}
+	filter := ExcludeDeleted(bson.M{"has_pii": true})
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find API data with PII: %w", err)
@@ -146,7 +261,7 @@ func (mi *MongoInstance) FindAPIDataByRiskLevel(riskLevel string) ([]UserAPIData
 	collection := mi.GetCollection("user_api_data")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	filter := bson.M{"highest_risk": riskLevel}
+	filter := ExcludeDeleted(bson.M{"highest_risk": riskLevel})
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find API data by risk level: %w", err)
@@ -194,11 +309,15 @@ func (mi *MongoInstance) FindLatestPIIAnalysisReport() (*PIIAnalysisReport, erro
 	return &report, nil
 }
 
-func (mi *MongoInstance) GetPIIComplianceStats() (map[string]interface{}, error) {
+// GetPIIComplianceStats aggregates the stored documents. critical_risk_apis
+// and high_risk_apis count the documents whose highest risk is criticalLevel
+// and highLevel, the two highest configured levels; an empty level counts none.
+func (mi *MongoInstance) GetPIIComplianceStats(criticalLevel, highLevel string) (map[string]interface{}, error) {
 	collection := mi.GetCollection("user_api_data")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	pipeline := []bson.M{
+		{"$match": ExcludeDeleted(bson.M{})},
 		{
 			"$group": bson.M{
 				"_id": nil,
@@ -212,24 +331,8 @@ func (mi *MongoInstance) GetPIIComplianceStats() (map[string]interface{}, error)
 						},
 					},
 				},
-				"critical_risk_apis": bson.M{
-					"$sum": bson.M{
-						"$cond": bson.M{
-							"if":   bson.M{"$eq": []interface{}{"$highest_risk", "CRITICAL"}},
-							"then": 1,
-							"else": 0,
-						},
-					},
-				},
-				"high_risk_apis": bson.M{
-					"$sum": bson.M{
-						"$cond": bson.M{
-							"if":   bson.M{"$eq": []interface{}{"$highest_risk", "HIGH"}},
-							"then": 1,
-							"else": 0,
-						},
-					},
-				},
+				"critical_risk_apis": countRiskLevel(criticalLevel),
+				"high_risk_apis": countRiskLevel(highLevel),
 				"avg_risk_score": bson.M{"$avg": "$risk_score"},
 				"total_pii_findings": bson.M{"$sum": "$pii_count"},
 			},
@@ -264,4 +367,194 @@ func (mi *MongoInstance) GetPIIComplianceStats() (map[string]interface{}, error)
 	}
 	stats["compliance_percentage"] = compliancePercentage
 	return map[string]interface{}(stats), nil
-}
\ No newline at end of file
+}
+
+// countRiskLevel sums the documents whose highest risk is level.
+func countRiskLevel(level string) bson.M {
+	if level == "" {
+		return bson.M{"$sum": 0}
+	}
+	return bson.M{
+		"$sum": bson.M{
+			"$cond": bson.M{
+				"if":   bson.M{"$eq": []interface{}{"$highest_risk", level}},
+				"then": 1,
+				"else": 0,
+			},
+		},
+	}
+}
+
+// FindDeferredAPIData returns up to limit documents whose full PII analysis was deferred.
+func (mi *MongoInstance) FindDeferredAPIData(ctx context.Context, limit int) ([]UserAPIData, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := ExcludeDeleted(bson.M{"analysis_deferred": true})
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find deferred API data: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var results []UserAPIData
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode deferred API data: %w", err)
+	}
+	return results, nil
+}
+
+// StoreFullAnalysis stores the full analysis of a stored document and clears
+// its deferred flag, if any.
+func (mi *MongoInstance) StoreFullAnalysis(ctx context.Context, data UserAPIData) error {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	update := bson.M{
+		"$set": bson.M{
+			"pii_findings":        data.PIIFindings,
+			"findings_truncated":  data.FindingsTruncated,
+			"sensitive_fields":    data.SensitiveFields,
+			"risk_score":          data.RiskScore,
+			"highest_risk":        data.HighestRisk,
+			"has_pii":             data.HasPII,
+			"pii_count":           data.PIICount,
+			"analysis_partial":    data.AnalysisPartial,
+			"analysis_timed_out":  data.AnalysisTimedOut,
+			"body_skipped_binary": data.BodySkippedBinary,
+			"last_pii_analysis":   time.Now(),
+		},
+		"$unset": bson.M{"analysis_deferred": ""},
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": data.ID}, update); err != nil {
+		return fmt.Errorf("failed to store full analysis: %w", err)
+	}
+	return nil
+}
+
+// FindUserAPIDataByID returns a document that hasn't been soft-deleted. It
+// returns mongo.ErrNoDocuments, wrapped, when there is none.
+func (mi *MongoInstance) FindUserAPIDataByID(ctx context.Context, id primitive.ObjectID) (UserAPIData, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var data UserAPIData
+	if err := collection.FindOne(ctx, ExcludeDeleted(bson.M{"_id": id})).Decode(&data); err != nil {
+		return UserAPIData{}, fmt.Errorf("failed to find API data: %w", err)
+	}
+	return data, nil
+}
+
+// FindRecentAPIData returns the limit most recent documents that haven't been soft-deleted.
+func (mi *MongoInstance) FindRecentAPIData(ctx context.Context, limit int) ([]UserAPIData, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	opts := options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	cursor, err := collection.Find(ctx, ExcludeDeleted(bson.M{}), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find recent API data: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var results []UserAPIData
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode recent API data: %w", err)
+	}
+	return results, nil
+}
+
+// SampleAPIData picks a random percent of the documents that haven't been
+// soft-deleted and passes them to fn in batches of up to batchSize. It
+// returns the number of documents the sample was drawn from.
+func (mi *MongoInstance) SampleAPIData(ctx context.Context, percent float64, batchSize int, fn func([]UserAPIData) error) (int64, error) {
+	collection := mi.GetCollection("user_api_data")
+	countCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	total, err := collection.CountDocuments(countCtx, ExcludeDeleted(bson.M{}))
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count API data: %w", err)
+	}
+	size := int64(math.Ceil(float64(total) * percent / 100))
+	if size == 0 {
+		return total, nil
+	}
+	pipeline := []bson.M{
+		{"$match": ExcludeDeleted(bson.M{})},
+		{"$sample": bson.M{"size": size}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return total, fmt.Errorf("failed to sample API data: %w", err)
+	}
+	defer cursor.Close(ctx)
+	batch := make([]UserAPIData, 0, batchSize)
+	for cursor.Next(ctx) {
+		var data UserAPIData
+		if err := cursor.Decode(&data); err != nil {
+			return total, fmt.Errorf("failed to decode sampled API data: %w", err)
+		}
+		batch = append(batch, data)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return total, err
+			}
+			batch = make([]UserAPIData, 0, batchSize)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return total, fmt.Errorf("failed to iterate sampled API data: %w", err)
+	}
+	if len(batch) > 0 {
+		return total, fn(batch)
+	}
+	return total, nil
+}
+
+// FindAPIDataWithFindingsAfter returns up to limit documents with findings
+// whose id is greater than afterID, in id order, for batch jobs over all findings.
+func (mi *MongoInstance) FindAPIDataWithFindingsAfter(ctx context.Context, afterID primitive.ObjectID, limit int) ([]UserAPIData, error) {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": bson.M{"$gt": afterID}, "pii_findings.0": bson.M{"$exists": true}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API data with findings: %w", err)
+	}
+	defer cursor.Close(ctx)
+	var results []UserAPIData
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode API data with findings: %w", err)
+	}
+	return results, nil
+}
+
+// UpdatePIISummary replaces the findings of a document together with the
+// counts and risk metrics derived from them.
+func (mi *MongoInstance) UpdatePIISummary(ctx context.Context, id primitive.ObjectID, findings []PIIFinding, riskScore int, highestRisk string, sensitiveFields []string) error {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{
+		"pii_findings":     findings,
+		"pii_count":        len(findings),
+		"has_pii":          len(findings) > 0,
+		"risk_score":       riskScore,
+		"highest_risk":     highestRisk,
+		"sensitive_fields": sensitiveFields,
+	}}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return fmt.Errorf("failed to update PII summary: %w", err)
+	}
+	return nil
+}
+
+// UpdatePIIFindings replaces the findings of a document, leaving its risk metrics untouched.
+func (mi *MongoInstance) UpdatePIIFindings(ctx context.Context, id primitive.ObjectID, findings []PIIFinding) error {
+	collection := mi.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"pii_findings": findings}}); err != nil {
+		return fmt.Errorf("failed to update PII findings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSaveUserAPIDataDuplicateKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name      string
+		response  bson.D
+		wantErr   bool
+		wantDebug bool
+	}{
+		{name: "inserted", response: mtest.CreateSuccessResponse()},
+		{
+			name:      "duplicate key",
+			response:  mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error collection: raven.user_api_data index: _id_"}),
+			wantDebug: true,
+		},
+		{
+			name:      "legacy duplicate key code",
+			response:  mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11001, Message: "E11001 duplicate key on update"}),
+			wantDebug: true,
+		},
+		{
+			name:     "other write error",
+			response: mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 121, Message: "Document failed validation"}),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.response)
+			debugLogging = true
+			defer func() { debugLogging = false }()
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(io.Discard)
+
+			mi := &MongoInstance{Client: mt.Client, DB: mt.DB}
+			err := mi.SaveUserAPIData(context.Background(), UserAPIData{Method: "POST", APIEndpoint: "/users"})
+			if (err != nil) != tt.wantErr {
+				mt.Fatalf("SaveUserAPIData error = %v, want error %v", err, tt.wantErr)
+			}
+			if debug := strings.Contains(buf.String(), "DEBUG: API data already stored"); debug != tt.wantDebug {
+				mt.Errorf("debug log = %v, want %v; log:\n%s", debug, tt.wantDebug, buf.String())
+			}
+		})
+	}
+}
@@ -4,17 +4,18 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"regexp"
 	"strconv"
 	"time"
 	"github.com/gin-gonic/gin"
 	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type PIIFinding struct {
+	ID            string    `bson:"finding_id,omitempty" json:"id,omitempty"`
 	PIIType       string    `bson:"pii_type" json:"pii_type"`
 	DetectedValue string    `bson:"detected_value" json:"detected_value"`
 	FieldName     string    `bson:"field_name,omitempty" json:"field_name,omitempty"`
@@ -23,27 +24,48 @@ type PIIFinding struct {
 	RiskLevel     string    `bson:"risk_level" json:"risk_level"`
 	Category      string    `bson:"category" json:"category"`
 	Tags          []string  `bson:"tags" json:"tags"`
+	Frameworks    []string  `bson:"frameworks,omitempty" json:"frameworks,omitempty"`
+	Confidence    float64   `bson:"confidence,omitempty" json:"confidence,omitempty"`
 	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
+	FirstSeen     time.Time `bson:"first_seen,omitempty" json:"first_seen,omitempty"`
+	FalsePositive bool      `bson:"false_positive,omitempty" json:"false_positive,omitempty"`
+	Labels        []string  `bson:"labels,omitempty" json:"labels,omitempty"`
 }
 
 type UserAPIData struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	APIEndpoint     string             `bson:"api_endpoint" json:"api_endpoint"`
-	Method          string             `bson:"method" json:"method"`
-	RequestHeaders  map[string]string  `bson:"request_headers,omitempty" json:"request_headers,omitempty"`
-	ResponseHeaders map[string]string  `bson:"response_headers,omitempty" json:"response_headers,omitempty"`
-	RequestBody     interface{}        `bson:"request_body,omitempty" json:"request_body,omitempty"`
-	ResponseBody    interface{}        `bson:"response_body,omitempty" json:"response_body,omitempty"`
-	SensitiveFields []string           `bson:"sensitive_fields,omitempty" json:"sensitive_fields,omitempty"`
-	HasPII          bool               `bson:"has_pii" json:"has_pii"`
-	PIICount        int                `bson:"pii_count" json:"pii_count"`
-	RiskScore       int                `bson:"risk_score" json:"risk_score"`
-	HighestRisk     string             `bson:"highest_risk,omitempty" json:"highest_risk,omitempty"`
-	PIIFindings     []PIIFinding       `bson:"pii_findings,omitempty" json:"pii_findings,omitempty"`
-	Timestamp       time.Time          `bson:"timestamp" json:"timestamp"`
-	Source          string             `bson:"source" json:"source"`
-	URL             string             `bson:"url" json:"url"`
-	LastPIIAnalysis time.Time          `bson:"last_pii_analysis,omitempty" json:"last_pii_analysis,omitempty"`
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	APIEndpoint        string             `bson:"api_endpoint" json:"api_endpoint"`
+	Method             string             `bson:"method" json:"method"`
+	StatusCode         int                `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	RequestHeaders     map[string]string  `bson:"request_headers,omitempty" json:"request_headers,omitempty"`
+	ResponseHeaders    map[string]string  `bson:"response_headers,omitempty" json:"response_headers,omitempty"`
+	RequestTrailers    map[string]string  `bson:"request_trailers,omitempty" json:"request_trailers,omitempty"`
+	ResponseTrailers   map[string]string  `bson:"response_trailers,omitempty" json:"response_trailers,omitempty"`
+	RequestBody        interface{}        `bson:"request_body,omitempty" json:"request_body,omitempty"`
+	ResponseBody       interface{}        `bson:"response_body,omitempty" json:"response_body,omitempty"`
+	SensitiveFields    []string           `bson:"sensitive_fields,omitempty" json:"sensitive_fields,omitempty"`
+	HasPII             bool               `bson:"has_pii" json:"has_pii"`
+	PIICount           int                `bson:"pii_count" json:"pii_count"`
+	RiskScore          int                `bson:"risk_score" json:"risk_score"`
+	HighestRisk        string             `bson:"highest_risk,omitempty" json:"highest_risk,omitempty"`
+	PIIFindings        []PIIFinding       `bson:"pii_findings,omitempty" json:"pii_findings,omitempty"`
+	FindingsTruncated  bool               `bson:"findings_truncated,omitempty" json:"findings_truncated,omitempty"`
+	Timestamp          time.Time          `bson:"timestamp" json:"timestamp"`
+	Source             string             `bson:"source" json:"source"`
+	Owner              string             `bson:"owner,omitempty" json:"owner,omitempty"`
+	Labels             []string           `bson:"labels,omitempty" json:"labels,omitempty"`
+	URL                string             `bson:"url" json:"url"`
+	LastPIIAnalysis    time.Time          `bson:"last_pii_analysis,omitempty" json:"last_pii_analysis,omitempty"`
+	BodyTruncated      bool               `bson:"body_truncated,omitempty" json:"body_truncated,omitempty"`
+	HeadersStripped    int                `bson:"headers_stripped,omitempty" json:"headers_stripped,omitempty"`
+	BodyCompressed     bool               `bson:"body_compressed,omitempty" json:"-"`
+	AnalysisPartial    bool               `bson:"analysis_partial,omitempty" json:"analysis_partial,omitempty"`
+	AnalysisTimedOut   bool               `bson:"analysis_timed_out,omitempty" json:"analysis_timed_out,omitempty"`
+	AnalysisDeferred   bool               `bson:"analysis_deferred,omitempty" json:"analysis_deferred,omitempty"`
+	ResponseSampledOut bool               `bson:"response_sampled_out,omitempty" json:"response_sampled_out,omitempty"`
+	BodySkippedBinary  bool               `bson:"body_skipped_binary,omitempty" json:"body_skipped_binary,omitempty"`
+	SchemaVersion      int                `bson:"schema_version,omitempty" json:"schema_version,omitempty"`
+	DeletedAt          *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 type PaginatedResponse struct {
@@ -52,12 +74,22 @@ type PaginatedResponse struct {
 }
 
 type APIHandler struct {
-	mongo db.MongoInstance
+	mongo         db.MongoInstance
+	piiService    *services.PIIService
+	consumer      *services.KafkaConsumerService
+	pipeline      *services.IngestPipeline
+	jobs          *services.JobManager
+	confirmations *services.ConfirmationTokens
 }
 
-func NewAPIHandler(mongoInstance db.MongoInstance) *APIHandler {
+func NewAPIHandler(mongoInstance db.MongoInstance, piiService *services.PIIService, consumer *services.KafkaConsumerService, pipeline *services.IngestPipeline) *APIHandler {
 	return &APIHandler{
-		mongo: mongoInstance,
+		mongo:         mongoInstance,
+		piiService:    piiService,
+		consumer:      consumer,
+		pipeline:      pipeline,
+		jobs:          services.NewJobManager(),
+		confirmations: services.NewConfirmationTokens(bulkDeleteTokenTTL),
 	}
 }
 
@@ -69,6 +101,10 @@ func (h *APIHandler) getAPILogs(c *gin.Context) {
     method := c.Query("method")
     hasPiiStr := c.Query("has_pii")
     riskLevel := c.Query("risk_level")
+    owner := c.Query("owner")
+    label := c.Query("label")
+    findingValue := c.Query("finding_value")
+    includeDeleted := c.Query("include_deleted") == "true"
 
     page, err := strconv.Atoi(pageStr)
     if err != nil || page < 1 {
@@ -81,36 +117,34 @@ func (h *APIHandler) getAPILogs(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
         return
     }
+    if includeDeleted && !isAdmin(c) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "include_deleted requires admin access"})
+        return
+    }
     skip := (page - 1) * limit
     filter := bson.M{}
-
-    if searchQuery != "" {
-        filter["$or"] = []bson.M{
-            {"api_endpoint": bson.M{"$regex": primitive.Regex{Pattern: searchQuery, Options: "i"}}},
-            {"url": bson.M{"$regex": primitive.Regex{Pattern: searchQuery, Options: "i"}}},
-        }
+    if !includeDeleted {
+        db.ExcludeDeleted(filter)
     }
 
-    // Fix hostname search
-    if searchHostname != "" {
-        filter["url"] = bson.M{"$regex": primitive.Regex{Pattern: searchHostname, Options: "i"}}
-    }
-    if method != "" {
-        filter["method"] = bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(method) + "$", Options: "i"}}
+    logFilter := LogFilter{
+        Query:        searchQuery,
+        Hostname:     searchHostname,
+        Method:       method,
+        RiskLevel:    riskLevel,
+        Owner:        owner,
+        Label:        label,
+        FindingValue: findingValue,
     }
-
     if hasPiiStr != "" {
         hasPiiBool, parseErr := strconv.ParseBool(hasPiiStr)
         if parseErr != nil {
             c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid value for has_pii. Must be 'true' or 'false'."})
             return
         }
-        filter["has_pii"] = hasPiiBool
-    }
-
-    if riskLevel != "" {
-        filter["highest_risk"] = riskLevel
+        logFilter.HasPII = &hasPiiBool
     }
+    logFilter.apply(filter)
     log.Printf("Applied filters: %+v", filter)
 
     collection := h.mongo.GetCollection("user_api_data")
@@ -139,6 +173,9 @@ func (h *APIHandler) getAPILogs(c *gin.Context) {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode API data"})
         return
     }
+    for i := range apiData {
+        restoreBodies(&apiData[i])
+    }
 
     response := PaginatedResponse{
         Items: apiData,
@@ -159,6 +196,9 @@ func (h *APIHandler) getAPILog(c *gin.Context) {
 		return
 	}
 	filter := bson.M{"_id": objectID}
+	if !(c.Query("include_deleted") == "true" && isAdmin(c)) {
+		db.ExcludeDeleted(filter)
+	}
 	collection := h.mongo.GetCollection("user_api_data")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -169,11 +209,190 @@ func (h *APIHandler) getAPILog(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "API data not found"})
 		return
 	}
+	restoreBodies(&apiData)
 
 	c.JSON(http.StatusOK, apiData)
 }
 
+// restoreBodies prepares stored bodies for a response. Bodies stored
+// gzip-compressed are decompressed; a body that fails to decompress is dropped
+// from the response rather than served as binary. Structured bodies are
+// converted from their BSON form so they render as JSON objects and arrays,
+// like string bodies holding JSON.
+func restoreBodies(apiData *UserAPIData) {
+	if apiData.BodyCompressed {
+		var err error
+		if apiData.RequestBody, err = db.DecompressBody(apiData.RequestBody); err != nil {
+			log.Printf("Failed to decompress request body of %s: %v", apiData.ID.Hex(), err)
+		}
+		if apiData.ResponseBody, err = db.DecompressBody(apiData.ResponseBody); err != nil {
+			log.Printf("Failed to decompress response body of %s: %v", apiData.ID.Hex(), err)
+		}
+		apiData.BodyCompressed = false
+	}
+	apiData.RequestBody = db.PlainBody(apiData.RequestBody)
+	apiData.ResponseBody = db.PlainBody(apiData.ResponseBody)
+}
+
+func (h *APIHandler) deleteAPILog(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	found, err := h.mongo.SoftDeleteUserAPIData(c.Request.Context(), objectID)
+	if err != nil {
+		log.Printf("Failed to delete API data %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete API data"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API data not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API data deleted", "id": objectID.Hex()})
+}
+
+func (h *APIHandler) restoreAPILog(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	found, err := h.mongo.RestoreUserAPIData(c.Request.Context(), objectID)
+	if err != nil {
+		log.Printf("Failed to restore API data %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore API data"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted API data not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API data restored", "id": objectID.Hex()})
+}
+
+func (h *APIHandler) getConsumerHealth(c *gin.Context) {
+	health := h.consumer.Health()
+	status := http.StatusOK
+	if health.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, health)
+}
+
 func (h *APIHandler) SetupAPIRoutes(router *gin.Engine) {
-	router.GET("/api/logs", h.getAPILogs)
-	router.GET("/api/logs/:id", h.getAPILog)
+	routes := h.routes()
+	for _, r := range routes {
+		handlers := append([]gin.HandlerFunc{}, r.middleware...)
+		if r.admin {
+			handlers = append([]gin.HandlerFunc{requireAdmin}, handlers...)
+		}
+		router.Handle(r.method, r.path, append(handlers, r.handler)...)
+	}
+	spec := openAPISpec(routes)
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+}
+
+// routes lists every API endpoint with the details the OpenAPI spec needs.
+func (h *APIHandler) routes() []route {
+	includeDeleted := queryParam{"include_deleted", "boolean", "Include soft-deleted documents (admin only)"}
+	return []route{
+		{method: http.MethodGet, path: "/api/logs", summary: "List stored API logs", handler: h.getAPILogs, status: http.StatusOK, response: PaginatedResponse{},
+			query: []queryParam{
+				{"page", "integer", "Page number, from 1"},
+				{"limit", "integer", "Page size, 1-100"},
+				{"query", "string", "Regex matched against endpoint and URL"},
+				{"hostname", "string", "Regex matched against the URL"},
+				{"method", "string", "HTTP method"},
+				{"has_pii", "boolean", "Only documents with or without PII"},
+				{"risk_level", "string", "Highest risk level"},
+				{"owner", "string", "Owning team, or 'unassigned'"},
+				{"label", "string", "Triage label on the document or one of its findings"},
+				{"finding_value", "string", "Masked value of a finding, matched exactly"},
+				includeDeleted,
+			}},
+		{method: http.MethodGet, path: "/api/logs/trend", summary: "Daily highest risk score and PII count of one endpoint", handler: h.getEndpointTrend, status: http.StatusOK, response: EndpointTrend{},
+			query: []queryParam{
+				{"endpoint", "string", "API endpoint, matched case-insensitively"},
+				{"method", "string", "HTTP method"},
+				{"days", "integer", "Number of days, 1-365 (default 30)"},
+			}},
+		{method: http.MethodGet, path: "/api/logs/:id", summary: "Get a stored API log", handler: h.getAPILog, status: http.StatusOK, response: UserAPIData{},
+			query: []queryParam{includeDeleted}},
+		{method: http.MethodGet, path: "/api/logs/:id/har", summary: "Download an API log as a HAR file", handler: h.exportHAR, status: http.StatusOK, response: services.HAR{},
+			query: []queryParam{{"raw", "boolean", "Export unmasked values (admin only)"}}},
+		{method: http.MethodGet, path: "/api/logs/:id/reanalyze/preview", summary: "Compare a log's stored findings with a fresh analysis, without saving", handler: h.previewReanalysis, status: http.StatusOK, response: services.ReanalysisPreview{}},
+		{method: http.MethodDelete, path: "/api/logs/:id", summary: "Soft-delete an API log", admin: true, handler: h.deleteAPILog, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodPost, path: "/api/logs/bulk-delete", summary: "Preview a bulk delete by filter, or start it with the preview's confirmation token", admin: true, handler: h.bulkDelete, request: bulkDeleteRequest{}, status: http.StatusOK, response: BulkDeletePreview{}},
+		{method: http.MethodPut, path: "/api/logs/:id/labels", summary: "Add or remove triage labels on a log or its findings", handler: h.updateLabels, request: updateLabelsRequest{}, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodGet, path: "/api/labels", summary: "Labels in use with document and finding counts", handler: h.listLabels, status: http.StatusOK, response: listOf{db.LabelCount{}}},
+		{method: http.MethodPost, path: "/api/logs/:id/restore", summary: "Restore a soft-deleted API log", admin: true, handler: h.restoreAPILog, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodPost, path: "/api/pii/test-pattern", summary: "Test a regex against a sample", handler: h.testPIIPattern, request: testPatternRequest{}, status: http.StatusOK, response: services.PatternTestResult{}},
+		{method: http.MethodPost, path: "/api/pii/simulate", summary: "Compare findings on recent documents under a proposed config", admin: true, handler: h.simulatePIIConfig, request: simulateRequest{}, status: http.StatusOK, response: services.SimulationResult{}},
+		{method: http.MethodGet, path: "/api/pii/compare", summary: "Compare findings on recent documents under two stored config versions", admin: true, handler: h.compareConfigVersions, status: http.StatusOK, response: services.ConfigComparison{},
+			query: []queryParam{
+				{"a", "string", "Name of the first config version"},
+				{"b", "string", "Name of the second config version"},
+				{"sample_size", "integer", "Number of recent logs, 1-1000 (default 200)"},
+			}},
+		{method: http.MethodGet, path: "/api/pii/config/versions", summary: "List stored config versions", handler: h.listConfigVersions, status: http.StatusOK, response: listOf{db.ConfigVersion{}}},
+		{method: http.MethodPost, path: "/api/pii/config/versions", summary: "Store a named config version, by default the running config", admin: true, handler: h.createConfigVersion, request: configVersionRequest{}, status: http.StatusCreated, response: db.ConfigVersion{}},
+		{method: http.MethodDelete, path: "/api/pii/config/versions/:name", summary: "Delete a stored config version", admin: true, handler: h.deleteConfigVersion, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodGet, path: "/api/pii/risky-endpoints", summary: "Endpoints ranked by risk", handler: h.getRiskyEndpoints, status: http.StatusOK, response: listOf{RiskyEndpointSummary{}},
+			query: []queryParam{{"limit", "integer", "Number of endpoints, 1-100"}}},
+		{method: http.MethodGet, path: "/api/pii/risk-histogram", summary: "Document counts per risk score bucket", handler: h.getRiskHistogram, status: http.StatusOK, response: listOf{RiskBucket{}},
+			query: []queryParam{
+				{"buckets", "integer", "Number of buckets MongoDB chooses boundaries for, 1-100 (default 10)"},
+				{"boundaries", "string", "Comma-separated increasing bucket boundaries, overriding buckets"},
+			}},
+		{method: http.MethodGet, path: "/api/pii/reports/:id/export", summary: "Export a PII report as JSON or PDF", handler: h.exportPIIReport, status: http.StatusOK, response: PIIAnalysisReport{},
+			query: []queryParam{{"format", "string", "'json' (default) or 'pdf'"}}},
+		{method: http.MethodGet, path: "/api/pii/pattern-stats", summary: "Match counts per pattern", handler: h.getPatternStats, status: http.StatusOK, response: services.PatternStats{}},
+		{method: http.MethodGet, path: "/api/pii/pattern-feedback", summary: "Precision per pattern from reviewed findings", handler: h.getPatternFeedback, status: http.StatusOK, response: listOf{services.PatternCalibration{}}},
+		{method: http.MethodPost, path: "/api/pii/pattern-feedback/calibrate", summary: "Recompute pattern precision from reviewed findings", admin: true, handler: h.calibratePatterns, status: http.StatusOK, response: listOf{services.PatternCalibration{}}},
+		{method: http.MethodGet, path: "/api/pii/config", summary: "Summary of the loaded PII config", handler: h.getPIIConfigSummary, status: http.StatusOK, response: services.ConfigSummary{},
+			query: []queryParam{{"include_patterns", "boolean", "Include pattern names"}}},
+		{method: http.MethodGet, path: "/api/pii/config/export", summary: "Download the active PII config, stored patterns included", admin: true, handler: h.exportPIIConfig, status: http.StatusOK, response: services.PIIConfig{}},
+		{method: http.MethodPost, path: "/api/pii/config/import", summary: "Replace the PII config file and reload it", admin: true, handler: h.importPIIConfig, request: services.PIIConfig{}, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodPost, path: "/api/pii/config/reload", summary: "Reload the PII config", admin: true, handler: h.reloadPIIConfig, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodGet, path: "/api/pii/findings/stream", summary: "Stream findings as NDJSON", handler: h.streamFindings, status: http.StatusOK, contentType: "application/x-ndjson",
+			query: []queryParam{
+				{"from", "string", "RFC3339 start time"},
+				{"to", "string", "RFC3339 end time"},
+				{"risk_level", "string", "Finding risk level"},
+				{"category", "string", "Finding category"},
+			}},
+		{method: http.MethodPost, path: "/api/pii/remask", summary: "Start a job re-masking stored findings", admin: true, handler: h.remaskFindings, status: http.StatusAccepted, response: services.Job{}},
+		{method: http.MethodPost, path: "/api/pii/reanalyze-sample", summary: "Start a job re-analyzing a random sample of stored logs", admin: true, handler: h.reanalyzeSample, status: http.StatusAccepted, response: services.Job{},
+			query: []queryParam{{"percent", "number", "Percent of stored logs to re-analyze, above 0 and at most 100"}}},
+		{method: http.MethodPost, path: "/api/maintenance/migrate-schema", summary: "Start a job upgrading stored logs to the current schema version", admin: true, handler: h.migrateSchema, status: http.StatusAccepted, response: services.Job{}},
+		{method: http.MethodPost, path: "/api/maintenance/report-retention", summary: "Start a job rolling up old PII reports into weekly and monthly summaries", admin: true, handler: h.applyReportRetention, status: http.StatusAccepted, response: services.Job{}},
+		{method: http.MethodPost, path: "/api/maintenance/reindex", summary: "Create missing indexes and list the indexes present", admin: true, handler: h.reindex, status: http.StatusOK, response: ReindexReport{}},
+		{method: http.MethodPost, path: "/api/maintenance/compact", summary: "Start a job deduplicating stored findings", admin: true, handler: h.compactFindings, status: http.StatusAccepted, response: services.Job{}},
+		{method: http.MethodGet, path: "/api/pii/patterns", summary: "List stored PII patterns", handler: h.listPIIPatterns, status: http.StatusOK, response: listOf{db.StoredPIIPattern{}}},
+		{method: http.MethodPost, path: "/api/pii/patterns", summary: "Create a PII pattern", admin: true, handler: h.createPIIPattern, request: piiPatternRequest{}, status: http.StatusCreated, response: db.StoredPIIPattern{}},
+		{method: http.MethodPut, path: "/api/pii/patterns/:id", summary: "Replace a PII pattern", admin: true, handler: h.updatePIIPattern, request: piiPatternRequest{}, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodDelete, path: "/api/pii/patterns/:id", summary: "Delete a PII pattern", admin: true, handler: h.deletePIIPattern, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodGet, path: "/api/owners", summary: "List endpoint owner mappings", handler: h.listEndpointOwners, status: http.StatusOK, response: listOf{db.EndpointOwner{}}},
+		{method: http.MethodGet, path: "/api/owners/rollup", summary: "Traffic and findings per owner", handler: h.getOwnerRollup, status: http.StatusOK, response: listOf{OwnerRollup{}}},
+		{method: http.MethodPost, path: "/api/owners", summary: "Create an endpoint owner mapping", admin: true, handler: h.createEndpointOwner, request: endpointOwnerRequest{}, status: http.StatusCreated, response: db.EndpointOwner{}},
+		{method: http.MethodPut, path: "/api/owners/:id", summary: "Replace an endpoint owner mapping", admin: true, handler: h.updateEndpointOwner, request: endpointOwnerRequest{}, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodDelete, path: "/api/owners/:id", summary: "Delete an endpoint owner mapping", admin: true, handler: h.deleteEndpointOwner, status: http.StatusOK, response: MessageResponse{}},
+		{method: http.MethodGet, path: "/api/jobs/:id", summary: "Get the status of a background job", admin: true, handler: h.getJob, status: http.StatusOK, response: services.Job{}},
+		{method: http.MethodGet, path: "/api/stats/compliance", summary: "Share of documents without PII and the resulting compliance status", handler: h.getComplianceStats, status: http.StatusOK, response: ComplianceStats{}},
+		{method: http.MethodGet, path: "/api/stats/sources", summary: "Document counts and PII rates per ingest source", handler: h.getSourceStats, status: http.StatusOK, response: listOf{SourceStats{}}},
+		{method: http.MethodGet, path: "/api/consumer/health", summary: "Kafka consumer health", handler: h.getConsumerHealth, status: http.StatusOK, response: services.ConsumerHealth{}},
+		{method: http.MethodGet, path: "/api/status", summary: "Status of every component", middleware: []gin.HandlerFunc{statusRateLimit()}, handler: h.getStatus, status: http.StatusOK, response: StatusSummary{}},
+		{method: http.MethodPost, path: "/api/gdpr/erase", summary: "Erase or redact a data subject's values", admin: true, handler: h.eraseSubject, request: eraseSubjectRequest{}, status: http.StatusOK, response: services.ErasureResult{}},
+		{method: http.MethodPost, path: "/api/dsar", summary: "Report where a data subject's values are stored", admin: true, handler: h.subjectAccess, request: subjectAccessRequest{}, status: http.StatusOK, response: services.SubjectReport{},
+			query: []queryParam{
+				{"page", "integer", "Page number, from 1"},
+				{"limit", "integer", "Documents per page, 1-100"},
+			}},
+		{method: http.MethodPost, path: "/api/ingest/ndjson", summary: "Ingest an NDJSON log file", admin: true, handler: h.ingestNDJSON, status: http.StatusOK, response: NDJSONIngestSummary{}},
+		{method: http.MethodPost, path: "/api/ingest/log", summary: "Ingest one log message", admin: true, handler: h.ingestLog, request: services.KafkaLogMessage{}, status: http.StatusOK, response: services.IngestResult{}},
+	}
 }
\ No newline at end of file
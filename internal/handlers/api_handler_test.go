@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter registers the API routes of a handler without backing
+// services, for requests that are answered before reaching a handler.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	(&APIHandler{}).SetupAPIRoutes(router)
+	return router
+}
+
+func TestIngestRoutesRequireAdmin(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	tests := []struct {
+		name string
+		path string
+		key  string
+	}{
+		{name: "log without key", path: "/api/ingest/log"},
+		{name: "log with wrong key", path: "/api/ingest/log", key: "guess"},
+		{name: "ndjson without key", path: "/api/ingest/ndjson"},
+		{name: "ndjson with wrong key", path: "/api/ingest/ndjson", key: "guess"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{}`))
+			if tt.key != "" {
+				req.Header.Set("X-Admin-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
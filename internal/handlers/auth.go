@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAdmin reports whether the request carries the configured admin key.
+// Admin access is disabled entirely when ADMIN_API_KEY is unset.
+func isAdmin(c *gin.Context) bool {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" {
+		return false
+	}
+	provided := c.GetHeader("X-Admin-Key")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(adminKey)) == 1
+}
+
+// requireAdmin rejects requests that don't carry the admin key.
+func requireAdmin(c *gin.Context) {
+	if !isAdmin(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return
+	}
+	c.Next()
+}
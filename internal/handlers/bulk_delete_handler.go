@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const bulkDeleteTokenTTL = 10 * time.Minute
+
+type bulkDeleteRequest struct {
+	Filter            LogFilter `json:"filter"`
+	Hard              bool      `json:"hard"`
+	ConfirmationToken string    `json:"confirmation_token"`
+}
+
+type BulkDeletePreview struct {
+	Matched           int64     `json:"matched"`
+	Hard              bool      `json:"hard"`
+	ConfirmationToken string    `json:"confirmation_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type BulkDeleteResult struct {
+	Deleted int64 `json:"deleted"`
+	Hard    bool  `json:"hard"`
+}
+
+// bulkDelete deletes the documents matching a filter in two steps. Without a
+// confirmation token it only counts the matches and issues a token for that
+// filter; echoing the token back starts a job doing the delete. Documents are
+// soft-deleted unless hard is set.
+func (h *APIHandler) bulkDelete(c *gin.Context) {
+	var req bulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Filter.isEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filter must not be empty"})
+		return
+	}
+	// The token is bound to the exact filter and mode it was issued for.
+	subject, err := json.Marshal(bulkDeleteRequest{Filter: req.Filter, Hard: req.Hard})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter"})
+		return
+	}
+	filter := req.Filter.apply(db.ExcludeDeleted(bson.M{}))
+
+	if req.ConfirmationToken == "" {
+		matched, err := h.mongo.CountUserAPIData(c.Request.Context(), filter)
+		if err != nil {
+			log.Printf("Failed to count documents for bulk delete: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count matching documents"})
+			return
+		}
+		token, expiresAt := h.confirmations.Issue(string(subject))
+		c.JSON(http.StatusOK, BulkDeletePreview{Matched: matched, Hard: req.Hard, ConfirmationToken: token, ExpiresAt: expiresAt})
+		return
+	}
+	if !h.confirmations.Redeem(req.ConfirmationToken, string(subject)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Confirmation token is invalid, expired or for a different filter"})
+		return
+	}
+
+	actor := c.ClientIP()
+	job := h.jobs.Start("bulk_delete", func(ctx context.Context) (interface{}, error) {
+		var deleted int64
+		var err error
+		if req.Hard {
+			deleted, err = h.mongo.DeleteUserAPIDataMatching(ctx, filter)
+		} else {
+			deleted, err = h.mongo.SoftDeleteUserAPIDataMatching(ctx, filter)
+		}
+		entry := db.AuditEntry{
+			Action: "bulk_delete",
+			Actor:  actor,
+			Details: map[string]interface{}{
+				"filter":  req.Filter,
+				"hard":    req.Hard,
+				"deleted": deleted,
+			},
+		}
+		if err != nil {
+			entry.Details["error"] = err.Error()
+		}
+		if auditErr := h.mongo.SaveAuditEntry(ctx, entry); auditErr != nil {
+			log.Printf("Failed to record bulk delete in audit log: %v", auditErr)
+		}
+		return BulkDeleteResult{Deleted: deleted, Hard: req.Hard}, err
+	})
+	c.JSON(http.StatusAccepted, job)
+}
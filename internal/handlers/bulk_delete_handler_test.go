@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBulkDeleteConfirmation(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("preview then confirm", func(mt *mtest.T) {
+		count := func(n int64) bson.D {
+			return mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, bson.D{{Key: "n", Value: n}})
+		}
+		mt.AddMockResponses(
+			count(3),
+			count(3),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(3)}, bson.E{Key: "nModified", Value: int32(3)}),
+			mtest.CreateSuccessResponse(), // audit entry
+		)
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		h := NewAPIHandler(db.MongoInstance{Client: mt.Client, DB: mt.DB}, nil, nil, nil)
+		h.SetupAPIRoutes(router)
+		post := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/logs/bulk-delete", strings.NewReader(body))
+			req.Header.Set("X-Admin-Key", "secret")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			return rec
+		}
+		preview := func() BulkDeletePreview {
+			rec := post(`{"filter":{"risk_level":"HIGH"}}`)
+			var p BulkDeletePreview
+			if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &p) != nil || p.ConfirmationToken == "" {
+				mt.Fatalf("preview = %d %s, want a confirmation token", rec.Code, rec.Body)
+			}
+			return p
+		}
+
+		if rec := post(`{"filter":{}}`); rec.Code != http.StatusBadRequest {
+			mt.Errorf("empty filter status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+		p := preview()
+		if p.Matched != 3 || p.Hard {
+			mt.Errorf("preview = %+v, want 3 soft-deleted matches", p)
+		}
+		// A token only confirms the filter and mode it was issued for.
+		if rec := post(`{"filter":{"risk_level":"HIGH"},"hard":true,"confirmation_token":"` + p.ConfirmationToken + `"}`); rec.Code != http.StatusBadRequest {
+			mt.Errorf("hard delete with a soft preview token status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+		if rec := post(`{"filter":{"risk_level":"HIGH"},"confirmation_token":"` + p.ConfirmationToken + `"}`); rec.Code != http.StatusBadRequest {
+			mt.Errorf("reused token status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+
+		p = preview()
+		rec := post(`{"filter":{"risk_level":"HIGH"},"confirmation_token":"` + p.ConfirmationToken + `"}`)
+		var job services.Job
+		if rec.Code != http.StatusAccepted || json.Unmarshal(rec.Body.Bytes(), &job) != nil {
+			mt.Fatalf("confirm = %d %s, want a job", rec.Code, rec.Body)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for job.Status == services.JobStatusRunning && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			job, _ = h.jobs.Get(job.ID)
+		}
+		if job.Status != services.JobStatusCompleted {
+			mt.Fatalf("job = %+v, want completed", job)
+		}
+		if result, _ := job.Result.(BulkDeleteResult); result.Deleted != 3 || result.Hard {
+			mt.Errorf("job result = %+v, want 3 soft-deleted", job.Result)
+		}
+
+		var commands []string
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			commands = append(commands, event.CommandName)
+			if event.CommandName == "update" {
+				stmt := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+				if got, _ := stmt.Lookup("q", "highest_risk").StringValueOK(); got != "HIGH" {
+					mt.Errorf("update filter = %v, want highest_risk HIGH", stmt.Lookup("q"))
+				}
+				if _, ok := stmt.Lookup("u", "$set", "deleted_at").DateTimeOK(); !ok {
+					mt.Errorf("update = %v, want deleted_at set", stmt.Lookup("u"))
+				}
+			}
+		}
+		if want := "aggregate aggregate update insert"; strings.Join(commands, " ") != want {
+			mt.Errorf("commands = %v, want %s", commands, want)
+		}
+	})
+}
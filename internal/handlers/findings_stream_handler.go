@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const findingsStreamFlushEvery = 100
+
+// StreamedFinding is one line of the findings NDJSON stream: a (masked)
+// finding plus the document it belongs to, and the finding's history on the
+// document's endpoint.
+type StreamedFinding struct {
+	DocumentID  primitive.ObjectID `bson:"document_id" json:"document_id"`
+	APIEndpoint string             `bson:"api_endpoint" json:"api_endpoint"`
+	Method      string             `bson:"method" json:"method"`
+	PIIFinding  `bson:"finding"`
+	History     *db.FindingHistory `bson:"history,omitempty" json:"history,omitempty"`
+}
+
+// streamFindings writes every finding matching the filters as NDJSON, reading
+// from an $unwind cursor so the full result set is never held in memory.
+func (h *APIHandler) streamFindings(c *gin.Context) {
+	documentFilter := bson.M{"has_pii": true}
+	timestampFilter := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lte"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid '" + param + "' timestamp, expected RFC3339"})
+			return
+		}
+		timestampFilter[op] = t
+	}
+	if len(timestampFilter) > 0 {
+		documentFilter["timestamp"] = timestampFilter
+	}
+
+	findingFilter := bson.M{}
+	if riskLevel := c.Query("risk_level"); riskLevel != "" {
+		findingFilter["pii_findings.risk_level"] = riskLevel
+	}
+	if category := c.Query("category"); category != "" {
+		findingFilter["pii_findings.category"] = category
+	}
+
+	pipeline := []bson.M{
+		{"$match": db.ExcludeDeleted(documentFilter)},
+		{"$unwind": "$pii_findings"},
+		{"$match": findingFilter},
+		{"$lookup": bson.M{
+			"from": "finding_occurrences",
+			"let": bson.M{
+				"endpoint":   bson.M{"$toLower": "$api_endpoint"},
+				"method":     bson.M{"$toUpper": "$method"},
+				"finding_id": "$pii_findings.finding_id",
+			},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$api_endpoint", "$$endpoint"}},
+					bson.M{"$eq": bson.A{"$method", "$$method"}},
+					bson.M{"$eq": bson.A{"$finding_id", "$$finding_id"}},
+				}}}},
+				bson.M{"$project": bson.M{"_id": 0, "first_seen": 1, "last_seen": 1, "occurrences": 1}},
+			},
+			"as": "history",
+		}},
+		{"$project": bson.M{
+			"_id":          0,
+			"document_id":  "$_id",
+			"api_endpoint": 1,
+			"method":       1,
+			"finding":      "$pii_findings",
+			"history":      bson.M{"$arrayElemAt": bson.A{"$history", 0}},
+		}},
+	}
+
+	ctx := c.Request.Context()
+	cursor, err := h.mongo.GetCollection("user_api_data").Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate findings stream: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve findings"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+	for cursor.Next(ctx) {
+		var finding StreamedFinding
+		if err := cursor.Decode(&finding); err != nil {
+			log.Printf("Failed to decode streamed finding: %v", err)
+			continue
+		}
+		if err := encoder.Encode(finding); err != nil {
+			// The client went away; stop reading from the cursor.
+			return
+		}
+		written++
+		if written%findingsStreamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Findings stream ended early after %d findings: %v", written, err)
+	}
+	c.Writer.Flush()
+}
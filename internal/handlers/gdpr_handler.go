@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type eraseSubjectRequest struct {
+	Value string `json:"value" binding:"required"`
+	Mode  string `json:"mode"`
+}
+
+// eraseSubject handles right-to-erasure requests. The mode defaults to
+// GDPR_ERASE_MODE, or "redact" when that is unset.
+func (h *APIHandler) eraseSubject(c *gin.Context) {
+	var req eraseSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include a 'value' field"})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = os.Getenv("GDPR_ERASE_MODE")
+	}
+	if req.Mode == "" {
+		req.Mode = services.ErasureModeRedact
+	}
+	if req.Mode != services.ErasureModeDelete && req.Mode != services.ErasureModeRedact {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mode must be 'delete' or 'redact'"})
+		return
+	}
+
+	result, err := h.piiService.EraseSubject(c.Request.Context(), req.Value, req.Mode)
+	if err != nil {
+		log.Printf("Failed to erase subject data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to erase subject data"})
+		return
+	}
+
+	// The audit log must not become another copy of the subject's data, so
+	// only the masked form of the value is recorded.
+	entry := db.AuditEntry{
+		Action: "gdpr_erase",
+		Actor:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"subject":  h.piiService.MaskedForms(req.Value)[0],
+			"mode":     result.Mode,
+			"matched":  result.Matched,
+			"deleted":  result.Deleted,
+			"redacted": result.Redacted,
+		},
+	}
+	if err := h.mongo.SaveAuditEntry(c.Request.Context(), entry); err != nil {
+		log.Printf("Failed to record erasure in audit log: %v", err)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type subjectAccessRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+// subjectAccess handles data subject access requests. It reads only, but is
+// audited like an erasure since it discloses where a person's data is held.
+func (h *APIHandler) subjectAccess(c *gin.Context) {
+	var req subjectAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include a 'value' field"})
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page number"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.DefaultSubjectReportLimit)))
+	if err != nil || limit < 1 || limit > services.MaxSubjectReportLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	report, err := h.piiService.SubjectAccessReport(c.Request.Context(), req.Value, page, limit)
+	if err != nil {
+		log.Printf("Failed to build subject access report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build subject access report"})
+		return
+	}
+
+	entry := db.AuditEntry{
+		Action: "dsar_access",
+		Actor:  c.ClientIP(),
+		Details: map[string]interface{}{
+			"subject": h.piiService.MaskedForms(req.Value)[0],
+			"page":    report.Page,
+			"limit":   report.Limit,
+			"total":   report.Total,
+		},
+	}
+	if err := h.mongo.SaveAuditEntry(c.Request.Context(), entry); err != nil {
+		log.Printf("Failed to record subject access in audit log: %v", err)
+	}
+	c.JSON(http.StatusOK, report)
+}
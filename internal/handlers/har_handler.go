@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// exportHAR downloads a stored document as a single-entry HAR. Detected
+// values are masked unless raw=true is requested with the admin key; a
+// document whose findings were truncated can only be exported raw.
+func (h *APIHandler) exportHAR(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	raw := c.Query("raw") == "true"
+	if raw && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required for raw export"})
+		return
+	}
+
+	doc, err := h.mongo.FindUserAPIDataByID(c.Request.Context(), objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API data not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load API data for HAR export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API data"})
+		return
+	}
+	if !raw {
+		err := h.piiService.MaskStoredValues(&doc)
+		if errors.Is(err, services.ErrFindingsTruncated) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Can't mask this log: " + err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to mask %s for HAR export: %v", objectID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mask API data"})
+			return
+		}
+	}
+	data, err := services.ExportHAR(doc)
+	if err != nil {
+		log.Printf("Failed to export %s as HAR: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export HAR"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "raven-"+objectID.Hex()+".har"))
+	c.Data(http.StatusOK, "application/json", data)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const maxNDJSONLineSize = 10 * 1024 * 1024
+
+// maxIngestBodySize returns the largest log accepted by POST /api/ingest/log,
+// from INGEST_MAX_BODY_BYTES, defaulting to the NDJSON line limit.
+func maxIngestBodySize() int64 {
+	limit := int64(maxNDJSONLineSize)
+	if v := os.Getenv("INGEST_MAX_BODY_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			limit = n
+		} else {
+			log.Printf("Invalid INGEST_MAX_BODY_BYTES '%s', using default %d", v, limit)
+		}
+	}
+	return limit
+}
+
+// ingestLog runs one log message through the ingest pipeline. A log over the
+// size limit is rejected with 413, and one that fails validation with a 400
+// listing every problem, before anything is analyzed.
+func (h *APIHandler) ingestLog(c *gin.Context) {
+	limit := maxIngestBodySize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	var rawLog services.KafkaLogMessage
+	err := c.ShouldBindJSON(&rawLog)
+	var tooLarge *http.MaxBytesError
+	var invalid validator.ValidationErrors
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Log exceeds the %d byte limit", tooLarge.Limit)})
+		return
+	case err != nil && !errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log JSON", "problems": []string{err.Error()}})
+		return
+	}
+	if problems := logProblems(rawLog, invalid, limit); len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log", "problems": problems})
+		return
+	}
+	if rawLog.Source == "" {
+		rawLog.Source = "http_ingest"
+	}
+	result, err := h.pipeline.Ingest(c.Request.Context(), rawLog)
+	if err != nil {
+		if errors.Is(err, services.ErrMalformedLog) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log", "problems": []string{err.Error()}})
+			return
+		}
+		log.Printf("Failed to ingest log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest log"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// logProblems lists the problems that keep a log submitted over HTTP from
+// being ingested: the binding checks it failed, and payloads over limit
+// bytes. Kafka messages aren't checked: proxies log requests as they come,
+// and those are stored as well as they can be.
+func logProblems(rawLog services.KafkaLogMessage, invalid validator.ValidationErrors, limit int64) []string {
+	var problems []string
+	for _, fe := range invalid {
+		problems = append(problems, bindingProblem(fe))
+	}
+	payloads := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"requestPayload", rawLog.RequestPayload},
+		{"responsePayload", rawLog.ResponsePayload},
+	}
+	for _, p := range payloads {
+		if payloadSize(p.payload) > limit {
+			problems = append(problems, fmt.Sprintf("%s must be at most %d bytes", p.name, limit))
+		}
+	}
+	return problems
+}
+
+// bindingProblem describes a failed binding check by the field's JSON name.
+func bindingProblem(fe validator.FieldError) string {
+	name := fe.Field()
+	if field, ok := reflect.TypeOf(services.KafkaLogMessage{}).FieldByName(fe.StructField()); ok {
+		name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	}
+	switch fe.Tag() {
+	case "required":
+		return name + " is required"
+	case "alpha":
+		return name + " must contain only letters"
+	case "startswith":
+		return fmt.Sprintf("%s must start with '%s'", name, fe.Param())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", name, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", name, fe.Param())
+	default:
+		return fmt.Sprintf("%s fails the '%s' check", name, fe.Tag())
+	}
+}
+
+// payloadSize returns the size of a payload as JSON; text payloads count
+// their bytes.
+func payloadSize(payload interface{}) int64 {
+	switch p := payload.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(p))
+	default:
+		data, err := json.Marshal(p)
+		if err != nil {
+			return 0
+		}
+		return int64(len(data))
+	}
+}
+
+type NDJSONIngestSummary struct {
+	Lines        int `json:"lines"`
+	Processed    int `json:"processed"`
+	Stored       int `json:"stored"`
+	Skipped      int `json:"skipped"`
+	Failed       int `json:"failed"`
+	DeadLettered int `json:"dead_lettered"`
+}
+
+// NDJSON lines are stored in batches of up to ndjsonBatchSize logs, or fewer
+// once the buffered lines reach maxNDJSONLineSize bytes.
+const ndjsonBatchSize = 100
+
+// ndjsonLine is a parsed NDJSON line waiting for its batch to be ingested.
+type ndjsonLine struct {
+	number int
+	text   string
+	log    services.KafkaLogMessage
+}
+
+// ingestNDJSON streams an uploaded newline-delimited JSON log file through the
+// ingest pipeline. The file may be sent as the "file" part of a multipart form
+// or directly as the request body.
+func (h *APIHandler) ingestNDJSON(c *gin.Context) {
+	body, err := ndjsonBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineSize)
+	summary := NDJSONIngestSummary{}
+	var pending []ndjsonLine
+	pendingBytes := 0
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		rawLogs := make([]services.KafkaLogMessage, len(pending))
+		for i, line := range pending {
+			rawLogs[i] = line.log
+		}
+		results, errs, batch := h.pipeline.IngestBatch(ctx, rawLogs)
+		for i, line := range pending {
+			switch {
+			case errors.Is(errs[i], services.ErrMalformedLog):
+				h.deadLetterNDJSONLine(ctx, line.number, line.text, errs[i], &summary)
+			case errs[i] != nil:
+				summary.Failed++
+			case results[i].Stored:
+				summary.Processed++
+				summary.Stored++
+			case results[i].SkipReason != "":
+				summary.Processed++
+				summary.Skipped++
+			}
+		}
+		// Documents the batch insert could not store were dead-lettered by it.
+		summary.Failed += batch.Failed - batch.DeadLettered
+		summary.DeadLettered += batch.DeadLettered
+		pending, pendingBytes = pending[:0], 0
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		summary.Lines++
+
+		var rawLog services.KafkaLogMessage
+		err := json.Unmarshal(line, &rawLog)
+		if err == nil {
+			var invalid validator.ValidationErrors
+			errors.As(binding.Validator.ValidateStruct(&rawLog), &invalid)
+			if problems := logProblems(rawLog, invalid, maxIngestBodySize()); len(problems) > 0 {
+				err = fmt.Errorf("invalid log: %s", strings.Join(problems, "; "))
+			}
+		}
+		if err != nil {
+			h.deadLetterNDJSONLine(ctx, summary.Lines, string(line), err, &summary)
+			continue
+		}
+		if rawLog.Source == "" {
+			rawLog.Source = "ndjson_upload"
+		}
+		pending = append(pending, ndjsonLine{number: summary.Lines, text: string(line), log: rawLog})
+		pendingBytes += len(line)
+		if len(pending) == ndjsonBatchSize || pendingBytes >= maxNDJSONLineSize {
+			flush()
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		log.Printf("NDJSON ingest stopped after %d lines: %v", summary.Lines, err)
+		if errors.Is(err, bufio.ErrTooLong) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("NDJSON lines are limited to %d bytes", maxNDJSONLineSize), "summary": summary})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read NDJSON upload: " + err.Error(), "summary": summary})
+		return
+	}
+
+	log.Printf("NDJSON ingest complete: %+v", summary)
+	c.JSON(http.StatusOK, summary)
+}
+
+// deadLetterNDJSONLine stores a line that can never be ingested.
+func (h *APIHandler) deadLetterNDJSONLine(ctx context.Context, number int, line string, err error, summary *NDJSONIngestSummary) {
+	deadLetter := db.DeadLetter{Source: "ndjson_upload", Payload: line, Error: err.Error()}
+	if dlErr := h.mongo.SaveDeadLetter(ctx, deadLetter); dlErr != nil {
+		log.Printf("Failed to dead-letter NDJSON line %d: %v", number, dlErr)
+		summary.Failed++
+		return
+	}
+	summary.DeadLettered++
+}
+
+func ndjsonBody(c *gin.Context) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return c.Request.Body, nil
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, errors.New("invalid multipart upload")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("multipart upload must include a 'file' part")
+		}
+		if err != nil {
+			return nil, errors.New("invalid multipart upload")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+)
+
+func TestIngestLogValidation(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("INGEST_MAX_BODY_BYTES", "1024")
+	router := newTestRouter(t)
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantProblems []string
+	}{
+		{
+			name:         "missing method",
+			body:         `{"path": "/users", "host": "api.example.com"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantProblems: []string{"method is required"},
+		},
+		{
+			name:       "oversized body",
+			body:       `{"method": "POST", "path": "/users", "host": "api.example.com", "requestPayload": "` + strings.Repeat("a", 2048) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "every problem listed",
+			body:       `{"method": "GET /", "path": "users", "request_body_size": -1, "response_body_size": 2147483648}`,
+			wantStatus: http.StatusBadRequest,
+			wantProblems: []string{
+				"request_body_size must be at least 0",
+				"method must contain only letters",
+				"path must start with '/'",
+				"response_body_size must be at most 1073741824",
+				"host is required",
+			},
+		},
+		{
+			name:         "malformed JSON",
+			body:         `{"method": `,
+			wantStatus:   http.StatusBadRequest,
+			wantProblems: []string{"unexpected EOF"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/ingest/log", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Admin-Key", "secret")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var response ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if response.Error == "" {
+				t.Error("response has no error message")
+			}
+			if !reflect.DeepEqual(response.Problems, tt.wantProblems) {
+				t.Errorf("problems = %q, want %q", response.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestLogProblemsPayloadLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    []string
+	}{
+		{name: "no payload"},
+		{name: "text under limit", payload: strings.Repeat("a", 64)},
+		{name: "text over limit", payload: strings.Repeat("a", 65), want: []string{"requestPayload must be at most 64 bytes"}},
+		// {"a":"..."} adds 8 bytes of JSON around the value.
+		{name: "object over limit", payload: map[string]interface{}{"a": strings.Repeat("a", 57)}, want: []string{"requestPayload must be at most 64 bytes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawLog := services.KafkaLogMessage{RequestPayload: tt.payload}
+			if got := logProblems(rawLog, nil, 64); !slices.Equal(got, tt.want) {
+				t.Errorf("logProblems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIngestLogSchemaRequired checks the OpenAPI schema of submitted logs
+// marks the fields their binding tags require.
+func TestIngestLogSchemaRequired(t *testing.T) {
+	router := newTestRouter(t)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not JSON: %v", err)
+	}
+	schema, ok := spec.Components.Schemas["KafkaLogMessage"]
+	if !ok {
+		t.Fatal("spec has no KafkaLogMessage schema")
+	}
+	for _, name := range []string{"method", "path", "host"} {
+		if !slices.Contains(schema.Required, name) {
+			t.Errorf("required = %v, want it to include %q", schema.Required, name)
+		}
+	}
+}
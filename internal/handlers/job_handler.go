@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) getJob(c *gin.Context) {
+	job, ok := h.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// remaskFindings starts a job re-applying the current masking settings to
+// stored findings. Poll GET /api/jobs/:id for the remasked/skipped counts.
+func (h *APIHandler) remaskFindings(c *gin.Context) {
+	job := h.jobs.Start("remask", func(ctx context.Context) (interface{}, error) {
+		return h.piiService.RemaskFindings(ctx)
+	})
+	c.JSON(http.StatusAccepted, job)
+}
+
+// compactFindings starts a job deduplicating stored findings and recomputing
+// document risk metrics. Poll GET /api/jobs/:id for the counts.
+func (h *APIHandler) compactFindings(c *gin.Context) {
+	job := h.jobs.Start("compact", func(ctx context.Context) (interface{}, error) {
+		return h.piiService.CompactFindings(ctx)
+	})
+	c.JSON(http.StatusAccepted, job)
+}
+
+// migrateSchema starts a job upgrading stored documents to the current
+// schema version. Poll GET /api/jobs/:id for the counts.
+func (h *APIHandler) migrateSchema(c *gin.Context) {
+	job := h.jobs.Start("migrate_schema", func(ctx context.Context) (interface{}, error) {
+		return h.piiService.MigrateSchema(ctx)
+	})
+	c.JSON(http.StatusAccepted, job)
+}
+
+// applyReportRetention starts a job rolling up stored PII reports older than
+// the retention policy. Poll GET /api/jobs/:id for the counts.
+func (h *APIHandler) applyReportRetention(c *gin.Context) {
+	policy := services.ReportRetentionPolicyFromEnv()
+	job := h.jobs.Start("report_retention", func(ctx context.Context) (interface{}, error) {
+		return h.piiService.ApplyReportRetention(ctx, policy)
+	})
+	c.JSON(http.StatusAccepted, job)
+}
+
+// reanalyzeSample starts a job re-analyzing and storing a random percent of
+// the stored documents, to estimate a config change's impact cheaply.
+func (h *APIHandler) reanalyzeSample(c *gin.Context) {
+	percent, err := strconv.ParseFloat(c.Query("percent"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "percent must be a number greater than 0 and at most 100"})
+		return
+	}
+	job := h.jobs.Start("reanalyze_sample", func(ctx context.Context) (interface{}, error) {
+		return h.pipeline.ReanalyzeSample(ctx, percent)
+	})
+	c.JSON(http.StatusAccepted, job)
+}
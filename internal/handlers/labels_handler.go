@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxLabelLength = 64
+
+type updateLabelsRequest struct {
+	Add        []string `json:"add"`
+	Remove     []string `json:"remove"`
+	FindingIDs []string `json:"finding_ids"`
+}
+
+// normalizeLabels trims labels and drops blanks, reporting false if any is too long.
+func normalizeLabels(labels []string) ([]string, bool) {
+	var normalized []string
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		if len(label) > maxLabelLength {
+			return nil, false
+		}
+		normalized = append(normalized, label)
+	}
+	return normalized, true
+}
+
+// updateLabels adds and removes triage labels on a document, or on the
+// findings listed in finding_ids. Finding labels survive re-analysis.
+func (h *APIHandler) updateLabels(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var req updateLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	add, okAdd := normalizeLabels(req.Add)
+	remove, okRemove := normalizeLabels(req.Remove)
+	if !okAdd || !okRemove {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Labels must be at most 64 characters"})
+		return
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include labels to add or remove"})
+		return
+	}
+
+	found, err := h.mongo.UpdateLabels(c.Request.Context(), objectID, req.FindingIDs, add, remove)
+	if err != nil {
+		log.Printf("Failed to update labels of %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update labels"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API data not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Labels updated", "id": objectID.Hex()})
+}
+
+func (h *APIHandler) listLabels(c *gin.Context) {
+	labels, err := h.mongo.LabelCounts(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list labels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list labels"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": labels})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUpdateLabels(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	matched := func(n int32) bson.D {
+		return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n}, bson.E{Key: "nModified", Value: n})
+	}
+	tests := []struct {
+		name      string
+		body      string
+		responses []bson.D
+		status    int
+		// updates lists the operator and field of each update sent.
+		updates []string
+		// findingFilter is set when the update targets findings.
+		findingFilter bool
+	}{
+		{
+			name:      "add and remove on the document",
+			body:      `{"add":[" reviewed ","escalated"],"remove":["new"]}`,
+			responses: []bson.D{matched(1), matched(1)},
+			status:    http.StatusOK,
+			updates:   []string{"$addToSet labels", "$pull labels"},
+		},
+		{
+			name:          "add on findings",
+			body:          `{"add":["false-positive"],"finding_ids":["a1b2c3"]}`,
+			responses:     []bson.D{matched(1)},
+			status:        http.StatusOK,
+			updates:       []string{"$addToSet pii_findings.$[f].labels"},
+			findingFilter: true,
+		},
+		{
+			name:      "remove only",
+			body:      `{"remove":["new"]}`,
+			responses: []bson.D{matched(1)},
+			status:    http.StatusOK,
+			updates:   []string{"$pull labels"},
+		},
+		{
+			name:      "unknown document",
+			body:      `{"add":["reviewed"],"remove":["new"]}`,
+			responses: []bson.D{matched(0)},
+			status:    http.StatusNotFound,
+			updates:   []string{"$addToSet labels"},
+		},
+		{name: "blank labels", body: `{"add":["  "]}`, status: http.StatusBadRequest},
+		{name: "label too long", body: `{"add":["` + strings.Repeat("x", maxLabelLength+1) + `"]}`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+
+			id := primitive.NewObjectID()
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/logs/"+id.Hex()+"/labels", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				mt.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			var updates []string
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				stmt := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+				if got := stmt.Lookup("q", "_id").ObjectID(); got != id {
+					mt.Errorf("update filter _id = %s, want %s", got.Hex(), id.Hex())
+				}
+				elems, err := stmt.Lookup("u").Document().Elements()
+				if err != nil || len(elems) != 1 {
+					mt.Fatalf("update = %v, want one operator", stmt.Lookup("u"))
+				}
+				fields, _ := elems[0].Value().Document().Elements()
+				updates = append(updates, elems[0].Key()+" "+fields[0].Key())
+				_, hasFilter := stmt.Lookup("arrayFilters").ArrayOK()
+				if hasFilter != tt.findingFilter {
+					mt.Errorf("arrayFilters present = %v, want %v", hasFilter, tt.findingFilter)
+				}
+			}
+			if strings.Join(updates, ", ") != strings.Join(tt.updates, ", ") {
+				mt.Errorf("updates = %q, want %q", updates, tt.updates)
+			}
+		})
+	}
+}
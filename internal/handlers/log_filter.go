@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LogFilter selects stored documents. The log list takes it as query
+// parameters and bulk operations as JSON.
+type LogFilter struct {
+	Query     string `json:"query,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	Method    string `json:"method,omitempty"`
+	HasPII    *bool  `json:"has_pii,omitempty"`
+	RiskLevel string `json:"risk_level,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Label     string `json:"label,omitempty"`
+	// FindingValue matches the masked value of a finding exactly. Values
+	// are masked consistently, so it finds the same datum elsewhere.
+	FindingValue string `json:"finding_value,omitempty"`
+}
+
+// apply adds the set filters to a MongoDB filter and returns it.
+func (f LogFilter) apply(filter bson.M) bson.M {
+	if f.Query != "" {
+		filter["$or"] = []bson.M{
+			{"api_endpoint": bson.M{"$regex": primitive.Regex{Pattern: f.Query, Options: "i"}}},
+			{"url": bson.M{"$regex": primitive.Regex{Pattern: f.Query, Options: "i"}}},
+		}
+	}
+	if f.Hostname != "" {
+		filter["url"] = bson.M{"$regex": primitive.Regex{Pattern: f.Hostname, Options: "i"}}
+	}
+	if f.Method != "" {
+		filter["method"] = bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(f.Method) + "$", Options: "i"}}
+	}
+	if f.HasPII != nil {
+		filter["has_pii"] = *f.HasPII
+	}
+	if f.RiskLevel != "" {
+		filter["highest_risk"] = f.RiskLevel
+	}
+	// Documents stored before owners were assigned have no owner field.
+	if f.Owner == services.UnassignedOwner {
+		filter["owner"] = bson.M{"$in": bson.A{services.UnassignedOwner, nil}}
+	} else if f.Owner != "" {
+		filter["owner"] = f.Owner
+	}
+	if f.Label != "" {
+		filter["$and"] = []bson.M{{"$or": []bson.M{
+			{"labels": f.Label},
+			{"pii_findings.labels": f.Label},
+		}}}
+	}
+	if f.FindingValue != "" {
+		filter["pii_findings.detected_value"] = f.FindingValue
+	}
+	return filter
+}
+
+// isEmpty reports whether no filter is set, i.e. it matches every document.
+func (f LogFilter) isEmpty() bool {
+	return f == LogFilter{}
+}
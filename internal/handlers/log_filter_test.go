@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetAPILogsByFindingValue(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "masked email", value: "jo****oe"},
+		// Masks are matched exactly, not as a pattern.
+		{name: "masked ssn", value: "12*******89"},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			// The same masked datum leaked from two endpoints.
+			seeded := []UserAPIData{
+				{ID: primitive.NewObjectID(), APIEndpoint: "/users", Method: "POST", HasPII: true, PIIFindings: []PIIFinding{{PIIType: "EMAIL", DetectedValue: tt.value}}},
+				{ID: primitive.NewObjectID(), APIEndpoint: "/orders", Method: "GET", HasPII: true, PIIFindings: []PIIFinding{{PIIType: "EMAIL", DetectedValue: tt.value}}},
+			}
+			docs := make([]bson.D, len(seeded))
+			for i, data := range seeded {
+				raw, err := bson.Marshal(data)
+				if err != nil {
+					mt.Fatal(err)
+				}
+				if err := bson.Unmarshal(raw, &docs[i]); err != nil {
+					mt.Fatal(err)
+				}
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(len(docs))}}),
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, docs...),
+			)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/logs?finding_value="+url.QueryEscape(tt.value), nil))
+			if rec.Code != http.StatusOK {
+				mt.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+
+			queries := 0
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				queries++
+				filter := event.Command.Lookup("filter")
+				if event.CommandName == "aggregate" {
+					// CountDocuments matches in its first stage.
+					filter = event.Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match")
+				}
+				if got, ok := filter.Document().Lookup("pii_findings.detected_value").StringValueOK(); !ok || got != tt.value {
+					mt.Errorf("%s filter = %v, want pii_findings.detected_value %q", event.CommandName, filter, tt.value)
+				}
+			}
+			if queries != 2 {
+				mt.Errorf("%d queries sent, want a count and a find", queries)
+			}
+			var response PaginatedResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				mt.Fatalf("response is not JSON: %v", err)
+			}
+			if response.Total != int64(len(seeded)) || len(response.Items) != len(seeded) {
+				mt.Fatalf("returned %d of %d logs, want both", len(response.Items), response.Total)
+			}
+			for i, item := range response.Items {
+				if item.ID != seeded[i].ID {
+					mt.Errorf("log %d = %s, want %s", i, item.ID.Hex(), seeded[i].ID.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestLogFilterLabel(t *testing.T) {
+	filter := LogFilter{Query: "users", Label: "reviewed"}.apply(bson.M{})
+	if _, ok := filter["$or"]; !ok {
+		t.Errorf("filter %v lost the query", filter)
+	}
+	and, ok := filter["$and"].([]bson.M)
+	if !ok || len(and) != 1 {
+		t.Fatalf("filter %v, want the label under $and", filter)
+	}
+	want := []bson.M{{"labels": "reviewed"}, {"pii_findings.labels": "reviewed"}}
+	if got, _ := and[0]["$or"].([]bson.M); !reflect.DeepEqual(got, want) {
+		t.Errorf("label filter = %v, want %v", got, want)
+	}
+}
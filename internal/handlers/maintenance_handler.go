@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+)
+
+// reindexTimeout bounds a reindex, which builds any missing index in the
+// foreground of the request.
+const reindexTimeout = 5 * time.Minute
+
+type ReindexReport struct {
+	Results []db.IndexResult `json:"results"`
+	Errors  int              `json:"errors"`
+	// Indexes are the indexes on the managed collections after the run.
+	Indexes []db.IndexSpec `json:"indexes"`
+}
+
+// reindex re-runs the index setup done at startup, so indexes added since a
+// deployment started can be created without a redeploy. It is idempotent.
+func (h *APIHandler) reindex(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), reindexTimeout)
+	defer cancel()
+	results, err := h.mongo.EnsureIndexes(ctx)
+	if err != nil {
+		log.Printf("Reindex finished with errors: %v", err)
+	}
+	report := ReindexReport{Results: results}
+	var collections []string
+	seen := map[string]bool{}
+	for _, result := range results {
+		if result.Status == "failed" {
+			report.Errors++
+		}
+		if !seen[result.Collection] {
+			seen[result.Collection] = true
+			collections = append(collections, result.Collection)
+		}
+	}
+	report.Indexes, err = h.mongo.ListIndexSpecs(ctx, collections)
+	if err != nil {
+		log.Printf("Failed to list indexes after reindex: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list indexes"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
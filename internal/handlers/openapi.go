@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// route is one API endpoint. SetupAPIRoutes registers the routes and the
+// OpenAPI spec at /openapi.json is generated from the same list, so the two
+// can't drift apart.
+type route struct {
+	method     string
+	path       string
+	summary    string
+	admin      bool
+	middleware []gin.HandlerFunc
+	handler    gin.HandlerFunc
+	query      []queryParam
+	request    interface{}
+	status     int
+	response   interface{}
+	// contentType overrides application/json for non-JSON responses.
+	contentType string
+}
+
+type queryParam struct {
+	name        string
+	kind        string
+	description string
+}
+
+// listOf describes a {"items": [...]} response of the given item type.
+type listOf struct {
+	item interface{}
+}
+
+// MessageResponse is the shape of responses acknowledging an update or delete.
+type MessageResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id,omitempty"`
+}
+
+// ErrorResponse is the shape of every error response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Problems lists each validation failure of a rejected request.
+	Problems []string `json:"problems,omitempty"`
+}
+
+var pathParamRegex = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// openAPISpec builds an OpenAPI 3 description of routes.
+func openAPISpec(routes []route) gin.H {
+	schemas := gin.H{}
+	paths := gin.H{}
+	for _, r := range routes {
+		path := pathParamRegex.ReplaceAllString(r.path, "{$1}")
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+
+		var parameters []gin.H
+		for _, m := range pathParamRegex.FindAllStringSubmatch(r.path, -1) {
+			parameters = append(parameters, gin.H{"name": m[1], "in": "path", "required": true, "schema": gin.H{"type": "string"}})
+		}
+		for _, q := range r.query {
+			parameters = append(parameters, gin.H{"name": q.name, "in": "query", "description": q.description, "schema": gin.H{"type": q.kind}})
+		}
+
+		errorContent := gin.H{"application/json": gin.H{"schema": schemaFor(reflect.TypeOf(ErrorResponse{}), schemas)}}
+		responses := gin.H{"default": gin.H{"description": "Error", "content": errorContent}}
+		success := gin.H{"description": http.StatusText(r.status)}
+		switch {
+		case r.contentType != "":
+			success["content"] = gin.H{r.contentType: gin.H{}}
+		case r.response != nil:
+			success["content"] = gin.H{"application/json": gin.H{"schema": responseSchema(r.response, schemas)}}
+		}
+		responses[strconv.Itoa(r.status)] = success
+		if r.admin {
+			responses["403"] = gin.H{"description": "Admin access required", "content": errorContent}
+		}
+
+		operation := gin.H{"summary": r.summary, "responses": responses}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if r.request != nil {
+			operation["requestBody"] = gin.H{
+				"required": true,
+				"content":  gin.H{"application/json": gin.H{"schema": schemaFor(reflect.TypeOf(r.request), schemas)}},
+			}
+		}
+		if r.admin {
+			operation["security"] = []gin.H{{"adminKey": []string{}}}
+		}
+		item[strings.ToLower(r.method)] = operation
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info":    gin.H{"title": "RAVEN API", "version": "1.0.0"},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas": schemas,
+			"securitySchemes": gin.H{
+				"adminKey": gin.H{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+			},
+		},
+	}
+}
+
+func responseSchema(response interface{}, schemas gin.H) gin.H {
+	if list, ok := response.(listOf); ok {
+		return gin.H{
+			"type": "object",
+			"properties": gin.H{
+				"items": gin.H{"type": "array", "items": schemaFor(reflect.TypeOf(list.item), schemas)},
+			},
+		}
+	}
+	return schemaFor(reflect.TypeOf(response), schemas)
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// schemaFor derives a JSON schema from a Go type, following its json tags.
+// Named structs are added to schemas and referenced.
+func schemaFor(t reflect.Type, schemas gin.H) gin.H {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return gin.H{"type": "string", "format": "date-time"}
+	case t == objectIDType:
+		return gin.H{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return gin.H{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = gin.H{} // placeholder for recursive types
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return gin.H{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas gin.H) gin.H {
+	properties := gin.H{}
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaFor(field.Type, schemas)
+			if strings.Contains(field.Tag.Get("binding"), "required") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
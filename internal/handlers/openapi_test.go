@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var (
+	openAPIVersionRegex = regexp.MustCompile(`^3\.0\.\d+$`)
+	statusCodeRegex     = regexp.MustCompile(`^[1-5][0-9]{2}$`)
+	templateParamRegex  = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// openAPIMethods are the operation keys of an OpenAPI 3.0 path item.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPISchemaTypes are the schema types of OpenAPI 3.0.
+var openAPISchemaTypes = []string{"string", "number", "integer", "boolean", "array", "object"}
+
+// TestServedOpenAPISpec checks the served spec against the OpenAPI 3.0
+// structure and that it covers every registered route.
+func TestServedOpenAPISpec(t *testing.T) {
+	router := newTestRouter(t)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not JSON: %v", err)
+	}
+
+	if version, _ := spec["openapi"].(string); !openAPIVersionRegex.MatchString(version) {
+		t.Errorf("openapi = %q, want 3.0.x", version)
+	}
+	info, _ := spec["info"].(map[string]interface{})
+	title, _ := info["title"].(string)
+	version, _ := info["version"].(string)
+	if title == "" || version == "" {
+		t.Errorf("info = %v, want a title and version", info)
+	}
+	components, _ := spec["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	securitySchemes, _ := components["securitySchemes"].(map[string]interface{})
+	for name, schema := range schemas {
+		checkSchema(t, "#/components/schemas/"+name, schema, schemas)
+	}
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	if !ok || len(paths) == 0 {
+		t.Fatal("spec has no paths")
+	}
+	for path, rawItem := range paths {
+		if !strings.HasPrefix(path, "/") || strings.Contains(path, ":") {
+			t.Errorf("path %q is not an OpenAPI path template", path)
+		}
+		item, _ := rawItem.(map[string]interface{})
+		for method, rawOperation := range item {
+			where := strings.ToUpper(method) + " " + path
+			if !slices.Contains(openAPIMethods, method) {
+				t.Errorf("%s: unknown operation key", where)
+				continue
+			}
+			checkOperation(t, where, path, rawOperation, schemas, securitySchemes)
+		}
+	}
+
+	for _, r := range router.Routes() {
+		if r.Path == "/openapi.json" {
+			continue
+		}
+		path := pathParamRegex.ReplaceAllString(r.Path, "{$1}")
+		item, _ := paths[path].(map[string]interface{})
+		if _, ok := item[strings.ToLower(r.Method)]; !ok {
+			t.Errorf("route %s %s is missing from the spec", r.Method, r.Path)
+		}
+	}
+}
+
+func checkOperation(t *testing.T, where, path string, rawOperation interface{}, schemas, securitySchemes map[string]interface{}) {
+	t.Helper()
+	operation, ok := rawOperation.(map[string]interface{})
+	if !ok {
+		t.Errorf("%s: operation is not an object", where)
+		return
+	}
+
+	pathParams := map[string]bool{}
+	seen := map[string]bool{}
+	parameters, _ := operation["parameters"].([]interface{})
+	for _, rawParam := range parameters {
+		param, _ := rawParam.(map[string]interface{})
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		if name == "" || !slices.Contains([]string{"query", "header", "path", "cookie"}, in) {
+			t.Errorf("%s: invalid parameter %v", where, param)
+			continue
+		}
+		if seen[in+" "+name] {
+			t.Errorf("%s: duplicate %s parameter %q", where, in, name)
+		}
+		seen[in+" "+name] = true
+		if in == "path" {
+			pathParams[name] = true
+			if param["required"] != true {
+				t.Errorf("%s: path parameter %q is not required", where, name)
+			}
+		}
+		checkSchema(t, where+" parameter "+name, param["schema"], schemas)
+	}
+	for _, m := range templateParamRegex.FindAllStringSubmatch(path, -1) {
+		if !pathParams[m[1]] {
+			t.Errorf("%s: path parameter %q is not declared", where, m[1])
+		}
+		delete(pathParams, m[1])
+	}
+	for name := range pathParams {
+		t.Errorf("%s: path parameter %q is not in the path", where, name)
+	}
+
+	if rawBody, ok := operation["requestBody"]; ok {
+		body, _ := rawBody.(map[string]interface{})
+		checkContent(t, where+" request body", body["content"], schemas, true)
+	}
+
+	responses, _ := operation["responses"].(map[string]interface{})
+	if len(responses) == 0 {
+		t.Errorf("%s: no responses", where)
+	}
+	for code, rawResponse := range responses {
+		if code != "default" && !statusCodeRegex.MatchString(code) {
+			t.Errorf("%s: invalid response key %q", where, code)
+		}
+		response, _ := rawResponse.(map[string]interface{})
+		if description, _ := response["description"].(string); description == "" {
+			t.Errorf("%s: response %s has no description", where, code)
+		}
+		if content, ok := response["content"]; ok {
+			checkContent(t, where+" response "+code, content, schemas, false)
+		}
+	}
+
+	security, _ := operation["security"].([]interface{})
+	for _, rawRequirement := range security {
+		requirement, _ := rawRequirement.(map[string]interface{})
+		for name := range requirement {
+			if _, ok := securitySchemes[name]; !ok {
+				t.Errorf("%s: unknown security scheme %q", where, name)
+			}
+		}
+	}
+}
+
+func checkContent(t *testing.T, where string, rawContent interface{}, schemas map[string]interface{}, needSchema bool) {
+	t.Helper()
+	content, _ := rawContent.(map[string]interface{})
+	if len(content) == 0 {
+		t.Errorf("%s: no content", where)
+	}
+	for mediaType, rawMedia := range content {
+		media, _ := rawMedia.(map[string]interface{})
+		schema, ok := media["schema"]
+		if !ok {
+			if needSchema {
+				t.Errorf("%s: %s has no schema", where, mediaType)
+			}
+			continue
+		}
+		checkSchema(t, where+" "+mediaType, schema, schemas)
+	}
+}
+
+// checkSchema checks a schema object: references resolve, types are OpenAPI
+// 3.0 types and required properties exist.
+func checkSchema(t *testing.T, where string, rawSchema interface{}, schemas map[string]interface{}) {
+	t.Helper()
+	schema, ok := rawSchema.(map[string]interface{})
+	if !ok {
+		t.Errorf("%s: schema is not an object", where)
+		return
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		name, found := strings.CutPrefix(ref, "#/components/schemas/")
+		if _, exists := schemas[name]; !found || !exists {
+			t.Errorf("%s: unresolved $ref %q", where, ref)
+		}
+		return
+	}
+	if typ, ok := schema["type"]; ok && !slices.Contains(openAPISchemaTypes, typ.(string)) {
+		t.Errorf("%s: invalid type %v", where, typ)
+	}
+	if schema["type"] == "array" {
+		checkSchema(t, where+"[]", schema["items"], schemas)
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		checkSchema(t, where+"."+name, property, schemas)
+	}
+	if additional, ok := schema["additionalProperties"]; ok {
+		checkSchema(t, where+"{}", additional, schemas)
+	}
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		if _, ok := properties[name.(string)]; !ok {
+			t.Errorf("%s: required property %v is not defined", where, name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type endpointOwnerRequest struct {
+	Pattern string `json:"pattern" binding:"required"`
+	Owner   string `json:"owner" binding:"required"`
+}
+
+type OwnerRollup struct {
+	Owner       string `bson:"owner" json:"owner"`
+	Documents   int    `bson:"documents" json:"documents"`
+	APIsWithPII int    `bson:"apis_with_pii" json:"apis_with_pii"`
+	PIICount    int    `bson:"pii_count" json:"pii_count"`
+	RiskScore   int    `bson:"risk_score" json:"risk_score"`
+}
+
+// bindEndpointOwner parses an owner mapping from the request body, writing a
+// 400 response and returning false when it is unusable.
+func bindEndpointOwner(c *gin.Context) (db.EndpointOwner, bool) {
+	var req endpointOwnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include pattern and owner"})
+		return db.EndpointOwner{}, false
+	}
+	owner := db.EndpointOwner{Pattern: strings.TrimSpace(req.Pattern), Owner: strings.TrimSpace(req.Owner)}
+	if owner.Pattern == "" || owner.Owner == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pattern and owner must not be blank"})
+		return db.EndpointOwner{}, false
+	}
+	if strings.EqualFold(owner.Owner, services.UnassignedOwner) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner '" + services.UnassignedOwner + "' is reserved"})
+		return db.EndpointOwner{}, false
+	}
+	return owner, true
+}
+
+// reloadAfterOwnerChange applies a mapping change to ingestion. Documents
+// already stored keep the owner they were ingested with.
+func (h *APIHandler) reloadAfterOwnerChange(ctx context.Context) {
+	if err := h.pipeline.Owners().Reload(ctx); err != nil {
+		log.Printf("Failed to reload endpoint owners after mapping change: %v", err)
+	}
+}
+
+func (h *APIHandler) listEndpointOwners(c *gin.Context) {
+	owners, err := h.mongo.ListEndpointOwners(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list endpoint owners: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list endpoint owners"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": owners})
+}
+
+func (h *APIHandler) createEndpointOwner(c *gin.Context) {
+	owner, ok := bindEndpointOwner(c)
+	if !ok {
+		return
+	}
+	created, err := h.mongo.CreateEndpointOwner(c.Request.Context(), owner)
+	if errors.Is(err, db.ErrDuplicateOwnerPattern) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create endpoint owner for %s: %v", owner.Pattern, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create endpoint owner"})
+		return
+	}
+	h.reloadAfterOwnerChange(c.Request.Context())
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *APIHandler) updateEndpointOwner(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	owner, ok := bindEndpointOwner(c)
+	if !ok {
+		return
+	}
+	found, err := h.mongo.UpdateEndpointOwner(c.Request.Context(), objectID, owner)
+	if errors.Is(err, db.ErrDuplicateOwnerPattern) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update endpoint owner %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update endpoint owner"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Endpoint owner not found"})
+		return
+	}
+	h.reloadAfterOwnerChange(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"message": "Endpoint owner updated", "id": objectID.Hex()})
+}
+
+func (h *APIHandler) deleteEndpointOwner(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	found, err := h.mongo.DeleteEndpointOwner(c.Request.Context(), objectID)
+	if err != nil {
+		log.Printf("Failed to delete endpoint owner %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete endpoint owner"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Endpoint owner not found"})
+		return
+	}
+	h.reloadAfterOwnerChange(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{"message": "Endpoint owner deleted", "id": objectID.Hex()})
+}
+
+// getOwnerRollup summarizes stored traffic and findings per owner. Documents
+// ingested before owners existed count as unassigned.
+func (h *APIHandler) getOwnerRollup(c *gin.Context) {
+	pipeline := []bson.M{
+		{"$match": db.ExcludeDeleted(bson.M{})},
+		{"$group": bson.M{
+			"_id":           bson.M{"$ifNull": bson.A{"$owner", services.UnassignedOwner}},
+			"documents":     bson.M{"$sum": 1},
+			"apis_with_pii": bson.M{"$sum": bson.M{"$cond": bson.A{"$has_pii", 1, 0}}},
+			"pii_count":     bson.M{"$sum": "$pii_count"},
+			"risk_score":    bson.M{"$max": "$risk_score"},
+		}},
+		{"$sort": bson.D{{Key: "pii_count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$project": bson.M{
+			"_id":           0,
+			"owner":         "$_id",
+			"documents":     1,
+			"apis_with_pii": 1,
+			"pii_count":     1,
+			"risk_score":    1,
+		}},
+	}
+
+	collection := h.mongo.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate owner rollup: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve owner rollup"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	rollup := []OwnerRollup{}
+	if err := cursor.All(ctx, &rollup); err != nil {
+		log.Printf("Failed to decode owner rollup: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode owner rollup"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": rollup})
+}
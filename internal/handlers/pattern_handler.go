@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type piiPatternRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Mode         string   `json:"mode" binding:"required"`
+	Pattern      string   `json:"pattern" binding:"required"`
+	FieldNames   []string `json:"field_names"`
+	RiskLevel    string   `json:"risk_level" binding:"required"`
+	Category     string   `json:"category" binding:"required"`
+	Tags         []string `json:"tags"`
+	Frameworks   []string `json:"frameworks"`
+	MaskStrategy string   `json:"mask_strategy"`
+	Validate     string   `json:"validate"`
+}
+
+func (r piiPatternRequest) toStored() db.StoredPIIPattern {
+	return db.StoredPIIPattern{
+		Name:         r.Name,
+		Mode:         r.Mode,
+		Pattern:      r.Pattern,
+		FieldNames:   r.FieldNames,
+		RiskLevel:    r.RiskLevel,
+		Category:     r.Category,
+		Tags:         r.Tags,
+		Frameworks:   r.Frameworks,
+		MaskStrategy: r.MaskStrategy,
+		Validate:     r.Validate,
+	}
+}
+
+// bindPIIPattern parses and validates a pattern from the request body,
+// writing a 400 response and returning false when it is unusable.
+func (h *APIHandler) bindPIIPattern(c *gin.Context) (db.StoredPIIPattern, bool) {
+	var req piiPatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include name, mode, pattern, risk_level and category"})
+		return db.StoredPIIPattern{}, false
+	}
+	pattern := req.toStored()
+	if err := h.piiService.ValidateStoredPattern(pattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return db.StoredPIIPattern{}, false
+	}
+	return pattern, true
+}
+
+// reloadAfterPatternChange applies a pattern change to detection. The change
+// is already stored, so a failed reload is logged rather than reported.
+func (h *APIHandler) reloadAfterPatternChange() {
+	if err := h.piiService.Reload(); err != nil {
+		log.Printf("Failed to reload PII config after pattern change: %v", err)
+	}
+}
+
+func (h *APIHandler) listPIIPatterns(c *gin.Context) {
+	patterns, err := h.mongo.ListPIIPatterns(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list PII patterns: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list PII patterns"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": patterns})
+}
+
+func (h *APIHandler) createPIIPattern(c *gin.Context) {
+	pattern, ok := h.bindPIIPattern(c)
+	if !ok {
+		return
+	}
+	created, err := h.mongo.CreatePIIPattern(c.Request.Context(), pattern)
+	if errors.Is(err, db.ErrDuplicatePattern) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create PII pattern %s: %v", pattern.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create PII pattern"})
+		return
+	}
+	h.reloadAfterPatternChange()
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *APIHandler) updatePIIPattern(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	pattern, ok := h.bindPIIPattern(c)
+	if !ok {
+		return
+	}
+	found, err := h.mongo.UpdatePIIPattern(c.Request.Context(), objectID, pattern)
+	if errors.Is(err, db.ErrDuplicatePattern) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update PII pattern %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update PII pattern"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "PII pattern not found"})
+		return
+	}
+	h.reloadAfterPatternChange()
+	c.JSON(http.StatusOK, gin.H{"message": "PII pattern updated", "id": objectID.Hex()})
+}
+
+func (h *APIHandler) deletePIIPattern(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	found, err := h.mongo.DeletePIIPattern(c.Request.Context(), objectID)
+	if err != nil {
+		log.Printf("Failed to delete PII pattern %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete PII pattern"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "PII pattern not found"})
+		return
+	}
+	h.reloadAfterPatternChange()
+	c.JSON(http.StatusOK, gin.H{"message": "PII pattern deleted", "id": objectID.Hex()})
+}
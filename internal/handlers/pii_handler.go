@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type testPatternRequest struct {
+	Pattern string `json:"pattern" binding:"required"`
+	Mode    string `json:"mode"`
+	Sample  string `json:"sample"`
+}
+
+func (h *APIHandler) testPIIPattern(c *gin.Context) {
+	var req testPatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include a 'pattern' field"})
+		return
+	}
+	result, err := h.piiService.TestPattern(c.Request.Context(), req.Pattern, req.Mode, req.Sample)
+	if errors.Is(err, services.ErrPatternTestBusy) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type simulateRequest struct {
+	// Config is merged over the config file unless Replace is set.
+	Config     map[string]interface{} `json:"config" binding:"required"`
+	Replace    bool                   `json:"replace"`
+	SampleSize int                    `json:"sample_size"`
+}
+
+// simulatePIIConfig compares the findings of recent stored documents under
+// the running config and a proposed one, without writing anything.
+func (h *APIHandler) simulatePIIConfig(c *gin.Context) {
+	var req simulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include a 'config' object"})
+		return
+	}
+	if req.SampleSize == 0 {
+		req.SampleSize = services.DefaultSimulationSample
+	}
+	if req.SampleSize < 1 || req.SampleSize > services.MaxSimulationSample {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("sample_size must be between 1 and %d", services.MaxSimulationSample)})
+		return
+	}
+	proposed, err := json.Marshal(req.Config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config"})
+		return
+	}
+	result, err := h.piiService.Simulate(c.Request.Context(), proposed, req.Replace, req.SampleSize)
+	if err != nil {
+		log.Printf("PII config simulation failed: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type configVersionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Config is a whole config; without it the running config is saved.
+	Config map[string]interface{} `json:"config"`
+}
+
+func (h *APIHandler) createConfigVersion(c *gin.Context) {
+	var req configVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request must include a 'name' field"})
+		return
+	}
+	var config json.RawMessage
+	if req.Config != nil {
+		encoded, err := json.Marshal(req.Config)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config"})
+			return
+		}
+		config = encoded
+	}
+	version, err := h.piiService.SaveConfigVersion(c.Request.Context(), req.Name, req.Description, config)
+	if errors.Is(err, db.ErrDuplicateConfigVersion) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to save config version %q: %v", req.Name, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, version)
+}
+
+func (h *APIHandler) listConfigVersions(c *gin.Context) {
+	versions, err := h.mongo.ListConfigVersions(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list config versions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list config versions"})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+func (h *APIHandler) deleteConfigVersion(c *gin.Context) {
+	name := c.Param("name")
+	found, err := h.mongo.DeleteConfigVersion(c.Request.Context(), name)
+	if err != nil {
+		log.Printf("Failed to delete config version %q: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete config version"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Config version not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Config version deleted", "name": name})
+}
+
+// compareConfigVersions compares the findings of recent stored documents
+// under two stored config versions, without writing anything.
+func (h *APIHandler) compareConfigVersions(c *gin.Context) {
+	a, b := c.Query("a"), c.Query("b")
+	if a == "" || b == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameters 'a' and 'b' are required"})
+		return
+	}
+	sampleSize, err := strconv.Atoi(c.DefaultQuery("sample_size", strconv.Itoa(services.DefaultSimulationSample)))
+	if err != nil || sampleSize < 1 || sampleSize > services.MaxSimulationSample {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("sample_size must be between 1 and %d", services.MaxSimulationSample)})
+		return
+	}
+	result, err := h.piiService.CompareConfigVersions(c.Request.Context(), a, b, sampleSize)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Config version not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Config version comparison failed: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// previewReanalysis shows how a stored document's findings would change if it
+// were re-analyzed under the running config. Nothing is written.
+func (h *APIHandler) previewReanalysis(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	doc, err := h.mongo.FindUserAPIDataByID(c.Request.Context(), objectID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API data not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load API data for re-analysis preview: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API data"})
+		return
+	}
+	preview, err := h.piiService.PreviewReanalysis(c.Request.Context(), doc)
+	if err != nil {
+		log.Printf("Failed to preview re-analysis of %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-analyze API data"})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+type RiskyEndpointSummary struct {
+	APIEndpoint string `bson:"api_endpoint" json:"api_endpoint"`
+	Method      string `bson:"method" json:"method"`
+	RiskScore   int    `bson:"risk_score" json:"risk_score"`
+	PIICount    int    `bson:"pii_count" json:"pii_count"`
+	HighestRisk string `bson:"highest_risk" json:"highest_risk"`
+	Documents   int    `bson:"documents" json:"documents"`
+}
+
+func (h *APIHandler) getRiskyEndpoints(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	// Sorting by risk score first makes $first pick the highest_risk of the riskiest document.
+	pipeline := []bson.M{
+		{"$match": db.ExcludeDeleted(bson.M{"has_pii": true})},
+		{"$sort": bson.M{"risk_score": -1}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"api_endpoint": bson.M{"$toLower": "$api_endpoint"},
+				"method":       bson.M{"$toUpper": "$method"},
+			},
+			"risk_score":   bson.M{"$max": "$risk_score"},
+			"pii_count":    bson.M{"$sum": "$pii_count"},
+			"highest_risk": bson.M{"$first": "$highest_risk"},
+			"documents":    bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "risk_score", Value: -1}, {Key: "pii_count", Value: -1}}},
+		{"$limit": limit},
+		{"$project": bson.M{
+			"_id":          0,
+			"api_endpoint": "$_id.api_endpoint",
+			"method":       "$_id.method",
+			"risk_score":   1,
+			"pii_count":    1,
+			"highest_risk": 1,
+			"documents":    1,
+		}},
+	}
+
+	collection := h.mongo.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate risky endpoints: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve risky endpoints"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	endpoints := []RiskyEndpointSummary{}
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		log.Printf("Failed to decode risky endpoints: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode risky endpoints"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": endpoints})
+}
+
+const (
+	defaultRiskHistogramBuckets = 10
+	maxRiskHistogramBuckets     = 100
+)
+
+// RiskBucket counts the documents with a risk score in [min, max). The last
+// bucket of an automatic histogram also holds documents scoring max.
+type RiskBucket struct {
+	Min   int `bson:"min" json:"min"`
+	Max   int `bson:"max" json:"max"`
+	Count int `bson:"count" json:"count"`
+}
+
+// getRiskHistogram counts stored documents per risk score bucket. Buckets
+// are either explicit boundaries, in which case documents scoring outside
+// them are left out, or N buckets of roughly equal size chosen by MongoDB.
+func (h *APIHandler) getRiskHistogram(c *gin.Context) {
+	var boundaries []int
+	if raw := c.Query("boundaries"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			bound, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || (len(boundaries) > 0 && bound <= boundaries[len(boundaries)-1]) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "boundaries must be increasing integers"})
+				return
+			}
+			boundaries = append(boundaries, bound)
+		}
+		if len(boundaries) < 2 || len(boundaries) > maxRiskHistogramBuckets+1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("boundaries must list between 2 and %d values", maxRiskHistogramBuckets+1)})
+			return
+		}
+	}
+	buckets, err := strconv.Atoi(c.DefaultQuery("buckets", strconv.Itoa(defaultRiskHistogramBuckets)))
+	if err != nil || buckets < 1 || buckets > maxRiskHistogramBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("buckets must be between 1 and %d", maxRiskHistogramBuckets)})
+		return
+	}
+
+	match := db.ExcludeDeleted(bson.M{})
+	var group bson.M
+	if boundaries != nil {
+		match["risk_score"] = bson.M{"$gte": boundaries[0], "$lt": boundaries[len(boundaries)-1]}
+		group = bson.M{"$bucket": bson.M{"groupBy": "$risk_score", "boundaries": boundaries}}
+	} else {
+		group = bson.M{"$bucketAuto": bson.M{"groupBy": "$risk_score", "buckets": buckets}}
+	}
+	pipeline := []bson.M{
+		{"$match": match},
+		group,
+		{"$project": bson.M{
+			"_id":   0,
+			"min":   bson.M{"$ifNull": bson.A{"$_id.min", "$_id"}},
+			"max":   "$_id.max",
+			"count": 1,
+		}},
+	}
+
+	collection := h.mongo.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate risk histogram: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve risk histogram"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	histogram := []RiskBucket{}
+	if err := cursor.All(ctx, &histogram); err != nil {
+		log.Printf("Failed to decode risk histogram: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode risk histogram"})
+		return
+	}
+	if boundaries != nil {
+		histogram = fillRiskBuckets(boundaries, histogram)
+	}
+	c.Header("Cache-Control", "private, max-age=60")
+	c.JSON(http.StatusOK, gin.H{"items": histogram})
+}
+
+// fillRiskBuckets lists every bucket between the boundaries, with a zero
+// count for those $bucket left out because no document fell in them.
+func fillRiskBuckets(boundaries []int, counted []RiskBucket) []RiskBucket {
+	counts := make(map[int]int, len(counted))
+	for _, b := range counted {
+		counts[b.Min] = b.Count
+	}
+	filled := make([]RiskBucket, 0, len(boundaries)-1)
+	for i := 0; i < len(boundaries)-1; i++ {
+		filled = append(filled, RiskBucket{Min: boundaries[i], Max: boundaries[i+1], Count: counts[boundaries[i]]})
+	}
+	return filled
+}
+
+func (h *APIHandler) getPatternStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.piiService.PatternStats())
+}
+
+// getPatternFeedback lists the per-pattern precision from reviewed findings,
+// as of the last calibration.
+func (h *APIHandler) getPatternFeedback(c *gin.Context) {
+	calibrations, err := h.piiService.PatternCalibrations(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list pattern feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve pattern feedback"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": calibrations})
+}
+
+// calibratePatterns recomputes the pattern feedback now instead of waiting
+// for the periodic calibration.
+func (h *APIHandler) calibratePatterns(c *gin.Context) {
+	calibrations, err := h.piiService.CalibrateFromFeedback(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to calibrate patterns from feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calibrate patterns"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": calibrations})
+}
+
+func (h *APIHandler) reloadPIIConfig(c *gin.Context) {
+	if err := h.piiService.Reload(); err != nil {
+		log.Printf("Failed to reload PII config: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "PII config reloaded"})
+}
+
+// maxConfigImportSize caps the size of an imported config.
+const maxConfigImportSize = 5 << 20
+
+// exportPIIConfig downloads the active config, stored patterns included, in
+// the shape of regexpii.json.
+func (h *APIHandler) exportPIIConfig(c *gin.Context) {
+	data, err := h.piiService.ExportConfig()
+	if err != nil {
+		log.Printf("Failed to export PII config: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export PII config"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="regexpii.json"`)
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// importPIIConfig replaces the config file with the request body, as
+// downloaded from exportPIIConfig, and reloads it.
+func (h *APIHandler) importPIIConfig(c *gin.Context) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxConfigImportSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Config must be at most %d bytes", maxConfigImportSize)})
+		return
+	}
+	if err := h.piiService.ImportConfig(data); err != nil {
+		log.Printf("Failed to import PII config: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "PII config imported"})
+}
+
+func (h *APIHandler) getPIIConfigSummary(c *gin.Context) {
+	includeNames := c.Query("include_patterns") == "true"
+	c.JSON(http.StatusOK, h.piiService.ConfigSummary(includeNames))
+}
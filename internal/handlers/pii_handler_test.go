@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetRiskHistogram(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	serve := func(mt *mtest.T, query string) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pii/risk-histogram"+query, nil))
+		return rec
+	}
+	decode := func(mt *mtest.T, rec *httptest.ResponseRecorder) []RiskBucket {
+		if rec.Code != http.StatusOK {
+			mt.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		if cc := rec.Header().Get("Cache-Control"); cc != "private, max-age=60" {
+			mt.Errorf("Cache-Control = %q", cc)
+		}
+		var body struct {
+			Items []RiskBucket `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			mt.Fatalf("response is not JSON: %v", err)
+		}
+		return body.Items
+	}
+	bucket := func(min, max, count int32) bson.D {
+		return bson.D{{Key: "min", Value: min}, {Key: "max", Value: max}, {Key: "count", Value: count}}
+	}
+
+	mt.Run("automatic buckets", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+			bucket(0, 20, 7), bucket(20, 95, 3)))
+		got := decode(mt, serve(mt, "?buckets=2"))
+		want := []RiskBucket{{Min: 0, Max: 20, Count: 7}, {Min: 20, Max: 95, Count: 3}}
+		if !reflect.DeepEqual(got, want) {
+			mt.Errorf("histogram = %+v, want %+v", got, want)
+		}
+		stage := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Index(1).Value().Document()
+		if n := stage.Lookup("$bucketAuto", "buckets").AsInt64(); n != 2 {
+			mt.Errorf("$bucketAuto buckets = %d, want 2", n)
+		}
+	})
+
+	mt.Run("explicit boundaries", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+			bucket(0, 10, 4), bucket(50, 100, 1)))
+		got := decode(mt, serve(mt, "?boundaries=0,10,50,100"))
+		want := []RiskBucket{{Min: 0, Max: 10, Count: 4}, {Min: 10, Max: 50}, {Min: 50, Max: 100, Count: 1}}
+		if !reflect.DeepEqual(got, want) {
+			mt.Errorf("histogram = %+v, want %+v", got, want)
+		}
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		score := pipeline.Index(0).Value().Document().Lookup("$match", "risk_score").Document()
+		if score.Lookup("$gte").AsInt64() != 0 || score.Lookup("$lt").AsInt64() != 100 {
+			mt.Errorf("risk_score match = %v, want [0, 100)", score)
+		}
+		if _, err := pipeline.Index(1).Value().Document().LookupErr("$bucket"); err != nil {
+			mt.Errorf("second stage = %v, want $bucket", pipeline.Index(1))
+		}
+	})
+
+	mt.Run("explicit boundaries on an empty collection", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch))
+		got := decode(mt, serve(mt, "?boundaries=0,50,100"))
+		want := []RiskBucket{{Min: 0, Max: 50}, {Min: 50, Max: 100}}
+		if !reflect.DeepEqual(got, want) {
+			mt.Errorf("histogram = %+v, want %+v", got, want)
+		}
+	})
+
+	for _, query := range []string{"?buckets=0", "?buckets=101", "?buckets=x", "?boundaries=10", "?boundaries=0,50,50", "?boundaries=0,a"} {
+		mt.Run("invalid "+query, func(mt *mtest.T) {
+			if rec := serve(mt, query); rec.Code != http.StatusBadRequest {
+				mt.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
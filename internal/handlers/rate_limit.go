@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimit allows each client IP at most limit requests per window. Counts
+// are kept in memory and reset when the window rolls over.
+func rateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	windowStart := time.Now()
+
+	return func(c *gin.Context) {
+		mu.Lock()
+		if time.Since(windowStart) >= window {
+			counts = make(map[string]int)
+			windowStart = time.Now()
+		}
+		ip := c.ClientIP()
+		counts[ip]++
+		allowed := counts[ip] <= limit
+		retryAfter := window - time.Since(windowStart)
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", formatSeconds(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func formatSeconds(d time.Duration) string {
+	seconds := int(d.Seconds() + 0.999)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
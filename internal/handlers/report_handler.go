@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PIIAnalysisReport struct {
+	ID                     primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ReportDate             time.Time              `bson:"report_date" json:"report_date"`
+	TotalAPIsAnalyzed      int                    `bson:"total_apis_analyzed" json:"total_apis_analyzed"`
+	APIsWithPII            int                    `bson:"apis_with_pii" json:"apis_with_pii"`
+	TotalPIIFindings       int                    `bson:"total_pii_findings" json:"total_pii_findings"`
+	RiskLevelBreakdown     map[string]int         `bson:"risk_level_breakdown" json:"risk_level_breakdown"`
+	CategoryBreakdown      map[string]int         `bson:"category_breakdown" json:"category_breakdown"`
+	DetectionModeBreakdown map[string]int         `bson:"detection_mode_breakdown" json:"detection_mode_breakdown"`
+	TopRiskyEndpoints      []RiskyEndpointSummary `bson:"top_risky_endpoints" json:"top_risky_endpoints"`
+	ComplianceStatus       string                 `bson:"compliance_status" json:"compliance_status"`
+	CreatedAt              time.Time              `bson:"created_at" json:"created_at"`
+	Period                 string                 `bson:"period,omitempty" json:"period,omitempty"`
+	RolledUp               int                    `bson:"rolled_up,omitempty" json:"rolled_up,omitempty"`
+}
+
+func (h *APIHandler) exportPIIReport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be 'json' or 'pdf'."})
+		return
+	}
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	collection := h.mongo.GetCollection("pii_analysis_reports")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var report PIIAnalysisReport
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+			return
+		}
+		log.Printf("Failed to find PII report %s: %v", objectID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve report"})
+		return
+	}
+
+	filename := fmt.Sprintf("pii-report-%s.%s", report.ReportDate.In(services.ReportLocation()).Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Status(http.StatusOK)
+	if err := writeReportPDF(c.Writer, report); err != nil {
+		log.Printf("Failed to render PII report %s as PDF: %v", objectID.Hex(), err)
+	}
+}
+
+// writeReportPDF streams report to w as a PDF, one page at a time.
+func writeReportPDF(w io.Writer, report PIIAnalysisReport) error {
+	pdf := newReportPDF(w, "PII Analysis Report")
+	pdf.text("PII Analysis Report", 18, true)
+	pdf.space(6)
+
+	summary := [][2]string{
+		{"Report date", report.ReportDate.In(services.ReportLocation()).Format("2006-01-02 15:04 MST")},
+		{"Compliance status", report.ComplianceStatus},
+		{"APIs analyzed", strconv.Itoa(report.TotalAPIsAnalyzed)},
+		{"APIs with PII", strconv.Itoa(report.APIsWithPII)},
+		{"Total PII findings", strconv.Itoa(report.TotalPIIFindings)},
+	}
+	for _, row := range summary {
+		pdf.row([]float64{170, 340}, row[:], false, false)
+	}
+
+	writeBreakdown(pdf, "Risk level breakdown", report.RiskLevelBreakdown)
+	writeBreakdown(pdf, "Category breakdown", report.CategoryBreakdown)
+	writeBreakdown(pdf, "Detection mode breakdown", report.DetectionModeBreakdown)
+
+	writeSectionHeading(pdf, "Top risky endpoints")
+	widths := []float64{227, 57, 71, 71, 85}
+	pdf.row(widths, []string{"Endpoint", "Method", "Risk score", "PII count", "Highest risk"}, true, true)
+	for _, endpoint := range report.TopRiskyEndpoints {
+		pdf.row(widths, []string{
+			endpoint.APIEndpoint,
+			endpoint.Method,
+			strconv.Itoa(endpoint.RiskScore),
+			strconv.Itoa(endpoint.PIICount),
+			endpoint.HighestRisk,
+		}, false, true)
+	}
+	return pdf.Close()
+}
+
+func writeSectionHeading(pdf *reportPDF, title string) {
+	pdf.space(12)
+	pdf.text(title, 13, true)
+}
+
+func writeBreakdown(pdf *reportPDF, title string, breakdown map[string]int) {
+	writeSectionHeading(pdf, title)
+	keys := make([]string, 0, len(breakdown))
+	for key := range breakdown {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pdf.row([]float64{170, 85}, []string{key, strconv.Itoa(breakdown[key])}, false, true)
+	}
+}
+
+func truncateForCell(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max-3] + "..."
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points, and the page margin.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 40.0
+)
+
+// Objects written up front; pages and their contents are numbered from
+// pdfFirstPageObj on.
+const (
+	pdfCatalogObj = iota + 1
+	pdfPagesObj
+	pdfFontObj
+	pdfBoldFontObj
+	pdfInfoObj
+	pdfFirstPageObj
+)
+
+// reportPDF writes a PDF page by page: each page goes out as soon as it is
+// full, so memory stays bounded by one page however long the report is. It
+// covers what reports need, Helvetica text lines and bordered table rows.
+type reportPDF struct {
+	w       *bufio.Writer
+	written int64
+	offsets map[int]int64
+	nextObj int
+	pages   []int
+	page    *bytes.Buffer
+	y       float64
+	err     error
+}
+
+func newReportPDF(w io.Writer, title string) *reportPDF {
+	pdf := &reportPDF{
+		w:       bufio.NewWriter(w),
+		offsets: map[int]int64{},
+		nextObj: pdfFirstPageObj,
+	}
+	pdf.write("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	pdf.object(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+	pdf.object(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	pdf.object(pdfBoldFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	pdf.object(pdfInfoObj, fmt.Sprintf("<< /Title (%s) /Producer (RAVEN) >>", pdfEscape(title)))
+	return pdf
+}
+
+func (p *reportPDF) write(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.written += int64(n)
+	p.err = err
+}
+
+func (p *reportPDF) object(num int, body string) {
+	p.offsets[num] = p.written
+	p.write("%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+// text writes a line of text, bold or regular, at size points.
+func (p *reportPDF) text(s string, size float64, bold bool) {
+	height := size * 1.5
+	p.reserve(height)
+	fmt.Fprintf(p.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", pdfFont(bold), size, pdfMargin, p.y-size, pdfEscape(s))
+	p.y -= height
+}
+
+// space leaves a vertical gap, unless it falls at the top of a page.
+func (p *reportPDF) space(height float64) {
+	if p.page != nil && p.y-height > pdfMargin {
+		p.y -= height
+	}
+}
+
+// row writes a table row of cells with the given widths, bordered or not.
+// Text too wide for its cell is cut short.
+func (p *reportPDF) row(widths []float64, cells []string, bold, border bool) {
+	const height, size = 18.0, 9.0
+	p.reserve(height)
+	x := pdfMargin
+	for i, cell := range cells {
+		if border {
+			fmt.Fprintf(p.page, "%.2f %.2f %.2f %.2f re S\n", x, p.y-height, widths[i], height)
+		}
+		// Helvetica averages about half its size per character.
+		fit := int((widths[i] - 6) / (size * 0.5))
+		fmt.Fprintf(p.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", pdfFont(bold), size, x+3, p.y-height+5, pdfEscape(truncateForCell(cell, fit)))
+		x += widths[i]
+	}
+	p.y -= height
+}
+
+// reserve starts a new page unless the current one has height left.
+func (p *reportPDF) reserve(height float64) {
+	if p.page != nil && p.y-height >= pdfMargin {
+		return
+	}
+	p.flushPage()
+	p.page = &bytes.Buffer{}
+	p.y = pdfPageHeight - pdfMargin
+}
+
+// flushPage writes out the current page and its contents.
+func (p *reportPDF) flushPage() {
+	if p.page == nil {
+		return
+	}
+	contents, page := p.nextObj, p.nextObj+1
+	p.nextObj += 2
+	p.object(contents, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", p.page.Len(), p.page.Bytes()))
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, pdfBoldFontObj, contents))
+	p.pages = append(p.pages, page)
+	p.page = nil
+	if p.err == nil {
+		p.err = p.w.Flush()
+	}
+}
+
+// Close writes the last page, the page tree and the cross-reference table.
+func (p *reportPDF) Close() error {
+	if p.page == nil && len(p.pages) == 0 {
+		p.reserve(0)
+	}
+	p.flushPage()
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.written
+	p.write("xref\n0 %d\n0000000000 65535 f \n", p.nextObj)
+	for num := 1; num < p.nextObj; num++ {
+		p.write("%010d 00000 n \n", p.offsets[num])
+	}
+	p.write("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.nextObj, pdfCatalogObj, pdfInfoObj, xref)
+	if p.err == nil {
+		p.err = p.w.Flush()
+	}
+	return p.err
+}
+
+func pdfFont(bold bool) string {
+	if bold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// pdfEscape encodes s as the body of a PDF string in WinAnsiEncoding.
+// Characters outside Latin-1 become '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
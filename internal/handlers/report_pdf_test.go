@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkPDF verifies the cross-reference table points at each object and
+// returns the page count.
+func checkPDF(t *testing.T, data []byte) int {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[offset:min(offset+12, len(data))])
+		}
+	}
+	count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(data)
+	if count == nil {
+		t.Fatal("missing page tree")
+	}
+	pages, _ := strconv.Atoi(string(count[1]))
+	return pages
+}
+
+func TestWriteReportPDF(t *testing.T) {
+	endpoints := func(n int) []RiskyEndpointSummary {
+		out := make([]RiskyEndpointSummary, n)
+		for i := range out {
+			out[i] = RiskyEndpointSummary{APIEndpoint: fmt.Sprintf("/api/users/%d/profile", i), Method: "GET", RiskScore: 10, PIICount: 2, HighestRisk: "HIGH"}
+		}
+		return out
+	}
+	tests := []struct {
+		name      string
+		endpoints int
+		minPages  int
+		maxPages  int
+	}{
+		{name: "no endpoints", endpoints: 0, minPages: 1, maxPages: 1},
+		{name: "top ten", endpoints: 10, minPages: 1, maxPages: 1},
+		{name: "spans pages", endpoints: 200, minPages: 4, maxPages: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := PIIAnalysisReport{
+				ReportDate:         time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+				ComplianceStatus:   "NEEDS_ATTENTION",
+				TotalAPIsAnalyzed:  100,
+				RiskLevelBreakdown: map[string]int{"HIGH": 3, "LOW": 1},
+				TopRiskyEndpoints:  endpoints(tt.endpoints),
+			}
+			var buf bytes.Buffer
+			if err := writeReportPDF(&buf, report); err != nil {
+				t.Fatalf("writeReportPDF: %v", err)
+			}
+			pages := checkPDF(t, buf.Bytes())
+			if pages < tt.minPages || pages > tt.maxPages {
+				t.Errorf("pages = %d, want %d-%d", pages, tt.minPages, tt.maxPages)
+			}
+			if !strings.Contains(buf.String(), "(NEEDS_ATTENTION)") {
+				t.Error("compliance status missing from the PDF")
+			}
+		})
+	}
+}
+
+func TestReportPDFStreamsPages(t *testing.T) {
+	var buf bytes.Buffer
+	pdf := newReportPDF(&buf, "Test")
+	for i := 0; i < 100; i++ {
+		pdf.row([]float64{100}, []string{strconv.Itoa(i)}, false, true)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/Type /Page ")) {
+		t.Fatal("no page was written before Close")
+	}
+	if err := pdf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if pages := checkPDF(t, buf.Bytes()); pages < 2 {
+		t.Errorf("pages = %d, want at least 2", pages)
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: `a (b) c\d`, want: `a \(b\) c\\d`},
+		{in: "line\nbreak", want: "line break"},
+		{in: "café", want: "caf\xe9"},
+		{in: "日本", want: "??"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := pdfEscape(tt.in); got != tt.want {
+				t.Errorf("pdfEscape(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type SourceStats struct {
+	Source      string  `bson:"source" json:"source"`
+	Documents   int     `bson:"documents" json:"documents"`
+	APIsWithPII int     `bson:"apis_with_pii" json:"apis_with_pii"`
+	PIICount    int     `bson:"pii_count" json:"pii_count"`
+	PIIRate     float64 `bson:"pii_rate" json:"pii_rate"`
+}
+
+// getSourceStats breaks stored documents down by ingest source, with the
+// share of each source's documents that contain PII. Documents without a
+// source count as unknown.
+func (h *APIHandler) getSourceStats(c *gin.Context) {
+	source := bson.M{"$ifNull": bson.A{"$source", ""}}
+	pipeline := []bson.M{
+		{"$match": db.ExcludeDeleted(bson.M{})},
+		{"$group": bson.M{
+			"_id":           bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{source, ""}}, services.UnknownSource, "$source"}},
+			"documents":     bson.M{"$sum": 1},
+			"apis_with_pii": bson.M{"$sum": bson.M{"$cond": bson.A{"$has_pii", 1, 0}}},
+			"pii_count":     bson.M{"$sum": "$pii_count"},
+		}},
+		{"$sort": bson.D{{Key: "documents", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$project": bson.M{
+			"_id":           0,
+			"source":        "$_id",
+			"documents":     1,
+			"apis_with_pii": 1,
+			"pii_count":     1,
+			"pii_rate":      bson.M{"$divide": bson.A{"$apis_with_pii", "$documents"}},
+		}},
+	}
+
+	collection := h.mongo.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate source stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve source stats"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	stats := []SourceStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		log.Printf("Failed to decode source stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode source stats"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": stats})
+}
+
+type ComplianceStats struct {
+	TotalAPIs            int                           `bson:"total_apis" json:"total_apis"`
+	APIsWithPII          int                           `bson:"apis_with_pii" json:"apis_with_pii"`
+	CriticalRiskAPIs     int                           `bson:"critical_risk_apis" json:"critical_risk_apis"`
+	HighRiskAPIs         int                           `bson:"high_risk_apis" json:"high_risk_apis"`
+	CriticalRiskLevel    string                        `bson:"-" json:"critical_risk_level"`
+	HighRiskLevel        string                        `bson:"-" json:"high_risk_level"`
+	AvgRiskScore         float64                       `bson:"avg_risk_score" json:"avg_risk_score"`
+	TotalPIIFindings     int                           `bson:"total_pii_findings" json:"total_pii_findings"`
+	CompliancePercentage float64                       `bson:"compliance_percentage" json:"compliance_percentage"`
+	ComplianceStatus     string                        `bson:"-" json:"compliance_status"`
+	Thresholds           services.ComplianceThresholds `bson:"-" json:"thresholds"`
+}
+
+// getComplianceStats reports the share of stored documents without PII and
+// the compliance status it maps to under the configured thresholds. The
+// critical and high risk counts are of the two highest configured levels.
+func (h *APIHandler) getComplianceStats(c *gin.Context) {
+	levels := append(h.piiService.RiskLevelsByWeight(), "", "")
+	raw, err := h.mongo.GetPIIComplianceStats(levels[0], levels[1])
+	if err != nil {
+		log.Printf("Failed to aggregate compliance stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve compliance stats"})
+		return
+	}
+	var stats ComplianceStats
+	data, err := bson.Marshal(raw)
+	if err == nil {
+		err = bson.Unmarshal(data, &stats)
+	}
+	if err != nil {
+		log.Printf("Failed to decode compliance stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode compliance stats"})
+		return
+	}
+	stats.CriticalRiskLevel, stats.HighRiskLevel = levels[0], levels[1]
+	stats.Thresholds = services.ComplianceThresholdsFromEnv()
+	stats.ComplianceStatus = stats.Thresholds.Status(stats.CompliancePercentage)
+	c.JSON(http.StatusOK, stats)
+}
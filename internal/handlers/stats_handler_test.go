@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetSourceStats(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("per-source breakdown", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+			bson.D{{Key: "source", Value: "kafka"}, {Key: "documents", Value: int32(40)}, {Key: "apis_with_pii", Value: int32(10)}, {Key: "pii_count", Value: int32(25)}, {Key: "pii_rate", Value: 0.25}},
+			bson.D{{Key: "source", Value: services.UnknownSource}, {Key: "documents", Value: int32(2)}, {Key: "apis_with_pii", Value: int32(0)}, {Key: "pii_count", Value: int32(0)}, {Key: "pii_rate", Value: 0.0}},
+		))
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats/sources", nil))
+		if rec.Code != http.StatusOK {
+			mt.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+
+		event := mt.GetStartedEvent()
+		if event == nil || event.CommandName != "aggregate" {
+			mt.Fatalf("command = %v, want aggregate", event)
+		}
+		stages, _ := event.Command.Lookup("pipeline").Array().Values()
+		match := stages[0].Document().Lookup("$match").Document()
+		if _, ok := match.Lookup("deleted_at").DocumentOK(); !ok {
+			mt.Errorf("$match = %v, want deleted documents excluded", match)
+		}
+		group := stages[1].Document().Lookup("$group").Document()
+		if _, ok := group.Lookup("_id", "$cond").ArrayOK(); !ok {
+			mt.Errorf("$group _id = %v, want missing sources grouped as unknown", group.Lookup("_id"))
+		}
+
+		var response struct {
+			Items []SourceStats `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			mt.Fatalf("response is not JSON: %v", err)
+		}
+		want := []SourceStats{
+			{Source: "kafka", Documents: 40, APIsWithPII: 10, PIICount: 25, PIIRate: 0.25},
+			{Source: services.UnknownSource, Documents: 2},
+		}
+		if len(response.Items) != len(want) {
+			mt.Fatalf("items = %+v, want %+v", response.Items, want)
+		}
+		for i := range want {
+			if response.Items[i] != want[i] {
+				mt.Errorf("item %d = %+v, want %+v", i, response.Items[i], want[i])
+			}
+		}
+	})
+}
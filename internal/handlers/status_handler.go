@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DatabaseStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ConfigStatus struct {
+	Patterns   services.PatternCounts `json:"patterns"`
+	LastReload time.Time              `json:"last_reload"`
+}
+
+// StatusSummary combines the state of every component for a status page.
+type StatusSummary struct {
+	Status       string                  `json:"status"`
+	Database     DatabaseStatus          `json:"database"`
+	Consumer     services.ConsumerHealth `json:"consumer"`
+	Config       ConfigStatus            `json:"config"`
+	LastReportAt *time.Time              `json:"last_report_at,omitempty"`
+	GeneratedAt  time.Time               `json:"generated_at"`
+}
+
+// statusRateLimit returns the per-IP limit for GET /api/status, from
+// STATUS_RATE_LIMIT (requests per minute, default 60).
+func statusRateLimit() gin.HandlerFunc {
+	limit := 60
+	if v := os.Getenv("STATUS_RATE_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		} else {
+			log.Printf("Invalid STATUS_RATE_LIMIT '%s', using default %d", v, limit)
+		}
+	}
+	return rateLimit(limit, time.Minute)
+}
+
+// getStatus reports "down" when MongoDB is unreachable and "degraded" when the
+// consumer is behind or stalled with messages waiting.
+func (h *APIHandler) getStatus(c *gin.Context) {
+	summary := StatusSummary{
+		Status:      "ok",
+		Database:    DatabaseStatus{Status: "ok"},
+		Consumer:    h.consumer.Health(),
+		GeneratedAt: time.Now(),
+	}
+	config := h.piiService.ConfigSummary(false)
+	summary.Config = ConfigStatus{Patterns: config.PatternCounts, LastReload: config.LastReload}
+
+	if err := h.mongo.Ping(c.Request.Context()); err != nil {
+		summary.Database = DatabaseStatus{Status: "down", Error: err.Error()}
+		summary.Status = "down"
+	} else {
+		report, err := h.mongo.FindLatestPIIAnalysisReport()
+		if err != nil {
+			log.Printf("Failed to load latest report for status: %v", err)
+		} else if report != nil {
+			summary.LastReportAt = &report.CreatedAt
+		}
+	}
+	if summary.Status == "ok" && summary.Consumer.Status != "ok" {
+		summary.Status = "degraded"
+	}
+
+	status := http.StatusOK
+	if summary.Status == "down" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, summary)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultTrendDays = 30
+	maxTrendDays     = 365
+)
+
+// TrendPoint is one day of an endpoint's trend.
+type TrendPoint struct {
+	Date         string `bson:"_id" json:"date"`
+	MaxRiskScore int    `bson:"max_risk_score" json:"max_risk_score"`
+	PIICount     int    `bson:"pii_count" json:"pii_count"`
+	Documents    int    `bson:"documents" json:"documents"`
+}
+
+type EndpointTrend struct {
+	APIEndpoint string       `json:"api_endpoint"`
+	Method      string       `json:"method,omitempty"`
+	Days        []TrendPoint `json:"days"`
+}
+
+// getEndpointTrend returns a daily series of the highest risk score and the
+// PII count of one endpoint, oldest day first. Days are bucketed in the
+// report timezone and days without traffic are zero-filled. The endpoint is
+// matched as normalized by the risky endpoints ranking: case-insensitively,
+// with the method upper-cased.
+func (h *APIHandler) getEndpointTrend(c *gin.Context) {
+	endpoint := strings.ToLower(c.Query("endpoint"))
+	if endpoint == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "endpoint is required"})
+		return
+	}
+	method := strings.ToUpper(c.Query("method"))
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultTrendDays)))
+	if err != nil || days < 1 || days > maxTrendDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+
+	loc := services.ReportLocation()
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -(days - 1))
+
+	conditions := bson.A{bson.M{"$eq": bson.A{bson.M{"$toLower": "$api_endpoint"}, endpoint}}}
+	if method != "" {
+		conditions = append(conditions, bson.M{"$eq": bson.A{bson.M{"$toUpper": "$method"}, method}})
+	}
+	pipeline := []bson.M{
+		{"$match": db.ExcludeDeleted(bson.M{
+			"timestamp": bson.M{"$gte": start.UTC()},
+			"$expr":     bson.M{"$and": conditions},
+		})},
+		{"$group": bson.M{
+			"_id":            bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$timestamp", "timezone": loc.String()}},
+			"max_risk_score": bson.M{"$max": "$risk_score"},
+			"pii_count":      bson.M{"$sum": "$pii_count"},
+			"documents":      bson.M{"$sum": 1},
+		}},
+	}
+
+	collection := h.mongo.GetCollection("user_api_data")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("Failed to aggregate endpoint trend: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve endpoint trend"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []TrendPoint
+	if err := cursor.All(ctx, &buckets); err != nil {
+		log.Printf("Failed to decode endpoint trend: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode endpoint trend"})
+		return
+	}
+	byDate := make(map[string]TrendPoint, len(buckets))
+	for _, b := range buckets {
+		byDate[b.Date] = b
+	}
+
+	trend := EndpointTrend{APIEndpoint: endpoint, Method: method, Days: make([]TrendPoint, 0, days)}
+	for day := start; len(trend.Days) < days; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		point, ok := byDate[date]
+		if !ok {
+			point = TrendPoint{Date: date}
+		}
+		trend.Days = append(trend.Days, point)
+	}
+	c.JSON(http.StatusOK, trend)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetEndpointTrend(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	today := time.Now().In(services.ReportLocation())
+	date := func(daysAgo int) string { return today.AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+
+	mt.Run("zero-filled days", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: date(0)}, {Key: "max_risk_score", Value: int32(80)}, {Key: "pii_count", Value: int32(5)}, {Key: "documents", Value: int32(2)}},
+			bson.D{{Key: "_id", Value: date(2)}, {Key: "max_risk_score", Value: int32(30)}, {Key: "pii_count", Value: int32(1)}, {Key: "documents", Value: int32(1)}},
+		))
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/logs/trend?endpoint=/API/Users&method=post&days=3", nil))
+		if rec.Code != http.StatusOK {
+			mt.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+		var trend EndpointTrend
+		if err := json.Unmarshal(rec.Body.Bytes(), &trend); err != nil {
+			mt.Fatalf("response is not JSON: %v", err)
+		}
+		if trend.APIEndpoint != "/api/users" || trend.Method != "POST" {
+			mt.Errorf("trend of %s %s, want POST /api/users", trend.Method, trend.APIEndpoint)
+		}
+		want := []TrendPoint{
+			{Date: date(2), MaxRiskScore: 30, PIICount: 1, Documents: 1},
+			{Date: date(1)},
+			{Date: date(0), MaxRiskScore: 80, PIICount: 5, Documents: 2},
+		}
+		if len(trend.Days) != len(want) {
+			mt.Fatalf("days = %+v, want %+v", trend.Days, want)
+		}
+		for i := range want {
+			if trend.Days[i] != want[i] {
+				mt.Errorf("day %d = %+v, want %+v", i, trend.Days[i], want[i])
+			}
+		}
+
+		event := mt.GetStartedEvent()
+		match := event.Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match").Document()
+		conditions, _ := match.Lookup("$expr", "$and").Array().Values()
+		if len(conditions) != 2 {
+			mt.Fatalf("$expr = %v, want endpoint and method conditions", match.Lookup("$expr"))
+		}
+		endpoint, _ := conditions[0].Document().Lookup("$eq").Array().Values()
+		method, _ := conditions[1].Document().Lookup("$eq").Array().Values()
+		if endpoint[1].StringValue() != "/api/users" || method[1].StringValue() != "POST" {
+			mt.Errorf("matched %v and %v, want the normalized endpoint and method", endpoint[1], method[1])
+		}
+		since := match.Lookup("timestamp", "$gte").Time()
+		if wantSince := time.Date(today.Year(), today.Month(), today.Day()-2, 0, 0, 0, 0, today.Location()); !since.Equal(wantSince) {
+			mt.Errorf("since = %v, want %v", since, wantSince)
+		}
+	})
+
+	for _, query := range []string{"days=3", "endpoint=/api/users&days=0", "endpoint=/api/users&days=366", "endpoint=/api/users&days=x"} {
+		mt.Run("invalid "+query, func(mt *mtest.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			(&APIHandler{mongo: db.MongoInstance{Client: mt.Client, DB: mt.DB}}).SetupAPIRoutes(router)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/logs/trend?"+query, nil))
+			if rec.Code != http.StatusBadRequest {
+				mt.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package nationalid
+
+import "regexp"
+
+// Brazilian CPF: 11 digits, optionally formatted as 000.000.000-00, ending
+// in two mod-11 check digits.
+func init() {
+	register(Country{
+		Code:     "BR",
+		IDType:   "BR_CPF",
+		TLDs:     []string{"br"},
+		Pattern:  regexp.MustCompile(`\b[0-9]{3}\.?[0-9]{3}\.?[0-9]{3}-?[0-9]{2}\b`),
+		Validate: validateCPF,
+	})
+}
+
+func validateCPF(value string) bool {
+	digits := digitsOf(value)
+	if len(digits) != 11 {
+		return false
+	}
+	// Repeated digits pass the checksum but are never issued.
+	allSame := true
+	for i := 1; i < len(digits); i++ {
+		if digits[i] != digits[0] {
+			allSame = false
+			break
+		}
+	}
+	if allSame {
+		return false
+	}
+	for _, n := range []int{9, 10} {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(digits[i]-'0') * (n + 1 - i)
+		}
+		check := sum * 10 % 11
+		if check == 10 {
+			check = 0
+		}
+		if check != int(digits[n]-'0') {
+			return false
+		}
+	}
+	return true
+}
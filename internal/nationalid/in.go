@@ -0,0 +1,53 @@
+package nationalid
+
+import "regexp"
+
+// Indian Aadhaar number: 12 digits, not starting with 0 or 1, whose last
+// digit is a Verhoeff check digit.
+func init() {
+	register(Country{
+		Code:     "IN",
+		IDType:   "IN_AADHAAR",
+		TLDs:     []string{"in"},
+		Pattern:  regexp.MustCompile(`\b[2-9][0-9]{3} ?[0-9]{4} ?[0-9]{4}\b`),
+		Validate: validateAadhaar,
+	})
+}
+
+var (
+	verhoeffMultiply = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermute = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+func validateAadhaar(value string) bool {
+	digits := digitsOf(value)
+	if len(digits) != 12 {
+		return false
+	}
+	check := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		check = verhoeffMultiply[check][verhoeffPermute[i%8][d]]
+	}
+	return check == 0
+}
@@ -0,0 +1,96 @@
+// Package nationalid detects national identification numbers. Each country
+// registers a regex and an optional checksum validator, so supporting a new
+// country only takes a new file in this package.
+package nationalid
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Country describes how one country's national ID is recognized.
+type Country struct {
+	Code     string
+	IDType   string
+	TLDs     []string
+	Pattern  *regexp.Regexp
+	Validate func(string) bool
+}
+
+// Match is a national ID found in a text.
+type Match struct {
+	Country string
+	IDType  string
+	Value   string
+}
+
+var registry = map[string]Country{}
+
+func register(c Country) {
+	registry[c.Code] = c
+}
+
+// Codes returns the codes of all registered countries.
+func Codes() []string {
+	codes := make([]string, 0, len(registry))
+	for code := range registry {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// IsIDType reports whether idType is the ID type of a registered country.
+func IsIDType(idType string) bool {
+	for _, c := range registry {
+		if c.IDType == idType {
+			return true
+		}
+	}
+	return false
+}
+
+// CountryForHost returns the code of the registered country whose TLD the
+// host ends in, if any.
+func CountryForHost(host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for code, c := range registry {
+		for _, tld := range c.TLDs {
+			if strings.HasSuffix(host, "."+tld) {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Detect finds the national IDs of the given countries in text. Unknown
+// country codes are ignored.
+func Detect(text string, countries []string) []Match {
+	var matches []Match
+	for _, code := range countries {
+		c, ok := registry[strings.ToUpper(code)]
+		if !ok {
+			continue
+		}
+		for _, value := range c.Pattern.FindAllString(text, -1) {
+			if c.Validate != nil && !c.Validate(value) {
+				continue
+			}
+			matches = append(matches, Match{Country: c.Code, IDType: c.IDType, Value: value})
+		}
+	}
+	return matches
+}
+
+// digitsOf strips everything but ASCII digits from value.
+func digitsOf(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package nationalid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateAadhaar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"234123412346", true},
+		{"2341 2341 2346", true},
+		{"234123412345", false}, // wrong check digit
+		{"234123412364", false}, // transposed digits
+		{"23412341234", false},  // too short
+	}
+	for _, tt := range tests {
+		if got := validateAadhaar(tt.value); got != tt.want {
+			t.Errorf("validateAadhaar(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCPF(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"529.982.247-25", true},
+		{"52998224725", true},
+		{"529.982.247-26", false}, // wrong second check digit
+		{"529.982.247-15", false}, // wrong first check digit
+		{"111.111.111-11", false}, // repeated digits
+		{"5299822472", false},     // too short
+	}
+	for _, tt := range tests {
+		if got := validateCPF(tt.value); got != tt.want {
+			t.Errorf("validateCPF(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		countries []string
+		want      []Match
+	}{
+		{
+			name:      "valid aadhaar",
+			text:      "aadhaar 2341 2341 2346 on file",
+			countries: []string{"IN"},
+			want:      []Match{{Country: "IN", IDType: "IN_AADHAAR", Value: "2341 2341 2346"}},
+		},
+		{
+			name:      "invalid aadhaar",
+			text:      "aadhaar 2341 2341 2345 on file",
+			countries: []string{"IN"},
+		},
+		{
+			name:      "valid cpf",
+			text:      "cpf: 529.982.247-25",
+			countries: []string{"br"},
+			want:      []Match{{Country: "BR", IDType: "BR_CPF", Value: "529.982.247-25"}},
+		},
+		{
+			name:      "invalid cpf",
+			text:      "cpf: 529.982.247-26",
+			countries: []string{"BR"},
+		},
+		{
+			name:      "nino",
+			text:      "NI number AB 12 34 56 C",
+			countries: []string{"UK"},
+			want:      []Match{{Country: "UK", IDType: "UK_NINO", Value: "AB 12 34 56 C"}},
+		},
+		{
+			name:      "unallocated nino prefix",
+			text:      "NI number GB123456C",
+			countries: []string{"UK"},
+		},
+		{
+			name:      "country not enabled",
+			text:      "cpf: 529.982.247-25",
+			countries: []string{"IN", "XX"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.text, tt.countries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountryForHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		want   string
+		wantOK bool
+	}{
+		{"api.example.com.br", "BR", true},
+		{"API.Example.IN.", "IN", true},
+		{"gov.uk", "UK", true},
+		{"api.example.com", "", false},
+		{"brazil.example", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := CountryForHost(tt.host)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("CountryForHost(%q) = %q, %v, want %q, %v", tt.host, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package nationalid
+
+import (
+	"regexp"
+	"strings"
+)
+
+// UK National Insurance number: two prefix letters, six digits, suffix A-D.
+// D, F, I, Q, U and V never appear in the prefix, and O never as its second letter.
+func init() {
+	register(Country{
+		Code:     "UK",
+		IDType:   "UK_NINO",
+		TLDs:     []string{"uk"},
+		Pattern:  regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?[0-9]{2} ?[0-9]{2} ?[0-9]{2} ?[A-D]\b`),
+		Validate: validateNINO,
+	})
+}
+
+// validateNINO rejects the prefixes HMRC never allocates.
+func validateNINO(value string) bool {
+	switch strings.ToUpper(value[:2]) {
+	case "BG", "GB", "KN", "NK", "NT", "TN", "ZZ":
+		return false
+	}
+	return true
+}
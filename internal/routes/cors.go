@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsMiddleware builds the CORS policy from the environment:
+//
+//	CORS_ALLOWED_ORIGINS    comma-separated origins, "https://*.example.com" wildcards allowed
+//	CORS_ALLOWED_METHODS    comma-separated methods (default GET, POST, PUT, DELETE, OPTIONS)
+//	CORS_ALLOWED_HEADERS    comma-separated request headers (default Origin, Content-Type, X-Admin-Key)
+//	CORS_ALLOW_CREDENTIALS  "true" to let browsers send cookies and auth headers
+//	CORS_MODE               "dev" to allow every origin, for local development only
+//
+// With no origins configured, every cross-origin request is rejected and only
+// same-origin requests are served.
+func corsMiddleware() gin.HandlerFunc {
+	config := cors.Config{
+		AllowMethods: envList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		AllowHeaders: envList("CORS_ALLOWED_HEADERS", []string{"Origin", "Content-Type", "X-Admin-Key"}),
+		MaxAge:       12 * time.Hour,
+	}
+
+	if strings.EqualFold(os.Getenv("CORS_MODE"), "dev") {
+		// Browsers refuse credentials with a wildcard origin, so they stay off here.
+		log.Println("Warning: CORS_MODE=dev, allowing requests from every origin")
+		config.AllowAllOrigins = true
+		return cors.New(config)
+	}
+
+	var origins []string
+	for _, origin := range envList("CORS_ALLOWED_ORIGINS", nil) {
+		if origin == "*" {
+			log.Println("Warning: ignoring '*' in CORS_ALLOWED_ORIGINS, set CORS_MODE=dev to allow every origin")
+			continue
+		}
+		origins = append(origins, strings.TrimSuffix(origin, "/"))
+	}
+	if len(origins) == 0 {
+		log.Println("CORS_ALLOWED_ORIGINS not set, rejecting cross-origin requests")
+		config.AllowOriginFunc = func(string) bool { return false }
+		return cors.New(config)
+	}
+	config.AllowOrigins = origins
+	config.AllowWildcard = true
+	config.AllowCredentials, _ = strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	log.Printf("CORS allowed origins: %s (credentials: %t)", strings.Join(origins, ", "), config.AllowCredentials)
+	return cors.New(config)
+}
+
+// envList reads a comma-separated environment variable, dropping blank entries.
+func envList(key string, fallback []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
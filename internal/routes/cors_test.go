@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string
+		method          string
+		origin          string
+		wantStatus      int
+		wantAllow       string
+		wantCredentials string
+	}{
+		{name: "no origins, same-origin request", wantStatus: http.StatusOK},
+		{name: "no origins, cross-origin request", origin: "https://app.example.com", wantStatus: http.StatusForbidden},
+		{
+			name:       "allowed origin",
+			env:        map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com/, https://admin.example.com"},
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantAllow:  "https://app.example.com",
+		},
+		{
+			name:       "disallowed origin",
+			env:        map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com"},
+			origin:     "https://evil.example.org",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "disallowed origin preflight",
+			env:        map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com"},
+			method:     http.MethodOptions,
+			origin:     "https://evil.example.org",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wildcard subdomain",
+			env:        map[string]string{"CORS_ALLOWED_ORIGINS": "https://*.example.com"},
+			origin:     "https://reports.example.com",
+			wantStatus: http.StatusOK,
+			wantAllow:  "https://reports.example.com",
+		},
+		{
+			name:       "star ignored outside dev mode",
+			env:        map[string]string{"CORS_ALLOWED_ORIGINS": "*"},
+			origin:     "https://evil.example.org",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:            "credentials",
+			env:             map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com", "CORS_ALLOW_CREDENTIALS": "true"},
+			origin:          "https://app.example.com",
+			wantStatus:      http.StatusOK,
+			wantAllow:       "https://app.example.com",
+			wantCredentials: "true",
+		},
+		{
+			name:       "dev mode",
+			env:        map[string]string{"CORS_MODE": "dev", "CORS_ALLOW_CREDENTIALS": "true"},
+			origin:     "https://evil.example.org",
+			wantStatus: http.StatusOK,
+			wantAllow:  "*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"CORS_ALLOWED_ORIGINS", "CORS_ALLOW_CREDENTIALS", "CORS_MODE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			router := gin.New()
+			router.Use(corsMiddleware())
+			router.GET("/api/summary", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "http://api.example.com/api/summary", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
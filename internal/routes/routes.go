@@ -2,17 +2,19 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/gin-contrib/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/RavenSec10/Raven_Backend/db"
 	"github.com/RavenSec10/Raven_Backend/internal/handlers"
+	"github.com/RavenSec10/Raven_Backend/internal/services"
 )
 
-func SetupRoutes(router *gin.Engine, mongoInstance db.MongoInstance) {
-	router.Use(cors.Default())
+func SetupRoutes(router *gin.Engine, mongoInstance db.MongoInstance, piiService *services.PIIService, consumer *services.KafkaConsumerService, pipeline *services.IngestPipeline) {
+	router.Use(corsMiddleware())
 
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "Welcome to the RAVEN API"})
 	})
-	apiHandler := handlers.NewAPIHandler(mongoInstance)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	apiHandler := handlers.NewAPIHandler(mongoInstance, piiService, consumer, pipeline)
 	apiHandler.SetupAPIRoutes(router)
 }
\ No newline at end of file
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const alertRequestTimeout = 10 * time.Second
+
+// AlertNotifier posts alerts to a generic JSON webhook and/or a Slack
+// incoming webhook. A nil *AlertNotifier is valid and behaves as a no-op.
+type AlertNotifier struct {
+	webhookURL string
+	slackURL   string
+	client     *http.Client
+}
+
+// NewAlertNotifierFromEnv returns a notifier for ALERT_WEBHOOK_URL and
+// SLACK_WEBHOOK_URL, or nil when neither is set.
+func NewAlertNotifierFromEnv() *AlertNotifier {
+	webhookURL, slackURL := os.Getenv("ALERT_WEBHOOK_URL"), os.Getenv("SLACK_WEBHOOK_URL")
+	if webhookURL == "" && slackURL == "" {
+		return nil
+	}
+	return &AlertNotifier{
+		webhookURL: webhookURL,
+		slackURL:   slackURL,
+		client:     &http.Client{Timeout: alertRequestTimeout},
+	}
+}
+
+// webhookAlert is the body posted to ALERT_WEBHOOK_URL.
+type webhookAlert struct {
+	Type    string      `json:"type"`
+	Message string      `json:"message"`
+	Alert   interface{} `json:"alert"`
+}
+
+// Notify delivers an alert of the given type to every configured webhook.
+// The Slack webhook gets message as plain text; the generic webhook gets
+// the message along with the alert itself.
+func (n *AlertNotifier) Notify(ctx context.Context, alertType, message string, alert interface{}) error {
+	if n == nil {
+		return nil
+	}
+	var firstErr error
+	if n.webhookURL != "" {
+		if err := n.post(ctx, n.webhookURL, webhookAlert{Type: alertType, Message: message, Alert: alert}); err != nil {
+			firstErr = err
+		}
+	}
+	if n.slackURL != "" {
+		if err := n.post(ctx, n.slackURL, map[string]string{"text": message}); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (n *AlertNotifier) post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		alertDeliveryFailures.Inc()
+		return fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		alertDeliveryFailures.Inc()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("alert webhook returned %s: %s", resp.Status, respBody)
+	}
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		log.Printf("Warning: Could not drain alert webhook response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// slowBody returns a JSON array of n records, each with an email and a long
+// note the slow pattern has to scan in full.
+func slowBody(n int) string {
+	note := strings.Repeat("lorem ipsum dolor sit amet ", 50)
+	var b strings.Builder
+	b.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"email":"user%d@example.com","note":%q}`, i, note)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func TestAnalysisTimeout(t *testing.T) {
+	s := newTestPIIService(t)
+	// A counted repetition the regexp engine can't skip through, and that
+	// never matches the notes.
+	s.config.DetectionModes.ValueOnly.Patterns["SLOW_PATTERN"] = PIIPattern{
+		RegexPattern: `(?:[a-z]+ ?){1,100}!`,
+		RiskLevel:    "LOW",
+		Category:     "PII",
+	}
+	if err := s.compileRegexPatterns(); err != nil {
+		t.Fatalf("compileRegexPatterns: %v", err)
+	}
+	apiData := db.UserAPIData{
+		APIEndpoint:  "/api/users",
+		Method:       "GET",
+		URL:          "https://api.example.com/api/users",
+		ResponseBody: slowBody(60),
+	}
+
+	s.analysisTimeout = 0
+	start := time.Now()
+	full := s.AnalyzePIIInAPIData(context.Background(), apiData)
+	unbounded := time.Since(start)
+	if full.TimedOut || full.AnalysisPartial || full.TotalCount < 60 {
+		t.Fatalf("unbounded analysis: %d findings, timed out %v, partial %v", full.TotalCount, full.TimedOut, full.AnalysisPartial)
+	}
+
+	s.analysisTimeout = unbounded / 10
+	start = time.Now()
+	partial := s.AnalyzePIIInAPIData(context.Background(), apiData)
+	elapsed := time.Since(start)
+	if !partial.TimedOut || !partial.AnalysisPartial {
+		t.Fatalf("timed out %v, partial %v after %s with a %s deadline, want both set", partial.TimedOut, partial.AnalysisPartial, elapsed, s.analysisTimeout)
+	}
+	if partial.TotalCount == 0 || partial.TotalCount >= full.TotalCount {
+		t.Errorf("%d findings within the deadline, want some of the %d", partial.TotalCount, full.TotalCount)
+	}
+	if elapsed > unbounded/2 {
+		t.Errorf("analysis took %s with a %s deadline, unbounded %s", elapsed, s.analysisTimeout, unbounded)
+	}
+	if partial.HighestRisk == "" || partial.RiskScore == 0 {
+		t.Errorf("partial result risk %q, score %d, want the gathered findings scored", partial.HighestRisk, partial.RiskScore)
+	}
+}
+
+func TestAnalysisCancelled(t *testing.T) {
+	s := newTestPIIService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := s.AnalyzePIIInAPIData(ctx, db.UserAPIData{
+		APIEndpoint: "/api/users",
+		Method:      "POST",
+		RequestBody: `{"email":"jane@example.com"}`,
+	})
+	if !result.AnalysisPartial || result.TimedOut || result.TotalCount != 0 {
+		t.Errorf("cancelled analysis: %d findings, partial %v, timed out %v, want none, partial and not timed out",
+			result.TotalCount, result.AnalysisPartial, result.TimedOut)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const compactionBatchSize = 200
+
+type CompactionResult struct {
+	Documents  int `json:"documents"`
+	Compacted  int `json:"compacted"`
+	Duplicates int `json:"duplicates"`
+	Orphans    int `json:"orphans"`
+}
+
+// CompactFindings rewrites stored documents whose findings arrays have
+// drifted: findings sharing an id are merged into one, findings without a
+// type are dropped, and the counts and risk metrics are recomputed from what
+// is left. Findings stored before ids existed get theirs. Documents are
+// processed in batches on the worker pool.
+func (s *PIIService) CompactFindings(ctx context.Context) (CompactionResult, error) {
+	var result CompactionResult
+	afterID := primitive.NilObjectID
+	for {
+		docs, err := s.db.FindAPIDataWithFindingsAfter(ctx, afterID, compactionBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(docs) == 0 {
+			return result, nil
+		}
+		afterID = docs[len(docs)-1].ID
+
+		type outcome struct {
+			duplicates, orphans int
+			compacted           bool
+			err                 error
+		}
+		outcomes := make([]outcome, len(docs))
+		parallelEach(len(docs), func(i int) {
+			if ctx.Err() != nil {
+				outcomes[i].err = ctx.Err()
+				return
+			}
+			doc := &docs[i]
+			findings, duplicates, orphans := compactFindings(*doc)
+			outcomes[i] = outcome{duplicates: duplicates, orphans: orphans}
+			if doc.FindingsTruncated {
+				// Only a sample of the findings is stored, so the count and
+				// risk metrics can't be recomputed from it.
+				if duplicates == 0 && orphans == 0 {
+					return
+				}
+				outcomes[i].err = s.db.UpdatePIIFindings(ctx, doc.ID, findings)
+				outcomes[i].compacted = outcomes[i].err == nil
+				return
+			}
+			summary := s.findingsSummary(findings)
+			if duplicates == 0 && orphans == 0 && len(findings) == len(doc.PIIFindings) && summary.matches(*doc) {
+				return
+			}
+			outcomes[i].err = s.db.UpdatePIISummary(ctx, doc.ID, findings, summary.riskScore, summary.highestRisk, summary.sensitiveFields)
+			outcomes[i].compacted = outcomes[i].err == nil
+		})
+		for _, o := range outcomes {
+			if o.err != nil {
+				return result, o.err
+			}
+			result.Documents++
+			result.Duplicates += o.duplicates
+			result.Orphans += o.orphans
+			if o.compacted {
+				result.Compacted++
+			}
+		}
+	}
+}
+
+// compactFindings merges findings sharing an id, keeping the earliest
+// first-seen time, the latest timestamp, any false-positive flag and every
+// label, and drops findings without a type. Those can't match a detection
+// and only carry a leftover flag or labels. Order is kept.
+func compactFindings(doc db.UserAPIData) (compacted []db.PIIFinding, duplicates, orphans int) {
+	index := make(map[string]int, len(doc.PIIFindings))
+	compacted = make([]db.PIIFinding, 0, len(doc.PIIFindings))
+	for _, f := range doc.PIIFindings {
+		if f.PIIType == "" {
+			orphans++
+			continue
+		}
+		if f.ID == "" {
+			f.ID = storedFindingID(doc, f)
+		}
+		i, seen := index[f.ID]
+		if !seen {
+			index[f.ID] = len(compacted)
+			compacted = append(compacted, f)
+			continue
+		}
+		duplicates++
+		kept := &compacted[i]
+		if !f.FirstSeen.IsZero() && (kept.FirstSeen.IsZero() || f.FirstSeen.Before(kept.FirstSeen)) {
+			kept.FirstSeen = f.FirstSeen
+		}
+		if f.Timestamp.After(kept.Timestamp) {
+			kept.Timestamp = f.Timestamp
+		}
+		kept.FalsePositive = kept.FalsePositive || f.FalsePositive
+		for _, label := range f.Labels {
+			if !slices.Contains(kept.Labels, label) {
+				kept.Labels = append(kept.Labels, label)
+			}
+		}
+	}
+	return compacted, duplicates, orphans
+}
+
+// findingsSummary holds the document fields derived from its findings.
+type findingsSummary struct {
+	count           int
+	riskScore       int
+	highestRisk     string
+	sensitiveFields []string
+}
+
+func (s *PIIService) findingsSummary(findings []db.PIIFinding) findingsSummary {
+	results := make([]PIIDetectionResult, len(findings))
+	summary := findingsSummary{count: len(findings)}
+	for i, f := range findings {
+		results[i] = PIIDetectionResult{RiskLevel: f.RiskLevel}
+		if !slices.Contains(summary.sensitiveFields, f.PIIType) {
+			summary.sensitiveFields = append(summary.sensitiveFields, f.PIIType)
+		}
+	}
+	s.mu.RLock()
+	summary.riskScore, summary.highestRisk = s.calculateRiskMetrics(results)
+	s.mu.RUnlock()
+	return summary
+}
+
+func (f findingsSummary) matches(doc db.UserAPIData) bool {
+	return doc.PIICount == f.count && doc.HasPII == (f.count > 0) &&
+		doc.RiskScore == f.riskScore && doc.HighestRisk == f.highestRisk &&
+		slices.Equal(doc.SensitiveFields, f.sensitiveFields)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCompactFindings(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+	doc := db.UserAPIData{
+		ID:          primitive.NewObjectID(),
+		APIEndpoint: "/api/users",
+		Method:      "GET",
+		HasPII:      true,
+		PIICount:    4,
+		PIIFindings: []db.PIIFinding{
+			{ID: "a", PIIType: "EMAIL", DetectedValue: "j***@example.com", FieldName: "email", Location: "response_body", RiskLevel: "MEDIUM", FirstSeen: day(3), Timestamp: day(3), Labels: []string{"triaged"}},
+			{ID: "b", PIIType: "US_SSN", DetectedValue: "***-**-6789", FieldName: "ssn", Location: "response_body", RiskLevel: "HIGH", FirstSeen: day(2), Timestamp: day(2)},
+			{ID: "a", PIIType: "EMAIL", DetectedValue: "j***@example.com", FieldName: "email", Location: "response_body", RiskLevel: "MEDIUM", FirstSeen: day(1), Timestamp: day(5), Labels: []string{"triaged", "customer"}, FalsePositive: true},
+			// Left behind by a label update on a finding that is gone.
+			{ID: "c", Labels: []string{"stale"}},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("duplicates and orphans", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, toBSONDoc(mt.T, doc)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(1)}, bson.E{Key: "nModified", Value: int32(1)}),
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch),
+		)
+		s := newTestPIIService(mt)
+		s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+		result, err := s.CompactFindings(context.Background())
+		if err != nil {
+			mt.Fatalf("CompactFindings: %v", err)
+		}
+		want := CompactionResult{Documents: 1, Compacted: 1, Duplicates: 1, Orphans: 1}
+		if result != want {
+			mt.Errorf("result = %+v, want %+v", result, want)
+		}
+
+		mt.GetStartedEvent() // find
+		evt := mt.GetStartedEvent()
+		if evt == nil || evt.CommandName != "update" {
+			mt.Fatalf("command = %v, want update", evt)
+		}
+		set := evt.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		var stored []db.PIIFinding
+		if err := set.Lookup("pii_findings").Unmarshal(&stored); err != nil {
+			mt.Fatalf("decode pii_findings: %v", err)
+		}
+		if len(stored) != 2 || stored[0].ID != "a" || stored[1].ID != "b" {
+			mt.Fatalf("stored findings = %+v, want a and b in order", stored)
+		}
+		merged := stored[0]
+		if !merged.FirstSeen.Equal(day(1)) || !merged.Timestamp.Equal(day(5)) || !merged.FalsePositive {
+			mt.Errorf("merged finding first seen %v, timestamp %v, false positive %v, want %v, %v, true",
+				merged.FirstSeen, merged.Timestamp, merged.FalsePositive, day(1), day(5))
+		}
+		if !reflect.DeepEqual(merged.Labels, []string{"triaged", "customer"}) {
+			mt.Errorf("merged labels = %v, want [triaged customer]", merged.Labels)
+		}
+		if count := set.Lookup("pii_count").AsInt64(); count != 2 {
+			mt.Errorf("pii_count = %d, want 2", count)
+		}
+		if highest := set.Lookup("highest_risk").StringValue(); highest != "HIGH" {
+			mt.Errorf("highest_risk = %s, want HIGH", highest)
+		}
+	})
+}
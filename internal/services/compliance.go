@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+const (
+	StatusCompliant          = "COMPLIANT"
+	StatusPartiallyCompliant = "PARTIALLY_COMPLIANT"
+	StatusNonCompliant       = "NON_COMPLIANT"
+)
+
+// ComplianceThresholds are the compliance percentages, the share of
+// documents without PII, at which a status is reached. Below Partial a
+// dataset is non-compliant.
+type ComplianceThresholds struct {
+	Partial   float64 `json:"partial"`
+	Compliant float64 `json:"compliant"`
+}
+
+var DefaultComplianceThresholds = ComplianceThresholds{Partial: 80, Compliant: 95}
+
+var (
+	complianceThresholdsOnce sync.Once
+	complianceThresholds     ComplianceThresholds
+)
+
+// Validate checks that both thresholds are percentages and that the
+// partial threshold doesn't exceed the compliant one.
+func (t ComplianceThresholds) Validate() error {
+	if t.Partial < 0 || t.Compliant > 100 {
+		return fmt.Errorf("compliance thresholds must be between 0 and 100, got %g and %g", t.Partial, t.Compliant)
+	}
+	if t.Partial > t.Compliant {
+		return fmt.Errorf("partial compliance threshold %g is above the compliant threshold %g", t.Partial, t.Compliant)
+	}
+	return nil
+}
+
+// Status returns the compliance status for a compliance percentage.
+func (t ComplianceThresholds) Status(percentage float64) string {
+	switch {
+	case percentage >= t.Compliant:
+		return StatusCompliant
+	case percentage >= t.Partial:
+		return StatusPartiallyCompliant
+	default:
+		return StatusNonCompliant
+	}
+}
+
+// ComplianceThresholdsFromEnv reads COMPLIANCE_PARTIAL_THRESHOLD and
+// COMPLIANCE_COMPLIANT_THRESHOLD, defaulting to 80 and 95. Thresholds out of
+// order fall back to the defaults. Every compliance status is computed with
+// these thresholds.
+func ComplianceThresholdsFromEnv() ComplianceThresholds {
+	complianceThresholdsOnce.Do(func() {
+		complianceThresholds = ComplianceThresholds{
+			Partial:   envFloat("COMPLIANCE_PARTIAL_THRESHOLD", DefaultComplianceThresholds.Partial),
+			Compliant: envFloat("COMPLIANCE_COMPLIANT_THRESHOLD", DefaultComplianceThresholds.Compliant),
+		}
+		if err := complianceThresholds.Validate(); err != nil {
+			log.Printf("Warning: %v, using defaults", err)
+			complianceThresholds = DefaultComplianceThresholds
+		}
+	})
+	return complianceThresholds
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestComplianceThresholdsStatus(t *testing.T) {
+	tests := []struct {
+		thresholds ComplianceThresholds
+		percentage float64
+		want       string
+	}{
+		{DefaultComplianceThresholds, 100, StatusCompliant},
+		{DefaultComplianceThresholds, 95, StatusCompliant},
+		{DefaultComplianceThresholds, 94.9, StatusPartiallyCompliant},
+		{DefaultComplianceThresholds, 80, StatusPartiallyCompliant},
+		{DefaultComplianceThresholds, 79.9, StatusNonCompliant},
+		{ComplianceThresholds{Partial: 50, Compliant: 70}, 75, StatusCompliant},
+		{ComplianceThresholds{Partial: 50, Compliant: 70}, 70, StatusCompliant},
+		{ComplianceThresholds{Partial: 50, Compliant: 70}, 60, StatusPartiallyCompliant},
+		{ComplianceThresholds{Partial: 50, Compliant: 70}, 49, StatusNonCompliant},
+		{ComplianceThresholds{Partial: 99, Compliant: 99.9}, 99.5, StatusPartiallyCompliant},
+		{ComplianceThresholds{Partial: 99, Compliant: 99.9}, 95, StatusNonCompliant},
+		// Equal thresholds leave no partial band.
+		{ComplianceThresholds{Partial: 90, Compliant: 90}, 90, StatusCompliant},
+		{ComplianceThresholds{Partial: 90, Compliant: 90}, 89.9, StatusNonCompliant},
+		{ComplianceThresholds{Partial: 0, Compliant: 100}, 0, StatusPartiallyCompliant},
+	}
+	for _, tt := range tests {
+		if got := tt.thresholds.Status(tt.percentage); got != tt.want {
+			t.Errorf("%+v.Status(%g) = %s, want %s", tt.thresholds, tt.percentage, got, tt.want)
+		}
+	}
+}
+
+func TestComplianceThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		thresholds ComplianceThresholds
+		wantErr    bool
+	}{
+		{DefaultComplianceThresholds, false},
+		{ComplianceThresholds{Partial: 90, Compliant: 90}, false},
+		{ComplianceThresholds{Partial: 0, Compliant: 100}, false},
+		{ComplianceThresholds{Partial: 95, Compliant: 80}, true},
+		{ComplianceThresholds{Partial: -1, Compliant: 80}, true},
+		{ComplianceThresholds{Partial: 80, Compliant: 101}, true},
+		{ComplianceThresholds{Partial: 101, Compliant: 100}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.thresholds.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() = %v, want error %v", tt.thresholds, err, tt.wantErr)
+		}
+	}
+}
+
+func TestComplianceThresholdsFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		partial   string
+		compliant string
+		want      ComplianceThresholds
+	}{
+		{name: "unset", want: DefaultComplianceThresholds},
+		{name: "custom", partial: "60", compliant: "75", want: ComplianceThresholds{Partial: 60, Compliant: 75}},
+		{name: "partial only", partial: "90", want: ComplianceThresholds{Partial: 90, Compliant: 95}},
+		{name: "out of order", partial: "96", compliant: "90", want: DefaultComplianceThresholds},
+		{name: "out of range", compliant: "120", want: DefaultComplianceThresholds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COMPLIANCE_PARTIAL_THRESHOLD", tt.partial)
+			t.Setenv("COMPLIANCE_COMPLIANT_THRESHOLD", tt.compliant)
+			complianceThresholdsOnce = sync.Once{}
+			t.Cleanup(func() { complianceThresholdsOnce = sync.Once{} })
+			if got := ComplianceThresholdsFromEnv(); got != tt.want {
+				t.Errorf("ComplianceThresholdsFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+type PatternCounts struct {
+	FieldBased        int `json:"field_based"`
+	ValueOnly         int `json:"value_only"`
+	KeywordBased      int `json:"keyword_based"`
+	IdentityDocuments int `json:"identity_documents"`
+}
+
+type PatternNames struct {
+	FieldBased        []string `json:"field_based"`
+	ValueOnly         []string `json:"value_only"`
+	KeywordBased      []string `json:"keyword_based"`
+	IdentityDocuments []string `json:"identity_documents"`
+}
+
+// ConfigSummary describes the loaded PII config without exposing any regexes.
+type ConfigSummary struct {
+	PatternCounts PatternCounts  `json:"pattern_counts"`
+	Categories    []string       `json:"categories"`
+	RiskLevels    map[string]int `json:"risk_levels"`
+	Patterns      *PatternNames  `json:"patterns,omitempty"`
+	Profile       *ActiveProfile `json:"profile,omitempty"`
+	LastReload    time.Time      `json:"last_reload"`
+}
+
+// ConfigSummary summarizes the active config. Pattern names are only listed
+// when includeNames is set.
+func (s *PIIService) ConfigSummary(includeNames bool) ConfigSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	modes := s.config.DetectionModes
+	summary := ConfigSummary{
+		PatternCounts: PatternCounts{
+			FieldBased:        len(modes.FieldBased.Patterns),
+			ValueOnly:         len(modes.ValueOnly.Patterns),
+			KeywordBased:      len(modes.KeywordBased.Patterns),
+			IdentityDocuments: len(s.config.IdentityDocuments),
+		},
+		Categories: append([]string{}, s.config.Categories...),
+		RiskLevels: make(map[string]int, len(s.config.RiskLevels)),
+		LastReload: s.lastReload,
+		Profile:    s.profile,
+	}
+	for level, value := range s.config.RiskLevels {
+		summary.RiskLevels[level] = value
+	}
+	if includeNames {
+		summary.Patterns = &PatternNames{
+			FieldBased:        sortedKeys(modes.FieldBased.Patterns),
+			ValueOnly:         sortedKeys(modes.ValueOnly.Patterns),
+			KeywordBased:      sortedKeys(modes.KeywordBased.Patterns),
+			IdentityDocuments: sortedKeys(s.config.IdentityDocuments),
+		}
+	}
+	return summary
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
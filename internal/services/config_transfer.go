@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// configFileMu serializes imports, which rewrite the config file.
+var configFileMu sync.Mutex
+
+// ExportConfig returns the active config, the file merged with the stored
+// patterns, encoded in the shape of regexpii.json. Stored patterns are
+// marked "stored": true.
+func (s *PIIService) ExportConfig() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := json.MarshalIndent(s.config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PII config: %w", err)
+	}
+	return data, nil
+}
+
+// ImportConfig validates a config in the shape of regexpii.json, replaces
+// the config file with it and reloads. The running config and the file are
+// left untouched if the config is invalid, and the file is restored if the
+// reload fails. Patterns marked stored are left out of the file: they live in
+// MongoDB and are merged over the imported config as usual, so importing an
+// export is lossless.
+func (s *PIIService) ImportConfig(data []byte) error {
+	config, err := parseImportedConfig(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode PII config: %w", err)
+	}
+
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+	previous, err := readPIIConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := writePIIConfigFile(append(encoded, '\n')); err != nil {
+		return err
+	}
+	if err := s.Reload(); err != nil {
+		if restoreErr := writePIIConfigFile(previous); restoreErr != nil {
+			return fmt.Errorf("%w (and restoring the previous config failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func parseImportedConfig(data []byte) (PIIConfig, error) {
+	var config PIIConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return PIIConfig{}, fmt.Errorf("invalid config: %w", err)
+	}
+	dropStoredPatterns(&config)
+	if len(config.RiskLevels) == 0 {
+		return PIIConfig{}, errors.New("invalid config: risk_levels is empty")
+	}
+	for level, weight := range config.RiskLevels {
+		if level == noRiskLevel {
+			return PIIConfig{}, fmt.Errorf("invalid config: risk level '%s' is reserved", noRiskLevel)
+		}
+		if weight <= 0 {
+			return PIIConfig{}, fmt.Errorf("invalid config: risk level '%s' must have a positive weight", level)
+		}
+	}
+	if err := validatePatternRegexes(config); err != nil {
+		return PIIConfig{}, err
+	}
+	modes := config.DetectionModes
+	for mode, patterns := range map[string]map[string]PIIPattern{
+		"field_based":   modes.FieldBased.Patterns,
+		"value_only":    modes.ValueOnly.Patterns,
+		"keyword_based": modes.KeywordBased.Patterns,
+	} {
+		for name, pattern := range patterns {
+			if _, ok := config.RiskLevels[pattern.RiskLevel]; !ok {
+				return PIIConfig{}, fmt.Errorf("%s pattern '%s' has unknown risk level '%s'", mode, name, pattern.RiskLevel)
+			}
+			if pattern.Validate != "" {
+				if _, ok := validators[pattern.Validate]; !ok {
+					return PIIConfig{}, fmt.Errorf("%s pattern '%s' has unknown validator '%s'", mode, name, pattern.Validate)
+				}
+			}
+		}
+	}
+	for name, profile := range config.Profiles {
+		if err := validateProfile(config, name, profile); err != nil {
+			return PIIConfig{}, err
+		}
+	}
+	return config, nil
+}
+
+// writePIIConfigFile replaces the config file through a rename, so a reader
+// never sees a partly written file.
+func writePIIConfigFile(data []byte) error {
+	path := filepath.Join("config", "regexpii.json")
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".regexpii-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write PII config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write PII config file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write PII config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write PII config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write PII config file: %w", err)
+	}
+	return nil
+}
+
+// dropStoredPatterns removes the patterns marked stored from config.
+func dropStoredPatterns(config *PIIConfig) {
+	modes := &config.DetectionModes
+	for _, patterns := range []map[string]PIIPattern{modes.FieldBased.Patterns, modes.ValueOnly.Patterns, modes.KeywordBased.Patterns} {
+		for name, pattern := range patterns {
+			if pattern.Stored {
+				delete(patterns, name)
+			}
+		}
+	}
+}
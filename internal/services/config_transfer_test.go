@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// useConfigCopy runs the rest of the test in a temporary directory holding
+// a copy of the shipped config files, so imports don't rewrite them.
+func useConfigCopy(t *testing.T) {
+	t.Helper()
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"regexpii.json", "suppression_rules.json"} {
+		data, err := os.ReadFile(filepath.Join(root, "config", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config", name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(root) })
+}
+
+func TestConfigExportImportRoundTrip(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	storedPattern := func(name, mode, pattern string) bson.D {
+		return bson.D{
+			{Key: "name", Value: name},
+			{Key: "mode", Value: mode},
+			{Key: "pattern", Value: pattern},
+			{Key: "risk_level", Value: "HIGH"},
+			{Key: "category", Value: "custom"},
+		}
+	}
+	tests := []struct {
+		name   string
+		stored bson.D
+		mode   string
+	}{
+		{name: "new stored pattern", stored: storedPattern("EMPLOYEE_ID", "value_only", `\bEMP-\d{6}\b`), mode: "value_only"},
+		{name: "stored pattern over a file pattern", stored: storedPattern("PAN_CARD", "value_only", `\b[A-Z]{5}\d{4}[A-Z]\b`), mode: "value_only"},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			useConfigCopy(mt.T)
+			name := tt.stored.Map()["name"].(string)
+			// NewPIIService and the reload after the import each list the
+			// stored patterns.
+			for i := 0; i < 2; i++ {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.pii_patterns", mtest.FirstBatch, tt.stored))
+			}
+			s, err := NewPIIService(db.MongoInstance{Client: mt.Client, DB: mt.DB})
+			if err != nil {
+				mt.Fatalf("NewPIIService: %v", err)
+			}
+			exported, err := s.ExportConfig()
+			if err != nil {
+				mt.Fatalf("ExportConfig: %v", err)
+			}
+			if !s.config.DetectionModes.ValueOnly.Patterns[name].Stored {
+				mt.Fatalf("pattern %s not marked stored", name)
+			}
+
+			if err := s.ImportConfig(exported); err != nil {
+				mt.Fatalf("ImportConfig: %v", err)
+			}
+			file, err := os.ReadFile(filepath.Join("config", "regexpii.json"))
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if bytes.Contains(file, []byte(`"stored"`)) {
+				mt.Errorf("config file holds stored patterns")
+			}
+			fileConfig, err := parseImportedConfig(file)
+			if err != nil {
+				mt.Fatalf("config file: %v", err)
+			}
+			if _, ok := fileConfig.DetectionModes.ValueOnly.Patterns[name]; ok {
+				mt.Errorf("config file holds %s pattern %s", tt.mode, name)
+			}
+
+			reexported, err := s.ExportConfig()
+			if err != nil {
+				mt.Fatalf("ExportConfig after import: %v", err)
+			}
+			if !bytes.Equal(reexported, exported) {
+				mt.Errorf("export after import differs:\n%s", firstDifference(string(exported), string(reexported)))
+			}
+		})
+	}
+}
+
+// firstDifference shows the first line where two exports differ.
+func firstDifference(a, b string) string {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; i < len(linesA) && i < len(linesB); i++ {
+		if linesA[i] != linesB[i] {
+			return "line " + strings.TrimSpace(linesA[i]) + " became " + strings.TrimSpace(linesB[i])
+		}
+	}
+	return "lengths differ"
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// ConfigComparison reports how detection differs between two stored config
+// versions on the same documents. Current holds the results under A and
+// Proposed those under B.
+type ConfigComparison struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	SimulationResult
+}
+
+// SaveConfigVersion stores a config under name for later comparisons. An
+// empty config snapshots the running one, stored patterns included; any
+// other config must be a whole config that passes import validation.
+func (s *PIIService) SaveConfigVersion(ctx context.Context, name, description string, config json.RawMessage) (db.ConfigVersion, error) {
+	if len(config) == 0 {
+		exported, err := s.ExportConfig()
+		if err != nil {
+			return db.ConfigVersion{}, err
+		}
+		config = exported
+	} else if _, err := parseImportedConfig(config); err != nil {
+		return db.ConfigVersion{}, err
+	}
+	return s.db.CreateConfigVersion(ctx, db.ConfigVersion{
+		Name:        name,
+		Description: description,
+		Config:      string(config),
+	})
+}
+
+// CompareConfigVersions analyzes the sampleSize most recent stored documents
+// under versions a and b and reports how the results differ. Both run with
+// the stored patterns and suppression rules of the running service. Nothing
+// is written.
+func (s *PIIService) CompareConfigVersions(ctx context.Context, a, b string, sampleSize int) (ConfigComparison, error) {
+	serviceA, err := s.configVersionService(ctx, a)
+	if err != nil {
+		return ConfigComparison{}, err
+	}
+	serviceB, err := s.configVersionService(ctx, b)
+	if err != nil {
+		return ConfigComparison{}, err
+	}
+	result, err := compareServices(ctx, serviceA, serviceB, sampleSize)
+	if err != nil {
+		return ConfigComparison{}, err
+	}
+	return ConfigComparison{A: a, B: b, SimulationResult: result}, nil
+}
+
+func (s *PIIService) configVersionService(ctx context.Context, name string) (*PIIService, error) {
+	version, err := s.db.FindConfigVersion(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	service, err := s.newSimulatedService(json.RawMessage(version.Config), true)
+	if err != nil {
+		return nil, fmt.Errorf("config version '%s': %w", name, err)
+	}
+	return service, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCompareConfigVersions(t *testing.T) {
+	base, err := newTestPIIService(t).ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Version b adds a value-only pattern for employee ids.
+	var withPattern PIIConfig
+	if err := json.Unmarshal(base, &withPattern); err != nil {
+		t.Fatal(err)
+	}
+	withPattern.DetectionModes.ValueOnly.Patterns["EMPLOYEE_ID"] = PIIPattern{
+		RegexPattern: `\bEMP-\d{6}\b`,
+		RiskLevel:    "MEDIUM",
+		Category:     "IDENTITY",
+		Tags:         []string{"employee"},
+	}
+	added, err := json.Marshal(withPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseImportedConfig(added); err != nil {
+		t.Fatalf("version b is invalid: %v", err)
+	}
+
+	docs := []db.UserAPIData{
+		{ID: primitive.NewObjectID(), APIEndpoint: "/staff", Method: "GET", ResponseBody: `{"badge":"EMP-123456","email":"jane@example.com"}`},
+		{ID: primitive.NewObjectID(), APIEndpoint: "/staff", Method: "GET", ResponseBody: `{"email":"john@example.com"}`},
+		{ID: primitive.NewObjectID(), APIEndpoint: "/health", Method: "GET", ResponseBody: `{"status":"ok"}`},
+	}
+	versions := map[string]string{"a": string(base), "b": string(added)}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name        string
+		a, b        string
+		wantChanged int
+		wantDelta   int
+		wantGained  int
+		wantLost    int
+	}{
+		{name: "b adds a pattern", a: "a", b: "b", wantChanged: 1, wantDelta: 1, wantGained: 1},
+		{name: "b to a loses it", a: "b", b: "a", wantChanged: 1, wantDelta: -1, wantLost: 1},
+		{name: "same version", a: "a", b: "a"},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			var responses []bson.D
+			for _, name := range []string{tt.a, tt.b} {
+				version := toBSONDoc(mt.T, db.ConfigVersion{Name: name, Config: versions[name]})
+				responses = append(responses,
+					mtest.CreateCursorResponse(0, "raven.config_versions", mtest.FirstBatch, version),
+					mtest.CreateCursorResponse(0, "raven.pii_patterns", mtest.FirstBatch), // no stored patterns
+				)
+			}
+			var stored []bson.D
+			for _, doc := range docs {
+				stored = append(stored, toBSONDoc(mt.T, doc))
+			}
+			responses = append(responses, mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, stored...))
+			mt.AddMockResponses(responses...)
+			s := newTestPIIService(mt)
+			s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+			comparison, err := s.CompareConfigVersions(context.Background(), tt.a, tt.b, len(docs))
+			if err != nil {
+				mt.Fatalf("CompareConfigVersions: %v", err)
+			}
+			if comparison.A != tt.a || comparison.B != tt.b || comparison.Documents != len(docs) {
+				mt.Errorf("compared %s and %s on %d documents, want %s and %s on %d", comparison.A, comparison.B, comparison.Documents, tt.a, tt.b, len(docs))
+			}
+			if comparison.ChangedCount != tt.wantChanged || comparison.FindingsDelta != tt.wantDelta {
+				mt.Errorf("changed %d documents with delta %d, want %d with delta %d", comparison.ChangedCount, comparison.FindingsDelta, tt.wantChanged, tt.wantDelta)
+			}
+			if tt.wantChanged == 0 {
+				return
+			}
+			changed := comparison.Changed[0]
+			if changed.ID != docs[0].ID.Hex() {
+				mt.Errorf("changed document %s, want %s", changed.ID, docs[0].ID.Hex())
+			}
+			if len(changed.Gained) != tt.wantGained || len(changed.Lost) != tt.wantLost {
+				mt.Fatalf("gained %v and lost %v, want %d and %d", changed.Gained, changed.Lost, tt.wantGained, tt.wantLost)
+			}
+			for _, f := range append(changed.Gained, changed.Lost...) {
+				if f.PIIType != "EMPLOYEE_ID" || f.Location != "response_body" {
+					mt.Errorf("differing finding = %+v, want EMPLOYEE_ID in response_body", f)
+				}
+			}
+		})
+	}
+}
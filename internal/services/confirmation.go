@@ -0,0 +1,61 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ConfirmationTokens issues single-use tokens that confirm a destructive
+// operation previewed earlier. A token is bound to the operation's subject,
+// so it can't confirm a different one, and expires after ttl.
+type ConfirmationTokens struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]confirmation
+}
+
+type confirmation struct {
+	subject   string
+	expiresAt time.Time
+}
+
+func NewConfirmationTokens(ttl time.Duration) *ConfirmationTokens {
+	return &ConfirmationTokens{ttl: ttl, tokens: map[string]confirmation{}}
+}
+
+// Issue returns a new token for subject and when it expires.
+func (t *ConfirmationTokens) Issue(subject string) (string, time.Time) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand never fails on supported platforms
+	}
+	token := hex.EncodeToString(b)
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, c := range t.tokens {
+		if now.After(c.expiresAt) {
+			delete(t.tokens, key)
+		}
+	}
+	t.tokens[token] = confirmation{subject: subject, expiresAt: expiresAt}
+	return token, expiresAt
+}
+
+// Redeem consumes token and reports whether it was issued for subject and
+// hasn't expired.
+func (t *ConfirmationTokens) Redeem(token, subject string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.tokens[token]
+	if !ok {
+		return false
+	}
+	delete(t.tokens, token)
+	return c.subject == subject && time.Now().Before(c.expiresAt)
+}
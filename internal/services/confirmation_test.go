@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfirmationTokens(t *testing.T) {
+	tokens := NewConfirmationTokens(time.Minute)
+	token, expiresAt := tokens.Issue("delete high risk")
+	if time.Until(expiresAt) <= 0 || time.Until(expiresAt) > time.Minute {
+		t.Errorf("token expires at %v, want within a minute", expiresAt)
+	}
+	if tokens.Redeem(token, "delete everything") {
+		t.Error("token confirmed a different subject")
+	}
+	if tokens.Redeem(token, "delete high risk") {
+		t.Error("token was redeemed after a failed attempt, want it consumed")
+	}
+
+	token, _ = tokens.Issue("delete high risk")
+	if !tokens.Redeem(token, "delete high risk") {
+		t.Fatal("token did not confirm its subject")
+	}
+	if tokens.Redeem(token, "delete high risk") {
+		t.Error("token was redeemed twice")
+	}
+	if tokens.Redeem("unknown", "delete high risk") {
+		t.Error("an unknown token was redeemed")
+	}
+
+	expired := NewConfirmationTokens(-time.Second)
+	token, _ = expired.Issue("delete high risk")
+	if expired.Redeem(token, "delete high risk") {
+		t.Error("an expired token was redeemed")
+	}
+}
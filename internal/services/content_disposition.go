@@ -0,0 +1,45 @@
+package services
+
+import (
+	"mime"
+	"strings"
+)
+
+// contentDispositionField is the field name findings in a Content-Disposition
+// filename are reported under.
+const contentDispositionField = "content_disposition_filename"
+
+// filenameSeparators are read as spaces, so values joined into a filename
+// such as "john_doe_ssn_123-45-6789.pdf" still sit on word boundaries.
+var filenameSeparators = strings.NewReplacer("_", " ", "+", " ")
+
+// analyzeContentDisposition scans the filename of a Content-Disposition
+// header, which upload and download traffic often names after a person or
+// an id, in place of the raw header value. An RFC 5987 filename* is decoded
+// and preferred over filename.
+func (s *PIIService) analyzeContentDisposition(value, location string, result *PIIAnalysisResult) {
+	filename := contentDispositionFilename(value)
+	if filename == "" {
+		return
+	}
+	for _, finding := range s.detectPIIInText(result.modes, "", filenameSeparators.Replace(filename), location) {
+		finding.FieldName = contentDispositionField
+		result.Findings = append(result.Findings, finding)
+	}
+}
+
+// contentDispositionFilename returns the filename parameter of a
+// Content-Disposition value. Values mime can't parse, such as an unquoted
+// filename with spaces, fall back to a plain parameter scan.
+func contentDispositionFilename(value string) string {
+	if _, params, err := mime.ParseMediaType(value); err == nil {
+		return params["filename"]
+	}
+	for _, param := range strings.Split(value, ";") {
+		name, filename, found := strings.Cut(param, "=")
+		if found && strings.EqualFold(strings.TrimSpace(name), "filename") {
+			return strings.Trim(strings.TrimSpace(filename), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestContentDispositionFilename(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: `attachment; filename="jane.doe@example.com.pdf"`, want: "jane.doe@example.com.pdf"},
+		{value: `attachment; filename*=UTF-8''J%C3%BCrgen_M%C3%BCller_ssn_123-45-6789.pdf; filename="fallback.pdf"`, want: "Jürgen_Müller_ssn_123-45-6789.pdf"},
+		{value: `attachment; filename=John Doe W2.pdf`, want: "John Doe W2.pdf"},
+		{value: `inline`, want: ""},
+	}
+	for _, tt := range tests {
+		if got := contentDispositionFilename(tt.value); got != tt.want {
+			t.Errorf("contentDispositionFilename(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeContentDisposition(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string // type|location|field|value
+	}{
+		{
+			name:  "name and ssn in the filename",
+			value: `attachment; filename="john_doe_ssn_123-45-6789.pdf"`,
+			want:  []string{"US_SSN|response_headers|content_disposition_filename|123-45-6789"},
+		},
+		{
+			name:  "encoded filename preferred",
+			value: `attachment; filename*=UTF-8''J%C3%BCrgen+M%C3%BCller+123-45-6789.pdf; filename="report.pdf"`,
+			want:  []string{"US_SSN|response_headers|content_disposition_filename|123-45-6789"},
+		},
+		{
+			name:  "no filename",
+			value: `inline`,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:     "/api/documents/42",
+				Method:          "GET",
+				URL:             "https://api.example.com/api/documents/42",
+				ResponseHeaders: map[string]string{"Content-Disposition": tt.value},
+			})
+			var got []string
+			for _, f := range result.Findings {
+				got = append(got, f.PIIType+"|"+f.Location+"|"+f.FieldName+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"mime"
+	"strings"
+)
+
+// skipsResponseContentType reports whether a response's Content-Type
+// matches response_content_type_skip, such as "image/*", so its body is
+// binary noise not worth analyzing. Parameters like charset are ignored.
+func (s *PIIService) skipsResponseContentType(responseHeaders map[string]string) bool {
+	contentType := headerValue(responseHeaders, "Content-Type")
+	if contentType == "" || len(s.config.ResponseContentTypeSkip) == 0 {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+		mediaType = strings.TrimSpace(mediaType)
+	}
+	for _, pattern := range s.config.ResponseContentTypeSkip {
+		if pattern != "" && globMatch(pattern, mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestResponseContentTypeSkip(t *testing.T) {
+	tests := []struct {
+		contentType string
+		skipped     bool
+	}{
+		{contentType: "image/png", skipped: true},
+		{contentType: "IMAGE/SVG+XML; charset=utf-8", skipped: true},
+		{contentType: "application/octet-stream", skipped: true},
+		{contentType: "application/json; charset=utf-8", skipped: false},
+		{contentType: "", skipped: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			s := newTestPIIService(t)
+			apiData := db.UserAPIData{
+				APIEndpoint:  "/api/avatar",
+				Method:       "GET",
+				RequestBody:  `{"email":"john@example.com"}`,
+				ResponseBody: `{"email":"jane@example.com"}`,
+			}
+			if tt.contentType != "" {
+				apiData.ResponseHeaders = map[string]string{"content-type": tt.contentType}
+			}
+			result := s.AnalyzePIIInAPIData(context.Background(), apiData)
+			responseFindings := 0
+			for _, f := range result.Findings {
+				if f.Location == "response_body" {
+					responseFindings++
+				}
+			}
+			if result.BodySkippedBinary != tt.skipped || (responseFindings == 0) != tt.skipped {
+				t.Errorf("skipped %v with %d response body findings, want skipped %v", result.BodySkippedBinary, responseFindings, tt.skipped)
+			}
+			if countFindings(result.Findings, "EMAIL") == responseFindings {
+				t.Error("the request body was not analyzed")
+			}
+		})
+	}
+}
+
+func TestImageResponseNotScanned(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("image response", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		s := newTestPIIService(mt)
+		pipeline := &IngestPipeline{
+			piiService: s,
+			mongo:      db.MongoInstance{Client: mt.Client, DB: mt.DB},
+			owners:     &OwnerResolver{},
+			sampler:    newResponseSampler(),
+		}
+		results, errs, _ := pipeline.IngestBatch(context.Background(), []KafkaLogMessage{{
+			Method:              "GET",
+			Path:                "/static/avatar",
+			Host:                "cdn.example.com",
+			StatusCode:          "200",
+			ResponseContentType: "image/jpeg",
+			ResponsePayload:     "\xff\xd8\xff\xe0 jane@example.com 123-45-6789",
+		}})
+		if errs[0] != nil || !results[0].Stored || results[0].HasPII {
+			mt.Fatalf("result = %+v, %v, want stored without findings", results[0], errs[0])
+		}
+		raw := mt.GetStartedEvent().Command.Lookup("documents").Array().Index(0).Value().Document()
+		var stored db.UserAPIData
+		if err := bson.Unmarshal(raw, &stored); err != nil {
+			mt.Fatalf("decode inserted document: %v", err)
+		}
+		if !stored.BodySkippedBinary || stored.ResponseHeaders["Content-Type"] != "image/jpeg" {
+			mt.Errorf("body_skipped_binary = %v, response headers %v, want skipped with the logged content type",
+				stored.BodySkippedBinary, stored.ResponseHeaders)
+		}
+	})
+}
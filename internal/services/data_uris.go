@@ -0,0 +1,111 @@
+package services
+
+import (
+	"encoding/base64"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// dataURITag marks findings in the decoded content of a data URI.
+const dataURITag = "DATA_URI"
+
+// maxDataURIDecodedSize bounds how much of a data URI's content is decoded
+// and scanned. Longer content is scanned up to the bound.
+const maxDataURIDecodedSize = 64 << 10
+
+// dataURIRegex matches base64 data URIs such as
+// "data:text/plain;charset=utf-8;base64,...". The media type is optional.
+var dataURIRegex = regexp.MustCompile(`(?i)data:([a-z0-9!#$&^_.+-]+/[a-z0-9!#$&^_.+-]+)?((?:;[a-z0-9!#$&^_.+-]+=[^;,\s"]*)*);base64,([a-z0-9+/]+={0,2})`)
+
+// analyzeDataURIs decodes the base64 data URIs embedded in text, such as
+// inline documents in a body, and scans the content of those with a text
+// media type. Images and other binary content are skipped. JSON content is
+// walked like embedded JSON below fieldName. Other text is matched against
+// key like the string value it encodes, and scanned as free text when that
+// finds nothing; its findings are reported under fieldName. Findings are
+// tagged DATA_URI.
+func (s *PIIService) analyzeDataURIs(text, key, fieldName, location string, result *PIIAnalysisResult) {
+	if !strings.Contains(text, ";base64,") {
+		return
+	}
+	for _, match := range dataURIRegex.FindAllStringSubmatch(text, -1) {
+		if !textMediaType(match[1]) {
+			continue
+		}
+		content, ok := decodeDataURIContent(match[3])
+		if !ok {
+			continue
+		}
+		start := len(result.Findings)
+		s.guard(result, location+" data URI", func() {
+			if embedded, ok := decodeEmbeddedJSON(content); ok {
+				s.analyzeJSONObject(embedded, fieldName, location, 1, result)
+				return
+			}
+			var findings []PIIDetectionResult
+			if key != "" {
+				findings = s.detectPIIInField(result.modes, key, content, location)
+			}
+			if len(findings) == 0 {
+				findings = s.detectPIIInText(result.modes, "", content, location)
+			}
+			for _, finding := range findings {
+				finding.FieldName = fieldName
+				result.Findings = append(result.Findings, finding)
+			}
+		})
+		for i := start; i < len(result.Findings); i++ {
+			f := &result.Findings[i]
+			f.Tags = append(append([]string{}, f.Tags...), dataURITag)
+		}
+	}
+}
+
+// textMediaType reports whether a data URI's media type holds text. A data
+// URI without one is text/plain.
+func textMediaType(mediaType string) bool {
+	if mediaType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/x-www-form-urlencoded", "application/csv":
+		return true
+	}
+	return false
+}
+
+// decodeDataURIContent decodes base64 content up to maxDataURIDecodedSize.
+// Content that isn't valid UTF-8 is rejected as binary despite its media
+// type. When the content is cut at the bound, its partial last token is
+// dropped.
+func decodeDataURIContent(encoded string) (string, bool) {
+	truncated := false
+	if limit := base64.StdEncoding.EncodedLen(maxDataURIDecodedSize); len(encoded) > limit {
+		encoded, truncated = encoded[:limit], true
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		// Some encoders leave out the padding.
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return "", false
+		}
+	}
+	if !utf8.Valid(decoded) {
+		return "", false
+	}
+	content := string(decoded)
+	if truncated {
+		content = trimTruncatedTail(content).(string)
+	}
+	return content, content != ""
+}
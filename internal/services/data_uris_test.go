@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestAnalyzeDataURIs(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name string
+		body string
+		want []string // type|field|value
+	}{
+		{
+			name: "text data URI",
+			body: `{"contact":{"email":"data:text/plain;charset=utf-8;base64,` + encode("jane@example.com") + `"}}`,
+			want: []string{"EMAIL|contact.email|jane@example.com"},
+		},
+		{
+			name: "json data URI",
+			body: `{"attachment":"data:application/json;base64,` + encode(`{"email":"jane@example.com"}`) + `"}`,
+			want: []string{"EMAIL|attachment.email|jane@example.com"},
+		},
+		{
+			name: "data URI without a media type",
+			body: "see data:;base64," + encode("SSN: 123-45-6789"),
+			want: []string{"INLINE_SSN||123-45-6789", "US_SSN||123-45-6789"},
+		},
+		{
+			name: "image data URI",
+			body: `{"email":"data:image/png;base64,` + encode("jane@example.com") + `"}`,
+			want: nil,
+		},
+		{
+			name: "binary content under a text type",
+			body: `{"email":"data:text/plain;base64,` + encode("\xff\xfe\x00jane@example.com") + `"}`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/documents",
+				Method:      "POST",
+				RequestBody: tt.body,
+			})
+			var got []string
+			for _, f := range result.Findings {
+				if !slices.Contains(f.Tags, dataURITag) {
+					t.Errorf("finding %s at %s is not tagged %s", f.PIIType, f.FieldName, dataURITag)
+				}
+				got = append(got, f.PIIType+"|"+f.FieldName+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// StartBackfill periodically completes the full analysis of documents stored
+// with deferred analysis, pausing while ingestion is still shedding load.
+func (p *IngestPipeline) StartBackfill(ctx context.Context) {
+	if p.shedLag <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.backfillTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if p.overloaded() {
+				continue
+			}
+			p.backfillDeferred(ctx)
+		}
+	}
+}
+
+// backfillDeferred runs full analysis over one batch of deferred documents.
+func (p *IngestPipeline) backfillDeferred(ctx context.Context) {
+	deferred, err := p.mongo.FindDeferredAPIData(ctx, p.backfillSize)
+	if err != nil {
+		log.Printf("Error loading deferred documents for backfill: %v", err)
+		return
+	}
+	completed := 0
+	for _, apiData := range deferred {
+		if ctx.Err() != nil || p.overloaded() {
+			break
+		}
+		if _, err := p.reanalyze(ctx, apiData); err != nil {
+			log.Printf("Skipping backfill of %s: %v", apiData.ID.Hex(), err)
+			continue
+		}
+		analysisBackfilled.Inc()
+		completed++
+	}
+	if completed > 0 {
+		log.Printf("Backfilled full PII analysis for %d deferred documents", completed)
+	}
+}
+
+// reanalyze runs full analysis over a stored document and stores the result,
+// merging its findings with the stored ones. It returns the updated document.
+func (p *IngestPipeline) reanalyze(ctx context.Context, apiData db.UserAPIData) (db.UserAPIData, error) {
+	stored := apiData
+	if err := apiData.DecompressBodies(); err != nil {
+		return stored, err
+	}
+	piiAnalysis := p.piiService.AnalyzePIIInAPIData(ctx, apiData)
+	apiData.SensitiveFields = nil
+	p.enrichUserAPIData(&apiData, piiAnalysis)
+	apiData.PIIFindings = db.MergePIIFindings(stored.PIIFindings, apiData.PIIFindings)
+	if err := p.mongo.StoreFullAnalysis(ctx, apiData); err != nil {
+		return stored, err
+	}
+	// Findings stored earlier were counted then; only those the analysis
+	// adds are new observations.
+	if err := p.mongo.RecordFindingOccurrences(ctx, apiData.APIEndpoint, apiData.Method, apiData.Timestamp, newFindings(stored.PIIFindings, apiData.PIIFindings)); err != nil {
+		log.Printf("Error recording finding occurrences of %s: %v", apiData.ID.Hex(), err)
+	}
+	return apiData, nil
+}
+
+// newFindings returns the findings of current whose id isn't in previous.
+func newFindings(previous, current []db.PIIFinding) []db.PIIFinding {
+	known := make(map[string]bool, len(previous))
+	for _, finding := range previous {
+		known[finding.ID] = true
+	}
+	var added []db.PIIFinding
+	for _, finding := range current {
+		if !known[finding.ID] {
+			added = append(added, finding)
+		}
+	}
+	return added
+}
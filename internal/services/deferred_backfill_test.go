@@ -0,0 +1,35 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestNewFindings(t *testing.T) {
+	email := db.PIIFinding{ID: "email-1", PIIType: "EMAIL"}
+	ssn := db.PIIFinding{ID: "ssn-1", PIIType: "US_SSN"}
+	phone := db.PIIFinding{ID: "phone-1", PIIType: "PHONE_NUMBER"}
+	tests := []struct {
+		name              string
+		previous, current []db.PIIFinding
+		want              []string
+	}{
+		{name: "re-analysis finds nothing new", previous: []db.PIIFinding{email, ssn}, current: []db.PIIFinding{ssn, email}},
+		{name: "full analysis adds findings", previous: []db.PIIFinding{email}, current: []db.PIIFinding{email, ssn, phone}, want: []string{"ssn-1", "phone-1"}},
+		{name: "nothing stored at ingest", current: []db.PIIFinding{email}, want: []string{"email-1"}},
+		{name: "findings dropped", previous: []db.PIIFinding{email, ssn}, current: []db.PIIFinding{email}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range newFindings(tt.previous, tt.current) {
+				got = append(got, f.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newFindings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
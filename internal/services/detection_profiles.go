@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DetectionProfile tunes detection for one deployment environment, so one
+// config can run strict in production and quiet in development. Unset
+// fields leave the config as it is.
+type DetectionProfile struct {
+	Description string `json:"description,omitempty"`
+	// Modes are the detection modes that run; sources limited by
+	// source_detection_modes run the modes enabled in both.
+	Modes []string `json:"modes,omitempty"`
+	// Patterns, when set, are the only finding types reported.
+	Patterns        []string `json:"patterns,omitempty"`
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+	// MinRiskLevel and MinConfidence drop findings below them, after risk
+	// escalation and confidence calibration.
+	MinRiskLevel  string  `json:"minRiskLevel,omitempty"`
+	MinConfidence float64 `json:"minConfidence,omitempty"`
+	// MaskRevealPrefix and MaskRevealSuffix replace the config-wide reveal
+	// counts. Per-pattern mask options still take precedence.
+	MaskRevealPrefix *int `json:"maskRevealPrefix,omitempty"`
+	MaskRevealSuffix *int `json:"maskRevealSuffix,omitempty"`
+}
+
+// ActiveProfile names the profile in effect and what it sets.
+type ActiveProfile struct {
+	Name string `json:"name"`
+	DetectionProfile
+}
+
+// resolveProfile selects the profile named by DETECTION_PROFILE, or else the
+// one named like ENV, from the loaded config. An unknown DETECTION_PROFILE is
+// an error; an ENV without a matching profile runs the config unchanged.
+func (s *PIIService) resolveProfile() error {
+	s.profile = nil
+	name := os.Getenv("DETECTION_PROFILE")
+	if name == "" {
+		env := strings.ToLower(os.Getenv("ENV"))
+		if _, ok := s.config.Profiles[env]; !ok {
+			return nil
+		}
+		name = env
+	}
+	profile, ok := s.config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("detection profile '%s' is not defined", name)
+	}
+	if err := validateProfile(s.config, name, profile); err != nil {
+		return err
+	}
+	s.profile = &ActiveProfile{Name: name, DetectionProfile: profile}
+	log.Printf("Using detection profile '%s'", name)
+	return nil
+}
+
+func validateProfile(config PIIConfig, name string, profile DetectionProfile) error {
+	for _, mode := range profile.Modes {
+		switch mode {
+		case "field_based", "value_only", "keyword_based":
+		default:
+			return fmt.Errorf("detection profile '%s' has unknown mode '%s'", name, mode)
+		}
+	}
+	if profile.MinRiskLevel != "" {
+		if _, ok := config.RiskLevels[profile.MinRiskLevel]; !ok {
+			return fmt.Errorf("detection profile '%s' has unknown risk level '%s'", name, profile.MinRiskLevel)
+		}
+	}
+	if profile.MinConfidence < 0 || profile.MinConfidence > 1 {
+		return fmt.Errorf("detection profile '%s' must have a minConfidence between 0 and 1", name)
+	}
+	return nil
+}
+
+// profileModes limits modes to those the active profile enables.
+func (s *PIIService) profileModes(modes detectionModes) detectionModes {
+	if s.profile == nil || len(s.profile.Modes) == 0 {
+		return modes
+	}
+	enabled := map[string]bool{}
+	for _, mode := range s.profile.Modes {
+		enabled[mode] = true
+	}
+	modes.fieldBased = modes.fieldBased && enabled["field_based"]
+	modes.valueOnly = modes.valueOnly && enabled["value_only"]
+	modes.keywordBased = modes.keywordBased && enabled["keyword_based"]
+	return modes
+}
+
+// applyProfile drops the findings the active profile leaves out.
+func (s *PIIService) applyProfile(findings []PIIDetectionResult) []PIIDetectionResult {
+	if s.profile == nil {
+		return findings
+	}
+	p := s.profile
+	minRisk := s.config.RiskLevels[p.MinRiskLevel]
+	kept := findings[:0]
+	for _, f := range findings {
+		if len(p.Patterns) > 0 && !slices.Contains(p.Patterns, f.PIIType) {
+			continue
+		}
+		if slices.Contains(p.ExcludePatterns, f.PIIType) {
+			continue
+		}
+		if p.MinRiskLevel != "" && s.config.RiskLevels[f.RiskLevel] < minRisk {
+			continue
+		}
+		// A confidence of zero means certain.
+		if f.Confidence != 0 && f.Confidence < p.MinConfidence {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// useProfiles points the service at a config copy holding profiles.
+func useProfiles(t *testing.T, profiles map[string]DetectionProfile) {
+	t.Helper()
+	useConfigCopy(t)
+	path := filepath.Join("config", "regexpii.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	config["profiles"] = profiles
+	if data, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectionProfiles(t *testing.T) {
+	useProfiles(t, map[string]DetectionProfile{
+		"production": {
+			MaskRevealPrefix: intPtr(0),
+			MaskRevealSuffix: intPtr(4),
+		},
+		"development": {
+			Modes:        []string{"field_based"},
+			MinRiskLevel: "HIGH",
+		},
+	})
+	apiData := db.UserAPIData{
+		APIEndpoint: "/users",
+		Method:      "POST",
+		RequestBody: map[string]interface{}{
+			"email":      "john.doe@example.com",
+			"ssn":        "123-45-6789",
+			"first_name": "John",
+		},
+	}
+	tests := []struct {
+		name        string
+		profileEnv  string
+		env         string
+		wantProfile string
+		want        map[string]string
+	}{
+		{
+			name:        "production",
+			profileEnv:  "production",
+			wantProfile: "production",
+			want: map[string]string{
+				"US_SSN":    "*******6789",
+				"EMAIL":     "****************.com",
+				"FULL_NAME": "****",
+			},
+		},
+		{
+			name:        "development",
+			profileEnv:  "development",
+			wantProfile: "development",
+			want: map[string]string{
+				"US_SSN": "12*******89",
+			},
+		},
+		{
+			name:        "selected by ENV",
+			env:         "Development",
+			wantProfile: "development",
+			want: map[string]string{
+				"US_SSN": "12*******89",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DETECTION_PROFILE", tt.profileEnv)
+			t.Setenv("ENV", tt.env)
+			s := newTestPIIService(t)
+			if s.profile == nil || s.profile.Name != tt.wantProfile {
+				t.Fatalf("active profile = %+v, want %q", s.profile, tt.wantProfile)
+			}
+
+			result := s.AnalyzePIIInAPIData(context.Background(), apiData)
+			got := map[string]string{}
+			for _, f := range result.Findings {
+				got[f.PIIType] = f.DetectedValue
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
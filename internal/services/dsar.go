@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+const (
+	DefaultSubjectReportLimit = 20
+	MaxSubjectReportLimit     = 100
+)
+
+// SubjectFinding is a finding whose masked value matches the data subject's.
+type SubjectFinding struct {
+	PIIType   string `json:"pii_type"`
+	Location  string `json:"location"`
+	FieldName string `json:"field_name,omitempty"`
+	RiskLevel string `json:"risk_level"`
+	Category  string `json:"category,omitempty"`
+}
+
+// SubjectDocument is a stored document holding the data subject's value.
+// RawLocations lists where the value itself appears, which also covers
+// values no pattern detected.
+type SubjectDocument struct {
+	ID           string           `json:"id"`
+	Timestamp    time.Time        `json:"timestamp"`
+	Findings     []SubjectFinding `json:"findings"`
+	RawLocations []string         `json:"raw_locations,omitempty"`
+}
+
+type SubjectEndpoint struct {
+	APIEndpoint string            `json:"api_endpoint"`
+	Method      string            `json:"method"`
+	Documents   []SubjectDocument `json:"documents"`
+}
+
+// SubjectReport is one page of a data subject access report. Total counts
+// the matching documents across all pages.
+type SubjectReport struct {
+	Page      int               `json:"page"`
+	Limit     int               `json:"limit"`
+	Total     int64             `json:"total"`
+	Endpoints []SubjectEndpoint `json:"endpoints"`
+}
+
+// SubjectAccessReport collects the stored documents holding value, for data
+// subject access requests, grouped by endpoint. Documents are matched on the
+// masked values of their findings and on their URL and uncompressed bodies,
+// so short values can match other subjects' masked forms. The
+// page holds up to limit documents, newest first; nothing is written.
+func (s *PIIService) SubjectAccessReport(ctx context.Context, value string, page, limit int) (SubjectReport, error) {
+	maskedForms := s.MaskedForms(value)
+	docs, total, err := s.db.FindAPIDataForSubject(ctx, value, maskedForms, (page-1)*limit, limit)
+	if err != nil {
+		return SubjectReport{}, err
+	}
+	masked := make(map[string]bool, len(maskedForms))
+	for _, form := range maskedForms {
+		masked[form] = true
+	}
+
+	report := SubjectReport{Page: page, Limit: limit, Total: total, Endpoints: []SubjectEndpoint{}}
+	endpoints := map[string]int{}
+	for _, doc := range docs {
+		if err := doc.DecompressBodies(); err != nil {
+			log.Printf("Reporting findings only for %s: %v", doc.ID.Hex(), err)
+		}
+		entry := SubjectDocument{
+			ID:           doc.ID.Hex(),
+			Timestamp:    doc.Timestamp,
+			Findings:     []SubjectFinding{},
+			RawLocations: rawValueLocations(doc, value),
+		}
+		for _, finding := range doc.PIIFindings {
+			if !masked[finding.DetectedValue] {
+				continue
+			}
+			entry.Findings = append(entry.Findings, SubjectFinding{
+				PIIType:   finding.PIIType,
+				Location:  finding.Location,
+				FieldName: finding.FieldName,
+				RiskLevel: finding.RiskLevel,
+				Category:  finding.Category,
+			})
+		}
+
+		key := doc.Method + " " + doc.APIEndpoint
+		i, ok := endpoints[key]
+		if !ok {
+			i = len(report.Endpoints)
+			endpoints[key] = i
+			report.Endpoints = append(report.Endpoints, SubjectEndpoint{APIEndpoint: doc.APIEndpoint, Method: doc.Method})
+		}
+		report.Endpoints[i].Documents = append(report.Endpoints[i].Documents, entry)
+	}
+	return report, nil
+}
+
+// rawValueLocations returns the parts of a document whose text contains value.
+func rawValueLocations(doc db.UserAPIData, value string) []string {
+	var locations []string
+	if strings.Contains(doc.URL, value) || strings.Contains(decodedURL(doc.URL), value) {
+		locations = append(locations, "url")
+	}
+	for _, h := range []struct {
+		location string
+		headers  map[string]string
+	}{
+		{"request_headers", doc.RequestHeaders},
+		{"response_headers", doc.ResponseHeaders},
+		{"request_trailers", doc.RequestTrailers},
+		{"response_trailers", doc.ResponseTrailers},
+	} {
+		for _, headerValue := range h.headers {
+			if strings.Contains(headerValue, value) {
+				locations = append(locations, h.location)
+				break
+			}
+		}
+	}
+	for _, b := range []struct {
+		location string
+		body     interface{}
+	}{
+		{"request_body", doc.RequestBody},
+		{"response_body", doc.ResponseBody},
+	} {
+		found := false
+		collectBodyValues(b.body, "", func(_, text string) {
+			found = found || strings.Contains(text, value)
+		})
+		if found {
+			locations = append(locations, b.location)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSubjectAccessReportIsolation(t *testing.T) {
+	const jane, john = "jane@example.com", "john.smith@example.org"
+	s := newTestPIIService(t)
+	maskedJane, maskedJohn := s.MaskedForms(jane)[0], s.MaskedForms(john)[0]
+	if maskedJane == maskedJohn {
+		t.Fatalf("the subjects share the masked form %q", maskedJane)
+	}
+	emailFinding := func(masked, location string) db.PIIFinding {
+		return db.PIIFinding{PIIType: "EMAIL", Location: location, FieldName: "email", DetectedValue: masked, RiskLevel: "MEDIUM"}
+	}
+	docs := []db.UserAPIData{
+		{
+			ID: primitive.NewObjectID(), APIEndpoint: "/users", Method: "POST",
+			RequestBody: `{"email":"` + jane + `"}`,
+			PIIFindings: []db.PIIFinding{emailFinding(maskedJane, "request_body")},
+		},
+		{
+			ID: primitive.NewObjectID(), APIEndpoint: "/users", Method: "POST",
+			RequestBody: `{"email":"` + john + `"}`,
+			PIIFindings: []db.PIIFinding{emailFinding(maskedJohn, "request_body")},
+		},
+		{
+			ID: primitive.NewObjectID(), APIEndpoint: "/teams/7", Method: "GET",
+			URL:          "https://api.example.com/teams/7?owner=" + john,
+			ResponseBody: `{"members":["` + jane + `","` + john + `"]}`,
+			PIIFindings: []db.PIIFinding{
+				emailFinding(maskedJane, "response_body"),
+				emailFinding(maskedJohn, "response_body"),
+				emailFinding(maskedJohn, "query_params"),
+			},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		name        string
+		value       string
+		otherMasked string
+		other       string
+		// matching indexes the documents the database returns for value.
+		matching      []int
+		wantEndpoints []string
+		wantFindings  int
+		wantRaw       map[string][]string
+	}{
+		{
+			name: "jane", value: jane, other: john, otherMasked: maskedJohn,
+			matching:      []int{0, 2},
+			wantEndpoints: []string{"GET /teams/7", "POST /users"},
+			wantFindings:  2,
+			wantRaw:       map[string][]string{"/users": {"request_body"}, "/teams/7": {"response_body"}},
+		},
+		{
+			name: "john", value: john, other: jane, otherMasked: maskedJane,
+			matching:      []int{1, 2},
+			wantEndpoints: []string{"GET /teams/7", "POST /users"},
+			wantFindings:  3,
+			wantRaw:       map[string][]string{"/users": {"request_body"}, "/teams/7": {"url", "response_body"}},
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			var batch []bson.D
+			for _, i := range tt.matching {
+				batch = append(batch, toBSONDoc(mt.T, docs[i]))
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(len(batch))}}),
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, batch...),
+			)
+			s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+			report, err := s.SubjectAccessReport(context.Background(), tt.value, 1, DefaultSubjectReportLimit)
+			if err != nil {
+				mt.Fatalf("SubjectAccessReport: %v", err)
+			}
+
+			find := mt.GetStartedEvent()
+			for find != nil && find.CommandName != "find" {
+				find = mt.GetStartedEvent()
+			}
+			if find == nil {
+				mt.Fatal("no find command sent")
+			}
+			filter := find.Command.Lookup("filter").String()
+			if !strings.Contains(filter, s.MaskedForms(tt.value)[0]) {
+				mt.Errorf("filter %s doesn't look for the subject's masked value", filter)
+			}
+			if strings.Contains(filter, tt.otherMasked) || strings.Contains(filter, tt.other) {
+				mt.Errorf("filter %s looks for the other subject", filter)
+			}
+
+			if report.Total != int64(len(tt.matching)) {
+				mt.Errorf("Total = %d, want %d", report.Total, len(tt.matching))
+			}
+			var endpoints []string
+			findings := 0
+			for _, endpoint := range report.Endpoints {
+				endpoints = append(endpoints, endpoint.Method+" "+endpoint.APIEndpoint)
+				for _, doc := range endpoint.Documents {
+					findings += len(doc.Findings)
+					if !reflect.DeepEqual(doc.RawLocations, tt.wantRaw[endpoint.APIEndpoint]) {
+						mt.Errorf("%s raw locations = %v, want %v", endpoint.APIEndpoint, doc.RawLocations, tt.wantRaw[endpoint.APIEndpoint])
+					}
+				}
+			}
+			sort.Strings(endpoints)
+			if !reflect.DeepEqual(endpoints, tt.wantEndpoints) {
+				mt.Errorf("endpoints = %v, want %v", endpoints, tt.wantEndpoints)
+			}
+			if findings != tt.wantFindings {
+				mt.Errorf("reported %d findings, want %d (only the subject's)", findings, tt.wantFindings)
+			}
+		})
+	}
+}
+
+// toBSONDoc encodes a document the way it is stored, for mocked replies.
+func toBSONDoc(t *testing.T, v interface{}) bson.D {
+	t.Helper()
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+const (
+	esDefaultIndex   = "raven-pii-findings"
+	esBufferSize     = 1000
+	esBatchSize      = 200
+	esFlushInterval  = 5 * time.Second
+	esRequestTimeout = 10 * time.Second
+)
+
+// ElasticsearchSink indexes PII findings into Elasticsearch in the background.
+// A nil *ElasticsearchSink is valid and behaves as a no-op.
+type ElasticsearchSink struct {
+	url      string
+	index    string
+	username string
+	password string
+	client   *http.Client
+	events   chan esFindingDocument
+	dropped  atomic.Int64
+	done     chan struct{}
+}
+
+type esFindingDocument struct {
+	APIEndpoint   string    `json:"api_endpoint"`
+	Method        string    `json:"method"`
+	Source        string    `json:"source"`
+	PIIType       string    `json:"pii_type"`
+	DetectedValue string    `json:"detected_value"`
+	FieldName     string    `json:"field_name,omitempty"`
+	Location      string    `json:"location"`
+	DetectionMode string    `json:"detection_mode"`
+	RiskLevel     string    `json:"risk_level"`
+	Category      string    `json:"category"`
+	Tags          []string  `json:"tags"`
+	Timestamp     time.Time `json:"@timestamp"`
+}
+
+// NewElasticsearchSinkFromEnv returns a sink configured from ELASTICSEARCH_URL,
+// or nil when Elasticsearch is not configured.
+func NewElasticsearchSinkFromEnv() *ElasticsearchSink {
+	esURL := strings.TrimRight(os.Getenv("ELASTICSEARCH_URL"), "/")
+	if esURL == "" {
+		return nil
+	}
+	index := os.Getenv("ELASTICSEARCH_INDEX")
+	if index == "" {
+		index = esDefaultIndex
+	}
+	log.Printf("Elasticsearch sink enabled, indexing findings into %s", index)
+	return &ElasticsearchSink{
+		url:      esURL,
+		index:    index,
+		username: os.Getenv("ELASTICSEARCH_USERNAME"),
+		password: os.Getenv("ELASTICSEARCH_PASSWORD"),
+		client:   &http.Client{Timeout: esRequestTimeout},
+		events:   make(chan esFindingDocument, esBufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Enqueue queues the (already masked) findings of a saved document for indexing.
+// It never blocks: findings that don't fit in the buffer are dropped and counted.
+func (s *ElasticsearchSink) Enqueue(apiData db.UserAPIData) {
+	if s == nil {
+		return
+	}
+	for _, finding := range apiData.PIIFindings {
+		doc := esFindingDocument{
+			APIEndpoint:   apiData.APIEndpoint,
+			Method:        apiData.Method,
+			Source:        apiData.Source,
+			PIIType:       finding.PIIType,
+			DetectedValue: finding.DetectedValue,
+			FieldName:     finding.FieldName,
+			Location:      finding.Location,
+			DetectionMode: finding.DetectionMode,
+			RiskLevel:     finding.RiskLevel,
+			Category:      finding.Category,
+			Tags:          finding.Tags,
+			Timestamp:     apiData.Timestamp,
+		}
+		select {
+		case s.events <- doc:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of findings that were never indexed.
+func (s *ElasticsearchSink) Dropped() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.dropped.Load()
+}
+
+// Start flushes queued findings in batches until the context is canceled,
+// then drains whatever is still queued.
+func (s *ElasticsearchSink) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	defer close(s.done)
+	ticker := time.NewTicker(esFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]esFindingDocument, 0, esBatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			s.drain(batch)
+			log.Printf("Elasticsearch sink stopped. Dropped findings: %d", s.Dropped())
+			return
+		case doc := <-s.events:
+			batch = append(batch, doc)
+			// Once canceled, the batch is left for drain to flush.
+			if len(batch) >= esBatchSize && ctx.Err() == nil {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 && ctx.Err() == nil {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// Wait blocks until Start has drained the queue after cancellation, or until
+// ctx is done.
+func (s *ElasticsearchSink) Wait(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// drain flushes the pending batch and the findings still queued at shutdown.
+// The shutdown context is already canceled, so the flushes get their own
+// deadline.
+func (s *ElasticsearchSink) drain(batch []esFindingDocument) {
+	ctx, cancel := context.WithTimeout(context.Background(), esRequestTimeout)
+	defer cancel()
+	for {
+		select {
+		case doc := <-s.events:
+			batch = append(batch, doc)
+			if len(batch) >= esBatchSize {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		default:
+			if len(batch) > 0 {
+				s.flush(ctx, batch)
+			}
+			return
+		}
+	}
+}
+
+func (s *ElasticsearchSink) flush(ctx context.Context, batch []esFindingDocument) {
+	failed, err := s.bulkIndex(ctx, batch)
+	if err != nil {
+		s.dropped.Add(int64(len(batch)))
+		log.Printf("Error indexing %d findings into Elasticsearch: %v", len(batch), err)
+		return
+	}
+	s.dropped.Add(int64(failed))
+}
+
+// bulkIndex sends a batch through the bulk API and returns how many of its
+// items Elasticsearch rejected.
+func (s *ElasticsearchSink) bulkIndex(ctx context.Context, batch []esFindingDocument) (int, error) {
+	var body bytes.Buffer
+	action, err := json.Marshal(map[string]interface{}{"index": map[string]string{"_index": s.index}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode bulk action: %w", err)
+	}
+	for _, doc := range batch {
+		source, err := json.Marshal(doc)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode finding: %w", err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(source)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/_bulk", &body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("bulk request returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil || !result.Errors {
+		return 0, nil
+	}
+	failed := 0
+	var firstError json.RawMessage
+	for _, item := range result.Items {
+		for _, outcome := range item {
+			if len(outcome.Error) > 0 || outcome.Status >= 300 {
+				failed++
+				if firstError == nil {
+					firstError = outcome.Error
+				}
+			}
+		}
+	}
+	log.Printf("Warning: Elasticsearch rejected %d of %d findings in a bulk request, first error: %s", failed, len(batch), string(firstError))
+	return failed, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// bulkServer records the lines of every bulk request it receives and answers
+// with the response built by respond for that request's documents.
+type bulkServer struct {
+	mu       sync.Mutex
+	auth     []string
+	requests [][]string
+}
+
+func newBulkServer(t *testing.T, respond func(docs int) string) (*bulkServer, *httptest.Server) {
+	t.Helper()
+	bs := &bulkServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/_bulk" {
+			t.Errorf("request %s %s, want POST /_bulk", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+		}
+		var lines []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		user, pass, _ := r.BasicAuth()
+		bs.mu.Lock()
+		bs.requests = append(bs.requests, lines)
+		bs.auth = append(bs.auth, user+":"+pass)
+		bs.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, respond(len(lines)/2))
+	}))
+	t.Cleanup(srv.Close)
+	return bs, srv
+}
+
+func newTestSink(url string) *ElasticsearchSink {
+	return &ElasticsearchSink{
+		url:      url,
+		index:    "findings",
+		username: "raven",
+		password: "secret",
+		client:   &http.Client{Timeout: esRequestTimeout},
+		events:   make(chan esFindingDocument, esBufferSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func bulkOK(docs int) string {
+	return `{"errors":false,"items":[]}`
+}
+
+func testAPIData(findings int) db.UserAPIData {
+	apiData := db.UserAPIData{
+		APIEndpoint: "/api/users",
+		Method:      "POST",
+		Source:      "kafka",
+		Timestamp:   time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for i := 0; i < findings; i++ {
+		apiData.PIIFindings = append(apiData.PIIFindings, db.PIIFinding{
+			PIIType:       "EMAIL",
+			DetectedValue: fmt.Sprintf("u%d***@example.com", i),
+			FieldName:     "email",
+			Location:      "request_body",
+			RiskLevel:     "MEDIUM",
+		})
+	}
+	return apiData
+}
+
+func TestBulkIndexBody(t *testing.T) {
+	bs, srv := newBulkServer(t, bulkOK)
+	sink := newTestSink(srv.URL)
+	sink.Enqueue(testAPIData(2))
+	batch := []esFindingDocument{<-sink.events, <-sink.events}
+
+	failed, err := sink.bulkIndex(context.Background(), batch)
+	if err != nil || failed != 0 {
+		t.Fatalf("bulkIndex = %d, %v, want 0, nil", failed, err)
+	}
+	if len(bs.requests) != 1 {
+		t.Fatalf("got %d bulk requests, want 1", len(bs.requests))
+	}
+	if bs.auth[0] != "raven:secret" {
+		t.Errorf("basic auth = %q, want raven:secret", bs.auth[0])
+	}
+	lines := bs.requests[0]
+	if len(lines) != 4 {
+		t.Fatalf("bulk body has %d lines, want 4: %q", len(lines), lines)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		if lines[i] != `{"index":{"_index":"findings"}}` {
+			t.Errorf("line %d = %s, want an index action", i, lines[i])
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i+1]), &doc); err != nil {
+			t.Fatalf("line %d is not a document: %v", i+1, err)
+		}
+		want := fmt.Sprintf("u%d***@example.com", i/2)
+		if doc["detected_value"] != want || doc["api_endpoint"] != "/api/users" || doc["@timestamp"] != "2026-06-01T12:00:00Z" {
+			t.Errorf("document %d = %v, want masked value %s", i/2, doc, want)
+		}
+	}
+}
+
+func TestBulkItemErrorsCountAsDropped(t *testing.T) {
+	_, srv := newBulkServer(t, func(docs int) string {
+		return `{"errors":true,"items":[
+			{"index":{"status":201}},
+			{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}},
+			{"index":{"status":429,"error":{"type":"es_rejected_execution_exception"}}}
+		]}`
+	})
+	sink := newTestSink(srv.URL)
+	sink.Enqueue(testAPIData(3))
+	sink.flush(context.Background(), []esFindingDocument{<-sink.events, <-sink.events, <-sink.events})
+	if got := sink.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+}
+
+func TestBulkRequestFailureDropsBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	sink := newTestSink(srv.URL)
+	sink.Enqueue(testAPIData(2))
+	sink.flush(context.Background(), []esFindingDocument{<-sink.events, <-sink.events})
+	if got := sink.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+}
+
+func TestSinkDrainsQueueOnShutdown(t *testing.T) {
+	bs, srv := newBulkServer(t, bulkOK)
+	sink := newTestSink(srv.URL)
+	// More than one batch is queued and none has been flushed yet.
+	const queued = esBatchSize + 50
+	sink.Enqueue(testAPIData(queued))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sink.Start(ctx)
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	sink.Wait(waitCtx)
+	if waitCtx.Err() != nil {
+		t.Fatal("Wait returned before the sink stopped")
+	}
+	indexed := 0
+	for _, lines := range bs.requests {
+		indexed += len(lines) / 2
+	}
+	if indexed != queued {
+		t.Errorf("indexed %d findings at shutdown, want %d", indexed, queued)
+	}
+	if sink.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", sink.Dropped())
+	}
+}
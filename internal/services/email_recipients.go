@@ -0,0 +1,92 @@
+package services
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// recipientFields are the header and body fields of email-relay and
+// notification APIs that hold address lists, compared without case,
+// dashes or underscores.
+var recipientFields = map[string]bool{
+	"to":         true,
+	"cc":         true,
+	"bcc":        true,
+	"replyto":    true,
+	"from":       true,
+	"sender":     true,
+	"recipients": true,
+}
+
+func isRecipientField(fieldName string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(fieldName))
+	return recipientFields[normalized]
+}
+
+// detectEmailRecipients reports each address in a recipient list such as
+// `"John Doe" <john@acme.com>; jane@acme.com` as its own EMAIL finding, and
+// each display name that passes the FULL_NAME pattern as a name finding.
+// It covers recipient fields and email fields holding more than a bare
+// address, which the anchored EMAIL pattern can't match.
+func (s *PIIService) detectEmailRecipients(fieldName, fieldValue, location string) []PIIDetectionResult {
+	patterns := s.config.DetectionModes.FieldBased.Patterns
+	emailPattern, ok := patterns["EMAIL"]
+	emailRegex := s.compiledRegex["field_EMAIL"]
+	if !ok || emailRegex == nil {
+		return nil
+	}
+	if !isRecipientField(fieldName) &&
+		!(fieldNameHasHint(fieldName, emailPattern.FieldNames) && strings.ContainsAny(fieldValue, ",;<")) {
+		return nil
+	}
+
+	namePattern, hasNamePattern := patterns["FULL_NAME"]
+	nameRegex := s.compiledRegex["field_FULL_NAME"]
+	finding := func(patternName string, pattern PIIPattern, value string) PIIDetectionResult {
+		s.stats.record("field_based", patternName)
+		return PIIDetectionResult{
+			PIIType:       patternName,
+			DetectedValue: s.maskValue(value, pattern.MaskOptions),
+			rawValue:      value,
+			FieldName:     fieldName,
+			Location:      location,
+			DetectionMode: "field_based",
+			RiskLevel:     pattern.RiskLevel,
+			Category:      pattern.Category,
+			Tags:          pattern.Tags,
+			Frameworks:    pattern.Frameworks,
+			Confidence:    pattern.Confidence,
+			Timestamp:     time.Now(),
+		}
+	}
+
+	var findings []PIIDetectionResult
+	for _, addr := range parseRecipients(fieldValue) {
+		if !emailRegex.MatchString(addr.Address) {
+			continue
+		}
+		findings = append(findings, finding("EMAIL", emailPattern, addr.Address))
+		if addr.Name != "" && hasNamePattern && nameRegex != nil && nameRegex.MatchString(addr.Name) {
+			findings = append(findings, finding("FULL_NAME", namePattern, addr.Name))
+		}
+	}
+	return findings
+}
+
+// parseRecipients parses a comma- or semicolon-separated address list. When
+// the list as a whole is malformed, the entries that parse on their own are
+// kept.
+func parseRecipients(value string) []*mail.Address {
+	list := strings.ReplaceAll(value, ";", ",")
+	if addrs, err := mail.ParseAddressList(list); err == nil {
+		return addrs
+	}
+	var addrs []*mail.Address
+	for _, entry := range strings.Split(list, ",") {
+		if addr, err := mail.ParseAddress(strings.TrimSpace(entry)); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
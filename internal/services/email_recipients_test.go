@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestDetectEmailRecipients(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value string
+		want  []string // type|value
+	}{
+		{
+			name:  "multi-recipient cc",
+			field: "cc",
+			value: `"John Doe" <john@acme.com>; jane@acme.com, Amy Lee <amy@acme.com>`,
+			want: []string{
+				"EMAIL|amy@acme.com",
+				"EMAIL|jane@acme.com",
+				"EMAIL|john@acme.com",
+				"FULL_NAME|Amy Lee",
+				"FULL_NAME|John Doe",
+			},
+		},
+		{
+			name:  "reply-to header",
+			field: "Reply-To",
+			value: "support@acme.com",
+			want:  []string{"EMAIL|support@acme.com"},
+		},
+		{
+			name:  "malformed entry dropped",
+			field: "bcc",
+			value: "jane@acme.com; not an address; john@acme.com",
+			want:  []string{"EMAIL|jane@acme.com", "EMAIL|john@acme.com"},
+		},
+		{
+			name:  "not a recipient field",
+			field: "subject",
+			value: "jane@acme.com, john@acme.com",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			var got []string
+			for _, f := range s.detectEmailRecipients(tt.field, tt.value, "request_body") {
+				if f.FieldName != tt.field {
+					t.Errorf("finding reported under %q, want %q", f.FieldName, tt.field)
+				}
+				got = append(got, f.PIIType+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecipientListInBody(t *testing.T) {
+	s := newTestPIIService(t)
+	result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+		APIEndpoint: "/api/notifications",
+		Method:      "POST",
+		RequestBody: `{"to":"jane@acme.com","cc":"john@acme.com; amy@acme.com"}`,
+	})
+	if got := countFindings(result.Findings, "EMAIL"); got != 3 {
+		t.Errorf("EMAIL findings = %d, want 3: %+v", got, result.Findings)
+	}
+	if len(result.Findings) != 3 {
+		t.Errorf("findings = %+v, want only the three addresses", result.Findings)
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// UnassignedOwner is recorded for endpoints that no owner mapping matches.
+const UnassignedOwner = "unassigned"
+
+// OwnerResolver resolves an endpoint to its owning team from the
+// endpoint_owners mappings.
+type OwnerResolver struct {
+	mongo db.MongoInstance
+
+	mu       sync.RWMutex
+	mappings []db.EndpointOwner
+}
+
+func NewOwnerResolver(mongoInstance db.MongoInstance) *OwnerResolver {
+	return &OwnerResolver{mongo: mongoInstance}
+}
+
+// Reload replaces the mappings with those currently stored.
+func (r *OwnerResolver) Reload(ctx context.Context) error {
+	if r.mongo.DB == nil {
+		return nil
+	}
+	mappings, err := r.mongo.ListEndpointOwners(ctx)
+	if err != nil {
+		return err
+	}
+	sortOwnerMappings(mappings)
+	r.mu.Lock()
+	r.mappings = mappings
+	r.mu.Unlock()
+	log.Printf("Loaded %d endpoint owner mappings", len(mappings))
+	return nil
+}
+
+// Resolve returns the owner of endpoint, or UnassignedOwner.
+func (r *OwnerResolver) Resolve(endpoint string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.mappings {
+		if globMatch(m.Pattern, endpoint) {
+			return m.Owner
+		}
+	}
+	return UnassignedOwner
+}
+
+// sortOwnerMappings orders mappings so the first match is the most specific:
+// exact patterns before globs, then more literal characters, then fewer
+// wildcards. Remaining ties keep the oldest mapping first.
+func sortOwnerMappings(mappings []db.EndpointOwner) {
+	sort.SliceStable(mappings, func(i, j int) bool {
+		wi, wj := globWildcards(mappings[i].Pattern), globWildcards(mappings[j].Pattern)
+		if (wi == 0) != (wj == 0) {
+			return wi == 0
+		}
+		li, lj := len(mappings[i].Pattern)-wi, len(mappings[j].Pattern)-wj
+		if li != lj {
+			return li > lj
+		}
+		return wi < wj
+	})
+}
+
+func globWildcards(pattern string) int {
+	return strings.Count(pattern, "*") + strings.Count(pattern, "?")
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestOwnerResolverPrecedence(t *testing.T) {
+	// Mappings come back from the store oldest first.
+	mappings := []db.EndpointOwner{
+		{Pattern: "/api/*", Owner: "platform"},
+		{Pattern: "/api/billing/*", Owner: "billing"},
+		{Pattern: "/api/billing/invoices/?", Owner: "invoices-short"},
+		{Pattern: "/api/billing/invoices/*", Owner: "invoices"},
+		{Pattern: "/api/billing/invoices/export", Owner: "exports"},
+		{Pattern: "/api/*/health", Owner: "sre"},
+		{Pattern: "/api/user?/*", Owner: "identity"},
+		{Pattern: "/api/users/*", Owner: "accounts"},
+		{Pattern: "/api/users/*", Owner: "accounts-duplicate"},
+	}
+	sortOwnerMappings(mappings)
+	r := &OwnerResolver{mappings: mappings}
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "/api/billing/invoices/export", want: "exports"},
+		// Equally specific globs: the older mapping wins.
+		{endpoint: "/api/billing/invoices/7", want: "invoices-short"},
+		{endpoint: "/api/billing/invoices/2024/pdf", want: "invoices"},
+		{endpoint: "/api/billing/refunds", want: "billing"},
+		{endpoint: "/api/orders/health", want: "sre"},
+		{endpoint: "/api/users/1", want: "accounts"},
+		{endpoint: "/api/orders", want: "platform"},
+		{endpoint: "/internal/metrics", want: UnassignedOwner},
+	}
+	for _, tt := range tests {
+		if got := r.Resolve(tt.endpoint); got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
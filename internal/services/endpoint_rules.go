@@ -0,0 +1,30 @@
+package services
+
+import "strings"
+
+// EndpointRule matches the endpoints matching Endpoint, a case-insensitive
+// glob such as "/health" or "*.js", and Method when it is set.
+type EndpointRule struct {
+	Endpoint string `json:"endpoint"`
+	Method   string `json:"method,omitempty"`
+}
+
+func (r EndpointRule) matches(apiEndpoint, method string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return globMatch(r.Endpoint, apiEndpoint)
+}
+
+// Denylisted reports whether an endpoint matches endpoint_denylist, in
+// which case its traffic is not ingested at all.
+func (s *PIIService) Denylisted(apiEndpoint, method string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rule := range s.config.EndpointDenylist {
+		if rule.Endpoint != "" && rule.matches(apiEndpoint, method) {
+			return true
+		}
+	}
+	return false
+}
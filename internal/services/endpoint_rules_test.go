@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDenylisted(t *testing.T) {
+	s := newTestPIIService(t)
+	s.config.EndpointDenylist = []EndpointRule{
+		{Endpoint: "/health"},
+		{Endpoint: "/metrics", Method: "GET"},
+		{Endpoint: "*.js"},
+		{},
+	}
+	tests := []struct {
+		endpoint, method string
+		want             bool
+	}{
+		{"/health", "GET", true},
+		{"/HEALTH", "HEAD", true},
+		{"/metrics", "get", true},
+		{"/metrics", "POST", false},
+		{"/static/app.js", "GET", true},
+		{"/api/users", "GET", false},
+	}
+	for _, tt := range tests {
+		if got := s.Denylisted(tt.endpoint, tt.method); got != tt.want {
+			t.Errorf("Denylisted(%s, %s) = %v, want %v", tt.endpoint, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestDenylistedEndpointNotIngested(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("denylisted", func(mt *mtest.T) {
+		s := newTestPIIService(mt)
+		s.config.EndpointDenylist = []EndpointRule{{Endpoint: "/health"}}
+		pipeline := &IngestPipeline{
+			piiService: s,
+			mongo:      db.MongoInstance{Client: mt.Client, DB: mt.DB},
+			owners:     &OwnerResolver{},
+			sampler:    newResponseSampler(),
+		}
+		result, err := pipeline.Ingest(context.Background(), KafkaLogMessage{
+			Method:          "GET",
+			Path:            "/health",
+			Host:            "api.example.com",
+			StatusCode:      "200",
+			ResponsePayload: `{"email":"jane@example.com"}`,
+		})
+		if err != nil {
+			mt.Fatalf("Ingest: %v", err)
+		}
+		if result.SkipReason != "denylisted" || result.Stored || result.HasPII {
+			mt.Errorf("result = %+v, want skipped as denylisted", result)
+		}
+		if evt := mt.GetStartedEvent(); evt != nil {
+			mt.Errorf("command %s sent for a denylisted endpoint", evt.CommandName)
+		}
+		if matches := s.PatternStats().Patterns; len(matches) != 0 {
+			mt.Errorf("patterns matched for a denylisted endpoint: %+v", matches)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+func envInt(name string, defaultValue int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Warning: Invalid %s value '%s', using default %d", name, v, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+func envDuration(name string, defaultValue time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Warning: Invalid %s value '%s', using default %s", name, v, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+func envBool(name string, defaultValue bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Warning: Invalid %s value '%s', using default %t", name, v, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func envFloat(name string, defaultValue float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("Warning: Invalid %s value '%s', using default %g", name, v, defaultValue)
+		return defaultValue
+	}
+	return f
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErasureModeDelete = "delete"
+	ErasureModeRedact = "redact"
+
+	redactedValue = "[REDACTED]"
+)
+
+type ErasureResult struct {
+	Mode     string `json:"mode"`
+	Matched  int    `json:"matched"`
+	Deleted  int64  `json:"deleted"`
+	Redacted int    `json:"redacted"`
+}
+
+// MaskedForms returns every form value can take at rest after masking, under
+// the default settings and each pattern's own mask options.
+func (s *PIIService) MaskedForms(value string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := map[string]bool{}
+	var forms []string
+	add := func(opts MaskOptions) {
+		masked := s.maskValue(value, opts)
+		if !seen[masked] {
+			seen[masked] = true
+			forms = append(forms, masked)
+		}
+	}
+	add(MaskOptions{})
+	add(MaskOptions{MaskStrategy: maskStrategyFormatPreserving})
+	modes := s.config.DetectionModes
+	for _, patterns := range []map[string]PIIPattern{modes.FieldBased.Patterns, modes.ValueOnly.Patterns, modes.KeywordBased.Patterns} {
+		for _, pattern := range patterns {
+			add(pattern.MaskOptions)
+		}
+	}
+	for _, doc := range s.config.IdentityDocuments {
+		add(doc.MaskOptions)
+	}
+	add(s.config.NationalIDs.MaskOptions)
+	add(s.config.PostalAddresses.MaskOptions)
+	return forms
+}
+
+// erasureBatchSize is how many documents EraseSubject reads at a time.
+const erasureBatchSize = 100
+
+// EraseSubject deletes or redacts every stored document holding value, for
+// right-to-erasure requests. Findings are matched on their masked form, which
+// can collide for short values, so in delete mode only documents whose URL or
+// body holds the value itself are deleted; documents matched by a masked
+// finding alone are redacted instead, which only rewrites the matching values.
+func (s *PIIService) EraseSubject(ctx context.Context, value, mode string) (ErasureResult, error) {
+	if mode != ErasureModeDelete && mode != ErasureModeRedact {
+		return ErasureResult{}, fmt.Errorf("unknown erasure mode '%s'", mode)
+	}
+	maskedForms := s.MaskedForms(value)
+	masked := make(map[string]bool, len(maskedForms))
+	for _, form := range maskedForms {
+		masked[form] = true
+	}
+
+	result := ErasureResult{Mode: mode}
+	err := s.db.EachAPIDataForErasure(ctx, value, maskedForms, erasureBatchSize, func(docs []db.UserAPIData) error {
+		var deleteIDs []primitive.ObjectID
+		for _, doc := range docs {
+			action, err := erasureAction(doc, value, masked, mode)
+			if err != nil {
+				log.Printf("Failed to check document %s for erasure: %v", doc.ID.Hex(), err)
+				continue
+			}
+			switch action {
+			case ErasureModeDelete:
+				result.Matched++
+				deleteIDs = append(deleteIDs, doc.ID)
+			case ErasureModeRedact:
+				result.Matched++
+				if err := redactDocument(&doc, value, masked); err != nil {
+					log.Printf("Failed to redact document %s: %v", doc.ID.Hex(), err)
+					continue
+				}
+				if err := s.db.ReplaceUserAPIData(ctx, doc); err != nil {
+					return err
+				}
+				result.Redacted++
+			}
+		}
+		if len(deleteIDs) == 0 {
+			return nil
+		}
+		deleted, err := s.db.DeleteUserAPIDataByIDs(ctx, deleteIDs)
+		result.Deleted += deleted
+		return err
+	})
+	return result, err
+}
+
+// erasureAction returns how EraseSubject handles doc in mode: delete or
+// redact, or "" when doc doesn't hold value after all. Deleting needs the
+// value itself in the URL or a body, compressed or not; a match on a masked
+// finding alone falls back to redaction.
+func erasureAction(doc db.UserAPIData, value string, masked map[string]bool, mode string) (string, error) {
+	if err := doc.DecompressBodies(); err != nil {
+		return "", err
+	}
+	raw := strings.Contains(doc.URL, value) || bodyContains(doc.RequestBody, value) || bodyContains(doc.ResponseBody, value)
+	if raw {
+		return mode, nil
+	}
+	for _, finding := range doc.PIIFindings {
+		if masked[finding.DetectedValue] {
+			return ErasureModeRedact, nil
+		}
+	}
+	return "", nil
+}
+
+// redactDocument replaces value in the URL, headers and bodies of a document,
+// and the detected value of findings whose masked value is in masked.
+func redactDocument(doc *db.UserAPIData, value string, masked map[string]bool) error {
+	compressed := doc.BodyCompressed
+	if err := doc.DecompressBodies(); err != nil {
+		return err
+	}
+	doc.URL = strings.ReplaceAll(doc.URL, value, redactedValue)
+	for _, headers := range headerMaps(doc) {
+		for name, headerValue := range headers {
+			headers[name] = strings.ReplaceAll(headerValue, value, redactedValue)
+		}
+	}
+	doc.RequestBody = replaceInBody(doc.RequestBody, value, redactedValue)
+	doc.ResponseBody = replaceInBody(doc.ResponseBody, value, redactedValue)
+	for i := range doc.PIIFindings {
+		if masked[doc.PIIFindings[i].DetectedValue] {
+			doc.PIIFindings[i].DetectedValue = redactedValue
+		}
+	}
+	if compressed {
+		return doc.CompressBodies()
+	}
+	return nil
+}
+
+// replaceInBody replaces value with replacement in every string of a stored
+// body, in place where the body is a map or array.
+func replaceInBody(body interface{}, value, replacement string) interface{} {
+	switch v := body.(type) {
+	case string:
+		return strings.ReplaceAll(v, value, replacement)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = replaceInBody(item, value, replacement)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replaceInBody(item, value, replacement)
+		}
+		return v
+	case primitive.D:
+		for i := range v {
+			v[i].Value = replaceInBody(v[i].Value, value, replacement)
+		}
+		return v
+	case primitive.A:
+		for i, item := range v {
+			v[i] = replaceInBody(item, value, replacement)
+		}
+		return v
+	default:
+		return body
+	}
+}
+
+// bodyContains reports whether any string of a stored body contains value.
+func bodyContains(body interface{}, value string) bool {
+	switch v := body.(type) {
+	case string:
+		return strings.Contains(v, value)
+	case map[string]interface{}:
+		for _, item := range v {
+			if bodyContains(item, value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if bodyContains(item, value) {
+				return true
+			}
+		}
+	case primitive.D:
+		for _, elem := range v {
+			if bodyContains(elem.Value, value) {
+				return true
+			}
+		}
+	case primitive.A:
+		for _, item := range v {
+			if bodyContains(item, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
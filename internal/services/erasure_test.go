@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestErasureAction(t *testing.T) {
+	const value = "jane@example.com"
+	s := newTestPIIService(t)
+	masked := map[string]bool{}
+	for _, form := range s.MaskedForms(value) {
+		masked[form] = true
+	}
+	maskedFinding := []db.PIIFinding{{PIIType: "EMAIL", DetectedValue: s.MaskedForms(value)[0]}}
+
+	tests := []struct {
+		name     string
+		doc      db.UserAPIData
+		compress bool
+		mode     string
+		want     string
+	}{
+		{name: "raw value in url", mode: ErasureModeDelete, want: ErasureModeDelete,
+			doc: db.UserAPIData{URL: "https://api.example.com/users?email=" + value}},
+		{name: "raw value in string body", mode: ErasureModeDelete, want: ErasureModeDelete,
+			doc: db.UserAPIData{RequestBody: `{"email":"` + value + `"}`}},
+		{name: "raw value in structured body", mode: ErasureModeDelete, want: ErasureModeDelete,
+			doc: db.UserAPIData{ResponseBody: primitive.D{{Key: "user", Value: primitive.A{"x", value}}}}},
+		{name: "raw value in compressed body", mode: ErasureModeDelete, want: ErasureModeDelete, compress: true,
+			doc: db.UserAPIData{RequestBody: `{"email":"` + value + `"}`}},
+		{name: "masked finding only", mode: ErasureModeDelete, want: ErasureModeRedact,
+			doc: db.UserAPIData{RequestBody: `{"email":"j***@example.com"}`, PIIFindings: maskedFinding}},
+		{name: "masked finding and compressed body without value", mode: ErasureModeDelete, want: ErasureModeRedact, compress: true,
+			doc: db.UserAPIData{RequestBody: `{"note":"nothing"}`, PIIFindings: maskedFinding}},
+		{name: "raw value in redact mode", mode: ErasureModeRedact, want: ErasureModeRedact,
+			doc: db.UserAPIData{URL: "https://api.example.com/users?email=" + value}},
+		{name: "compressed body without value", mode: ErasureModeDelete, want: "", compress: true,
+			doc: db.UserAPIData{RequestBody: `{"email":"john@example.com"}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := tt.doc
+			if tt.compress {
+				if err := doc.CompressBodies(); err != nil {
+					t.Fatalf("CompressBodies() = %v", err)
+				}
+			}
+			got, err := erasureAction(doc, value, masked, tt.mode)
+			if err != nil {
+				t.Fatalf("erasureAction() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("erasureAction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactDocument(t *testing.T) {
+	const value = "jane@example.com"
+	s := newTestPIIService(t)
+	maskedForm := s.MaskedForms(value)[0]
+	masked := map[string]bool{maskedForm: true}
+
+	for _, compress := range []bool{false, true} {
+		name := "plain"
+		if compress {
+			name = "compressed"
+		}
+		t.Run(name, func(t *testing.T) {
+			doc := db.UserAPIData{
+				URL:            "https://api.example.com/users?email=" + value,
+				RequestHeaders: map[string]string{"X-Email": value},
+				RequestBody:    `{"email":"` + value + `","other":"john@example.com"}`,
+				ResponseBody:   map[string]interface{}{"emails": []interface{}{value}},
+				PIIFindings: []db.PIIFinding{
+					{PIIType: "EMAIL", DetectedValue: maskedForm},
+					{PIIType: "PHONE_NUMBER", DetectedValue: "***-0100"},
+				},
+			}
+			if compress {
+				if err := doc.CompressBodies(); err != nil {
+					t.Fatalf("CompressBodies() = %v", err)
+				}
+			}
+			if err := redactDocument(&doc, value, masked); err != nil {
+				t.Fatalf("redactDocument() = %v", err)
+			}
+			if doc.BodyCompressed != compress {
+				t.Errorf("BodyCompressed = %v, want %v", doc.BodyCompressed, compress)
+			}
+			if err := doc.DecompressBodies(); err != nil {
+				t.Fatalf("DecompressBodies() = %v", err)
+			}
+			if strings.Contains(doc.URL, value) || strings.Contains(doc.RequestHeaders["X-Email"], value) ||
+				bodyContains(doc.RequestBody, value) || bodyContains(doc.ResponseBody, value) {
+				t.Errorf("value left in document: %+v", doc)
+			}
+			if !bodyContains(doc.RequestBody, "john@example.com") {
+				t.Errorf("other values redacted: %v", doc.RequestBody)
+			}
+			if doc.PIIFindings[0].DetectedValue != redactedValue || doc.PIIFindings[1].DetectedValue != "***-0100" {
+				t.Errorf("findings = %+v, want only the matching one redacted", doc.PIIFindings)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestReanalysisKeepsFindingIDs(t *testing.T) {
+	s := newTestPIIService(t)
+	pipeline := &IngestPipeline{piiService: s}
+	doc := db.UserAPIData{
+		APIEndpoint:    "/api/users",
+		Method:         "POST",
+		URL:            "https://api.example.com/api/users?ssn=123-45-6789",
+		RequestHeaders: map[string]string{"X-Email": "jane@example.com"},
+		RequestBody:    `{"phone":"+1 415 555 0100","email":"john@example.com"}`,
+	}
+	analyze := func() []db.PIIFinding {
+		stored := doc
+		pipeline.enrichUserAPIData(&stored, s.AnalyzePIIInAPIData(context.Background(), doc))
+		return stored.PIIFindings
+	}
+
+	first := analyze()
+	if len(first) < 2 {
+		t.Fatalf("findings = %+v, want at least two", first)
+	}
+	firstSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	byID := map[string]db.PIIFinding{}
+	for i := range first {
+		if first[i].ID == "" {
+			t.Fatalf("finding %+v has no id", first[i])
+		}
+		first[i].FirstSeen = firstSeen
+		byID[first[i].ID] = first[i]
+	}
+	first[0].FalsePositive = true
+	first[0].Labels = []string{"reviewed"}
+
+	second := db.MergePIIFindings(first, analyze())
+	if len(second) != len(first) {
+		t.Fatalf("re-analysis found %d findings, want %d", len(second), len(first))
+	}
+	for _, f := range second {
+		prior, ok := byID[f.ID]
+		if !ok {
+			t.Errorf("finding %s %s got a new id %s", f.PIIType, f.Location, f.ID)
+			continue
+		}
+		if !f.FirstSeen.Equal(firstSeen) {
+			t.Errorf("finding %s first seen %v, want %v", f.ID, f.FirstSeen, firstSeen)
+		}
+		wantFP := prior.ID == first[0].ID
+		if f.FalsePositive != wantFP || (len(f.Labels) > 0) != wantFP {
+			t.Errorf("finding %s false positive %v labels %v, want the flags of %+v", f.ID, f.FalsePositive, f.Labels, prior)
+		}
+	}
+}
+
+func TestFindingID(t *testing.T) {
+	base := PIIDetectionResult{PIIType: "EMAIL", Location: "request_body", FieldName: "email", DetectedValue: "j***@example.com", rawValue: "jane@example.com", Timestamp: time.Now()}
+	tests := []struct {
+		name string
+		edit func(*PIIDetectionResult)
+		same bool
+	}{
+		{name: "new timestamp", edit: func(f *PIIDetectionResult) { f.Timestamp = f.Timestamp.Add(time.Hour) }, same: true},
+		{name: "new risk level", edit: func(f *PIIDetectionResult) { f.RiskLevel = "HIGH" }, same: true},
+		{name: "other type", edit: func(f *PIIDetectionResult) { f.PIIType = "PHONE_NUMBER" }},
+		{name: "other location", edit: func(f *PIIDetectionResult) { f.Location = "response_body" }},
+		{name: "other field", edit: func(f *PIIDetectionResult) { f.FieldName = "contact" }},
+		{name: "other mask of the same raw value", edit: func(f *PIIDetectionResult) { f.DetectedValue = "ja**@example.com" }, same: true},
+		{name: "other raw value under the same mask", edit: func(f *PIIDetectionResult) { f.rawValue = "jill@example.com" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finding := base
+			tt.edit(&finding)
+			if same := findingID(finding) == findingID(base); same != tt.same {
+				t.Errorf("same id = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestFindingIDsOfValuesMaskedAlike(t *testing.T) {
+	s := newTestPIIService(t)
+	analyze := func(email string) PIIDetectionResult {
+		result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+			APIEndpoint: "/api/users",
+			Method:      "POST",
+			RequestBody: `{"email":"` + email + `"}`,
+		})
+		if len(result.Findings) != 1 {
+			t.Fatalf("findings for %s = %+v, want one", email, result.Findings)
+		}
+		return result.Findings[0]
+	}
+	jane, jake := analyze("jane.doe@example.com"), analyze("jake.doe@example.com")
+	if jane.DetectedValue != jake.DetectedValue {
+		t.Fatalf("masked values %q and %q differ, want two raw values masked alike", jane.DetectedValue, jake.DetectedValue)
+	}
+	if jane.ID == jake.ID {
+		t.Errorf("both values got id %s", jane.ID)
+	}
+	if again := analyze("jane.doe@example.com"); again.ID != jane.ID {
+		t.Errorf("re-analysis id = %s, want %s", again.ID, jane.ID)
+	}
+}
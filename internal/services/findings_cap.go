@@ -0,0 +1,54 @@
+package services
+
+import "sort"
+
+// sampleFindings returns at most max findings representing all of them,
+// and whether any were dropped. Every PII type keeps its highest-risk
+// finding, even when that takes more than max; the remaining room goes to
+// the highest-risk findings left. The sample keeps the original order. A
+// max of zero or less keeps everything.
+func (s *PIIService) sampleFindings(findings []PIIDetectionResult, max int) ([]PIIDetectionResult, bool) {
+	if max <= 0 || len(findings) <= max {
+		return findings, false
+	}
+	s.mu.RLock()
+	weights := s.config.RiskLevels
+	s.mu.RUnlock()
+
+	// Indexes ordered by risk, then by position.
+	order := make([]int, len(findings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return weights[findings[order[a]].RiskLevel] > weights[findings[order[b]].RiskLevel]
+	})
+
+	keep := make([]bool, len(findings))
+	kept := 0
+	seenTypes := map[string]bool{}
+	for _, i := range order {
+		if !seenTypes[findings[i].PIIType] {
+			seenTypes[findings[i].PIIType] = true
+			keep[i] = true
+			kept++
+		}
+	}
+	for _, i := range order {
+		if kept >= max {
+			break
+		}
+		if !keep[i] {
+			keep[i] = true
+			kept++
+		}
+	}
+
+	sample := make([]PIIDetectionResult, 0, kept)
+	for i, finding := range findings {
+		if keep[i] {
+			sample = append(sample, finding)
+		}
+	}
+	return sample, true
+}
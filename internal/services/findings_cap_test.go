@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestSampleFindings(t *testing.T) {
+	finding := func(piiType, risk string) PIIDetectionResult {
+		return PIIDetectionResult{PIIType: piiType, RiskLevel: risk}
+	}
+	findings := []PIIDetectionResult{
+		finding("EMAIL", "MEDIUM"),
+		finding("EMAIL", "MEDIUM"),
+		finding("US_SSN", "CRITICAL"),
+		finding("PHONE", "LOW"),
+		finding("US_SSN", "CRITICAL"),
+		finding("EMAIL", "MEDIUM"),
+		finding("IP_ADDRESS", "LOW"),
+	}
+	tests := []struct {
+		name          string
+		max           int
+		wantTypes     []string
+		wantTruncated bool
+	}{
+		{name: "no cap", max: 0, wantTypes: []string{"EMAIL", "EMAIL", "US_SSN", "PHONE", "US_SSN", "EMAIL", "IP_ADDRESS"}},
+		{name: "at the cap", max: 7, wantTypes: []string{"EMAIL", "EMAIL", "US_SSN", "PHONE", "US_SSN", "EMAIL", "IP_ADDRESS"}},
+		{name: "over the cap", max: 5, wantTruncated: true, wantTypes: []string{"EMAIL", "US_SSN", "PHONE", "US_SSN", "IP_ADDRESS"}},
+		{name: "fewer than the types", max: 2, wantTruncated: true, wantTypes: []string{"EMAIL", "US_SSN", "PHONE", "IP_ADDRESS"}},
+	}
+	s := newTestPIIService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, truncated := s.sampleFindings(findings, tt.max)
+			var types []string
+			for _, f := range sample {
+				types = append(types, f.PIIType)
+			}
+			if truncated != tt.wantTruncated || !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("sampleFindings(%d) = %v, %v, want %v, %v", tt.max, types, truncated, tt.wantTypes, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestFindingsOverCap(t *testing.T) {
+	const maxFindings = 5
+	emails := func(n int) string {
+		values := make([]string, n)
+		for i := range values {
+			values[i] = fmt.Sprintf(`"user%d@example.com"`, i)
+		}
+		return `{"emails":[` + strings.Join(values, ",") + `]}`
+	}
+	tests := []struct {
+		name          string
+		emails        int
+		wantStored    int
+		wantTruncated bool
+	}{
+		{name: "under the cap", emails: 3, wantStored: 3},
+		{name: "over the cap", emails: 20, wantStored: maxFindings, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			p := &IngestPipeline{piiService: s, maxFindings: maxFindings}
+			doc := db.UserAPIData{APIEndpoint: "/users", Method: "GET", URL: "https://api.example.com/users", ResponseBody: emails(tt.emails)}
+			p.enrichUserAPIData(&doc, s.AnalyzePIIInAPIData(context.Background(), doc))
+
+			if len(doc.PIIFindings) != tt.wantStored || doc.FindingsTruncated != tt.wantTruncated || doc.PIICount != tt.emails {
+				t.Fatalf("stored %d of %d findings, truncated %v; want %d, truncated %v",
+					len(doc.PIIFindings), doc.PIICount, doc.FindingsTruncated, tt.wantStored, tt.wantTruncated)
+			}
+
+			err := s.MaskStoredValues(&doc)
+			if tt.wantTruncated {
+				if !errors.Is(err, ErrFindingsTruncated) {
+					t.Errorf("MaskStoredValues error = %v, want ErrFindingsTruncated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MaskStoredValues: %v", err)
+			}
+			body := doc.ResponseBody.(string)
+			for i := 0; i < tt.emails; i++ {
+				if raw := fmt.Sprintf("user%d@example.com", i); strings.Contains(body, raw) {
+					t.Errorf("masked body still holds %s: %s", raw, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var globCache sync.Map
+
+// globMatch reports whether value matches a shell-style glob, case-insensitively.
+// Unlike path.Match, '*' also matches '/', so "/internal/*" covers nested paths.
+func globMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	if cached, ok := globCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp).MatchString(value)
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	regex := regexp.MustCompile("(?i)^" + expr + "$")
+	globCache.Store(pattern, regex)
+	return regex.MatchString(value)
+}
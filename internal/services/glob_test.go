@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "", value: "/anything", want: true},
+		{pattern: "/api/users", value: "/api/users", want: true},
+		{pattern: "/api/users", value: "/api/users/1", want: false},
+		{pattern: "/api/*", value: "/api/users/1/orders", want: true},
+		{pattern: "/api/*", value: "/apiv2/users", want: false},
+		{pattern: "/api/users/?", value: "/api/users/7", want: true},
+		{pattern: "/api/users/?", value: "/api/users/42", want: false},
+		{pattern: "/API/Users", value: "/api/users", want: true},
+		{pattern: "/api/v1.0/*", value: "/api/v1x0/users", want: false},
+		{pattern: "/api/(users)", value: "/api/(users)", want: true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %t, want %t", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// graphQLAliases maps the aliases in the GraphQL query a request carries to
+// the fields they stand for, e.g. "e" to "email" for "e: email", so
+// response keys can be matched on the real field name. It returns nil for
+// requests that carry no query. An alias used for different fields, in one
+// query or across the operations of a batch, is ambiguous and left out.
+func graphQLAliases(apiData db.UserAPIData) map[string]string {
+	aliases := map[string]string{}
+	ambiguous := map[string]bool{}
+	for _, query := range graphQLQueries(apiData) {
+		for alias, field := range parseGraphQLAliases(query) {
+			if existing, ok := aliases[alias]; ok && existing != field {
+				ambiguous[alias] = true
+			}
+			aliases[alias] = field
+		}
+	}
+	for alias := range ambiguous {
+		delete(aliases, alias)
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	return aliases
+}
+
+// graphQLQueries returns the GraphQL queries of a request: the raw body of
+// an application/graphql request, the "query" of a JSON body or of each
+// operation in a batch, or the query parameter of a GET to a GraphQL path.
+func graphQLQueries(apiData db.UserAPIData) []string {
+	body, hasBody := harBodyText(apiData.RequestBody)
+	if hasBody {
+		contentType, _, _ := mime.ParseMediaType(headerValue(apiData.RequestHeaders, "Content-Type"))
+		if contentType == "application/graphql" {
+			return []string{body}
+		}
+		var operation struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal([]byte(body), &operation); err == nil && operation.Query != "" {
+			return []string{operation.Query}
+		}
+		var batch []struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal([]byte(body), &batch); err == nil {
+			var queries []string
+			for _, op := range batch {
+				if op.Query != "" {
+					queries = append(queries, op.Query)
+				}
+			}
+			return queries
+		}
+	}
+	if u, err := url.Parse(apiData.URL); err == nil && strings.Contains(strings.ToLower(u.Path), "graphql") {
+		if query := u.Query().Get("query"); query != "" {
+			return []string{query}
+		}
+	}
+	return nil
+}
+
+// parseGraphQLAliases returns the "alias: field" pairs of a GraphQL
+// document's selection sets. Arguments, variable definitions and directive
+// arguments sit in parentheses and are skipped, so "(order: DESC)" isn't
+// read as an alias; so are strings and comments. An alias used for
+// different fields is ambiguous and left out.
+func parseGraphQLAliases(query string) map[string]string {
+	aliases := map[string]string{}
+	ambiguous := map[string]bool{}
+	var previous, beforeColon string
+	afterColon := false
+	parens := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '"':
+			i = skipGraphQLString(query, i)
+			previous, afterColon = "", false
+			continue
+		case c == '(':
+			parens++
+		case c == ')':
+			if parens > 0 {
+				parens--
+			}
+		case c == ':' && parens == 0 && previous != "":
+			beforeColon, afterColon = previous, true
+			i++
+			continue
+		case isGraphQLNameStart(c):
+			start := i
+			for i < len(query) && isGraphQLNameChar(query[i]) {
+				i++
+			}
+			name := query[start:i]
+			if afterColon && parens == 0 && name != beforeColon {
+				if existing, ok := aliases[beforeColon]; ok && existing != name {
+					ambiguous[beforeColon] = true
+				}
+				aliases[beforeColon] = name
+			}
+			previous, afterColon = name, false
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		}
+		previous, afterColon = "", false
+		i++
+	}
+	for alias := range ambiguous {
+		delete(aliases, alias)
+	}
+	return aliases
+}
+
+// skipGraphQLString returns the index just past the string or block string
+// starting at i.
+func skipGraphQLString(query string, i int) int {
+	if strings.HasPrefix(query[i:], `"""`) {
+		if end := strings.Index(query[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(query)
+	}
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return isGraphQLNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// responseFieldKey returns the key a JSON key in the analyzed response body
+// is matched on: the field behind it when it is a GraphQL alias.
+func (result *PIIAnalysisResult) responseFieldKey(location, key string) string {
+	if location != "response_body" {
+		return key
+	}
+	if field, ok := result.graphQLAliases[key]; ok {
+		return field
+	}
+	return key
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestParseGraphQLAliases(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string
+	}{
+		{
+			name:  "aliases",
+			query: `query Q($id: ID!) { user(id: $id) { e: email, phone, contact { p: phone } } }`,
+			want:  map[string]string{"e": "email", "p": "phone"},
+		},
+		{
+			name:  "arguments are not aliases",
+			query: `{ users(sort: ASC, filter: {name: "x: y"}) @include(if: true) { id } }`,
+			want:  map[string]string{},
+		},
+		{
+			name:  "strings and comments",
+			query: "{ # a: b\n user(note: \"\"\"c: d\"\"\") { m: email } }",
+			want:  map[string]string{"m": "email"},
+		},
+		{
+			name:  "ambiguous alias",
+			query: `{ a: user { x: email } b: admin { x: phone } }`,
+			want:  map[string]string{"a": "user", "b": "admin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseGraphQLAliases(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aliases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLAliasedFields(t *testing.T) {
+	response := `{"data":{"user":{"e":"jane@example.com","id":"42"}}}`
+	tests := []struct {
+		name    string
+		apiData db.UserAPIData
+		want    []string // type|field|value
+	}{
+		{
+			name: "json body",
+			apiData: db.UserAPIData{
+				Method:      "POST",
+				URL:         "https://api.example.com/graphql",
+				RequestBody: `{"query":"{ user(id: 42) { e: email id } }"}`,
+			},
+			want: []string{"EMAIL|data.user.e|jane@example.com"},
+		},
+		{
+			name: "application/graphql body",
+			apiData: db.UserAPIData{
+				Method:         "POST",
+				URL:            "https://api.example.com/graphql",
+				RequestHeaders: map[string]string{"Content-Type": "application/graphql"},
+				RequestBody:    `{ user(id: 42) { e: email id } }`,
+			},
+			want: []string{"EMAIL|data.user.e|jane@example.com"},
+		},
+		{
+			name: "query parameter",
+			apiData: db.UserAPIData{
+				Method: "GET",
+				URL:    "https://api.example.com/graphql?query=%7B+user%28id%3A+42%29+%7B+e%3A+email+id+%7D+%7D",
+			},
+			want: []string{"EMAIL|data.user.e|jane@example.com"},
+		},
+		{
+			name: "no query",
+			apiData: db.UserAPIData{
+				Method: "POST",
+				URL:    "https://api.example.com/graphql",
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			apiData := tt.apiData
+			apiData.APIEndpoint = "/graphql"
+			apiData.ResponseBody = response
+			result := s.AnalyzePIIInAPIData(context.Background(), apiData)
+			var got []string
+			for _, f := range result.Findings {
+				if f.Location == "response_body" {
+					got = append(got, f.PIIType+"|"+f.FieldName+"|"+f.DetectedValue)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("response findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLAliasesAcrossBatch(t *testing.T) {
+	aliases := graphQLAliases(db.UserAPIData{
+		Method:      "POST",
+		URL:         "https://api.example.com/graphql",
+		RequestBody: `[{"query":"{ user { e: email m: phone } }"},{"query":"{ admin { e: email m: mobile } }"}]`,
+	})
+	if want := map[string]string{"e": "email"}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("aliases = %v, want %v", aliases, want)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"mime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// grpcWebTag marks findings in the messages of a gRPC-Web body.
+const grpcWebTag = "GRPC_WEB"
+
+// gRPC-Web frame flags. Trailer frames hold the call's trailing metadata as
+// "name: value" lines.
+const (
+	grpcWebFlagCompressed = 0x01
+	grpcWebFlagTrailer    = 0x80
+
+	grpcWebFrameHeaderLen = 5
+)
+
+type grpcWebFrame struct {
+	flag    byte
+	payload []byte
+}
+
+// analyzeGRPCWebBody scans the messages of a gRPC-Web body and reports
+// whether the body was one. JSON messages are walked like a JSON body, text
+// messages are scanned as free text and trailers like headers. Compressed
+// and binary protobuf messages are skipped: payloads carry no descriptor to
+// decode them with. Findings are tagged GRPC_WEB.
+func (s *PIIService) analyzeGRPCWebBody(body interface{}, headers map[string]string, location string, result *PIIAnalysisResult) bool {
+	frames, ok := grpcWebFrames(body, headers)
+	if !ok {
+		return false
+	}
+	start := len(result.Findings)
+	for _, frame := range frames {
+		if frame.flag&grpcWebFlagCompressed != 0 || !isPlainText(frame.payload) {
+			continue
+		}
+		message := string(frame.payload)
+		switch {
+		case frame.flag&grpcWebFlagTrailer != 0:
+			for _, line := range strings.Split(message, "\r\n") {
+				name, value, found := strings.Cut(line, ":")
+				if !found {
+					continue
+				}
+				findings := s.detectPIIInField(result.modes, strings.TrimSpace(name), strings.TrimSpace(value), location)
+				result.Findings = append(result.Findings, findings...)
+			}
+		default:
+			if embedded, ok := decodeEmbeddedJSON(message); ok {
+				s.analyzeJSONObject(embedded, "", location, 0, result)
+				continue
+			}
+			result.Findings = append(result.Findings, s.detectPIIInText(result.modes, "", message, location)...)
+		}
+	}
+	for i := start; i < len(result.Findings); i++ {
+		f := &result.Findings[i]
+		f.Tags = append(append([]string{}, f.Tags...), grpcWebTag)
+	}
+	return true
+}
+
+// grpcWebFrames returns the frames of a gRPC-Web body. grpc-web-text bodies
+// are base64 encoded first. Bodies of other content types are taken as
+// gRPC-Web only when they split into frames exactly.
+func grpcWebFrames(body interface{}, headers map[string]string) ([]grpcWebFrame, bool) {
+	text, ok := body.(string)
+	if !ok || text == "" {
+		return nil, false
+	}
+	raw := []byte(text)
+	mediaType, _, _ := mime.ParseMediaType(headerValue(headers, "Content-Type"))
+	if strings.HasPrefix(mediaType, "application/grpc-web-text") {
+		decoded, ok := decodeGRPCWebText(text)
+		if !ok {
+			return nil, false
+		}
+		raw = decoded
+	}
+	return parseGRPCWebFrames(raw)
+}
+
+// parseGRPCWebFrames splits data into frames of a flag byte, a 4-byte
+// big-endian length and the message. It fails unless the frames cover data
+// exactly, so other bodies aren't mistaken for frames.
+func parseGRPCWebFrames(data []byte) ([]grpcWebFrame, bool) {
+	var frames []grpcWebFrame
+	for len(data) > 0 {
+		if len(data) < grpcWebFrameHeaderLen {
+			return nil, false
+		}
+		flag := data[0]
+		if flag&^(grpcWebFlagCompressed|grpcWebFlagTrailer) != 0 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(data[1:grpcWebFrameHeaderLen])
+		if uint64(n) > uint64(len(data)-grpcWebFrameHeaderLen) {
+			return nil, false
+		}
+		end := grpcWebFrameHeaderLen + int(n)
+		frames = append(frames, grpcWebFrame{flag: flag, payload: data[grpcWebFrameHeaderLen:end]})
+		data = data[end:]
+	}
+	return frames, len(frames) > 0
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body. Servers may encode each
+// frame on its own, so the body can hold several padded base64 chunks.
+func decodeGRPCWebText(text string) ([]byte, bool) {
+	var decoded []byte
+	for text != "" {
+		end := strings.IndexByte(text, '=')
+		if end < 0 {
+			end = len(text)
+		}
+		for end < len(text) && text[end] == '=' {
+			end++
+		}
+		chunk, err := base64.StdEncoding.DecodeString(text[:end])
+		if err != nil {
+			return nil, false
+		}
+		decoded = append(decoded, chunk...)
+		text = text[end:]
+	}
+	return decoded, true
+}
+
+// isPlainText reports whether data is UTF-8 text without control characters
+// other than whitespace, which tells text messages from binary protobuf.
+func isPlainText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// grpcWebFrameBytes encodes one gRPC-Web frame.
+func grpcWebFrameBytes(flag byte, message string) string {
+	frame := make([]byte, grpcWebFrameHeaderLen, grpcWebFrameHeaderLen+len(message))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return string(append(frame, message...))
+}
+
+func TestAnalyzeGRPCWebBody(t *testing.T) {
+	textMessage := grpcWebFrameBytes(0, "SSN: 123-45-6789")
+	jsonMessage := grpcWebFrameBytes(0, `{"user":{"email":"jane@example.com"}}`)
+	trailer := grpcWebFrameBytes(grpcWebFlagTrailer, "grpc-status: 0\r\nx-user-email: john@example.com\r\n")
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string // type|field|value
+	}{
+		{
+			name:        "text message",
+			contentType: "application/grpc-web+proto",
+			body:        textMessage,
+			want:        []string{"INLINE_SSN||123-45-6789", "US_SSN||123-45-6789"},
+		},
+		{
+			name:        "json message and trailer",
+			contentType: "application/grpc-web",
+			body:        jsonMessage + trailer,
+			want:        []string{"EMAIL|user.email|jane@example.com", "EMAIL|x-user-email|john@example.com"},
+		},
+		{
+			name:        "grpc-web-text encoded per frame",
+			contentType: "application/grpc-web-text",
+			body:        base64.StdEncoding.EncodeToString([]byte(textMessage)) + base64.StdEncoding.EncodeToString([]byte(trailer)),
+			want:        []string{"EMAIL|x-user-email|john@example.com", "INLINE_SSN||123-45-6789", "US_SSN||123-45-6789"},
+		},
+		{
+			name:        "compressed frame skipped",
+			contentType: "application/grpc-web",
+			body:        grpcWebFrameBytes(grpcWebFlagCompressed, "SSN: 123-45-6789"),
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:     "/users.v1.Users/Get",
+				Method:          "POST",
+				ResponseHeaders: map[string]string{"Content-Type": tt.contentType},
+				ResponseBody:    tt.body,
+			})
+			var got []string
+			for _, f := range result.Findings {
+				if !slices.Contains(f.Tags, grpcWebTag) {
+					t.Errorf("finding %s at %q is not tagged %s", f.PIIType, f.FieldName, grpcWebTag)
+				}
+				got = append(got, f.PIIType+"|"+f.FieldName+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGRPCWebFrames(t *testing.T) {
+	frame := grpcWebFrameBytes(0, "hello")
+	tests := []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{name: "one frame", data: frame, ok: true},
+		{name: "two frames", data: frame + grpcWebFrameBytes(grpcWebFlagTrailer, "grpc-status: 0"), ok: true},
+		{name: "trailing bytes", data: frame + "x", ok: false},
+		{name: "short frame", data: frame[:len(frame)-1], ok: false},
+		{name: "unknown flag", data: grpcWebFrameBytes(0x02, "hello"), ok: false},
+		{name: "plain JSON", data: `{"email":"jane@example.com"}`, ok: false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseGRPCWebFrames([]byte(tt.data)); ok != tt.ok {
+			t.Errorf("%s: parsed = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
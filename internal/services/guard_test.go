@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestAnalyzeRecoversFromPanic(t *testing.T) {
+	const ssn = "123-45-6789"
+	tests := []struct {
+		name    string
+		apiData db.UserAPIData
+	}{
+		{
+			name: "request body field",
+			apiData: db.UserAPIData{
+				URL:            "https://api.example.com/api/users",
+				RequestHeaders: map[string]string{"X-Email": "jane@example.com"},
+				RequestBody:    `{"ssn":"` + ssn + `","contact":{"email":"john@example.com"}}`,
+			},
+		},
+		{
+			name: "request header",
+			apiData: db.UserAPIData{
+				URL:            "https://api.example.com/api/users",
+				RequestHeaders: map[string]string{"X-Email": "jane@example.com", "X-SSN": ssn},
+			},
+		},
+		{
+			name: "query parameter",
+			apiData: db.UserAPIData{
+				URL:         "https://api.example.com/api/users?ssn=" + ssn,
+				RequestBody: `{"email":"jane@example.com"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			// A nil regex makes field-based SSN detection panic.
+			s.compiledRegex["field_US_SSN"] = nil
+			tt.apiData.APIEndpoint, tt.apiData.Method = "/api/users", "POST"
+
+			result := s.AnalyzePIIInAPIData(context.Background(), tt.apiData)
+			if !result.AnalysisPartial {
+				t.Error("AnalysisPartial = false, want true")
+			}
+			if n := countFindings(result.Findings, "EMAIL"); n == 0 {
+				t.Errorf("EMAIL findings lost to the panic: %+v", result.Findings)
+			}
+			if n := countFindings(result.Findings, "US_SSN"); n != 0 {
+				t.Errorf("%d US_SSN findings from the panicking pattern", n)
+			}
+		})
+	}
+}
+
+func TestAnalyzeNotPartialWithoutPanic(t *testing.T) {
+	s := newTestPIIService(t)
+	result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+		APIEndpoint: "/api/users",
+		Method:      "POST",
+		URL:         "https://api.example.com/api/users?ssn=123-45-6789",
+		RequestBody: `{"email":"jane@example.com"}`,
+	})
+	if result.AnalysisPartial {
+		t.Error("AnalysisPartial = true, want false")
+	}
+	if countFindings(result.Findings, "US_SSN") == 0 {
+		t.Errorf("no US_SSN finding: %+v", result.Findings)
+	}
+}
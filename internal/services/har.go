@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// HAR is an HTTP Archive (HAR 1.2) document, limited to the fields RAVEN
+// can reconstruct from a stored document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	// Status is 0 for documents stored before the status code was captured.
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ParseHAR decodes a HAR document and checks that every entry has the
+// fields needed to replay it.
+func ParseHAR(data []byte) (HAR, error) {
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return HAR{}, fmt.Errorf("invalid HAR: %w", err)
+	}
+	if har.Log.Version == "" {
+		return HAR{}, errors.New("invalid HAR: missing log.version")
+	}
+	for i, entry := range har.Log.Entries {
+		if _, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err != nil {
+			return HAR{}, fmt.Errorf("invalid HAR: entry %d has an invalid startedDateTime: %w", i, err)
+		}
+		if entry.Request.Method == "" {
+			return HAR{}, fmt.Errorf("invalid HAR: entry %d has no request method", i)
+		}
+		if u, err := url.Parse(entry.Request.URL); err != nil || !u.IsAbs() {
+			return HAR{}, fmt.Errorf("invalid HAR: entry %d has no absolute request URL", i)
+		}
+	}
+	return har, nil
+}
+
+// ExportHAR reconstructs a single-entry HAR from a stored document and
+// returns it encoded. The encoding is checked to parse back to the same HAR.
+func ExportHAR(doc db.UserAPIData) ([]byte, error) {
+	if err := doc.DecompressBodies(); err != nil {
+		return nil, err
+	}
+	request := HARRequest{
+		Method:      doc.Method,
+		URL:         doc.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(doc.RequestHeaders),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if u, err := url.Parse(doc.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				request.QueryString = append(request.QueryString, HARNameValue{Name: name, Value: value})
+			}
+		}
+		sortNameValues(request.QueryString)
+	}
+	if text, ok := harBodyText(doc.RequestBody); ok {
+		request.PostData = &HARPostData{MimeType: headerValue(doc.RequestHeaders, "Content-Type"), Text: text}
+		request.BodySize = len(text)
+	}
+
+	response := HARResponse{
+		Status:      doc.StatusCode,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(doc.ResponseHeaders),
+		Content:     HARContent{MimeType: headerValue(doc.ResponseHeaders, "Content-Type")},
+		RedirectURL: headerValue(doc.ResponseHeaders, "Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if text, ok := harBodyText(doc.ResponseBody); ok {
+		response.Content.Text = text
+		response.Content.Size = len(text)
+		response.BodySize = len(text)
+	}
+
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "RAVEN", Version: "1.0.0"},
+		Entries: []HAREntry{{
+			StartedDateTime: doc.Timestamp.UTC().Format(time.RFC3339Nano),
+			Request:         request,
+			Response:        response,
+		}},
+	}}
+	data, err := json.Marshal(har)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	parsed, err := ParseHAR(data)
+	if err != nil {
+		return nil, err
+	}
+	reencoded, err := json.Marshal(parsed)
+	if err != nil || !bytes.Equal(reencoded, data) {
+		return nil, errors.New("generated HAR does not round-trip")
+	}
+	return data, nil
+}
+
+// ErrFindingsTruncated means a document's findings were capped when it was
+// stored, so the values of the dropped findings can't be located to mask.
+var ErrFindingsTruncated = errors.New("findings were truncated when the document was stored, so not every detected value can be masked")
+
+// MaskStoredValues replaces the raw values behind a document's findings with
+// their masked values throughout its URL, headers and bodies. Where the raw
+// value is ambiguous, every value that fits the mask is replaced. Documents
+// whose findings were truncated fail with ErrFindingsTruncated rather than
+// keep the dropped findings' values.
+func (s *PIIService) MaskStoredValues(doc *db.UserAPIData) error {
+	if doc.FindingsTruncated {
+		return ErrFindingsTruncated
+	}
+	if err := doc.DecompressBodies(); err != nil {
+		return err
+	}
+	for _, finding := range doc.PIIFindings {
+		fullyMasked := strings.Trim(finding.DetectedValue, "*") == ""
+		for _, raw := range rawCandidates(*doc, finding) {
+			if raw == finding.DetectedValue {
+				continue
+			}
+			// A mask of only '*' fits any text of its length, so it is
+			// applied to whole tokens alone.
+			if fullyMasked && !isWholeToken(*doc, finding, raw) {
+				continue
+			}
+			replaceStoredValue(doc, raw, finding.DetectedValue)
+		}
+	}
+	return nil
+}
+
+func replaceStoredValue(doc *db.UserAPIData, value, replacement string) {
+	doc.URL = strings.ReplaceAll(doc.URL, value, replacement)
+	doc.URL = strings.ReplaceAll(doc.URL, url.QueryEscape(value), replacement)
+	for _, headers := range headerMaps(doc) {
+		for name, v := range headers {
+			headers[name] = strings.ReplaceAll(v, value, replacement)
+		}
+	}
+	doc.RequestBody = replaceInBody(doc.RequestBody, value, replacement)
+	doc.ResponseBody = replaceInBody(doc.ResponseBody, value, replacement)
+}
+
+// isWholeToken reports whether value occurs at the finding's location with no
+// letter or digit directly before or after it.
+func isWholeToken(doc db.UserAPIData, finding db.PIIFinding, value string) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for _, text := range locationValues(doc, finding.Location, finding.FieldName) {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], value)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(value)
+			before, after := []rune(text[:start]), []rune(text[end:])
+			if (len(before) == 0 || !isWord(before[len(before)-1])) && (len(after) == 0 || !isWord(after[0])) {
+				return true
+			}
+			offset = start + 1
+		}
+	}
+	return false
+}
+
+func harHeaders(headers map[string]string) []HARNameValue {
+	result := make([]HARNameValue, 0, len(headers))
+	for name, value := range headers {
+		result = append(result, HARNameValue{Name: name, Value: value})
+	}
+	sortNameValues(result)
+	return result
+}
+
+func sortNameValues(values []HARNameValue) {
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Name != values[j].Name {
+			return values[i].Name < values[j].Name
+		}
+		return values[i].Value < values[j].Value
+	})
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// harBodyText returns a stored body as text: strings as they are and
+// structured bodies encoded as JSON.
+func harBodyText(body interface{}) (string, bool) {
+	switch v := body.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, v != ""
+	}
+	data, err := json.Marshal(db.PlainBody(body))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestExportHAR(t *testing.T) {
+	s := newTestPIIService(t)
+	doc := db.UserAPIData{
+		APIEndpoint:     "/api/users",
+		Method:          "POST",
+		StatusCode:      302,
+		URL:             "https://api.example.com/api/users?email=jane%40example.com&page=2",
+		RequestHeaders:  map[string]string{"Content-Type": "application/json", "X-Email": "jane@example.com"},
+		ResponseHeaders: map[string]string{"Location": "/welcome", "Content-Type": "text/plain"},
+		RequestBody:     map[string]interface{}{"email": "jane@example.com", "phone": "+1 415 555 0100"},
+		ResponseBody:    "created jane@example.com",
+		Timestamp:       time.Date(2026, 6, 1, 12, 0, 0, 0, time.FixedZone("IST", 19800)),
+	}
+	p := &IngestPipeline{piiService: s}
+	p.enrichUserAPIData(&doc, s.AnalyzePIIInAPIData(context.Background(), doc))
+	if err := doc.CompressBodies(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, masked := range []bool{false, true} {
+		name := "raw"
+		if masked {
+			name = "masked"
+		}
+		t.Run(name, func(t *testing.T) {
+			export := doc
+			export.RequestHeaders = map[string]string{}
+			for k, v := range doc.RequestHeaders {
+				export.RequestHeaders[k] = v
+			}
+			if masked {
+				if err := s.MaskStoredValues(&export); err != nil {
+					t.Fatalf("MaskStoredValues: %v", err)
+				}
+			}
+			data, err := ExportHAR(export)
+			if err != nil {
+				t.Fatalf("ExportHAR: %v", err)
+			}
+			har, err := ParseHAR(data)
+			if err != nil {
+				t.Fatalf("exported HAR doesn't parse: %v", err)
+			}
+			if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+				t.Fatalf("log = %+v, want one HAR 1.2 entry", har.Log)
+			}
+			entry := har.Log.Entries[0]
+			if entry.StartedDateTime != "2026-06-01T06:30:00Z" {
+				t.Errorf("startedDateTime = %s, want it in UTC", entry.StartedDateTime)
+			}
+			if entry.Request.Method != "POST" || entry.Response.Status != 302 || entry.Response.RedirectURL != "/welcome" {
+				t.Errorf("entry = %+v, want the stored request line, status and redirect", entry)
+			}
+			if len(entry.Request.QueryString) != 2 || entry.Request.QueryString[1].Name != "page" {
+				t.Errorf("query string = %+v, want email and page in order", entry.Request.QueryString)
+			}
+			if entry.Request.PostData == nil || entry.Request.PostData.MimeType != "application/json" || !strings.Contains(entry.Request.PostData.Text, `"phone"`) {
+				t.Errorf("post data = %+v, want the decompressed JSON body", entry.Request.PostData)
+			}
+			if entry.Response.Content.MimeType != "text/plain" || entry.Response.Content.Size != len(entry.Response.Content.Text) {
+				t.Errorf("content = %+v", entry.Response.Content)
+			}
+			if leaked := strings.Contains(string(data), "jane@example.com"); leaked == masked {
+				t.Errorf("raw email in %s export = %v: %s", name, leaked, data)
+			}
+		})
+	}
+}
+
+func TestParseHARRejectsIncompleteEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		har  string
+	}{
+		{name: "not JSON", har: `{"log":`},
+		{name: "no version", har: `{"log":{"entries":[]}}`},
+		{name: "bad start time", har: `{"log":{"version":"1.2","entries":[{"startedDateTime":"yesterday","request":{"method":"GET","url":"https://a.example/"}}]}}`},
+		{name: "no method", har: `{"log":{"version":"1.2","entries":[{"startedDateTime":"2026-06-01T12:00:00Z","request":{"url":"https://a.example/"}}]}}`},
+		{name: "relative URL", har: `{"log":{"version":"1.2","entries":[{"startedDateTime":"2026-06-01T12:00:00Z","request":{"method":"GET","url":"/users"}}]}}`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseHAR([]byte(tt.har)); err == nil {
+			t.Errorf("%s: ParseHAR succeeded, want an error", tt.name)
+		}
+	}
+}
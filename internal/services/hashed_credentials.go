@@ -0,0 +1,43 @@
+package services
+
+import (
+	"regexp"
+	"slices"
+)
+
+// HashedCredentialConfig tells password hashes apart from passwords sent in
+// the clear. Findings of Types whose value looks like a known hash format
+// are lowered to RiskLevel and tagged with Tag; other values keep the
+// pattern's own risk.
+type HashedCredentialConfig struct {
+	Types     []string `json:"types"`
+	RiskLevel string   `json:"riskLevel"`
+	Tag       string   `json:"tag"`
+}
+
+// passwordHashRegex matches modular crypt hashes (bcrypt, argon2, scrypt,
+// PBKDF2, SHA-crypt) and bare hex digests of MD5, SHA-1, SHA-256 and
+// SHA-512 length.
+var passwordHashRegex = regexp.MustCompile(`^(?:` +
+	`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}` +
+	`|\$argon2(?:id|i|d)\$v=\d+\$m=\d+,t=\d+,p=\d+\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+` +
+	`|\$(?:scrypt|7)\$\S+` +
+	`|\$pbkdf2(?:-sha(?:1|256|512))?\$\d+\$\S+` +
+	`|\$[56]\$(?:rounds=\d+\$)?[./A-Za-z0-9]{1,16}\$[./A-Za-z0-9]{43,86}` +
+	`|[0-9a-fA-F]{32}|[0-9a-fA-F]{40}|[0-9a-fA-F]{64}|[0-9a-fA-F]{128}` +
+	`)$`)
+
+// markHashedCredential lowers a credential finding whose raw value is a
+// password hash. It is called with the raw value before masking discards it.
+func (s *PIIService) markHashedCredential(finding *PIIDetectionResult, value string) {
+	cfg := s.config.HashedCredentials
+	if !slices.Contains(cfg.Types, finding.PIIType) || !passwordHashRegex.MatchString(value) {
+		return
+	}
+	if _, ok := s.config.RiskLevels[cfg.RiskLevel]; ok {
+		finding.RiskLevel = cfg.RiskLevel
+	}
+	if cfg.Tag != "" {
+		finding.Tags = append(append([]string{}, finding.Tags...), cfg.Tag)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestHashedCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		risk   string
+		hashed bool
+	}{
+		{name: "plaintext", value: "correct horse battery staple", risk: "CRITICAL"},
+		{name: "bcrypt", value: "$2b$12$KIXQJkFvQeQ2PAbUHfDmUe8VJ0Tn6n3b9n8gF3wE4qY1z5rWb6H7C", risk: "MEDIUM", hashed: true},
+		{name: "argon2id", value: "$argon2id$v=19$m=65536,t=3,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG", risk: "MEDIUM", hashed: true},
+		{name: "sha-256 hex", value: "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8", risk: "MEDIUM", hashed: true},
+		{name: "truncated bcrypt", value: "$2b$12$KIXQJkFvQeQ2PAbUHfDmUe", risk: "CRITICAL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/users",
+				Method:      "POST",
+				RequestBody: `{"password":"` + tt.value + `"}`,
+			})
+			var password *PIIDetectionResult
+			for i, f := range result.Findings {
+				if f.PIIType == "PASSWORD" {
+					password = &result.Findings[i]
+				}
+			}
+			if password == nil {
+				t.Fatalf("findings = %+v, want a PASSWORD", result.Findings)
+			}
+			if password.RiskLevel != tt.risk || slices.Contains(password.Tags, "HASHED_CREDENTIAL") != tt.hashed {
+				t.Errorf("password %s with tags %v, want %s, hashed %v", password.RiskLevel, password.Tags, tt.risk, tt.hashed)
+			}
+		})
+	}
+}
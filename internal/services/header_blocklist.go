@@ -0,0 +1,53 @@
+package services
+
+import "github.com/RavenSec10/Raven_Backend/db"
+
+// StripBlockedHeaders removes the headers and trailers matching
+// header_blocklist from a document, so they are neither analyzed nor stored, and returns how many
+// were removed. Entries are case-insensitive globs such as "x-internal-*".
+func (s *PIIService) StripBlockedHeaders(apiData *db.UserAPIData) int {
+	s.mu.RLock()
+	blocklist := s.config.HeaderBlocklist
+	s.mu.RUnlock()
+	if len(blocklist) == 0 {
+		return 0
+	}
+	stripped := 0
+	for _, headers := range headerMaps(apiData) {
+		for name := range headers {
+			if headerListed(name, blocklist) {
+				delete(headers, name)
+				stripped++
+			}
+		}
+	}
+	if stripped > 0 {
+		headersStripped.Add(float64(stripped))
+	}
+	return stripped
+}
+
+// headerMaps returns the request and response headers and trailers of a
+// document.
+func headerMaps(doc *db.UserAPIData) []map[string]string {
+	return []map[string]string{doc.RequestHeaders, doc.ResponseHeaders, doc.RequestTrailers, doc.ResponseTrailers}
+}
+
+// headerAnalyzed reports whether a header is in header_allowlist, which when
+// set limits analysis to the headers it names. Headers outside it are still
+// stored. The caller holds s.mu.
+func (s *PIIService) headerAnalyzed(name string) bool {
+	allowlist := s.config.HeaderAllowlist
+	return len(allowlist) == 0 || headerListed(name, allowlist)
+}
+
+// headerListed reports whether a header name matches one of the
+// case-insensitive globs in list.
+func headerListed(name string, list []string) bool {
+	for _, pattern := range list {
+		if pattern != "" && globMatch(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBlockedHeadersNeverStored(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("blocked headers", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		s := newTestPIIService(mt)
+		s.config.HeaderBlocklist = []string{"Authorization", "x-internal-*"}
+		pipeline := &IngestPipeline{
+			piiService: s,
+			mongo:      db.MongoInstance{Client: mt.Client, DB: mt.DB},
+			owners:     &OwnerResolver{},
+			sampler:    newResponseSampler(),
+		}
+		rawLog := KafkaLogMessage{
+			Method:     "GET",
+			Path:       "/api/items",
+			Host:       "api.example.com",
+			StatusCode: "200",
+			RequestHeaders: map[string]string{
+				"authorization":   "Bearer secret-token",
+				"X-Internal-User": "jane@example.com",
+				"Accept":          "application/json",
+			},
+			ResponseHeaders:  map[string]string{"X-INTERNAL-TRACE": "jane@example.com", "Content-Type": "application/json"},
+			ResponseTrailers: map[string]string{"X-Internal-Debug": "jane@example.com", "Grpc-Status": "0"},
+		}
+
+		results, errs, _ := pipeline.IngestBatch(context.Background(), []KafkaLogMessage{rawLog})
+		if errs[0] != nil || !results[0].Stored {
+			mt.Fatalf("result = %+v, %v, want stored", results[0], errs[0])
+		}
+		if results[0].HasPII {
+			mt.Error("findings were reported for blocked headers")
+		}
+		evt := mt.GetStartedEvent()
+		if evt == nil || evt.CommandName != "insert" {
+			mt.Fatalf("command = %v, want insert", evt)
+		}
+		raw := evt.Command.Lookup("documents").Array().Index(0).Value().Document()
+		var stored db.UserAPIData
+		if err := bson.Unmarshal(raw, &stored); err != nil {
+			mt.Fatalf("decode inserted document: %v", err)
+		}
+		if len(stored.RequestHeaders) != 1 || stored.RequestHeaders["Accept"] == "" {
+			mt.Errorf("stored request headers = %v, want only Accept", stored.RequestHeaders)
+		}
+		if len(stored.ResponseHeaders) != 1 || stored.ResponseHeaders["Content-Type"] == "" {
+			mt.Errorf("stored response headers = %v, want only Content-Type", stored.ResponseHeaders)
+		}
+		if len(stored.ResponseTrailers) != 1 || stored.ResponseTrailers["Grpc-Status"] == "" {
+			mt.Errorf("stored response trailers = %v, want only Grpc-Status", stored.ResponseTrailers)
+		}
+		if stored.HeadersStripped != 4 {
+			mt.Errorf("headers_stripped = %d, want 4", stored.HeadersStripped)
+		}
+	})
+}
+
+func TestHeaderAllowlist(t *testing.T) {
+	headers := map[string]string{
+		"X-User-Email":  "jane@example.com",
+		"X-Owner-Email": "john@example.com",
+		"X-Trace-Phone": "+1 415 555 0100",
+	}
+	tests := []struct {
+		name      string
+		allowlist []string
+		want      []string
+	}{
+		{name: "no allowlist", allowlist: nil, want: []string{"X-Owner-Email", "X-Trace-Phone", "X-User-Email"}},
+		{name: "exact", allowlist: []string{"x-user-email"}, want: []string{"X-User-Email"}},
+		{name: "glob", allowlist: []string{"X-*-Email"}, want: []string{"X-Owner-Email", "X-User-Email"}},
+		{name: "nothing listed", allowlist: []string{"Authorization"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.config.HeaderAllowlist = tt.allowlist
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:    "/api/users",
+				Method:         "GET",
+				URL:            "https://api.example.com/api/users",
+				RequestHeaders: headers,
+			})
+			var got []string
+			for _, f := range result.Findings {
+				if f.Location == "request_headers" && !slices.Contains(got, f.FieldName) {
+					got = append(got, f.FieldName)
+				}
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("headers with findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// IdentityDocumentConfig describes a government ID document type with a
+// per-region table of number formats. Formats are loosely structured, so
+// they are only evaluated for fields whose names carry one of FieldNames.
+type IdentityDocumentConfig struct {
+	MaskOptions
+
+	FieldNames []string          `json:"fieldNames"`
+	RiskLevel  string            `json:"riskLevel"`
+	Category   string            `json:"category"`
+	Tags       []string          `json:"tags"`
+	Frameworks []string          `json:"frameworks,omitempty"`
+	Regions    map[string]string `json:"regions"`
+	// Generic is the format of numbers from regions without their own
+	// entry. It is only tried when no regional format matches, and its
+	// findings carry no region tag.
+	Generic string `json:"generic,omitempty"`
+}
+
+type regionRegex struct {
+	region string
+	regex  *regexp.Regexp
+}
+
+func (s *PIIService) compileIdentityDocumentPatterns() {
+	for docType, doc := range s.config.IdentityDocuments {
+		regions := make([]string, 0, len(doc.Regions))
+		for region := range doc.Regions {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+
+		var compiled []regionRegex
+		for _, region := range regions {
+			regex, err := compilePattern(doc.Regions[region])
+			if err != nil {
+				log.Printf("Warning: Failed to compile %s regex for region %s: %v", docType, region, err)
+				continue
+			}
+			compiled = append(compiled, regionRegex{region: region, regex: regex})
+		}
+		// The generic format goes last, under no region.
+		if doc.Generic != "" {
+			regex, err := compilePattern(doc.Generic)
+			if err != nil {
+				log.Printf("Warning: Failed to compile generic %s regex: %v", docType, err)
+			} else {
+				compiled = append(compiled, regionRegex{regex: regex})
+			}
+		}
+		s.identityRegex[docType] = compiled
+	}
+}
+
+// detectIdentityDocument matches a field value against the regional formats of
+// every document type whose field-name hints match, tagging the matching
+// regions. A value no regional format matches may still match the generic one.
+func (s *PIIService) detectIdentityDocument(fieldName, fieldValue, location string) []PIIDetectionResult {
+	var findings []PIIDetectionResult
+	normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(fieldValue))
+	for docType, doc := range s.config.IdentityDocuments {
+		if !fieldNameHasHint(fieldName, doc.FieldNames) {
+			continue
+		}
+		var regions []string
+		generic := false
+		for _, rr := range s.identityRegex[docType] {
+			if !rr.regex.MatchString(normalized) {
+				continue
+			}
+			if rr.region == "" {
+				generic = len(regions) == 0
+				continue
+			}
+			regions = append(regions, rr.region)
+		}
+		if len(regions) == 0 && !generic {
+			continue
+		}
+		s.stats.record("field_based", docType)
+		tags := append(append([]string{}, doc.Tags...), regions...)
+		findings = append(findings, PIIDetectionResult{
+			PIIType:       docType,
+			DetectedValue: s.maskValue(fieldValue, doc.MaskOptions),
+			rawValue:      fieldValue,
+			FieldName:     fieldName,
+			Location:      location,
+			DetectionMode: "field_based",
+			RiskLevel:     doc.RiskLevel,
+			Category:      doc.Category,
+			Tags:          tags,
+			Frameworks:    doc.Frameworks,
+			Timestamp:     time.Now(),
+		})
+	}
+	return findings
+}
+
+// fieldNameHasHint reports whether a field name carries one of the hints as
+// whole words: a hint matches one word of the name or several adjacent words
+// run together, so "adid" matches adId and ad_id but not threadId, and
+// "driverlicense" matches driver_license. Case, underscores and dashes in
+// hints are ignored.
+func fieldNameHasHint(fieldName string, hints []string) bool {
+	words := splitFieldNameWords(fieldName)
+	for _, hint := range hints {
+		hint = strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(hint))
+		if hint == "" {
+			continue
+		}
+		for i := range words {
+			run := ""
+			for _, word := range words[i:] {
+				run += word
+				if run == hint {
+					return true
+				}
+				if len(run) >= len(hint) {
+					break
+				}
+			}
+		}
+	}
+	return false
+}
+
+// splitFieldNameWords splits snake_case, kebab-case and camelCase names into lowercase words.
+func splitFieldNameWords(fieldName string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	runes := []rune(fieldName)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
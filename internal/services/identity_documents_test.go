@@ -0,0 +1,131 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectIdentityDocument(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name       string
+		fieldName  string
+		value      string
+		wantType   string
+		wantRegion string
+		// wantGeneric expects a match of the generic format only.
+		wantGeneric bool
+	}{
+		{name: "US passport", fieldName: "passportNumber", value: "A12345678", wantType: "PASSPORT", wantRegion: "US"},
+		{name: "UK passport", fieldName: "passport", value: "123456789", wantType: "PASSPORT", wantRegion: "UK"},
+		{name: "German passport", fieldName: "passport_no", value: "C01X00T47", wantType: "PASSPORT", wantRegion: "EU-DE"},
+		{name: "French passport", fieldName: "passport", value: "12AB34567", wantType: "PASSPORT", wantRegion: "EU-FR"},
+		{name: "Italian passport", fieldName: "passport", value: "YA1234567", wantType: "PASSPORT", wantRegion: "EU-IT"},
+		{name: "Indian passport falls back", fieldName: "passport", value: "A1234567", wantType: "PASSPORT", wantGeneric: true},
+		{name: "7-digit passport falls back", fieldName: "passport", value: "1234567", wantType: "PASSPORT", wantGeneric: true},
+		{name: "US driver license", fieldName: "driverLicense", value: "D1234567", wantType: "DRIVERS_LICENSE", wantRegion: "US"},
+		{name: "UK driving licence", fieldName: "drivingLicence", value: "MORGA753116SM9IJ", wantType: "DRIVERS_LICENSE", wantRegion: "UK"},
+		{name: "French driver license", fieldName: "driverslicense", value: "1234 5678 9012", wantType: "DRIVERS_LICENSE", wantRegion: "EU-FR"},
+		{name: "unlisted driver license falls back", fieldName: "dl", value: "AB12C345678", wantType: "DRIVERS_LICENSE", wantGeneric: true},
+		{name: "passport field without a number", fieldName: "passport", value: "on file", wantType: ""},
+		{name: "license field without a number", fieldName: "driverLicense", value: "John Smith", wantType: ""},
+		{name: "software license key", fieldName: "licenseKey", value: "AB12345678", wantType: ""},
+		{name: "software license", fieldName: "software_license", value: "12345678", wantType: ""},
+		{name: "unrelated field", fieldName: "orderNumber", value: "A12345678", wantType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := s.detectIdentityDocument(tt.fieldName, tt.value, "request_body")
+			if tt.wantType == "" {
+				if len(findings) != 0 {
+					t.Fatalf("got %d findings, want none: %+v", len(findings), findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].PIIType != tt.wantType {
+				t.Fatalf("got %+v, want one %s finding", findings, tt.wantType)
+			}
+			doc := s.config.IdentityDocuments[tt.wantType]
+			var regions []string
+			for _, tag := range findings[0].Tags {
+				if !slices.Contains(doc.Tags, tag) {
+					regions = append(regions, tag)
+				}
+			}
+			if tt.wantGeneric {
+				if len(regions) != 0 {
+					t.Errorf("generic match tagged with regions %v", regions)
+				}
+				return
+			}
+			if !slices.Contains(regions, tt.wantRegion) {
+				t.Errorf("regions = %v, want %s", regions, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestFieldNameHasHint(t *testing.T) {
+	adHints := []string{"idfa", "gaid", "adid", "advertising", "advertisingid", "ifa"}
+	abaHints := []string{"routing", "routingnumber", "aba", "rtn"}
+	tests := []struct {
+		fieldName string
+		hints     []string
+		want      bool
+	}{
+		{fieldName: "adId", hints: adHints, want: true},
+		{fieldName: "ad_id", hints: adHints, want: true},
+		{fieldName: "AD-ID", hints: adHints, want: true},
+		{fieldName: "advertisingId", hints: adHints, want: true},
+		{fieldName: "advertisingid", hints: adHints, want: true},
+		{fieldName: "device_idfa", hints: adHints, want: true},
+		{fieldName: "threadId", hints: adHints, want: false},
+		{fieldName: "thread_id", hints: adHints, want: false},
+		{fieldName: "uploadId", hints: adHints, want: false},
+		{fieldName: "download_id", hints: adHints, want: false},
+		{fieldName: "payloadId", hints: adHints, want: false},
+		{fieldName: "leadId", hints: adHints, want: false},
+		{fieldName: "abaNumber", hints: abaHints, want: true},
+		{fieldName: "rtnCode", hints: abaHints, want: true},
+		{fieldName: "bank_routing_number", hints: abaHints, want: true},
+		{fieldName: "routingnumber", hints: abaHints, want: true},
+		{fieldName: "abandonedCart", hints: abaHints, want: false},
+		{fieldName: "driver_license", hints: []string{"driverlicense"}, want: true},
+		{fieldName: "page", hints: []string{"age"}, want: false},
+		{fieldName: "user.age", hints: []string{"age"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			if got := fieldNameHasHint(tt.fieldName, tt.hints); got != tt.want {
+				t.Errorf("fieldNameHasHint(%q) = %t, want %t", tt.fieldName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueOnlyFieldNameHints(t *testing.T) {
+	s := newTestPIIService(t)
+	modes := detectionModes{valueOnly: true}
+	const adID = "3f2b8c1e-4a5d-4e6f-9a7b-1c2d3e4f5a6b"
+	tests := []struct {
+		fieldName string
+		value     string
+		piiType   string
+		want      int
+	}{
+		{fieldName: "adId", value: adID, piiType: "ADVERTISING_ID", want: 1},
+		{fieldName: "threadId", value: adID, piiType: "ADVERTISING_ID", want: 0},
+		{fieldName: "uploadId", value: adID, piiType: "ADVERTISING_ID", want: 0},
+		{fieldName: "abaNumber", value: "021000021", piiType: "ABA_ROUTING_NUMBER", want: 1},
+		{fieldName: "rtnCode", value: "021000021", piiType: "ABA_ROUTING_NUMBER", want: 1},
+		{fieldName: "orderNumber", value: "021000021", piiType: "ABA_ROUTING_NUMBER", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fieldName, func(t *testing.T) {
+			findings := s.detectPIIInText(modes, tt.fieldName, tt.value, "request_body")
+			if got := countFindings(findings, tt.piiType); got != tt.want {
+				t.Errorf("%s findings = %d, want %d", tt.piiType, got, tt.want)
+			}
+		})
+	}
+}
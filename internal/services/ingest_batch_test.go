@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestIngestBatchPoisonDocument(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("poison document in a batch of ten", func(mt *mtest.T) {
+		const poison = 3
+		mt.AddMockResponses(
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: poison, Code: 2, Message: "poison document"}),
+			mtest.CreateSuccessResponse(), // dead letter
+		)
+		pipeline := &IngestPipeline{
+			piiService: newTestPIIService(mt),
+			mongo:      db.MongoInstance{Client: mt.Client, DB: mt.DB},
+			owners:     &OwnerResolver{},
+			sampler:    newResponseSampler(),
+		}
+		rawLogs := make([]KafkaLogMessage, 10)
+		for i := range rawLogs {
+			rawLogs[i] = KafkaLogMessage{Method: "GET", Path: fmt.Sprintf("/api/items/%d", i), Host: "api.example.com", StatusCode: "200"}
+		}
+
+		results, errs, batch := pipeline.IngestBatch(context.Background(), rawLogs)
+		if batch.Inserted != 9 || batch.Failed != 1 || batch.DeadLettered != 1 {
+			mt.Errorf("batch = %+v, want 9 inserted and 1 dead-lettered", batch)
+		}
+		for i := range rawLogs {
+			if errs[i] != nil {
+				mt.Errorf("errs[%d] = %v", i, errs[i])
+			}
+			if want := i != poison; results[i].Stored != want {
+				mt.Errorf("results[%d].Stored = %v, want %v", i, results[i].Stored, want)
+			}
+		}
+	})
+}
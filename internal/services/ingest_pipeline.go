@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrMalformedLog marks log messages that can never be ingested, as opposed to
+// transient failures such as a failed database write.
+var ErrMalformedLog = errors.New("malformed log message")
+
+// IngestPipeline maps, analyzes and stores API log messages. It is shared by
+// the Kafka consumer and the HTTP ingest endpoints.
+type IngestPipeline struct {
+	piiService *PIIService
+	mongo      db.MongoInstance
+	esSink     *ElasticsearchSink
+	policy     StorePolicy
+	owners     *OwnerResolver
+	sampler    *responseSampler
+
+	compressBodies bool
+	// maxFindings caps the findings stored per document; zero stores all.
+	maxFindings int
+
+	// Load shedding: while the observed consumer lag is above shedLag,
+	// documents get field-based analysis only. Zero disables shedding.
+	shedLag      int64
+	observedLag  atomic.Int64
+	backfillSize int
+	backfillTick time.Duration
+}
+
+type IngestResult struct {
+	Stored     bool   `json:"stored"`
+	SkipReason string `json:"skip_reason,omitempty"`
+	HasPII     bool   `json:"has_pii"`
+}
+
+func NewIngestPipeline(piiSvc *PIIService, mongoInstance db.MongoInstance, esSink *ElasticsearchSink, policy StorePolicy) *IngestPipeline {
+	owners := NewOwnerResolver(mongoInstance)
+	if err := owners.Reload(context.Background()); err != nil {
+		log.Printf("Error loading endpoint owners, all endpoints are unassigned: %v", err)
+	}
+	return &IngestPipeline{
+		piiService: piiSvc,
+		mongo:      mongoInstance,
+		esSink:     esSink,
+		policy:     policy,
+		owners:     owners,
+		sampler:    newResponseSampler(),
+
+		compressBodies: envBool("COMPRESS_STORED_BODIES", false),
+		maxFindings:    envInt("MAX_FINDINGS_PER_DOCUMENT", 500),
+		shedLag:        int64(envInt("LOAD_SHED_LAG", 0)),
+		backfillSize:   envInt("DEFERRED_BACKFILL_BATCH", 100),
+		backfillTick:   envDuration("DEFERRED_BACKFILL_INTERVAL", time.Minute),
+	}
+}
+
+// Owners returns the resolver that assigns owners to ingested documents.
+func (p *IngestPipeline) Owners() *OwnerResolver {
+	return p.owners
+}
+
+// ObserveLag records the consumer's current lag, which drives load shedding.
+func (p *IngestPipeline) ObserveLag(lag int64) {
+	p.observedLag.Store(lag)
+}
+
+// overloaded reports whether ingestion should shed analysis work.
+func (p *IngestPipeline) overloaded() bool {
+	return p.shedLag > 0 && p.observedLag.Load() > p.shedLag
+}
+
+// sampleResponse reports whether a document's response body is analyzed
+// under response_sampling. Request-side data is always analyzed.
+func (p *IngestPipeline) sampleResponse(apiData db.UserAPIData) bool {
+	rule, ok := p.piiService.responseSamplingRule(apiData.APIEndpoint, apiData.Method)
+	if !ok || rule.Rate <= 1 {
+		return true
+	}
+	if p.sampler.analyze(rule) {
+		responseBodiesSampled.WithLabelValues("analyzed").Inc()
+		return true
+	}
+	responseBodiesSampled.WithLabelValues("skipped").Inc()
+	return false
+}
+
+// Ingest runs a single log message through mapping, PII analysis and storage.
+// Errors wrapping ErrMalformedLog mean the message should be dropped, not retried.
+func (p *IngestPipeline) Ingest(ctx context.Context, rawLog KafkaLogMessage) (result IngestResult, err error) {
+	ctx, span := tracer.Start(ctx, "ingest")
+	defer func() {
+		span.SetAttributes(attribute.Bool("raven.stored", result.Stored), attribute.String("raven.skip_reason", result.SkipReason))
+		endSpan(span, err)
+	}()
+	apiData, result, err := p.prepare(ctx, rawLog)
+	if err != nil {
+		return result, err
+	}
+	span.SetAttributes(attrEndpoint.String(apiData.APIEndpoint), attrMethod.String(apiData.Method), attrPIICount.Int(apiData.PIICount))
+	if result.SkipReason != "" {
+		return result, nil
+	}
+	saveCtx, saveSpan := tracer.Start(ctx, "mongo.save")
+	err = p.mongo.SaveUserAPIData(saveCtx, apiData)
+	endSpan(saveSpan, err)
+	if err != nil {
+		log.Printf("Error saving API data to MongoDB: %v", err)
+		return result, err
+	}
+	p.afterSave(ctx, apiData)
+	result.Stored = true
+	return result, nil
+}
+
+// IngestBatch is Ingest for several log messages, storing the documents kept
+// with one batch insert. errs[i] is the error of rawLogs[i]. Documents the
+// insert fails to store are dead-lettered by it and counted in the returned
+// BatchResult; their results are not Stored.
+func (p *IngestPipeline) IngestBatch(ctx context.Context, rawLogs []KafkaLogMessage) (results []IngestResult, errs []error, batch db.BatchResult) {
+	ctx, span := tracer.Start(ctx, "ingest.batch", trace.WithAttributes(attribute.Int("raven.batch_size", len(rawLogs))))
+	defer span.End()
+	results = make([]IngestResult, len(rawLogs))
+	errs = make([]error, len(rawLogs))
+	var docs []db.UserAPIData
+	var positions []int
+	for i, rawLog := range rawLogs {
+		var apiData db.UserAPIData
+		apiData, results[i], errs[i] = p.prepare(ctx, rawLog)
+		if errs[i] == nil && results[i].SkipReason == "" {
+			docs = append(docs, apiData)
+			positions = append(positions, i)
+		}
+	}
+	if len(docs) == 0 {
+		return results, errs, batch
+	}
+
+	saveCtx, saveSpan := tracer.Start(ctx, "mongo.save_batch")
+	batch, err := p.mongo.SaveUserAPIDataBatch(saveCtx, docs)
+	endSpan(saveSpan, err)
+	if err != nil {
+		log.Printf("Error saving API data batch to MongoDB: %v", err)
+		for _, i := range positions {
+			errs[i] = err
+		}
+		return results, errs, batch
+	}
+	failed := make(map[int]bool, len(batch.FailedIndexes))
+	for _, j := range batch.FailedIndexes {
+		failed[j] = true
+	}
+	for j, apiData := range docs {
+		if failed[j] {
+			continue
+		}
+		p.afterSave(ctx, apiData)
+		results[positions[j]].Stored = true
+	}
+	return results, errs, batch
+}
+
+// prepare maps and analyzes a log message into the document to store. A
+// result with a SkipReason means the document is not to be stored.
+func (p *IngestPipeline) prepare(ctx context.Context, rawLog KafkaLogMessage) (db.UserAPIData, IngestResult, error) {
+	source := sourceLabel(rawLog.Source)
+	sourceMessagesConsumed.WithLabelValues(source).Inc()
+	_, mapSpan := tracer.Start(ctx, "ingest.map")
+	apiData, err := p.mapKafkaLogToUserAPIData(rawLog)
+	endSpan(mapSpan, err)
+	if err != nil {
+		return apiData, IngestResult{}, fmt.Errorf("%w: %v", ErrMalformedLog, err)
+	}
+	if p.piiService.Denylisted(apiData.APIEndpoint, apiData.Method) {
+		documentsDenylisted.Inc()
+		return apiData, IngestResult{SkipReason: "denylisted"}, nil
+	}
+	apiData.Owner = p.owners.Resolve(apiData.APIEndpoint)
+	apiData.HeadersStripped = p.piiService.StripBlockedHeaders(&apiData)
+
+	var piiAnalysis PIIAnalysisResult
+	if p.overloaded() {
+		piiAnalysis = p.piiService.AnalyzeFieldBasedOnly(ctx, apiData)
+		apiData.AnalysisDeferred = true
+	} else if p.sampleResponse(apiData) {
+		piiAnalysis = p.piiService.AnalyzePIIInAPIData(ctx, apiData)
+	} else {
+		piiAnalysis = p.piiService.AnalyzeWithoutResponseBody(ctx, apiData)
+		apiData.ResponseSampledOut = true
+	}
+	p.enrichUserAPIData(&apiData, piiAnalysis)
+	messagesProcessed.Inc()
+	if piiAnalysis.BodySkippedBinary {
+		responseBodiesSkippedBinary.Inc()
+	}
+	if apiData.HasPII {
+		sourceDocumentsWithPII.WithLabelValues(source).Inc()
+	}
+	result := IngestResult{HasPII: apiData.HasPII}
+
+	// A deferred document is always stored: the light analysis can't tell
+	// whether it would pass the policy.
+	if store, reason := p.policy.ShouldStore(piiAnalysis, p.piiService); !store && !apiData.AnalysisDeferred {
+		documentsSkipped.WithLabelValues(reason).Inc()
+		log.Printf("Skipping storage of %s %s (%s, risk: %s)", apiData.Method, sanitizeForLog(apiData.APIEndpoint), reason, apiData.HighestRisk)
+		result.SkipReason = reason
+		return apiData, result, nil
+	}
+
+	if apiData.HasPII {
+		log.Printf("PII DETECTED in %s %s. Risk: %s, Findings: %d", apiData.Method, sanitizeForLog(apiData.APIEndpoint), apiData.HighestRisk, apiData.PIICount)
+	}
+	// Bodies are compressed only after analysis, so detection always sees plain text.
+	if p.compressBodies {
+		if err := apiData.CompressBodies(); err != nil {
+			log.Printf("Error compressing bodies, storing them uncompressed: %v", err)
+		}
+	}
+	return apiData, result, nil
+}
+
+// afterSave counts a stored document, records its finding occurrences and
+// hands it to Elasticsearch.
+func (p *IngestPipeline) afterSave(ctx context.Context, apiData db.UserAPIData) {
+	documentsSaved.Inc()
+	sourceDocumentsSaved.WithLabelValues(sourceLabel(apiData.Source)).Inc()
+	if err := p.mongo.RecordFindingOccurrences(ctx, apiData.APIEndpoint, apiData.Method, apiData.Timestamp, apiData.PIIFindings); err != nil {
+		log.Printf("Error recording finding occurrences: %v", err)
+	}
+	if apiData.AnalysisDeferred {
+		analysisDeferred.Inc()
+	}
+	_, enqueueSpan := tracer.Start(ctx, "elasticsearch.enqueue")
+	p.esSink.Enqueue(apiData)
+	enqueueSpan.End()
+}
+
+func (p *IngestPipeline) mapKafkaLogToUserAPIData(rawLog KafkaLogMessage) (db.UserAPIData, error) {
+	njsTimeSeconds, err := parseNjsTime(rawLog.NjsTime)
+	parsedTimestamp := rawLog.TimestampMetadata
+	if err == nil {
+		parsedTimestamp = njsTimeSeconds
+	} else {
+		log.Printf("Warning: Could not parse NJS timestamp '%s'. Using Filebeat's timestamp. Error: %v", rawLog.NjsTime, err)
+	}
+	parsedTimestamp = parsedTimestamp.UTC()
+	scheme := "http"
+	host := rawLog.Host
+	if strings.HasPrefix(rawLog.Host, "https://") {
+		scheme = "https"
+		host = strings.TrimPrefix(rawLog.Host, "https://")
+	} else if strings.HasPrefix(rawLog.Host, "http://") {
+		scheme = "http"
+		host = strings.TrimPrefix(rawLog.Host, "http://")
+	}
+
+	fullURL := fmt.Sprintf("%s://%s%s", scheme, host, rawLog.Path)
+	apiEndpoint := rawLog.Path
+	if idx := strings.Index(apiEndpoint, "?"); idx != -1 {
+		apiEndpoint = apiEndpoint[:idx]
+	}
+
+	return db.UserAPIData{
+		APIEndpoint:      apiEndpoint,
+		Method:           rawLog.Method,
+		StatusCode:       parseStatusCode(rawLog.StatusCode),
+		URL:              fullURL,
+		RequestHeaders:   withHeader(rawLog.RequestHeaders, "Referer", rawLog.Referer),
+		ResponseHeaders:  withHeader(rawLog.ResponseHeaders, "Content-Type", rawLog.ResponseContentType),
+		RequestTrailers:  rawLog.RequestTrailers,
+		ResponseTrailers: rawLog.ResponseTrailers,
+		RequestBody:      rawLog.RequestPayload,
+		ResponseBody:     rawLog.ResponsePayload,
+		Source:           rawLog.Source,
+		Timestamp:        parsedTimestamp,
+		BodyTruncated:    isBodyTruncated(rawLog),
+		SchemaVersion:    CurrentSchemaVersion,
+	}, nil
+}
+
+// withHeader adds a header nginx logged separately, such as the referer or
+// the response content type, to the headers unless they already carry it,
+// so it is stored and analyzed with them. nginx logs a missing value as "-".
+func withHeader(headers map[string]string, header, value string) map[string]string {
+	if value == "" || value == "-" {
+		return headers
+	}
+	for name := range headers {
+		if strings.EqualFold(name, header) {
+			return headers
+		}
+	}
+	merged := make(map[string]string, len(headers)+1)
+	for name, v := range headers {
+		merged[name] = v
+	}
+	merged[header] = value
+	return merged
+}
+
+// isBodyTruncated reports whether the captured response payload is shorter than
+// the size the proxy reported for it, i.e. the log line only carries a prefix.
+func isBodyTruncated(rawLog KafkaLogMessage) bool {
+	if rawLog.IsGzipCompressed || rawLog.ResponseBodySize <= 0 {
+		return false
+	}
+	body, ok := rawLog.ResponsePayload.(string)
+	if !ok || body == "" {
+		return false
+	}
+	return len(body) < rawLog.ResponseBodySize
+}
+
+// parseStatusCode returns the response status nginx logged, or 0 when it is
+// missing or not a valid status code.
+func parseStatusCode(statusCode string) int {
+	code, err := strconv.Atoi(strings.TrimSpace(statusCode))
+	if err != nil || code < 100 || code > 599 {
+		return 0
+	}
+	return code
+}
+
+func parseNjsTime(njsTimeString string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(njsTimeString, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse NJS time string '%s' as integer seconds: %w", njsTimeString, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// enrichUserAPIData populates the PII summary fields in the UserAPIData struct.
+// The count, risk metrics and sensitive fields cover every finding, but past
+// maxFindings only a representative sample of the findings is stored.
+func (p *IngestPipeline) enrichUserAPIData(apiData *db.UserAPIData, piiAnalysis PIIAnalysisResult) {
+	apiData.HasPII = piiAnalysis.TotalCount > 0
+	apiData.PIICount = piiAnalysis.TotalCount
+	apiData.RiskScore = piiAnalysis.RiskScore
+	apiData.HighestRisk = piiAnalysis.HighestRisk
+	apiData.AnalysisPartial = piiAnalysis.AnalysisPartial
+	apiData.AnalysisTimedOut = piiAnalysis.TimedOut
+	apiData.BodySkippedBinary = piiAnalysis.BodySkippedBinary
+
+	var dbFindings []db.PIIFinding
+	var sensitiveFieldsMap = make(map[string]bool)
+
+	for _, finding := range piiAnalysis.Findings {
+		if !sensitiveFieldsMap[finding.PIIType] {
+			apiData.SensitiveFields = append(apiData.SensitiveFields, finding.PIIType)
+			sensitiveFieldsMap[finding.PIIType] = true
+		}
+	}
+	sample, truncated := p.piiService.sampleFindings(piiAnalysis.Findings, p.maxFindings)
+	apiData.FindingsTruncated = truncated
+	for _, finding := range sample {
+		dbFindings = append(dbFindings, db.PIIFinding{
+			ID:            finding.ID,
+			PIIType:       finding.PIIType,
+			DetectedValue: finding.DetectedValue,
+			FieldName:     finding.FieldName,
+			Location:      finding.Location,
+			DetectionMode: finding.DetectionMode,
+			RiskLevel:     finding.RiskLevel,
+			Category:      finding.Category,
+			Tags:          finding.Tags,
+			Frameworks:    finding.Frameworks,
+			Confidence:    finding.Confidence,
+			Timestamp:     finding.Timestamp,
+			FirstSeen:     finding.Timestamp,
+		})
+	}
+	apiData.PIIFindings = dbFindings
+}
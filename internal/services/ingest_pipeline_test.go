@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestIsBodyTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		log  KafkaLogMessage
+		want bool
+	}{
+		{name: "shorter than reported", log: KafkaLogMessage{ResponsePayload: `{"a":"b`, ResponseBodySize: 100}, want: true},
+		{name: "full body", log: KafkaLogMessage{ResponsePayload: `{"a":"b"}`, ResponseBodySize: 9}, want: false},
+		{name: "size not reported", log: KafkaLogMessage{ResponsePayload: `{"a":"b`}, want: false},
+		{name: "gzip compressed", log: KafkaLogMessage{ResponsePayload: "x", ResponseBodySize: 100, IsGzipCompressed: true}, want: false},
+		{name: "empty body", log: KafkaLogMessage{ResponseBodySize: 100}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBodyTruncated(tt.log); got != tt.want {
+				t.Errorf("isBodyTruncated() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapKafkaLogTimestampsToUTC(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want time.Time
+	}{
+		{
+			name: "zoned @timestamp",
+			log:  `{"@timestamp":"2026-03-01T09:15:30+05:30","time":"-","method":"GET","path":"/api/users"}`,
+			want: time.Date(2026, 3, 1, 3, 45, 30, 0, time.UTC),
+		},
+		{
+			name: "NJS unix seconds",
+			log:  `{"@timestamp":"2026-03-01T09:15:30+05:30","time":"1772400000","method":"GET","path":"/api/users"}`,
+			want: time.Date(2026, 3, 1, 21, 20, 0, 0, time.UTC),
+		},
+	}
+	p := &IngestPipeline{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rawLog KafkaLogMessage
+			if err := json.Unmarshal([]byte(tt.log), &rawLog); err != nil {
+				t.Fatalf("decode log: %v", err)
+			}
+			apiData, err := p.mapKafkaLogToUserAPIData(rawLog)
+			if err != nil {
+				t.Fatalf("mapKafkaLogToUserAPIData: %v", err)
+			}
+			if !apiData.Timestamp.Equal(tt.want) || apiData.Timestamp.Location() != time.UTC {
+				t.Errorf("timestamp = %v, want %v", apiData.Timestamp, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Job is a long-running admin operation executed in the background. Jobs are
+// kept in memory only, so their status does not survive a restart, and
+// finished jobs are dropped once they are older than the manager's TTL.
+type Job struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     string      `json:"status"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// JobManager runs background jobs and tracks their status.
+type JobManager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+	// ttl is how long finished jobs stay available.
+	ttl time.Duration
+}
+
+// NewJobManager keeps finished jobs for JOB_TTL (default 24h).
+func NewJobManager() *JobManager {
+	return &JobManager{jobs: map[string]*Job{}, ttl: envDuration("JOB_TTL", 24*time.Hour)}
+}
+
+// Start runs fn in the background and returns the job tracking it. fn's
+// context is independent of the request that started the job.
+func (m *JobManager) Start(jobType string, fn func(ctx context.Context) (interface{}, error)) Job {
+	job := &Job{
+		ID:        primitive.NewObjectID().Hex(),
+		Type:      jobType,
+		Status:    JobStatusRunning,
+		StartedAt: time.Now(),
+	}
+	m.mu.Lock()
+	m.pruneLocked(job.StartedAt)
+	m.jobs[job.ID] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	go func() {
+		result, err := runJob(fn)
+		finished := time.Now()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		job.FinishedAt = &finished
+		job.Result = result
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.Error = err.Error()
+			log.Printf("Job %s (%s) failed: %v", job.ID, job.Type, err)
+			return
+		}
+		job.Status = JobStatusCompleted
+		log.Printf("Job %s (%s) completed in %s", job.ID, job.Type, finished.Sub(job.StartedAt))
+	}()
+	return snapshot
+}
+
+// runJob runs fn, turning a panic into an error so the job is marked failed
+// instead of staying running forever or taking the process down.
+func runJob(fn func(ctx context.Context) (interface{}, error)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(context.Background())
+}
+
+// pruneLocked drops the jobs that finished more than the TTL before now.
+// The caller holds m.mu.
+func (m *JobManager) pruneLocked(now time.Time) {
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > m.ttl {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Get returns a snapshot of a job.
+func (m *JobManager) Get(id string) (Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForJob polls until the job leaves the running state.
+func waitForJob(t *testing.T, m *JobManager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status != JobStatusRunning {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s still running", id)
+	return Job{}
+}
+
+func TestJobManagerStart(t *testing.T) {
+	tests := []struct {
+		name       string
+		fn         func(ctx context.Context) (interface{}, error)
+		wantStatus string
+		wantError  string
+		wantResult interface{}
+	}{
+		{
+			name:       "completed",
+			fn:         func(context.Context) (interface{}, error) { return 42, nil },
+			wantStatus: JobStatusCompleted,
+			wantResult: 42,
+		},
+		{
+			name:       "failed",
+			fn:         func(context.Context) (interface{}, error) { return nil, errors.New("boom") },
+			wantStatus: JobStatusFailed,
+			wantError:  "boom",
+		},
+		{
+			name:       "panicked",
+			fn:         func(context.Context) (interface{}, error) { panic("nil map") },
+			wantStatus: JobStatusFailed,
+			wantError:  "job panicked: nil map",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewJobManager()
+			started := m.Start("test", tt.fn)
+			if started.Status != JobStatusRunning {
+				t.Errorf("started status = %q, want %q", started.Status, JobStatusRunning)
+			}
+			job := waitForJob(t, m, started.ID)
+			if job.Status != tt.wantStatus || job.Error != tt.wantError || job.Result != tt.wantResult {
+				t.Errorf("job = %+v, want status %q error %q result %v", job, tt.wantStatus, tt.wantError, tt.wantResult)
+			}
+			if job.FinishedAt == nil {
+				t.Error("FinishedAt not set")
+			}
+		})
+	}
+}
+
+func TestJobManagerPrunesFinishedJobs(t *testing.T) {
+	m := NewJobManager()
+	m.ttl = time.Hour
+	done := func(context.Context) (interface{}, error) { return nil, nil }
+	block := make(chan struct{})
+	defer close(block)
+
+	expired := waitForJob(t, m, m.Start("test", done).ID)
+	recent := waitForJob(t, m, m.Start("test", done).ID)
+	running := m.Start("test", func(context.Context) (interface{}, error) {
+		<-block
+		return nil, nil
+	})
+	// Age the first job past the TTL and the running one's start.
+	m.mu.Lock()
+	old := time.Now().Add(-2 * time.Hour)
+	m.jobs[expired.ID].FinishedAt = &old
+	m.jobs[running.ID].StartedAt = old
+	m.mu.Unlock()
+
+	m.Start("test", done)
+	if _, ok := m.Get(expired.ID); ok {
+		t.Error("job finished past the TTL was kept")
+	}
+	if _, ok := m.Get(recent.ID); !ok {
+		t.Error("job finished within the TTL was pruned")
+	}
+	if _, ok := m.Get(running.ID); !ok {
+		t.Error("running job was pruned")
+	}
+}
@@ -2,23 +2,46 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
-	"strconv"
-	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/RavenSec10/Raven_Backend/db"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type KafkaConsumerService struct {
-	reader     *kafka.Reader
-	piiService *PIIService
-	mongo      db.MongoInstance
+	reader        *kafka.Reader
+	decoders      *logDecoders
+	pipeline      *IngestPipeline
+	mongo         db.MongoInstance
+	startedAt     time.Time
+	lastProcessed atomic.Int64
+	maxLag        int64
+	maxIdle       time.Duration
+
+	// seekToCheckpoint resumes from the kafka_offsets checkpoints instead of
+	// the broker's committed offsets on startup.
+	seekToCheckpoint bool
+	// transport carries the offset commits of seekToCheckpoints; nil uses
+	// kafka.DefaultTransport.
+	transport kafka.RoundTripper
 }
 
+type ConsumerHealth struct {
+	Status          string     `json:"status"`
+	Lag             int64      `json:"lag"`
+	IdleSeconds     float64    `json:"idle_seconds"`
+	LastProcessedAt *time.Time `json:"last_processed_at,omitempty"`
+	MaxLag          int64      `json:"max_lag"`
+	MaxIdleSeconds  float64    `json:"max_idle_seconds"`
+}
+
+// KafkaLogMessage is a log as proxies send it. Its binding tags are checked
+// only for logs submitted over HTTP; declared body sizes are capped at 1 GiB.
 type KafkaLogMessage struct {
 	TimestampMetadata time.Time `json:"@timestamp"`
 	Metadata          struct {
@@ -30,7 +53,9 @@ type KafkaLogMessage struct {
 	StatusText          string            `json:"status"`
 	UserAgent           string            `json:"user_agent"`
 	ResponseHeaders     map[string]string `json:"responseHeaders"`
-	RequestBodySize     int               `json:"request_body_size"`
+	RequestTrailers     map[string]string `json:"requestTrailers"`
+	ResponseTrailers    map[string]string `json:"responseTrailers"`
+	RequestBodySize     int               `json:"request_body_size" binding:"min=0,max=1073741824"`
 	IsGzipCompressed    bool              `json:"is_gzip_compressed"`
 	Service             string            `json:"service"`
 	HasRequestBody      bool              `json:"has_request_body"`
@@ -39,7 +64,7 @@ type KafkaLogMessage struct {
 	LogType             string            `json:"log_type"`
 	RequestPayload      interface{}       `json:"requestPayload"`
 	RequestTime         string            `json:"request_time"`
-	Method              string            `json:"method"`
+	Method              string            `json:"method" binding:"required,alpha"`
 	NjsTime             string            `json:"time"`
 	Referer             string            `json:"referer"`
 	ResponseSize        string            `json:"response_size"`
@@ -54,12 +79,12 @@ type KafkaLogMessage struct {
 	Type                string            `json:"type"`
 	StatusCode          string            `json:"statusCode"`
 	UpstreamTime        string            `json:"upstream_time"`
-	Path                string            `json:"path"`
-	ResponseBodySize    int               `json:"response_body_size"`
-	Host                string            `json:"host"`
+	Path                string            `json:"path" binding:"required,startswith=/"`
+	ResponseBodySize    int               `json:"response_body_size" binding:"min=0,max=1073741824"`
+	Host                string            `json:"host" binding:"required"`
 }
 // creates a new instance of the consumer service.
-func NewKafkaConsumerService(brokerAddress string, topic string, groupID string, piiSvc *PIIService, mongoInstance db.MongoInstance) *KafkaConsumerService {
+func NewKafkaConsumerService(brokerAddress string, topic string, groupID string, pipeline *IngestPipeline) *KafkaConsumerService {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{brokerAddress},
 		Topic:   topic,
@@ -71,9 +96,15 @@ func NewKafkaConsumerService(brokerAddress string, topic string, groupID string,
 	})
 
 	return &KafkaConsumerService{
-		reader:     reader,
-		piiService: piiSvc,
-		mongo:      mongoInstance,
+		reader:    reader,
+		decoders:  newLogDecodersFromEnv(),
+		pipeline:  pipeline,
+		mongo:     pipeline.mongo,
+		startedAt: time.Now(),
+		maxLag:    int64(envInt("CONSUMER_MAX_LAG", 1000)),
+		maxIdle:   envDuration("CONSUMER_MAX_IDLE", 5*time.Minute),
+
+		seekToCheckpoint: envBool("KAFKA_SEEK_TO_CHECKPOINT", false),
 	}
 }
 
@@ -81,131 +112,122 @@ func NewKafkaConsumerService(brokerAddress string, topic string, groupID string,
 func (s *KafkaConsumerService) Start(ctx context.Context) {
 	log.Println("Kafka consumer service started. Waiting for messages...")
 	defer s.reader.Close()
+	if s.seekToCheckpoint {
+		if err := s.seekToCheckpoints(ctx); err != nil {
+			log.Printf("Error seeking to offset checkpoints, using broker offsets: %v", err)
+		}
+	}
+	go s.reportHealth(ctx)
 
 	for {
-		msg, err := s.reader.FetchMessage(ctx)
+		msgCtx, span := tracer.Start(ctx, "kafka.consume", trace.WithSpanKind(trace.SpanKindConsumer))
+		msg, err := s.fetchMessage(msgCtx)
 		if err != nil {
+			span.End()
 			if ctx.Err() != nil {
 				break
 			}
 			log.Printf("Error fetching Kafka message: %v", err)
 			continue
 		}
-		s.processMessage(ctx, msg)
+		span.SetAttributes(
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.destination.partition.id", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		)
+		s.processMessage(msgCtx, msg)
+		span.End()
 	}
 
 	log.Println("Kafka consumer service stopped.")
 }
 
+// fetchMessage waits for the next Kafka message, tracing the wait.
+func (s *KafkaConsumerService) fetchMessage(ctx context.Context) (kafka.Message, error) {
+	ctx, span := tracer.Start(ctx, "kafka.fetch")
+	msg, err := s.reader.FetchMessage(ctx)
+	if ctx.Err() != nil {
+		err = nil
+	}
+	endSpan(span, err)
+	return msg, err
+}
+
 // processMessage handles a single Kafka message.
 func (s *KafkaConsumerService) processMessage(ctx context.Context, msg kafka.Message) {
 	log.Printf("Received message from Kafka topic '%s', partition %d, offset %d\n", msg.Topic, msg.Partition, msg.Offset)
 
-	var rawKafkaLog KafkaLogMessage
-	if err := json.Unmarshal(msg.Value, &rawKafkaLog); err != nil {
-		log.Printf("Error unmarshaling Kafka message into KafkaLogMessage: %v. Message: %s. Skipping message.", err, string(msg.Value))
+	_, span := tracer.Start(ctx, "kafka.decode")
+	rawKafkaLog, err := s.decoders.decode(msg)
+	endSpan(span, err)
+	if err != nil {
+		log.Printf("Error decoding Kafka message into KafkaLogMessage: %v. Skipping message.", err)
 		s.commitMessage(ctx, msg)
 		return
 	}
 
-	apiData, err := s.mapKafkaLogToUserAPIData(rawKafkaLog)
-	if err != nil {
-		log.Printf("Error mapping Kafka log to UserAPIData: %v. Skipping message.", err)
-		s.commitMessage(ctx, msg)
+	s.pipeline.ObserveLag(msg.HighWaterMark - msg.Offset - 1)
+	if _, err := s.pipeline.Ingest(ctx, rawKafkaLog); err != nil {
+		if errors.Is(err, ErrMalformedLog) {
+			log.Printf("Error mapping Kafka log to UserAPIData: %v. Skipping message.", err)
+			s.commitMessage(ctx, msg)
+		}
 		return
 	}
+	s.commitMessage(ctx, msg)
+}
 
-	piiAnalysis := s.piiService.AnalyzePIIInAPIData(apiData)
-	s.enrichUserAPIData(&apiData, piiAnalysis)
-	
-	if apiData.HasPII {
-		log.Printf("PII DETECTED in %s %s. Risk: %s, Findings: %d", apiData.Method, apiData.APIEndpoint, apiData.HighestRisk, apiData.PIICount)
-	}
-	if err := s.mongo.SaveUserAPIData(apiData); err != nil {
-		log.Printf("Error saving API data to MongoDB: %v", err)
+
+func (s *KafkaConsumerService) commitMessage(ctx context.Context, msg kafka.Message) {
+	if err := s.reader.CommitMessages(ctx, msg); err != nil {
+		log.Printf("Failed to commit Kafka message offset %d: %v", msg.Offset, err)
 		return
 	}
-	s.commitMessage(ctx, msg)
+	s.lastProcessed.Store(time.Now().UnixNano())
+	s.checkpointOffset(ctx, msg)
 }
 
-func (s *KafkaConsumerService) mapKafkaLogToUserAPIData(rawLog KafkaLogMessage) (db.UserAPIData, error) {
-	njsTimeSeconds, err := parseNjsTime(rawLog.NjsTime)
-	parsedTimestamp := rawLog.TimestampMetadata
-	if err == nil {
-		parsedTimestamp = njsTimeSeconds
-	} else {
-		log.Printf("Warning: Could not parse NJS timestamp '%s'. Using Filebeat's timestamp. Error: %v", rawLog.NjsTime, err)
+// Health reports the consumer's lag and idle time against the configured thresholds.
+func (s *KafkaConsumerService) Health() ConsumerHealth {
+	health := ConsumerHealth{
+		Status:         "ok",
+		Lag:            s.reader.Stats().Lag,
+		MaxLag:         s.maxLag,
+		MaxIdleSeconds: s.maxIdle.Seconds(),
 	}
-	scheme := "http"
-	host := rawLog.Host
-	if strings.HasPrefix(rawLog.Host, "https://") {
-		scheme = "https"
-		host = strings.TrimPrefix(rawLog.Host, "https://")
-	} else if strings.HasPrefix(rawLog.Host, "http://") {
-		scheme = "http"
-		host = strings.TrimPrefix(rawLog.Host, "http://")
+	since := s.startedAt
+	if last := s.lastProcessed.Load(); last != 0 {
+		since = time.Unix(0, last)
+		health.LastProcessedAt = &since
 	}
-	
-	fullURL := fmt.Sprintf("%s://%s%s", scheme, host, rawLog.Path)
-	apiEndpoint := rawLog.Path
-	if idx := strings.Index(apiEndpoint, "?"); idx != -1 {
-		apiEndpoint = apiEndpoint[:idx]
+	idle := time.Since(since)
+	health.IdleSeconds = idle.Seconds()
+	if s.degraded(health.Lag, idle) {
+		health.Status = "degraded"
 	}
 
-	return db.UserAPIData{
-		APIEndpoint:     apiEndpoint,
-		Method:          rawLog.Method,
-		URL:             fullURL,
-		RequestHeaders:  rawLog.RequestHeaders,
-		ResponseHeaders: rawLog.ResponseHeaders,
-		RequestBody:     rawLog.RequestPayload,
-		ResponseBody:    rawLog.ResponsePayload,
-		Source:          rawLog.Source,
-		Timestamp:       parsedTimestamp,
-	}, nil
+	consumerLag.Set(float64(health.Lag))
+	consumerIdleSeconds.Set(health.IdleSeconds)
+	return health
 }
 
-func parseNjsTime(njsTimeString string) (time.Time, error) {
-	seconds, err := strconv.ParseInt(njsTimeString, 10, 64)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse NJS time string '%s' as integer seconds: %w", njsTimeString, err)
-	}
-	return time.Unix(seconds, 0), nil
+// degraded reports whether the lag is above the threshold, or messages are
+// waiting while none has been processed for longer than allowed. An idle
+// consumer with nothing to read is healthy.
+func (s *KafkaConsumerService) degraded(lag int64, idle time.Duration) bool {
+	return lag > s.maxLag || (lag > 0 && idle > s.maxIdle)
 }
 
-
-// enrichUserAPIData populates the PII summary fields in the UserAPIData struct.
-func (s *KafkaConsumerService) enrichUserAPIData(apiData *db.UserAPIData, piiAnalysis PIIAnalysisResult) {
-	apiData.HasPII = piiAnalysis.TotalCount > 0
-	apiData.PIICount = piiAnalysis.TotalCount
-	apiData.RiskScore = piiAnalysis.RiskScore
-	apiData.HighestRisk = piiAnalysis.HighestRisk
-
-	var dbFindings []db.PIIFinding
-	var sensitiveFieldsMap = make(map[string]bool)
-	
-	for _, finding := range piiAnalysis.Findings {
-		dbFindings = append(dbFindings, db.PIIFinding{
-			PIIType:       finding.PIIType,
-			DetectedValue: finding.DetectedValue,
-			FieldName:     finding.FieldName,
-			Location:      finding.Location,
-			DetectionMode: finding.DetectionMode,
-			RiskLevel:     finding.RiskLevel,
-			Category:      finding.Category,
-			Tags:          finding.Tags,
-			Timestamp:     finding.Timestamp,
-		})
-		if !sensitiveFieldsMap[finding.PIIType] {
-			apiData.SensitiveFields = append(apiData.SensitiveFields, finding.PIIType)
-			sensitiveFieldsMap[finding.PIIType] = true
+// reportHealth keeps the lag and idle gauges current between health checks.
+func (s *KafkaConsumerService) reportHealth(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Health()
 		}
 	}
-	apiData.PIIFindings = dbFindings
-}
-
-func (s *KafkaConsumerService) commitMessage(ctx context.Context, msg kafka.Message) {
-	if err := s.reader.CommitMessages(ctx, msg); err != nil {
-		log.Printf("Failed to commit Kafka message offset %d: %v", msg.Offset, err)
-	}
 }
\ No newline at end of file
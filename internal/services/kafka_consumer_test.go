@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKafkaConsumerDegraded(t *testing.T) {
+	s := &KafkaConsumerService{maxLag: 1000, maxIdle: 5 * time.Minute}
+	tests := []struct {
+		name string
+		lag  int64
+		idle time.Duration
+		want bool
+	}{
+		{name: "caught up and busy", lag: 0, idle: time.Second, want: false},
+		{name: "idle with nothing to read", lag: 0, idle: time.Hour, want: false},
+		{name: "idle with messages waiting", lag: 10, idle: time.Hour, want: true},
+		{name: "lag under threshold", lag: 10, idle: time.Second, want: false},
+		{name: "lag over threshold", lag: 5000, idle: time.Second, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.degraded(tt.lag, tt.idle); got != tt.want {
+				t.Errorf("degraded(%d, %s) = %t, want %t", tt.lag, tt.idle, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const defaultKeywordContextWindow = 32
+
+// detectLabeledValues runs the keyword patterns that apply to text: wherever a
+// label such as "SSN:" matches, the value that follows it within the pattern's
+// context window is reported.
+func (s *PIIService) detectLabeledValues(text, location string) []PIIDetectionResult {
+	var findings []PIIDetectionResult
+	for patternName, pattern := range s.config.DetectionModes.KeywordBased.Patterns {
+		if pattern.ApplyTo != "text" {
+			continue
+		}
+		regex, exists := s.keywordRegex[patternName]
+		if !exists {
+			continue
+		}
+		window := pattern.ContextWindow
+		if window <= 0 {
+			window = defaultKeywordContextWindow
+		}
+		valueRegex := s.compiledRegex[fmt.Sprintf("keyword_value_%s", patternName)]
+		for _, loc := range regex.FindAllStringIndex(text, -1) {
+			end := loc[1] + window
+			if end > len(text) {
+				end = len(text)
+			}
+			value := labeledValue(text[loc[1]:end], end < len(text))
+			if value == "" {
+				continue
+			}
+			if valueRegex != nil && !valueRegex.MatchString(value) {
+				continue
+			}
+			if !passesValidation(pattern.Validate, value) {
+				continue
+			}
+			s.stats.record("keyword_based", patternName)
+			findings = append(findings, PIIDetectionResult{
+				PIIType:       patternName,
+				DetectedValue: s.maskValue(value, pattern.MaskOptions),
+				rawValue:      value,
+				Location:      location,
+				DetectionMode: "keyword_based",
+				RiskLevel:     pattern.RiskLevel,
+				Category:      pattern.Category,
+				Tags:          pattern.Tags,
+				Frameworks:    pattern.Frameworks,
+				Confidence:    pattern.Confidence,
+				Timestamp:     time.Now(),
+			})
+		}
+	}
+	return findings
+}
+
+// labeledValue extracts the value at the start of the text following a label.
+// Separators like ':' or '#' are skipped, and the value ends at the first
+// character that can't belong to an identifier, so it never runs on into the
+// next word or sentence. A space is only kept between digit groups, as in
+// "123 45 6789". cut reports whether the window ended before the text did.
+func labeledValue(after string, cut bool) string {
+	after = strings.TrimLeft(after, " \t:=#-\"'")
+	runes := []rune(after)
+	end := 0
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@' || r == '_' || r == '+' || r == '-' || r == '/':
+		case r == '.' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			// A period inside a value (an email, a dotted id), not a sentence end.
+		case r == ' ' && i > 0 && unicode.IsDigit(runes[i-1]) && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+		default:
+			return string(runes[:end])
+		}
+		end = i + 1
+	}
+	// The window may cut a value short; drop the partial trailing token
+	// rather than report a fragment.
+	if cut && end == len(runes) {
+		return ""
+	}
+	return string(runes[:end])
+}
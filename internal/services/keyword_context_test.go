@@ -0,0 +1,58 @@
+package services
+
+import "testing"
+
+func TestDetectLabeledValues(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "ssn label", text: "SSN: 123-45-6789", want: []string{"123-45-6789"}},
+		{name: "spelled out label", text: "Social Security Number = 123 45 6789 on file", want: []string{"123 45 6789"}},
+		{name: "value ends the sentence", text: "Customer SSN: 123-45-6789. Call back tomorrow.", want: []string{"123-45-6789"}},
+		{name: "label without a value", text: "SSN: pending. 123-45-6789 is the ticket number.", want: nil},
+		{name: "label at the end of a sentence", text: "Please confirm your SSN:. 123456789 was the order.", want: nil},
+		{name: "no label", text: "ticket 123-45-6789", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range s.detectLabeledValues(tt.text, "request_body") {
+				if f.PIIType != "INLINE_SSN" {
+					t.Errorf("finding type %s, want INLINE_SSN", f.PIIType)
+				}
+				got = append(got, f.rawValue)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("values = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("value %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLabeledValue(t *testing.T) {
+	tests := []struct {
+		after string
+		cut   bool
+		want  string
+	}{
+		{after: ": jane.doe@example.com, thanks", want: "jane.doe@example.com"},
+		{after: " #AB-1234. Next sentence", want: "AB-1234"},
+		{after: ": 123 45 6789 and more", want: "123 45 6789"},
+		{after: ": done. 123-45-6789", want: "done"},
+		{after: ": . 123-45-6789", want: ""},
+		{after: ": 123-45-67", cut: true, want: ""},
+	}
+	for _, tt := range tests {
+		if got := labeledValue(tt.after, tt.cut); got != tt.want {
+			t.Errorf("labeledValue(%q) = %q, want %q", tt.after, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// LogDecoder turns a Kafka message value into a log message.
+type LogDecoder interface {
+	Decode(value []byte) (KafkaLogMessage, error)
+}
+
+type jsonLogDecoder struct{}
+
+func (jsonLogDecoder) Decode(value []byte) (KafkaLogMessage, error) {
+	var msg KafkaLogMessage
+	err := json.Unmarshal(value, &msg)
+	return msg, err
+}
+
+// logDecoders selects the decoder of each Kafka message: by the message's
+// content-type header when a decoder is registered for it, and otherwise by
+// KAFKA_CONTENT_TYPE, which defaults to JSON.
+type logDecoders struct {
+	byContentType map[string]LogDecoder
+	defaultType   string
+}
+
+// newLogDecodersFromEnv registers the JSON decoder and, when
+// KAFKA_PROTO_DESCRIPTOR and KAFKA_PROTO_MESSAGE are set, a protobuf decoder.
+func newLogDecodersFromEnv() *logDecoders {
+	decoders := &logDecoders{
+		byContentType: map[string]LogDecoder{ContentTypeJSON: jsonLogDecoder{}},
+		defaultType:   ContentTypeJSON,
+	}
+	descriptorPath, messageName := os.Getenv("KAFKA_PROTO_DESCRIPTOR"), os.Getenv("KAFKA_PROTO_MESSAGE")
+	if descriptorPath != "" || messageName != "" {
+		decoder, err := NewProtobufLogDecoder(descriptorPath, messageName)
+		if err != nil {
+			log.Printf("Error loading protobuf log decoder, protobuf messages can't be decoded: %v", err)
+		} else {
+			decoders.byContentType[ContentTypeProtobuf] = decoder
+		}
+	}
+	if contentType := os.Getenv("KAFKA_CONTENT_TYPE"); contentType != "" {
+		if _, ok := decoders.byContentType[contentType]; ok {
+			decoders.defaultType = contentType
+		} else {
+			log.Printf("Warning: No decoder for KAFKA_CONTENT_TYPE '%s', using %s", contentType, ContentTypeJSON)
+		}
+	}
+	return decoders
+}
+
+func (d *logDecoders) decode(msg kafka.Message) (KafkaLogMessage, error) {
+	contentType := d.defaultType
+	for _, header := range msg.Headers {
+		if strings.EqualFold(header.Key, "content-type") {
+			if _, ok := d.byContentType[string(header.Value)]; ok {
+				contentType = string(header.Value)
+			}
+		}
+	}
+	decoded, err := d.byContentType[contentType].Decode(msg.Value)
+	if err != nil {
+		return KafkaLogMessage{}, fmt.Errorf("failed to decode %s message: %w", contentType, err)
+	}
+	return decoded, nil
+}
+
+// ProtobufLogDecoder decodes protobuf log messages described by a
+// FileDescriptorSet, as written by protoc --include_imports
+// --descriptor_set_out. A field maps to the JSON log key matching either its
+// proto name or its JSON name, so both "response_body_size" and
+// "response_payload" (JSON name responsePayload) land where the JSON log
+// format puts them. A field named timestamp, a google.protobuf.Timestamp or
+// an RFC 3339 string, maps to @timestamp. Scalars are converted to the type
+// of their log key, so status_code, request_size and response_size may be
+// integers and the body sizes strings holding integers.
+type ProtobufLogDecoder struct {
+	message protoreflect.MessageDescriptor
+}
+
+func NewProtobufLogDecoder(descriptorPath, messageName string) (*ProtobufLogDecoder, error) {
+	if descriptorPath == "" || messageName == "" {
+		return nil, fmt.Errorf("both a descriptor set and a message name are required")
+	}
+	data, err := os.ReadFile(descriptorPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set: %w", err)
+	}
+	return newProtobufLogDecoder(&set, messageName)
+}
+
+func newProtobufLogDecoder(set *descriptorpb.FileDescriptorSet, messageName string) (*ProtobufLogDecoder, error) {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("message %s not found in descriptor set: %w", messageName, err)
+	}
+	message, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", messageName)
+	}
+	return &ProtobufLogDecoder{message: message}, nil
+}
+
+// kafkaLogFields maps the top-level keys of a JSON log message to the types
+// of their fields.
+var kafkaLogFields = func() map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	t := reflect.TypeOf(KafkaLogMessage{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = t.Field(i).Type
+	}
+	return fields
+}()
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (d *ProtobufLogDecoder) Decode(value []byte) (KafkaLogMessage, error) {
+	message := dynamicpb.NewMessage(d.message)
+	if err := proto.Unmarshal(value, message); err != nil {
+		return KafkaLogMessage{}, err
+	}
+	fields := make(map[string]interface{})
+	var rangeErr error
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := protoLogKey(fd)
+		var value interface{}
+		if value, rangeErr = protoFieldValue(fd, v); rangeErr == nil {
+			fields[key], rangeErr = convertLogField(key, value)
+		}
+		return rangeErr == nil
+	})
+	if rangeErr != nil {
+		return KafkaLogMessage{}, rangeErr
+	}
+	// The fields take the same path as a JSON message from here on.
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return KafkaLogMessage{}, err
+	}
+	return jsonLogDecoder{}.Decode(data)
+}
+
+// protoLogKey returns the JSON log key a field maps to.
+func protoLogKey(fd protoreflect.FieldDescriptor) string {
+	key := string(fd.Name())
+	if _, ok := kafkaLogFields[key]; ok {
+		return key
+	}
+	if _, ok := kafkaLogFields[fd.JSONName()]; ok {
+		return fd.JSONName()
+	}
+	if key == "timestamp" {
+		return "@timestamp"
+	}
+	return key
+}
+
+// convertLogField converts a scalar field value to the type of its log key:
+// numbers and booleans to strings for string keys, and whole numbers held in
+// strings or floats to integers for integer keys. Other values are left to
+// the JSON decoding.
+func convertLogField(key string, value interface{}) (interface{}, error) {
+	t := kafkaLogFields[key]
+	switch {
+	case t == nil:
+		return value, nil
+	case t == timeType:
+		switch value.(type) {
+		case int32, int64, uint32, uint64, float32, float64:
+			return nil, fmt.Errorf("field %s must be a google.protobuf.Timestamp or an RFC 3339 string", key)
+		}
+	case t.Kind() == reflect.String:
+		switch v := value.(type) {
+		case int32, int64, uint32, uint64:
+			return fmt.Sprint(v), nil
+		case float32:
+			return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case t.Kind() == reflect.Int:
+		switch v := value.(type) {
+		case string:
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %q is not an integer", key, v)
+			}
+			return n, nil
+		case float32:
+			return floatToInt(key, float64(v))
+		case float64:
+			return floatToInt(key, v)
+		}
+	}
+	return value, nil
+}
+
+func floatToInt(key string, f float64) (int, error) {
+	if f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+		return 0, fmt.Errorf("field %s: %v is not an integer", key, f)
+	}
+	return int(f), nil
+}
+
+// protoFieldValue converts a field value to its JSON equivalent. Nested
+// messages, including well-known types such as google.protobuf.Struct and
+// Timestamp, use the protobuf JSON mapping.
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			item, err := protoSingularValue(fd, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case fd.IsMap():
+		entries := make(map[string]interface{})
+		var err error
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			entries[k.String()], err = protoSingularValue(fd.MapValue(), mv)
+			return err == nil
+		})
+		return entries, err
+	}
+	return protoSingularValue(fd, v)
+}
+
+func protoSingularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(data), nil
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name()), nil
+		}
+		return int32(v.Enum()), nil
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testLogDescriptorSet describes raven.test.ApiLog, a log message using
+// natural proto types: integer status and sizes, a Timestamp, and a string
+// body size.
+func testLogDescriptorSet() *descriptorpb.FileDescriptorSet {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("raven/test/api_log.proto"),
+		Package:    proto.String("raven.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("ApiLog"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("timestamp", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+				field("method", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("path", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("status_code", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				field("request_size", 5, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("response_size", 6, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+				field("response_body_size", 7, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("request_body_size", 8, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+				field("response_payload", 9, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("upstream_time", 10, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, ""),
+			},
+		}},
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		file,
+	}}
+}
+
+func TestProtobufLogDecoder(t *testing.T) {
+	decoder, err := newProtobufLogDecoder(testLogDescriptorSet(), "raven.test.ApiLog")
+	if err != nil {
+		t.Fatalf("newProtobufLogDecoder: %v", err)
+	}
+	at := time.Date(2026, 5, 4, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		fields  map[string]interface{}
+		check   func(t *testing.T, msg KafkaLogMessage)
+		wantErr string
+	}{
+		{
+			name: "natural types",
+			fields: map[string]interface{}{
+				"timestamp":          timestamppb.New(at),
+				"method":             "POST",
+				"path":               "/users",
+				"status_code":        int32(201),
+				"request_size":       int64(512),
+				"response_size":      uint64(2048),
+				"response_body_size": "1024",
+				"request_body_size":  float64(300),
+				"response_payload":   `{"email":"jane@example.com"}`,
+				"upstream_time":      0.25,
+			},
+			check: func(t *testing.T, msg KafkaLogMessage) {
+				if !msg.TimestampMetadata.Equal(at) {
+					t.Errorf("@timestamp = %v, want %v", msg.TimestampMetadata, at)
+				}
+				if msg.Method != "POST" || msg.Path != "/users" {
+					t.Errorf("method and path = %s %s", msg.Method, msg.Path)
+				}
+				if msg.StatusCode != "201" || msg.RequestSize != "512" || msg.ResponseSize != "2048" {
+					t.Errorf("statusCode, request_size, response_size = %q, %q, %q", msg.StatusCode, msg.RequestSize, msg.ResponseSize)
+				}
+				if msg.ResponseBodySize != 1024 || msg.RequestBodySize != 300 {
+					t.Errorf("body sizes = %d, %d", msg.RequestBodySize, msg.ResponseBodySize)
+				}
+				if msg.UpstreamTime != "0.25" {
+					t.Errorf("upstream_time = %q", msg.UpstreamTime)
+				}
+				if msg.ResponsePayload != `{"email":"jane@example.com"}` {
+					t.Errorf("responsePayload = %v", msg.ResponsePayload)
+				}
+			},
+		},
+		{
+			name:   "unset fields",
+			fields: map[string]interface{}{"method": "GET"},
+			check: func(t *testing.T, msg KafkaLogMessage) {
+				if !msg.TimestampMetadata.IsZero() || msg.StatusCode != "" || msg.ResponseBodySize != 0 {
+					t.Errorf("unset fields decoded as %v, %q, %d", msg.TimestampMetadata, msg.StatusCode, msg.ResponseBodySize)
+				}
+			},
+		},
+		{
+			name:    "body size not an integer",
+			fields:  map[string]interface{}{"response_body_size": "1kb"},
+			wantErr: "response_body_size",
+		},
+		{
+			name:    "fractional body size",
+			fields:  map[string]interface{}{"request_body_size": 1.5},
+			wantErr: "request_body_size",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := dynamicpb.NewMessage(decoder.message)
+			for name, value := range tt.fields {
+				fd := decoder.message.Fields().ByName(protoreflect.Name(name))
+				if ts, ok := value.(*timestamppb.Timestamp); ok {
+					message.Set(fd, protoreflect.ValueOfMessage(ts.ProtoReflect()))
+					continue
+				}
+				message.Set(fd, protoreflect.ValueOf(value))
+			}
+			data, err := proto.Marshal(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := decoder.Decode(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decode error = %v, want one naming %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			tt.check(t, msg)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	uuidSegmentRegex  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexSegmentRegex   = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	tokenSegmentRegex = regexp.MustCompile(`^[A-Za-z0-9_\-.~+=]{20,}$`)
+)
+
+// sanitizeForLog makes an endpoint or URL safe to write to logs: query values
+// are replaced with "***", id-looking path segments with ":id", and any
+// userinfo or fragment is dropped.
+func sanitizeForLog(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "[unparseable url]"
+	}
+
+	segments := strings.Split(parsed.Path, "/")
+	for i, segment := range segments {
+		if looksLikeIdentifier(segment) {
+			segments[i] = ":id"
+		}
+	}
+
+	var b strings.Builder
+	if parsed.Scheme != "" {
+		b.WriteString(parsed.Scheme + "://")
+	}
+	b.WriteString(parsed.Host)
+	b.WriteString(strings.Join(segments, "/"))
+
+	if parsed.RawQuery != "" {
+		keys := make([]string, 0)
+		for key := range parsed.Query() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			if i == 0 {
+				b.WriteByte('?')
+			} else {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key) + "=***")
+		}
+	}
+	return b.String()
+}
+
+// sanitizeURLError returns why a URL failed to parse without the URL itself,
+// which url.Error repeats in full.
+func sanitizeURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+func looksLikeIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if strings.Contains(segment, "@") {
+		return true
+	}
+	if strings.Trim(segment, "0123456789") == "" {
+		return true
+	}
+	if uuidSegmentRegex.MatchString(segment) || hexSegmentRegex.MatchString(segment) {
+		return true
+	}
+	return tokenSegmentRegex.MatchString(segment) && strings.ContainsAny(segment, "0123456789")
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestMain(m *testing.M) {
+	// The PII config and suppression rules are read relative to the
+	// repository root, where the service runs.
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		log.Fatalf("Failed to change to the repository root: %v", err)
+	}
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestPIIService loads the shipped config without a database.
+func newTestPIIService(t testing.TB) *PIIService {
+	t.Helper()
+	s, err := NewPIIService(db.MongoInstance{})
+	if err != nil {
+		t.Fatalf("NewPIIService: %v", err)
+	}
+	return s
+}
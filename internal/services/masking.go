@@ -0,0 +1,116 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	maskStrategyPartial          = "partial"
+	maskStrategyFormatPreserving = "format-preserving"
+)
+
+const (
+	defaultMaskRevealPrefix = 2
+	defaultMaskRevealSuffix = 2
+)
+
+// MaskOptions are the per-pattern masking settings. Unset reveal counts fall
+// back to the config-wide mask_reveal_prefix/mask_reveal_suffix.
+type MaskOptions struct {
+	MaskStrategy     string `json:"maskStrategy,omitempty"`
+	MaskRevealPrefix *int   `json:"maskRevealPrefix,omitempty"`
+	MaskRevealSuffix *int   `json:"maskRevealSuffix,omitempty"`
+}
+
+// maskingDisabledFromEnv reports whether PII_MASK_DISABLED turns masking off,
+// so findings keep their raw values for local debugging. It is refused when
+// ENV=production.
+func maskingDisabledFromEnv() bool {
+	if !envBool("PII_MASK_DISABLED", false) {
+		return false
+	}
+	if strings.EqualFold(os.Getenv("ENV"), "production") {
+		log.Println("WARNING: PII_MASK_DISABLED is ignored because ENV=production; findings stay masked")
+		return false
+	}
+	log.Println("WARNING: ************************************************************")
+	log.Println("WARNING: PII_MASK_DISABLED=true - findings store RAW detected values.")
+	log.Println("WARNING: For local development only. Never use with real data.")
+	log.Println("WARNING: ************************************************************")
+	return true
+}
+
+// maskValue masks a detected value using the pattern's configured strategy,
+// falling back to partial masking when none is set.
+func (s *PIIService) maskValue(value string, opts MaskOptions) string {
+	if s.maskingDisabled {
+		return value
+	}
+	switch opts.MaskStrategy {
+	case maskStrategyFormatPreserving:
+		return formatPreservingMask(value)
+	default:
+		prefix, suffix := opts.MaskRevealPrefix, opts.MaskRevealSuffix
+		if prefix == nil && s.profile != nil {
+			prefix = s.profile.MaskRevealPrefix
+		}
+		if prefix == nil {
+			prefix = s.config.MaskRevealPrefix
+		}
+		if suffix == nil && s.profile != nil {
+			suffix = s.profile.MaskRevealSuffix
+		}
+		if suffix == nil {
+			suffix = s.config.MaskRevealSuffix
+		}
+		return maskRevealing(value, prefix, suffix)
+	}
+}
+
+// maskRevealing keeps the first prefix and last suffix characters of a value
+// and stars out the rest. Nil counts use the 2/2 default. The counts are
+// reduced so that at most half the value is revealed, and values of four
+// characters or fewer are masked completely.
+func maskRevealing(value string, prefix, suffix *int) string {
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	keepPrefix, keepSuffix := defaultMaskRevealPrefix, defaultMaskRevealSuffix
+	if prefix != nil {
+		keepPrefix = max(*prefix, 0)
+	}
+	if suffix != nil {
+		keepSuffix = max(*suffix, 0)
+	}
+	for keepPrefix+keepSuffix > len(runes)/2 {
+		if keepSuffix >= keepPrefix {
+			keepSuffix--
+		} else {
+			keepPrefix--
+		}
+	}
+	return string(runes[:keepPrefix]) + strings.Repeat("*", len(runes)-keepPrefix-keepSuffix) + string(runes[len(runes)-keepSuffix:])
+}
+
+// formatPreservingMask replaces every digit with '#' and every letter with 'x',
+// keeping separators and length so the value's shape survives for analytics.
+// No character of the original value is retained, so it cannot be reversed.
+func formatPreservingMask(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+	for _, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune('#')
+		case unicode.IsLetter(r):
+			b.WriteRune('x')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"unicode"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestFormatPreservingMask(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "john.doe@acme.com", want: "xxxx.xxx@xxxx.xxx"},
+		{value: "4111-1111-1111-1111", want: "####-####-####-####"},
+		{value: "+1 (415) 555-0100", want: "+# (###) ###-####"},
+		{value: "AB12 CD34", want: "xx## xx##"},
+		{value: "josé@müller.de", want: "xxxx@xxxxxx.xx"},
+		{value: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := formatPreservingMask(tt.value)
+			if got != tt.want {
+				t.Fatalf("formatPreservingMask(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+			if len([]rune(got)) != len([]rune(tt.value)) {
+				t.Errorf("length changed from %d to %d", len([]rune(tt.value)), len([]rune(got)))
+			}
+			for _, r := range got {
+				if unicode.IsDigit(r) || unicode.IsLetter(r) && r != 'x' {
+					t.Errorf("mask %q keeps %q from the original", got, r)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskRevealing(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		prefix *int
+		suffix *int
+		want   string
+	}{
+		{name: "default counts", value: "john.doe@acme.com", want: "jo*************om"},
+		{name: "short value fully masked", value: "1234", want: "****"},
+		{name: "custom counts", value: "4111111111111111", prefix: intPtr(0), suffix: intPtr(4), want: "************1111"},
+		{name: "reveal capped at half", value: "abcdef", prefix: intPtr(3), suffix: intPtr(3), want: "ab***f"},
+		{name: "negative counts", value: "abcdefgh", prefix: intPtr(-1), suffix: intPtr(-1), want: "********"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskRevealing(tt.value, tt.prefix, tt.suffix); got != tt.want {
+				t.Errorf("maskRevealing(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskValueStrategy(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name string
+		opts MaskOptions
+		want string
+	}{
+		{name: "partial by default", opts: MaskOptions{}, want: "jo*************om"},
+		{name: "format-preserving", opts: MaskOptions{MaskStrategy: maskStrategyFormatPreserving}, want: "xxxx.xxx@xxxx.xxx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.maskValue("john.doe@acme.com", tt.opts); got != tt.want {
+				t.Errorf("maskValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskDisabledFlag(t *testing.T) {
+	const email = "jane.doe@example.com"
+	tests := []struct {
+		name    string
+		env     string
+		flag    string
+		wantRaw bool
+	}{
+		{name: "flag unset", env: "development"},
+		{name: "flag set in development", env: "development", flag: "true", wantRaw: true},
+		{name: "flag set without ENV", flag: "true", wantRaw: true},
+		{name: "flag set in production", env: "production", flag: "true"},
+		{name: "flag set in Production", env: "Production", flag: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENV", tt.env)
+			t.Setenv("PII_MASK_DISABLED", tt.flag)
+			s := newTestPIIService(t)
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/users",
+				Method:      "POST",
+				RequestBody: `{"email":"` + email + `"}`,
+			})
+			if len(result.Findings) != 1 {
+				t.Fatalf("findings = %+v, want one", result.Findings)
+			}
+			if raw := result.Findings[0].DetectedValue == email; raw != tt.wantRaw {
+				t.Errorf("detected value = %q, want raw %v", result.Findings[0].DetectedValue, tt.wantRaw)
+			}
+		})
+	}
+}
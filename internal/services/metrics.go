@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_messages_processed_total",
+		Help: "Number of ingested API log messages that were analyzed for PII.",
+	})
+	documentsSaved = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_documents_saved_total",
+		Help: "Number of API data documents written to MongoDB.",
+	})
+	documentsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_documents_skipped_total",
+		Help: "Number of analyzed documents not stored, by reason.",
+	}, []string{"reason"})
+	documentsDenylisted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_documents_denylisted_total",
+		Help: "Number of log messages dropped before analysis because their endpoint matches endpoint_denylist.",
+	})
+	findingsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_findings_suppressed_total",
+		Help: "Number of findings dropped or downgraded by suppression rules, by action and category.",
+	}, []string{"action", "category"})
+	analysisDeferred = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_analysis_deferred_total",
+		Help: "Number of documents stored with field-based analysis only because of load shedding.",
+	})
+	analysisTimeouts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_analysis_timeouts_total",
+		Help: "Number of documents whose analysis stopped at the per-document deadline.",
+	})
+	responseBodiesSkippedBinary = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_response_bodies_skipped_binary_total",
+		Help: "Number of response bodies not analyzed because their content type matches response_content_type_skip.",
+	})
+	findingSpikes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_finding_spikes_total",
+		Help: "Number of endpoint finding spikes detected against their rolling baselines.",
+	})
+	alertDeliveryFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_alert_delivery_failures_total",
+		Help: "Number of alert webhook requests that failed or were rejected.",
+	})
+	analysisBackfilled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_analysis_backfilled_total",
+		Help: "Number of deferred documents whose full analysis was completed by the backfill.",
+	})
+	headersStripped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "raven_headers_stripped_total",
+		Help: "Number of headers dropped before analysis and storage because they match header_blocklist.",
+	})
+	responseBodiesSampled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_response_bodies_sampled_total",
+		Help: "Number of response bodies of endpoints under response_sampling, by whether they were analyzed or skipped.",
+	}, []string{"result"})
+	sourceMessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_source_messages_consumed_total",
+		Help: "Number of log messages received for ingestion, by source.",
+	}, []string{"source"})
+	sourceDocumentsSaved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_source_documents_saved_total",
+		Help: "Number of API data documents written to MongoDB, by source.",
+	}, []string{"source"})
+	sourceDocumentsWithPII = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "raven_source_documents_with_pii_total",
+		Help: "Number of analyzed documents with at least one finding, by source.",
+	}, []string{"source"})
+	consumerLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "raven_kafka_consumer_lag",
+		Help: "Messages between the consumer's position and the partition high watermark.",
+	})
+	consumerIdleSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "raven_kafka_consumer_idle_seconds",
+		Help: "Seconds since the consumer last processed a message successfully.",
+	})
+)
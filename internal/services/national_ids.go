@@ -0,0 +1,71 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/internal/nationalid"
+)
+
+// NationalIDConfig enables national ID detection for a set of countries, plus
+// the country implied by the request host's TLD when MatchHostTLD is set.
+type NationalIDConfig struct {
+	MaskOptions
+
+	Countries    []string `json:"countries"`
+	MatchHostTLD bool     `json:"matchHostTLD"`
+	RiskLevel    string   `json:"riskLevel"`
+	Category     string   `json:"category"`
+	Tags         []string `json:"tags"`
+	Frameworks   []string `json:"frameworks,omitempty"`
+}
+
+// nationalIDCountries returns the countries whose national IDs are looked for
+// in a request to rawURL.
+func (s *PIIService) nationalIDCountries(rawURL string) []string {
+	cfg := s.config.NationalIDs
+	countries := append([]string{}, cfg.Countries...)
+	if !cfg.MatchHostTLD {
+		return countries
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return countries
+	}
+	code, ok := nationalid.CountryForHost(parsed.Hostname())
+	if !ok {
+		return countries
+	}
+	for _, c := range countries {
+		if strings.EqualFold(c, code) {
+			return countries
+		}
+	}
+	return append(countries, code)
+}
+
+// detectNationalIDs reports national IDs in text, tagged with their country.
+func (s *PIIService) detectNationalIDs(countries []string, text, location string) []PIIDetectionResult {
+	if len(countries) == 0 {
+		return nil
+	}
+	cfg := s.config.NationalIDs
+	var findings []PIIDetectionResult
+	for _, match := range nationalid.Detect(text, countries) {
+		s.stats.record("value_only", match.IDType)
+		findings = append(findings, PIIDetectionResult{
+			PIIType:       match.IDType,
+			DetectedValue: s.maskValue(match.Value, cfg.MaskOptions),
+			rawValue:      match.Value,
+			Location:      location,
+			DetectionMode: "value_only",
+			RiskLevel:     cfg.RiskLevel,
+			Category:      cfg.Category,
+			Tags:          append(append([]string{}, cfg.Tags...), match.Country),
+			Frameworks:    cfg.Frameworks,
+			Timestamp:     time.Now(),
+		})
+	}
+	return findings
+}
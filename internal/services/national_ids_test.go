@@ -0,0 +1,64 @@
+package services
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestNationalIDCountries(t *testing.T) {
+	tests := []struct {
+		name         string
+		countries    []string
+		matchHostTLD bool
+		url          string
+		want         []string
+	}{
+		{name: "configured only", countries: []string{"UK"}, url: "https://api.example.com.br/x", want: []string{"UK"}},
+		{name: "host tld added", countries: []string{"UK"}, matchHostTLD: true, url: "https://api.example.com.br/x", want: []string{"UK", "BR"}},
+		{name: "host tld already enabled", countries: []string{"br"}, matchHostTLD: true, url: "https://api.example.com.br/x", want: []string{"br"}},
+		{name: "unknown tld", matchHostTLD: true, url: "https://api.example.com/x", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.config.NationalIDs.Countries = tt.countries
+			s.config.NationalIDs.MatchHostTLD = tt.matchHostTLD
+			if got := s.nationalIDCountries(tt.url); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nationalIDCountries(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectNationalIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantType string
+		wantTag  string
+	}{
+		{name: "valid aadhaar", text: "id 2341 2341 2346", wantType: "IN_AADHAAR", wantTag: "IN"},
+		{name: "invalid aadhaar", text: "id 2341 2341 2345"},
+		{name: "valid cpf", text: "cpf 529.982.247-25", wantType: "BR_CPF", wantTag: "BR"},
+		{name: "invalid cpf", text: "cpf 529.982.247-26"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			findings := s.detectNationalIDs([]string{"IN", "BR"}, tt.text, "request_body")
+			if tt.wantType == "" {
+				if len(findings) != 0 {
+					t.Fatalf("findings = %+v, want none", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("findings = %+v, want one %s", findings, tt.wantType)
+			}
+			if f := findings[0]; f.PIIType != tt.wantType || !slices.Contains(f.Tags, tt.wantTag) {
+				t.Errorf("finding = %s %v, want %s tagged %s", f.PIIType, f.Tags, tt.wantType, tt.wantTag)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/segmentio/kafka-go"
+)
+
+// checkpointOffset records msg as processed in the kafka_offsets collection.
+// The broker's committed offset stays authoritative, so a failure here is
+// only logged.
+func (s *KafkaConsumerService) checkpointOffset(ctx context.Context, msg kafka.Message) {
+	if s.mongo.DB == nil {
+		return
+	}
+	checkpoint := db.KafkaOffset{
+		GroupID:   s.reader.Config().GroupID,
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset + 1,
+	}
+	if err := s.mongo.SaveKafkaOffset(ctx, checkpoint); err != nil {
+		log.Printf("Failed to checkpoint Kafka offset %d of partition %d: %v", msg.Offset, msg.Partition, err)
+	}
+}
+
+// seekToCheckpoints commits the stored checkpoints as the group's offsets, so
+// the reader resumes from them when it joins. Kafka accepts these commits only
+// while the group has no active members, i.e. before any instance has joined.
+func (s *KafkaConsumerService) seekToCheckpoints(ctx context.Context) error {
+	cfg := s.reader.Config()
+	checkpoints, err := s.mongo.LoadKafkaOffsets(ctx, cfg.GroupID, cfg.Topic)
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		log.Printf("No Kafka offset checkpoints for group %s, using broker offsets", cfg.GroupID)
+		return nil
+	}
+	commits := make([]kafka.OffsetCommit, 0, len(checkpoints))
+	for _, c := range checkpoints {
+		commits = append(commits, kafka.OffsetCommit{Partition: c.Partition, Offset: c.Offset})
+	}
+	client := &kafka.Client{Addr: kafka.TCP(cfg.Brokers...), Transport: s.transport}
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      cfg.GroupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{cfg.Topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit checkpointed offsets: %w", err)
+	}
+	for _, p := range resp.Topics[cfg.Topic] {
+		if p.Error != nil {
+			return fmt.Errorf("failed to commit checkpointed offset for partition %d: %w", p.Partition, p.Error)
+		}
+	}
+	log.Printf("Seeked group %s to %d checkpointed partition offsets", cfg.GroupID, len(commits))
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/offsetcommit"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// commitRecorder is a kafka.RoundTripper that records offset commits and
+// answers them, failing the partitions in failPartitions.
+type commitRecorder struct {
+	requests       []*offsetcommit.Request
+	failPartitions map[int32]bool
+}
+
+func (r *commitRecorder) RoundTrip(_ context.Context, _ net.Addr, msg protocol.Message) (protocol.Message, error) {
+	req := msg.(*offsetcommit.Request)
+	r.requests = append(r.requests, req)
+	resp := &offsetcommit.Response{}
+	for _, topic := range req.Topics {
+		respTopic := offsetcommit.ResponseTopic{Name: topic.Name}
+		for _, p := range topic.Partitions {
+			var code int16
+			if r.failPartitions[p.PartitionIndex] {
+				code = int16(kafka.RebalanceInProgress)
+			}
+			respTopic.Partitions = append(respTopic.Partitions, offsetcommit.ResponsePartition{PartitionIndex: p.PartitionIndex, ErrorCode: code})
+		}
+		resp.Topics = append(resp.Topics, respTopic)
+	}
+	return resp, nil
+}
+
+func TestSeekToCheckpoints(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	checkpoint := func(partition int, offset int64) bson.D {
+		return bson.D{
+			{Key: "group_id", Value: "raven"},
+			{Key: "topic", Value: "api-logs"},
+			{Key: "partition", Value: partition},
+			{Key: "offset", Value: offset},
+		}
+	}
+	tests := []struct {
+		name           string
+		checkpoints    []bson.D
+		failPartitions map[int32]bool
+		wantCommits    map[int32]int64
+		wantErr        string
+	}{
+		{
+			name:        "commits every checkpoint",
+			checkpoints: []bson.D{checkpoint(0, 42), checkpoint(1, 7), checkpoint(2, 1000)},
+			wantCommits: map[int32]int64{0: 42, 1: 7, 2: 1000},
+		},
+		{
+			name: "no checkpoints keeps broker offsets",
+		},
+		{
+			name:           "rejected commit",
+			checkpoints:    []bson.D{checkpoint(0, 42), checkpoint(1, 7)},
+			failPartitions: map[int32]bool{1: true},
+			wantCommits:    map[int32]int64{0: 42, 1: 7},
+			wantErr:        "partition 1",
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "raven.kafka_offsets", mtest.FirstBatch, tt.checkpoints...))
+			reader := kafka.NewReader(kafka.ReaderConfig{Brokers: []string{"127.0.0.1:1"}, Topic: "api-logs", GroupID: "raven"})
+			defer reader.Close()
+			transport := &commitRecorder{failPartitions: tt.failPartitions}
+			s := &KafkaConsumerService{
+				reader:    reader,
+				mongo:     db.MongoInstance{Client: mt.Client, DB: mt.DB},
+				transport: transport,
+			}
+
+			err := s.seekToCheckpoints(context.Background())
+			if tt.wantErr == "" && err != nil {
+				mt.Fatalf("seekToCheckpoints() = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				mt.Fatalf("seekToCheckpoints() = %v, want error containing %q", err, tt.wantErr)
+			}
+
+			if tt.wantCommits == nil {
+				if len(transport.requests) != 0 {
+					mt.Fatalf("%d offset commits sent, want none", len(transport.requests))
+				}
+				return
+			}
+			if len(transport.requests) != 1 {
+				mt.Fatalf("%d offset commits sent, want 1", len(transport.requests))
+			}
+			req := transport.requests[0]
+			if req.GroupID != "raven" || req.GenerationID != -1 {
+				mt.Errorf("commit group %q generation %d, want raven outside any generation", req.GroupID, req.GenerationID)
+			}
+			got := map[int32]int64{}
+			for _, topic := range req.Topics {
+				if topic.Name != "api-logs" {
+					mt.Errorf("commit for topic %q, want api-logs", topic.Name)
+				}
+				for _, p := range topic.Partitions {
+					got[p.PartitionIndex] = p.CommittedOffset
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantCommits) {
+				mt.Errorf("committed %v, want %v", got, tt.wantCommits)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// FeedbackCalibrationConfig drives the precision check over reviewed
+// findings. Patterns with at least MinReviewed reviewed findings and a
+// precision below MinPrecision are flagged; with AutoAdjust, their findings'
+// confidence is scaled down by their precision.
+type FeedbackCalibrationConfig struct {
+	TruePositiveLabels  []string `json:"truePositiveLabels"`
+	FalsePositiveLabels []string `json:"falsePositiveLabels"`
+	MinReviewed         int      `json:"minReviewed"`
+	MinPrecision        float64  `json:"minPrecision"`
+	AutoAdjust          bool     `json:"autoAdjust"`
+}
+
+// PatternCalibration is the feedback of one pattern and whether its
+// precision is poor.
+type PatternCalibration struct {
+	db.PatternFeedback
+	Reviewed      int  `json:"reviewed"`
+	PoorPrecision bool `json:"poor_precision"`
+}
+
+// precision is the share of reviewed findings that were true positives.
+func precision(truePositives, falsePositives int) float64 {
+	reviewed := truePositives + falsePositives
+	if reviewed == 0 {
+		return 0
+	}
+	return float64(truePositives) / float64(reviewed)
+}
+
+// calibrate annotates feedback with its precision and flags the patterns
+// whose precision is poor.
+func (cfg FeedbackCalibrationConfig) calibrate(feedback []db.PatternFeedback) []PatternCalibration {
+	calibrations := make([]PatternCalibration, 0, len(feedback))
+	for _, f := range feedback {
+		f.Precision = precision(f.TruePositives, f.FalsePositives)
+		reviewed := f.TruePositives + f.FalsePositives
+		calibrations = append(calibrations, PatternCalibration{
+			PatternFeedback: f,
+			Reviewed:        reviewed,
+			PoorPrecision:   reviewed >= cfg.MinReviewed && f.Precision < cfg.MinPrecision,
+		})
+	}
+	return calibrations
+}
+
+// CalibrateFromFeedback recomputes the per-pattern feedback aggregates,
+// stores them in pattern_feedback and logs the patterns with poor precision.
+// With autoAdjust, those patterns' confidence factors are updated.
+func (s *PIIService) CalibrateFromFeedback(ctx context.Context) ([]PatternCalibration, error) {
+	s.mu.RLock()
+	cfg := s.config.FeedbackCalibration
+	s.mu.RUnlock()
+
+	feedback, err := s.db.AggregatePatternFeedback(ctx, cfg.TruePositiveLabels, cfg.FalsePositiveLabels)
+	if err != nil {
+		return nil, err
+	}
+	calibrations := cfg.calibrate(feedback)
+	now := time.Now()
+	stored := make([]db.PatternFeedback, 0, len(calibrations))
+	factors := map[patternStatsKey]float64{}
+	for i := range calibrations {
+		calibrations[i].UpdatedAt = now
+		stored = append(stored, calibrations[i].PatternFeedback)
+		c := calibrations[i]
+		if !c.PoorPrecision {
+			continue
+		}
+		log.Printf("Pattern %s (%s) has poor precision: %.2f over %d reviewed findings", c.PIIType, c.DetectionMode, c.Precision, c.Reviewed)
+		if cfg.AutoAdjust {
+			factors[patternStatsKey{mode: c.DetectionMode, pattern: c.PIIType}] = c.Precision
+		}
+	}
+	if err := s.db.SavePatternFeedback(ctx, stored); err != nil {
+		return calibrations, err
+	}
+	s.mu.Lock()
+	s.confidenceFactors = factors
+	s.mu.Unlock()
+	return calibrations, nil
+}
+
+// PatternCalibrations returns the stored feedback aggregates, flagged
+// against the current thresholds.
+func (s *PIIService) PatternCalibrations(ctx context.Context) ([]PatternCalibration, error) {
+	feedback, err := s.db.ListPatternFeedback(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	cfg := s.config.FeedbackCalibration
+	s.mu.RUnlock()
+	return cfg.calibrate(feedback), nil
+}
+
+// StartFeedbackCalibration recalibrates every FEEDBACK_CALIBRATION_INTERVAL
+// (default one hour). A zero interval disables it.
+func (s *PIIService) StartFeedbackCalibration(ctx context.Context) {
+	interval := envDuration("FEEDBACK_CALIBRATION_INTERVAL", time.Hour)
+	if interval <= 0 || s.db.DB == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CalibrateFromFeedback(ctx); err != nil {
+				log.Printf("Error calibrating patterns from feedback: %v", err)
+			}
+		}
+	}
+}
+
+// minCalibratedConfidence keeps a scaled confidence above zero, which would
+// mean certain.
+const minCalibratedConfidence = 0.01
+
+// applyConfidenceFactors scales the confidence of findings from patterns
+// with poor precision. Zero confidence means certain, so it scales from 1.
+func (s *PIIService) applyConfidenceFactors(findings []PIIDetectionResult) {
+	if len(s.confidenceFactors) == 0 {
+		return
+	}
+	for i := range findings {
+		factor, ok := s.confidenceFactors[patternStatsKey{mode: findings[i].DetectionMode, pattern: findings[i].PIIType}]
+		if !ok {
+			continue
+		}
+		confidence := findings[i].Confidence
+		if confidence == 0 {
+			confidence = 1
+		}
+		findings[i].Confidence = max(confidence*factor, minCalibratedConfidence)
+	}
+}
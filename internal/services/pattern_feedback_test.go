@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCalibrateFromFeedback(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("seeded feedback", func(mt *mtest.T) {
+		seeded := []db.PatternFeedback{
+			{PIIType: "EMAIL", DetectionMode: "value_only", TruePositives: 30, FalsePositives: 10},
+			{PIIType: "PHONE", DetectionMode: "value_only", TruePositives: 5, FalsePositives: 25},
+			// Too few reviews to judge.
+			{PIIType: "US_SSN", DetectionMode: "value_only", TruePositives: 1, FalsePositives: 4},
+		}
+		docs := make([]bson.D, len(seeded))
+		for i, f := range seeded {
+			docs[i] = toBSONDoc(t, f)
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, docs...),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(0)}), // clear pattern_feedback
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(len(seeded))}),
+		)
+		s := newTestPIIService(mt)
+		s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+		s.config.FeedbackCalibration.AutoAdjust = true
+
+		calibrations, err := s.CalibrateFromFeedback(context.Background())
+		if err != nil {
+			mt.Fatalf("CalibrateFromFeedback: %v", err)
+		}
+		want := []struct {
+			precision float64
+			reviewed  int
+			poor      bool
+		}{
+			{precision: 0.75, reviewed: 40},
+			{precision: 5.0 / 30, reviewed: 30, poor: true},
+			{precision: 0.2, reviewed: 5},
+		}
+		if len(calibrations) != len(want) {
+			mt.Fatalf("got %d calibrations, want %d", len(calibrations), len(want))
+		}
+		for i, c := range calibrations {
+			if math.Abs(c.Precision-want[i].precision) > 1e-9 || c.Reviewed != want[i].reviewed || c.PoorPrecision != want[i].poor {
+				mt.Errorf("%s: precision %.3f over %d, poor %v; want %.3f over %d, poor %v",
+					c.PIIType, c.Precision, c.Reviewed, c.PoorPrecision, want[i].precision, want[i].reviewed, want[i].poor)
+			}
+		}
+
+		var inserted []float64
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName != "insert" {
+				continue
+			}
+			values, _ := event.Command.Lookup("documents").Array().Values()
+			for _, v := range values {
+				inserted = append(inserted, v.Document().Lookup("precision").Double())
+			}
+		}
+		if len(inserted) != len(seeded) || inserted[0] != 0.75 {
+			mt.Errorf("stored precisions = %v, want one per pattern", inserted)
+		}
+
+		findings := []PIIDetectionResult{
+			{PIIType: "PHONE", DetectionMode: "value_only", Confidence: 0.9},
+			{PIIType: "PHONE", DetectionMode: "field_based", Confidence: 0.9},
+			{PIIType: "EMAIL", DetectionMode: "value_only"},
+		}
+		s.applyConfidenceFactors(findings)
+		if got := findings[0].Confidence; math.Abs(got-0.15) > 1e-9 {
+			mt.Errorf("poor pattern confidence = %v, want 0.15", got)
+		}
+		if findings[1].Confidence != 0.9 || findings[2].Confidence != 0 {
+			mt.Errorf("other confidences = %v, %v, want unchanged", findings[1].Confidence, findings[2].Confidence)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type PatternMatchCount struct {
+	Pattern string `json:"pattern"`
+	Mode    string `json:"mode"`
+	Matches int64  `json:"matches"`
+}
+
+type PatternStats struct {
+	Since    time.Time           `json:"since"`
+	Patterns []PatternMatchCount `json:"patterns"`
+}
+
+type patternStatsKey struct {
+	mode    string
+	pattern string
+}
+
+// patternStats counts matches per pattern. It is safe for concurrent use.
+type patternStats struct {
+	mu     sync.Mutex
+	counts map[patternStatsKey]int64
+	since  time.Time
+}
+
+func newPatternStats() *patternStats {
+	return &patternStats{counts: make(map[patternStatsKey]int64), since: time.Now()}
+}
+
+func (p *patternStats) record(mode, pattern string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.counts[patternStatsKey{mode: mode, pattern: pattern}]++
+	p.mu.Unlock()
+}
+
+func (p *patternStats) reset() {
+	p.mu.Lock()
+	p.counts = make(map[patternStatsKey]int64)
+	p.since = time.Now()
+	p.mu.Unlock()
+}
+
+func (p *patternStats) snapshot() PatternStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := PatternStats{Since: p.since, Patterns: make([]PatternMatchCount, 0, len(p.counts))}
+	for key, count := range p.counts {
+		stats.Patterns = append(stats.Patterns, PatternMatchCount{Pattern: key.pattern, Mode: key.mode, Matches: count})
+	}
+	sort.Slice(stats.Patterns, func(i, j int) bool {
+		if stats.Patterns[i].Matches != stats.Patterns[j].Matches {
+			return stats.Patterns[i].Matches > stats.Patterns[j].Matches
+		}
+		return stats.Patterns[i].Pattern < stats.Patterns[j].Pattern
+	})
+	return stats
+}
+
+// PatternStats returns match counts per pattern since startup or the last config reload.
+func (s *PIIService) PatternStats() PatternStats {
+	return s.stats.snapshot()
+}
@@ -1,7 +1,13 @@
 package services
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"log"
@@ -9,35 +15,63 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/RavenSec10/Raven_Backend/config"
 	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PIIDetectionResult struct {
-	PIIType       string    `json:"pii_type"`
-	DetectedValue string    `json:"detected_value"`
-	FieldName     string    `json:"field_name,omitempty"`
-	Location      string    `json:"location"`
-	DetectionMode string    `json:"detection_mode"`
-	RiskLevel     string    `json:"risk_level"`
-	Category      string    `json:"category"`
-	Tags          []string  `json:"tags"`
+	ID            string   `json:"id"`
+	PIIType       string   `json:"pii_type"`
+	DetectedValue string   `json:"detected_value"`
+	// rawValue is the unmasked match. It only keys the finding id and is
+	// never serialized.
+	rawValue      string
+	FieldName     string   `json:"field_name,omitempty"`
+	Location      string   `json:"location"`
+	DetectionMode string   `json:"detection_mode"`
+	RiskLevel     string   `json:"risk_level"`
+	Category      string   `json:"category"`
+	Tags          []string `json:"tags"`
+	Frameworks    []string  `json:"frameworks,omitempty"`
+	Confidence    float64   `json:"confidence,omitempty"`
 	Timestamp     time.Time `json:"timestamp"`
 }
 
 type PIIAnalysisResult struct {
-	APIEndpoint string               `json:"api_endpoint"`
-	Method      string               `json:"method"`
-	URL         string               `json:"url"`
-	Findings    []PIIDetectionResult `json:"findings"`
-	TotalCount  int                  `json:"total_count"`
-	RiskScore   int                  `json:"risk_score"`
-	HighestRisk string               `json:"highest_risk"`
-	Timestamp   time.Time            `json:"timestamp"`
+	APIEndpoint   string               `json:"api_endpoint"`
+	Method        string               `json:"method"`
+	URL           string               `json:"url"`
+	Findings      []PIIDetectionResult `json:"findings"`
+	TotalCount    int                  `json:"total_count"`
+	RiskScore     int                  `json:"risk_score"`
+	HighestRisk   string               `json:"highest_risk"`
+	BodyTruncated bool                 `json:"body_truncated,omitempty"`
+	// AnalysisPartial is set when part of the document could not be analyzed.
+	AnalysisPartial bool `json:"analysis_partial,omitempty"`
+	// BodySkippedBinary is set when the response body was left out because
+	// its content type matches response_content_type_skip.
+	BodySkippedBinary bool `json:"body_skipped_binary,omitempty"`
+	// TimedOut is set when analysis stopped at the per-document deadline;
+	// the findings are those gathered until then.
+	TimedOut  bool      `json:"timed_out,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	modes detectionModes
+	ctx   context.Context
+	// graphQLAliases maps the aliases of a GraphQL request's query to their
+	// fields, for matching the keys of its response.
+	graphQLAliases map[string]string
 }
 
 type PIIPattern struct {
+	MaskOptions
+
 	FieldNames   []string `json:"fieldNames,omitempty"`
 	ValuePattern string   `json:"valuePattern,omitempty"`
 	RegexPattern string   `json:"regexPattern,omitempty"`
@@ -45,7 +79,20 @@ type PIIPattern struct {
 	RiskLevel    string   `json:"riskLevel"`
 	Category     string   `json:"category"`
 	Tags         []string `json:"tags"`
+	Frameworks   []string `json:"frameworks,omitempty"`
+	Validate     string   `json:"validate,omitempty"`
 	ApplyTo      string   `json:"applyTo,omitempty"`
+	// StrictFieldNames makes field names match only whole words of the
+	// field name, so "age" doesn't match "page".
+	StrictFieldNames bool `json:"strictFieldNames,omitempty"`
+	// Confidence below 1 marks a pattern as less certain; zero means certain.
+	Confidence float64 `json:"confidence,omitempty"`
+	// ContextWindow is how many characters after an inline label (applyTo
+	// "text") are searched for the labeled value.
+	ContextWindow int `json:"contextWindow,omitempty"`
+	// Stored marks a pattern merged from MongoDB. Exports carry the mark so
+	// imports leave such patterns out of the config file.
+	Stored bool `json:"stored,omitempty"`
 }
 
 type PIIConfig struct {
@@ -63,39 +110,184 @@ type PIIConfig struct {
 			Patterns    map[string]PIIPattern `json:"patterns"`
 		} `json:"keyword_based"`
 	} `json:"detection_modes"`
-	RiskLevels map[string]int `json:"risk_levels"`
-	Categories []string       `json:"categories"`
+	IdentityDocuments       map[string]IdentityDocumentConfig `json:"identity_documents"`
+	SourceDetectionModes    map[string][]string               `json:"source_detection_modes"`
+	ScanLocations           map[string]bool                   `json:"scan_locations"`
+	NationalIDs             NationalIDConfig                  `json:"national_ids"`
+	SessionIDs              SessionIDConfig                   `json:"session_ids"`
+	HeaderBlocklist         []string                          `json:"header_blocklist"`
+	HeaderAllowlist         []string                          `json:"header_allowlist"`
+	ResponseSampling        []ResponseSamplingRule            `json:"response_sampling"`
+	EndpointDenylist        []EndpointRule                    `json:"endpoint_denylist"`
+	ResponseContentTypeSkip []string                          `json:"response_content_type_skip"`
+	QuasiIdentifiers        QuasiIdentifierConfig             `json:"quasi_identifiers"`
+	StatusSeverity          []StatusSeverityRule              `json:"status_severity"`
+	HashedCredentials       HashedCredentialConfig            `json:"hashed_credentials"`
+	FeedbackCalibration     FeedbackCalibrationConfig         `json:"feedback_calibration"`
+	PostalAddresses         PostalAddressConfig               `json:"postal_addresses"`
+	MaskRevealPrefix        *int                              `json:"mask_reveal_prefix,omitempty"`
+	MaskRevealSuffix        *int                              `json:"mask_reveal_suffix,omitempty"`
+	Profiles                map[string]DetectionProfile       `json:"profiles,omitempty"`
+	RiskLevels              map[string]int                    `json:"risk_levels"`
+	Categories              []string                          `json:"categories"`
+}
+
+// scansLocation reports whether a location (request_body, url_path, ...) is
+// scanned. Locations missing from scan_locations are scanned.
+func (s *PIIService) scansLocation(location string) bool {
+	enabled, ok := s.config.ScanLocations[location]
+	return !ok || enabled
 }
 
+// detectionModes selects which detection modes run during one analysis.
+type detectionModes struct {
+	fieldBased   bool
+	valueOnly    bool
+	keywordBased bool
+
+	// nationalIDCountries are the countries whose national IDs value-only
+	// detection looks for.
+	nationalIDCountries []string
+	// skipResponseBody leaves the response body out of the analysis.
+	skipResponseBody bool
+}
+
+// detectionModesForSource returns the modes enabled for an ingest source.
+// Sources without an entry in source_detection_modes run every mode.
+func (s *PIIService) detectionModesForSource(source string) detectionModes {
+	enabled, ok := s.config.SourceDetectionModes[source]
+	if !ok {
+		return s.profileModes(detectionModes{fieldBased: true, valueOnly: true, keywordBased: true})
+	}
+	var modes detectionModes
+	for _, mode := range enabled {
+		switch mode {
+		case "field_based":
+			modes.fieldBased = true
+		case "value_only":
+			modes.valueOnly = true
+		case "keyword_based":
+			modes.keywordBased = true
+		}
+	}
+	return s.profileModes(modes)
+}
+
+type PatternTestResult struct {
+	Mode    string   `json:"mode"`
+	Matches []string `json:"matches"`
+	Count   int      `json:"count"`
+}
+
+const (
+	maxPatternLength     = 2048
+	maxPatternTestSample = 64 * 1024
+	patternTestTimeout   = 2 * time.Second
+
+	// maxConcurrentPatternTests bounds the matches still running after
+	// their request has timed out.
+	maxConcurrentPatternTests = 4
+
+	defaultAnalysisTimeout = 5 * time.Second
+)
+
 type PIIService struct {
 	db            db.MongoInstance
 	config        PIIConfig
 	compiledRegex map[string]*regexp.Regexp
 	fieldRegex    map[string]*regexp.Regexp
 	keywordRegex  map[string]*regexp.Regexp
+	identityRegex map[string][]regionRegex
+	addressRegex  *regexp.Regexp
+
+	suppressionRules []SuppressionRule
+	// profile is the detection profile selected from the config, if any.
+	profile *ActiveProfile
+
+	// mu guards the loaded config and compiled patterns, which Reload swaps out.
+	mu         sync.RWMutex
+	lastReload time.Time
+	stats      *patternStats
+
+	// confidenceFactors scale the confidence of patterns with poor precision
+	// in reviewed feedback. They are kept across config reloads.
+	confidenceFactors map[patternStatsKey]float64
+	// maskingDisabled keeps detected values raw; see maskingDisabledFromEnv.
+	maskingDisabled bool
+	// jsonStreamThreshold is the JSON body size from which bodies are
+	// analyzed with analyzeJSONStream. Zero disables streaming.
+	jsonStreamThreshold int
+	// analysisTimeout bounds the analysis of one document. Zero disables it.
+	analysisTimeout time.Duration
 }
 
 func NewPIIService(mongoInstance db.MongoInstance) (*PIIService, error) {
-	service := &PIIService{
+	service := newUnloadedPIIService(mongoInstance)
+	if err := service.load(); err != nil {
+		return nil, err
+	}
+	service.lastReload = time.Now()
+	service.stats = newPatternStats()
+	service.maskingDisabled = maskingDisabledFromEnv()
+	service.jsonStreamThreshold = envInt("JSON_STREAM_THRESHOLD", defaultJSONStreamThreshold)
+	service.analysisTimeout = envDuration("ANALYSIS_TIMEOUT", defaultAnalysisTimeout)
+	return service, nil
+}
+
+func newUnloadedPIIService(mongoInstance db.MongoInstance) *PIIService {
+	return &PIIService{
 		db:            mongoInstance,
 		compiledRegex: make(map[string]*regexp.Regexp),
 		fieldRegex:    make(map[string]*regexp.Regexp),
 		keywordRegex:  make(map[string]*regexp.Regexp),
+		identityRegex: make(map[string][]regionRegex),
 	}
-	if err := service.loadPIIConfig(); err != nil {
-		return nil, fmt.Errorf("failed to load PII config: %w", err)
+}
+
+func (s *PIIService) load() error {
+	if err := s.loadPIIConfig(); err != nil {
+		return fmt.Errorf("failed to load PII config: %w", err)
 	}
-	if err := service.compileRegexPatterns(); err != nil {
-		return nil, fmt.Errorf("failed to compile regex patterns: %w", err)
+	if err := s.resolveProfile(); err != nil {
+		return fmt.Errorf("failed to load PII config: %w", err)
 	}
-	return service, nil
+	s.mergeStoredPatterns()
+	if err := s.compileRegexPatterns(); err != nil {
+		return fmt.Errorf("failed to compile regex patterns: %w", err)
+	}
+	if err := s.loadSuppressionRules(); err != nil {
+		return fmt.Errorf("failed to load suppression rules: %w", err)
+	}
+	return nil
+}
+
+// Reload re-reads the PII config from disk and atomically swaps it in, resetting
+// pattern match statistics. The running config is kept if the new one fails to load.
+func (s *PIIService) Reload() error {
+	fresh := newUnloadedPIIService(s.db)
+	if err := fresh.load(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.config = fresh.config
+	s.compiledRegex = fresh.compiledRegex
+	s.fieldRegex = fresh.fieldRegex
+	s.keywordRegex = fresh.keywordRegex
+	s.identityRegex = fresh.identityRegex
+	s.addressRegex = fresh.addressRegex
+	s.suppressionRules = fresh.suppressionRules
+	s.profile = fresh.profile
+	s.lastReload = time.Now()
+	s.mu.Unlock()
+	s.stats.reset()
+	log.Println("PII config reloaded")
+	return nil
 }
 
 func (s *PIIService) loadPIIConfig() error {
-	configPath := filepath.Join("config", "regexpii.json")
-	data, err := os.ReadFile(configPath)
+	data, err := readPIIConfigFile()
 	if err != nil {
-		return fmt.Errorf("failed to read PII config file: %w", err)
+		return err
 	}
 	if err := json.Unmarshal(data, &s.config); err != nil {
 		return fmt.Errorf("failed to parse PII config JSON: %w", err)
@@ -107,10 +299,25 @@ func (s *PIIService) loadPIIConfig() error {
 	return nil
 }
 
+// readPIIConfigFile reads config/regexpii.json. When the file is missing the
+// config built into the binary is used instead, so the API still starts with
+// baseline detection; PII_CONFIG_STRICT=true makes a missing file an error.
+func readPIIConfigFile() ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join("config", "regexpii.json"))
+	if errors.Is(err, os.ErrNotExist) && !envBool("PII_CONFIG_STRICT", false) {
+		log.Println("WARNING: config/regexpii.json is missing; using the built-in default PII config. Set PII_CONFIG_STRICT=true to fail instead.")
+		return config.DefaultPIIConfig, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PII config file: %w", err)
+	}
+	return data, nil
+}
+
 func (s *PIIService) compileRegexPatterns() error {
 	for name, pattern := range s.config.DetectionModes.FieldBased.Patterns {
 		if pattern.ValuePattern != "" {
-			regex, err := regexp.Compile(pattern.ValuePattern)
+			regex, err := compilePattern(pattern.ValuePattern)
 			if err != nil {
 				log.Printf("Warning: Failed to compile field-based regex for %s: %v", name, err)
 				continue
@@ -120,7 +327,7 @@ func (s *PIIService) compileRegexPatterns() error {
 	}
 	for name, pattern := range s.config.DetectionModes.ValueOnly.Patterns {
 		if pattern.RegexPattern != "" {
-			regex, err := regexp.Compile(pattern.RegexPattern)
+			regex, err := compilePattern(pattern.RegexPattern)
 			if err != nil {
 				log.Printf("Warning: Failed to compile value-only regex for %s: %v", name, err)
 				continue
@@ -130,41 +337,323 @@ func (s *PIIService) compileRegexPatterns() error {
 	}
 	for name, pattern := range s.config.DetectionModes.KeywordBased.Patterns {
 		if pattern.RegexPattern != "" {
-			regex, err := regexp.Compile(pattern.RegexPattern)
+			regex, err := compilePattern(pattern.RegexPattern)
 			if err != nil {
 				log.Printf("Warning: Failed to compile keyword-based regex for %s: %v", name, err)
 				continue
 			}
 			s.keywordRegex[name] = regex
 		}
+		if pattern.ApplyTo == "text" && pattern.ValuePattern != "" {
+			regex, err := compilePattern(pattern.ValuePattern)
+			if err != nil {
+				log.Printf("Warning: Failed to compile keyword value regex for %s: %v", name, err)
+				continue
+			}
+			s.compiledRegex[fmt.Sprintf("keyword_value_%s", name)] = regex
+		}
 	}
+	s.compileIdentityDocumentPatterns()
+	s.compilePostalAddressPattern()
 	log.Printf("Compiled %d regex patterns successfully", len(s.compiledRegex)+len(s.keywordRegex))
 	return nil
 }
 
-func (s *PIIService) AnalyzePIIInAPIData(apiData db.UserAPIData) PIIAnalysisResult {
+// compilePattern compiles a detection regex. Go's RE2 engine matches in linear
+// time, so the remaining ReDoS exposure is pathologically large patterns.
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if len(expr) > maxPatternLength {
+		return nil, fmt.Errorf("pattern exceeds maximum length of %d characters", maxPatternLength)
+	}
+	return regexp.Compile(expr)
+}
+
+// matchPattern returns what a compiled pattern detects in text for the given mode.
+// Value-only patterns find every occurrence; field and keyword patterns match the whole input.
+func matchPattern(regex *regexp.Regexp, mode, text string) []string {
+	if mode == "value_only" {
+		return regex.FindAllString(text, -1)
+	}
+	if regex.MatchString(text) {
+		return []string{text}
+	}
+	return nil
+}
+
+// ErrPatternTestBusy is returned when every pattern test slot is taken.
+var ErrPatternTestBusy = errors.New("too many pattern tests in progress")
+
+// patternTestSlots holds a token per running pattern match. A match that
+// outlives its request keeps its slot until it finishes.
+var patternTestSlots = make(chan struct{}, maxConcurrentPatternTests)
+
+// TestPattern compiles and runs a candidate pattern against sample text without
+// touching the loaded config, returning the masked matches.
+func (s *PIIService) TestPattern(ctx context.Context, pattern, mode, sample string) (PatternTestResult, error) {
+	if mode == "" {
+		mode = "value_only"
+	}
+	if mode != "value_only" && mode != "field_based" && mode != "keyword_based" {
+		return PatternTestResult{}, fmt.Errorf("unknown detection mode '%s'", mode)
+	}
+	if len(sample) > maxPatternTestSample {
+		return PatternTestResult{}, fmt.Errorf("sample exceeds maximum size of %d bytes", maxPatternTestSample)
+	}
+	regex, err := compilePattern(pattern)
+	if err != nil {
+		return PatternTestResult{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	select {
+	case patternTestSlots <- struct{}{}:
+	default:
+		return PatternTestResult{}, ErrPatternTestBusy
+	}
+	ctx, cancel := context.WithTimeout(ctx, patternTestTimeout)
+	defer cancel()
+	done := make(chan []string, 1)
+	go func() {
+		matches := matchPattern(regex, mode, sample)
+		<-patternTestSlots
+		done <- matches
+	}()
+
+	select {
+	case <-ctx.Done():
+		return PatternTestResult{}, fmt.Errorf("pattern evaluation timed out after %s", patternTestTimeout)
+	case matches := <-done:
+		masked := make([]string, 0, len(matches))
+		for _, match := range matches {
+			masked = append(masked, s.maskSensitiveValue(match))
+		}
+		return PatternTestResult{Mode: mode, Matches: masked, Count: len(masked)}, nil
+	}
+}
+
+func (s *PIIService) AnalyzePIIInAPIData(ctx context.Context, apiData db.UserAPIData) PIIAnalysisResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.analyze(ctx, apiData, s.detectionModesForSource(apiData.Source))
+}
+
+// AnalyzeWithoutResponseBody runs the full analysis except on the response
+// body, for documents whose response body is sampled out.
+func (s *PIIService) AnalyzeWithoutResponseBody(ctx context.Context, apiData db.UserAPIData) PIIAnalysisResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	modes := s.detectionModesForSource(apiData.Source)
+	modes.skipResponseBody = true
+	return s.analyze(ctx, apiData, modes)
+}
+
+// AnalyzeFieldBasedOnly runs only field-based detection, the cheapest mode.
+// It is used to keep up under load; the full analysis is backfilled later.
+func (s *PIIService) AnalyzeFieldBasedOnly(ctx context.Context, apiData db.UserAPIData) PIIAnalysisResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.analyze(ctx, apiData, detectionModes{fieldBased: true})
+}
+
+// analyze runs detection over a document. Once ctx is done or the
+// analysis timeout passes, the remaining units are skipped and the result
+// holds the findings gathered so far.
+func (s *PIIService) analyze(ctx context.Context, apiData db.UserAPIData, modes detectionModes) PIIAnalysisResult {
+	ctx, span := tracer.Start(ctx, "pii.analyze", trace.WithAttributes(
+		attrEndpoint.String(apiData.APIEndpoint),
+		attrMethod.String(apiData.Method),
+	))
+	defer span.End()
+	if s.analysisTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.analysisTimeout)
+		defer cancel()
+	}
 	result := PIIAnalysisResult{
-		APIEndpoint: apiData.APIEndpoint,
-		Method:      apiData.Method,
-		URL:         apiData.URL,
-		Findings:    []PIIDetectionResult{},
-		Timestamp:   time.Now(),
-	}
-
-	s.analyzeHeaders(apiData.RequestHeaders, "request_headers", &result)
-	s.analyzeHeaders(apiData.ResponseHeaders, "response_headers", &result)	
-	s.analyzeGenericBody(apiData.RequestBody, "request_body", &result)
-	s.analyzeGenericBody(apiData.ResponseBody, "response_body", &result)
-	s.analyzeURL(apiData.URL, &result)
+		APIEndpoint:   apiData.APIEndpoint,
+		Method:        apiData.Method,
+		URL:           apiData.URL,
+		Findings:      []PIIDetectionResult{},
+		BodyTruncated: apiData.BodyTruncated,
+		Timestamp:     time.Now(),
+		modes:         modes,
+		ctx:           ctx,
+	}
+	if modes.valueOnly {
+		result.modes.nationalIDCountries = s.nationalIDCountries(apiData.URL)
+	}
+
+	if s.scansLocation("request_headers") {
+		s.guard(&result, "request_headers", func() { s.analyzeHeaders(apiData.RequestHeaders, "request_headers", &result) })
+	}
+	if s.scansLocation("response_headers") {
+		s.guard(&result, "response_headers", func() { s.analyzeHeaders(apiData.ResponseHeaders, "response_headers", &result) })
+	}
+	if s.scansLocation("request_trailers") {
+		s.guard(&result, "request_trailers", func() { s.analyzeHeaders(apiData.RequestTrailers, "request_trailers", &result) })
+	}
+	if s.scansLocation("response_trailers") {
+		s.guard(&result, "response_trailers", func() { s.analyzeHeaders(apiData.ResponseTrailers, "response_trailers", &result) })
+	}
+	if s.scansLocation("request_body") {
+		s.guard(&result, "request_body", func() {
+			if !s.analyzeGRPCWebBody(apiData.RequestBody, apiData.RequestHeaders, "request_body", &result) {
+				s.analyzeGenericBody(apiData.RequestBody, "request_body", &result)
+			}
+		})
+	}
+	if s.scansLocation("response_body") && !modes.skipResponseBody {
+		if s.skipsResponseContentType(apiData.ResponseHeaders) {
+			result.BodySkippedBinary = true
+		} else {
+			result.graphQLAliases = graphQLAliases(apiData)
+			responseBody := apiData.ResponseBody
+			if apiData.BodyTruncated {
+				responseBody = trimTruncatedTail(responseBody)
+			}
+			s.guard(&result, "response_body", func() {
+				if !s.analyzeGRPCWebBody(responseBody, apiData.ResponseHeaders, "response_body", &result) {
+					s.analyzeGenericBody(responseBody, "response_body", &result)
+				}
+			})
+		}
+	}
+	if s.scansLocation("url_path") || s.scansLocation("query_params") || s.scansLocation("url_fragment") || s.scansLocation("matrix_param") {
+		s.guard(&result, "url", func() { s.analyzeURL(apiData.URL, &result) })
+	}
+	s.analyzeEmbeddedURL(apiData.RequestHeaders, "Referer", "referer", &result)
+	s.analyzeEmbeddedURL(apiData.ResponseHeaders, "Location", "redirect_url", &result)
+	s.analyzePseudoHeaderURL(apiData.RequestHeaders, &result)
+	result.Findings = s.applySuppressionRules(apiData, result.Findings)
+	s.escalateQuasiIdentifiers(result.Findings)
+	s.applyStatusSeverity(result.Findings, apiData.StatusCode)
+	s.applyConfidenceFactors(result.Findings)
+	result.Findings = s.applyProfile(result.Findings)
+	for i := range result.Findings {
+		result.Findings[i].ID = findingID(result.Findings[i])
+	}
 	result.TotalCount = len(result.Findings)
 	result.RiskScore, result.HighestRisk = s.calculateRiskMetrics(result.Findings)
+	span.SetAttributes(attrPIICount.Int(result.TotalCount), attribute.Bool("raven.analysis_partial", result.AnalysisPartial))
+	if result.TimedOut {
+		analysisTimeouts.Inc()
+		log.Printf("Analysis of %s %s stopped after %s with %d findings", apiData.Method, sanitizeForLog(apiData.APIEndpoint), s.analysisTimeout, result.TotalCount)
+	}
 	return result
 }
 
+// stopped reports whether the analysis context is done, marking the result
+// partial, and timed out when the deadline passed.
+func (result *PIIAnalysisResult) stopped() bool {
+	if result.ctx == nil || result.ctx.Err() == nil {
+		return false
+	}
+	result.AnalysisPartial = true
+	if errors.Is(result.ctx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+	}
+	return true
+}
+
+// guard runs one unit of analysis, recovering from a panic so the rest of the
+// document is still analyzed. The result is marked partial instead.
+func (s *PIIService) guard(result *PIIAnalysisResult, unit string, analyze func()) {
+	if result.stopped() {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic analyzing %s of %s %s: %v", unit, result.Method, sanitizeForLog(result.APIEndpoint), r)
+			result.AnalysisPartial = true
+		}
+	}()
+	analyze()
+}
+
+// detectGuarded is detectPIIInField for a single field, dropping that field's
+// findings rather than the whole document if detection panics.
+func (s *PIIService) detectGuarded(result *PIIAnalysisResult, fieldName, fieldValue, location string) []PIIDetectionResult {
+	var findings []PIIDetectionResult
+	s.guard(result, location+" field "+sanitizeForLog(fieldName), func() {
+		findings = s.detectPIIInField(result.modes, fieldName, fieldValue, location)
+	})
+	return findings
+}
+
+// findingIDKey returns the key finding ids are derived with. It is read from
+// FINDING_ID_KEY on first use, once .env has been loaded. Without it a random
+// key is used, and ids change when the service restarts.
+var findingIDKey = sync.OnceValue(func() []byte {
+	if key := os.Getenv("FINDING_ID_KEY"); key != "" {
+		return []byte(key)
+	}
+	log.Println("Warning: FINDING_ID_KEY is not set, finding ids will change on restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Failed to generate a finding id key: %v", err)
+	}
+	return key
+})
+
+// findingID derives a stable id from what a finding is, where it is and the
+// raw value it matched, so re-analyzing an unchanged document yields the same
+// ids while distinct values that mask alike get distinct ones. The digest is
+// an HMAC, so an id can't be used to confirm a guessed value.
+func findingID(finding PIIDetectionResult) string {
+	value := "raw\x00" + finding.rawValue
+	if finding.rawValue == "" {
+		// Stored findings whose raw value can't be recovered.
+		value = "masked\x00" + finding.DetectedValue
+	}
+	mac := hmac.New(sha256.New, findingIDKey())
+	mac.Write([]byte(strings.Join([]string{
+		finding.PIIType, finding.Location, finding.FieldName, value,
+	}, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// storedFindingID is findingID for a stored finding, using the raw value
+// recovered from its document when there is exactly one candidate.
+func storedFindingID(doc db.UserAPIData, f db.PIIFinding) string {
+	raw, _ := recoverRawValue(doc, f)
+	return findingID(PIIDetectionResult{
+		PIIType:       f.PIIType,
+		Location:      f.Location,
+		FieldName:     f.FieldName,
+		DetectedValue: f.DetectedValue,
+		rawValue:      raw,
+	})
+}
+
+// The HTTP/2 request pseudo-headers that make up the request URL. They are
+// scanned together as a URL rather than as headers.
+const (
+	pseudoSchemeHeader    = ":scheme"
+	pseudoAuthorityHeader = ":authority"
+	pseudoPathHeader      = ":path"
+)
+
+// isPseudoURLHeader reports whether a header is part of the HTTP/2 request
+// URL and so is scanned by analyzePseudoHeaderURL.
+func isPseudoURLHeader(name string) bool {
+	return strings.EqualFold(name, pseudoAuthorityHeader) || strings.EqualFold(name, pseudoPathHeader)
+}
+
 func (s *PIIService) analyzeHeaders(headers map[string]string, location string, result *PIIAnalysisResult) {
 	for fieldName, fieldValue := range headers {
-		findings := s.detectPIIInField(fieldName, fieldValue, location)
+		if isPseudoURLHeader(fieldName) || !s.headerAnalyzed(fieldName) {
+			continue
+		}
+		if strings.EqualFold(fieldName, "Content-Disposition") {
+			s.guard(result, location+" filename", func() { s.analyzeContentDisposition(fieldValue, location, result) })
+			continue
+		}
+		findings := s.detectGuarded(result, fieldName, fieldValue, location)
 		result.Findings = append(result.Findings, findings...)
+		if result.modes.fieldBased {
+			s.guard(result, location+" session ids", func() {
+				result.Findings = append(result.Findings, s.detectSessionIDs(fieldName, fieldValue, location)...)
+			})
+		}
 	}
 }
 
@@ -180,58 +669,234 @@ func (s *PIIService) analyzeGenericBody(body interface{}, location string, resul
 		if s.isJSON(v) {
 			s.analyzeJSONForPII(v, location, result)
 		} else {
-			findings := s.detectPIIInText("", v, location)
+			findings := s.detectPIIInText(result.modes, "", v, location)
 			result.Findings = append(result.Findings, findings...)
+			s.analyzeDataURIs(v, "", "", location, result)
 		}
-	case map[string]interface{}:
-		s.analyzeJSONObject(v, "", location, result)
+	case map[string]interface{}, []interface{}, primitive.D, primitive.M, primitive.A:
+		// Payloads that arrive already parsed are analyzed in their canonical
+		// JSON string form, the same path as string bodies holding JSON.
+		canonical, err := json.Marshal(db.PlainBody(v))
+		if err != nil {
+			log.Printf("Warning: Could not encode %T body at %s for analysis: %v", v, location, err)
+			return
+		}
+		s.analyzeJSONForPII(string(canonical), location, result)
 	default:
 		log.Printf("Warning: analyzeGenericBody received unexpected body type %T at %s", v, location)
 	}
 }
 
+// trimTruncatedTail drops the trailing partial token of a truncated string body,
+// so a value cut off at the truncation boundary is neither matched nor masked.
+func trimTruncatedTail(body interface{}) interface{} {
+	text, ok := body.(string)
+	if !ok {
+		return body
+	}
+	idx := strings.LastIndexAny(text, " \t\r\n\",:;{}[]<>()=&")
+	if idx == -1 {
+		return ""
+	}
+	return text[:idx+1]
+}
+
 func (s *PIIService) analyzeJSONForPII(jsonStr, location string, result *PIIAnalysisResult) {
+	// Large bodies, typically arrays of records, are walked token by token.
+	// Callers check the body is valid JSON first, so the walk runs to the end.
+	if s.jsonStreamThreshold > 0 && len(jsonStr) >= s.jsonStreamThreshold {
+		if err := s.analyzeJSONStream(jsonStr, location, result); err != nil {
+			log.Printf("Warning: Streaming JSON analysis at %s stopped early: %v", location, err)
+		}
+		return
+	}
 	var jsonData interface{}
 	if err := json.Unmarshal([]byte(jsonStr), &jsonData); err != nil {
-		findings := s.detectPIIInText("", jsonStr, location)
+		findings := s.detectPIIInText(result.modes, "", jsonStr, location)
 		result.Findings = append(result.Findings, findings...)
 		return
 	}
-	s.analyzeJSONObject(jsonData, "", location, result)
+	s.analyzeJSONObject(jsonData, "", location, 0, result)
 }
 
 func (s *PIIService) analyzeURL(urlString string, result *PIIAnalysisResult) {
-	decodedURL, err := url.QueryUnescape(urlString)
-	if err != nil {
-		log.Printf("Error decoding URL: %v", err)
-		decodedURL = urlString
+	var locations urlLocations
+	for _, l := range []struct {
+		target *string
+		name   string
+	}{
+		{&locations.path, "url_path"},
+		{&locations.query, "query_params"},
+		{&locations.fragment, "url_fragment"},
+		{&locations.matrix, "matrix_param"},
+	} {
+		if s.scansLocation(l.name) {
+			*l.target = l.name
+		}
+	}
+	s.analyzeURLParts(urlString, locations, result)
+}
+
+// urlLocations are the locations findings in each part of a URL are reported
+// under. Parts with an empty location are not scanned.
+type urlLocations struct {
+	userinfo string
+	path     string
+	query    string
+	fragment string
+	matrix   string
+}
+
+// analyzeEmbeddedURL scans a URL carried in a header, such as a Referer or a
+// redirect Location, reporting findings in all its parts under location.
+func (s *PIIService) analyzeEmbeddedURL(headers map[string]string, headerName, location string, result *PIIAnalysisResult) {
+	if !s.scansLocation(location) {
+		return
 	}
-	parsedURL, err := url.Parse(decodedURL)
+	locations := urlLocations{userinfo: location, path: location, query: location, fragment: location, matrix: location}
+	for name, value := range headers {
+		if strings.EqualFold(name, headerName) && value != "" {
+			s.guard(result, location, func() { s.analyzeURLParts(value, locations, result) })
+		}
+	}
+}
+
+// analyzePseudoHeaderURL scans the request URL rebuilt from the HTTP/2
+// pseudo-headers under pseudo_path, so credentials in the :authority
+// userinfo are found as well as those in the :path query.
+func (s *PIIService) analyzePseudoHeaderURL(headers map[string]string, result *PIIAnalysisResult) {
+	if !s.scansLocation("pseudo_path") {
+		return
+	}
+	pseudoURL := pseudoHeaderURL(headers)
+	if pseudoURL == "" {
+		return
+	}
+	locations := urlLocations{userinfo: "pseudo_path", path: "pseudo_path", query: "pseudo_path", fragment: "pseudo_path", matrix: "pseudo_path"}
+	s.guard(result, "pseudo_path", func() { s.analyzeURLParts(pseudoURL, locations, result) })
+}
+
+// pseudoHeaderURL joins the :scheme, :authority and :path pseudo-headers
+// into a URL, or returns "" when there is neither an authority nor a path.
+// The scheme defaults to https.
+func pseudoHeaderURL(headers map[string]string) string {
+	var scheme, authority, path string
+	for name, value := range headers {
+		switch strings.ToLower(name) {
+		case pseudoSchemeHeader:
+			scheme = value
+		case pseudoAuthorityHeader:
+			authority = value
+		case pseudoPathHeader:
+			path = value
+		}
+	}
+	if authority == "" {
+		return path
+	}
+	if scheme == "" {
+		scheme = "https"
+	}
+	if !strings.HasPrefix(path, "/") {
+		// An asterisk-form path, as sent with OPTIONS, has no URL form.
+		path = ""
+	}
+	return scheme + "://" + authority + path
+}
+
+// analyzeURLParts scans the path segments, matrix parameters (";name=value"
+// after a segment), query parameters and fragment of a URL. The URL is parsed
+// as sent and each component is unescaped on its own, so an encoded "/" stays
+// inside its segment and query values are decoded exactly once.
+func (s *PIIService) analyzeURLParts(urlString string, locations urlLocations, result *PIIAnalysisResult) {
+	parsedURL, err := url.Parse(urlString)
 	if err != nil {
-		log.Printf("Error parsing URL: %v", err)
+		log.Printf("Error parsing URL %s: %v", sanitizeForLog(urlString), sanitizeURLError(err))
 		return
 	}
-	path := parsedURL.Path
-	pathSegments := strings.Split(path, "/")
+	if locations.userinfo != "" && parsedURL.User != nil {
+		if username := parsedURL.User.Username(); username != "" {
+			result.Findings = append(result.Findings, s.detectGuarded(result, "username", username, locations.userinfo)...)
+		}
+		if password, ok := parsedURL.User.Password(); ok && password != "" {
+			result.Findings = append(result.Findings, s.detectGuarded(result, "password", password, locations.userinfo)...)
+		}
+	}
+	pathSegments := strings.Split(parsedURL.EscapedPath(), "/")
+	var matrixParams []string
 	for i, segment := range pathSegments {
-		if segment != "" {
-			fieldName := s.inferFieldNameFromURL(pathSegments, i)
-			findings := s.detectPIIInField(fieldName, segment, "url_path")
-			result.Findings = append(result.Findings, findings...)
-			if fieldName == "url_path_segment" {
-				valueFindings := s.detectPIIInText("", segment, "url_path")
-				for _, finding := range valueFindings {
-					finding.FieldName = fmt.Sprintf("url_segment_%d", i)
-					result.Findings = append(result.Findings, finding)
+		segment, params, _ := strings.Cut(segment, ";")
+		pathSegments[i] = unescapeURLPart(segment, url.PathUnescape)
+		if params != "" {
+			matrixParams = append(matrixParams, strings.Split(params, ";")...)
+		}
+	}
+	if locations.path != "" {
+		for i, segment := range pathSegments {
+			if segment != "" {
+				fieldName := s.inferFieldNameFromURL(pathSegments, i)
+				findings := s.detectGuarded(result, fieldName, segment, locations.path)
+				result.Findings = append(result.Findings, findings...)
+				if fieldName == "url_path_segment" {
+					valueFindings := s.detectPIIInText(result.modes, "", segment, locations.path)
+					for _, finding := range valueFindings {
+						finding.FieldName = fmt.Sprintf("url_segment_%d", i)
+						result.Findings = append(result.Findings, finding)
+					}
 				}
 			}
 		}
 	}
-	queryParams := parsedURL.Query()
-	for key, values := range queryParams {
-		for _, value := range values {
-			findings := s.detectPIIInField(key, value, "query_params")
-			result.Findings = append(result.Findings, findings...)
+	if locations.matrix != "" {
+		s.analyzeURLParams(matrixParams, url.PathUnescape, locations.matrix, result)
+	}
+	if locations.query != "" {
+		queryParams, err := url.ParseQuery(parsedURL.RawQuery)
+		if err != nil {
+			log.Printf("Error decoding query of URL %s: %v", sanitizeForLog(urlString), err)
+		}
+		for key, values := range queryParams {
+			for _, value := range values {
+				findings := s.detectGuarded(result, key, value, locations.query)
+				result.Findings = append(result.Findings, findings...)
+			}
+		}
+	}
+	if locations.fragment != "" && parsedURL.Fragment != "" {
+		s.analyzeURLParams(strings.Split(parsedURL.EscapedFragment(), "&"), url.QueryUnescape, locations.fragment, result)
+	}
+}
+
+// unescapeURLPart decodes one escaped URL component, keeping it as sent when
+// it holds an invalid escape.
+func unescapeURLPart(part string, unescape func(string) (string, error)) string {
+	decoded, err := unescape(part)
+	if err != nil {
+		return part
+	}
+	return decoded
+}
+
+// analyzeURLParams scans "name=value" parameters as fields, including for
+// session ids such as ";jsessionid=...". A parameter without a name is
+// scanned as free text. Names and values are unescaped after splitting.
+func (s *PIIService) analyzeURLParams(params []string, unescape func(string) (string, error), location string, result *PIIAnalysisResult) {
+	for _, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			key, value = "", param
+		}
+		key, value = unescapeURLPart(key, unescape), unescapeURLPart(value, unescape)
+		if value == "" {
+			continue
+		}
+		if key == "" {
+			result.Findings = append(result.Findings, s.detectPIIInText(result.modes, "", value, location)...)
+			continue
+		}
+		result.Findings = append(result.Findings, s.detectGuarded(result, key, value, location)...)
+		if result.modes.fieldBased {
+			result.Findings = append(result.Findings, s.checkSessionValue(key, value, location, false)...)
 		}
 	}
 }
@@ -280,50 +945,75 @@ func (s *PIIService) inferFieldNameFromURL(pathSegments []string, currentIndex i
 	}
 }
 
-func (s *PIIService) detectPIIInField(fieldName, fieldValue, location string) []PIIDetectionResult {
+func (s *PIIService) detectPIIInField(modes detectionModes, fieldName, fieldValue, location string) []PIIDetectionResult {
 	var findings []PIIDetectionResult
 	fieldNameLower := strings.ToLower(fieldName)
-	for patternName, pattern := range s.config.DetectionModes.FieldBased.Patterns {
-		for _, targetField := range pattern.FieldNames {
-			if strings.Contains(fieldNameLower, strings.ToLower(targetField)) {
-				regexKey := fmt.Sprintf("field_%s", patternName)
-				if regex, exists := s.compiledRegex[regexKey]; exists {
-					if regex.MatchString(fieldValue) {
-						findings = append(findings, PIIDetectionResult{
-							PIIType:       patternName,
-							DetectedValue: s.maskSensitiveValue(fieldValue),
-							FieldName:     fieldName,
-							Location:      location,
-							DetectionMode: "field_based",
-							RiskLevel:     pattern.RiskLevel,
-							Category:      pattern.Category,
-							Tags:          pattern.Tags,
-							Timestamp:     time.Now(),
-						})
-						return findings
+	if modes.fieldBased {
+		if identityFindings := s.detectIdentityDocument(fieldName, fieldValue, location); len(identityFindings) > 0 {
+			return identityFindings
+		}
+		if recipientFindings := s.detectEmailRecipients(fieldName, fieldValue, location); len(recipientFindings) > 0 {
+			return recipientFindings
+		}
+		for patternName, pattern := range s.config.DetectionModes.FieldBased.Patterns {
+			for _, targetField := range pattern.FieldNames {
+				if pattern.StrictFieldNames && !fieldNameHasHint(fieldName, []string{targetField}) {
+					continue
+				}
+				if strings.Contains(fieldNameLower, strings.ToLower(targetField)) {
+					regexKey := fmt.Sprintf("field_%s", patternName)
+					if regex, exists := s.compiledRegex[regexKey]; exists {
+						if len(matchPattern(regex, "field_based", fieldValue)) > 0 && passesValidation(pattern.Validate, fieldValue) {
+							s.stats.record("field_based", patternName)
+							finding := PIIDetectionResult{
+								PIIType:       patternName,
+								DetectedValue: s.maskValue(fieldValue, pattern.MaskOptions),
+								rawValue:      fieldValue,
+								FieldName:     fieldName,
+								Location:      location,
+								DetectionMode: "field_based",
+								RiskLevel:     pattern.RiskLevel,
+								Category:      pattern.Category,
+								Tags:          pattern.Tags,
+								Frameworks:    pattern.Frameworks,
+								Confidence:    pattern.Confidence,
+								Timestamp:     time.Now(),
+							}
+							s.markHashedCredential(&finding, fieldValue)
+							return append(findings, finding)
+						}
 					}
 				}
 			}
 		}
 	}
-	for patternName, pattern := range s.config.DetectionModes.KeywordBased.Patterns {
-		if regex, exists := s.keywordRegex[patternName]; exists {
-			if regex.MatchString(fieldName) {
-				findings = append(findings, PIIDetectionResult{
-					PIIType:       patternName,
-					DetectedValue: s.maskSensitiveValue(fieldValue),
-					FieldName:     fieldName,
-					Location:      location,
-					DetectionMode: "keyword_based",
-					RiskLevel:     pattern.RiskLevel,
-					Category:      pattern.Category,
-					Tags:          pattern.Tags,
-					Timestamp:     time.Now(),
-				})
+	if modes.keywordBased {
+		for patternName, pattern := range s.config.DetectionModes.KeywordBased.Patterns {
+			if pattern.ApplyTo == "text" {
+				continue
+			}
+			if regex, exists := s.keywordRegex[patternName]; exists {
+				if len(matchPattern(regex, "keyword_based", fieldName)) > 0 {
+					s.stats.record("keyword_based", patternName)
+					findings = append(findings, PIIDetectionResult{
+						PIIType:       patternName,
+						DetectedValue: s.maskValue(fieldValue, pattern.MaskOptions),
+						rawValue:      fieldValue,
+						FieldName:     fieldName,
+						Location:      location,
+						DetectionMode: "keyword_based",
+						RiskLevel:     pattern.RiskLevel,
+						Category:      pattern.Category,
+						Tags:          pattern.Tags,
+						Frameworks:    pattern.Frameworks,
+						Confidence:    pattern.Confidence,
+						Timestamp:     time.Now(),
+					})
+				}
 			}
 		}
 	}
-	valueFindings := s.detectPIIInText(fieldNameLower, fieldValue, location)
+	valueFindings := s.detectPIIInText(modes, fieldName, fieldValue, location)
 	for _, finding := range valueFindings {
 		finding.FieldName = fieldName
 		findings = append(findings, finding)
@@ -331,8 +1021,18 @@ func (s *PIIService) detectPIIInField(fieldName, fieldValue, location string) []
 	return findings
 }
 
-func (s *PIIService) detectPIIInText(fieldNameLower, text, location string) []PIIDetectionResult {
+// detectPIIInText scans free text, or the value of fieldName, with the
+// value-only patterns. fieldName is passed as it appears, so hints can split
+// camelCase names into words.
+func (s *PIIService) detectPIIInText(modes detectionModes, fieldName, text, location string) []PIIDetectionResult {
 	var findings []PIIDetectionResult
+	fieldNameLower := strings.ToLower(fieldName)
+	if modes.keywordBased {
+		findings = append(findings, s.detectLabeledValues(text, location)...)
+	}
+	if !modes.valueOnly {
+		return findings
+	}
 	cardFields := []string{"cardnumber", "ccnumber", "creditcard", "card", "cc", "visa", "visacard", "mastercard", "maestro"}
 	for patternName, pattern := range s.config.DetectionModes.ValueOnly.Patterns {
 		skip := false
@@ -347,27 +1047,47 @@ func (s *PIIService) detectPIIInText(fieldNameLower, text, location string) []PI
 		if skip {
 			continue
 		}
+		// Patterns that list field names only fire in that field-name context.
+		if len(pattern.FieldNames) > 0 && !fieldNameHasHint(fieldName, pattern.FieldNames) {
+			continue
+		}
 		regexKey := fmt.Sprintf("value_%s", patternName)
 		if regex, exists := s.compiledRegex[regexKey]; exists {
-			matches := regex.FindAllString(text, -1)
+			matches := matchPattern(regex, "value_only", text)
 			for _, match := range matches {
+				if !passesValidation(pattern.Validate, match) {
+					continue
+				}
+				s.stats.record("value_only", patternName)
 				findings = append(findings, PIIDetectionResult{
 					PIIType:       patternName,
-					DetectedValue: s.maskSensitiveValue(match),
+					DetectedValue: s.maskValue(match, pattern.MaskOptions),
+					rawValue:      match,
 					Location:      location,
 					DetectionMode: "value_only",
 					RiskLevel:     pattern.RiskLevel,
 					Category:      pattern.Category,
 					Tags:          pattern.Tags,
+					Frameworks:    pattern.Frameworks,
+					Confidence:    pattern.Confidence,
 					Timestamp:     time.Now(),
 				})
 			}
 		}
 	}
+	findings = append(findings, s.detectNationalIDs(modes.nationalIDCountries, text, location)...)
+	findings = append(findings, s.detectPostalAddresses(text, location)...)
 	return findings
 }
 
-func (s *PIIService) analyzeJSONObject(data interface{}, prefix, location string, result *PIIAnalysisResult) {
+// maxEmbeddedJSONDepth caps how many layers of JSON encoded inside JSON
+// strings are decoded, e.g. {"payload": "{\"email\": ...}"}.
+const maxEmbeddedJSONDepth = 3
+
+// analyzeJSONObject scans a decoded JSON value. String values that hold a JSON
+// object or array are decoded and scanned in turn, reporting paths through
+// them such as payload.email; decodes counts the layers decoded so far.
+func (s *PIIService) analyzeJSONObject(data interface{}, prefix, location string, decodes int, result *PIIAnalysisResult) {
 	switch v := data.(type) {
 	case map[string]interface{}:
 		for key, value := range v {
@@ -377,32 +1097,85 @@ func (s *PIIService) analyzeJSONObject(data interface{}, prefix, location string
 			}
 			switch val := value.(type) {
 			case string:
-				findings := s.detectPIIInField(key, val, location)
-				result.Findings = append(result.Findings, findings...)
+				s.analyzeJSONString(key, fullKey, val, location, decodes, result)
 			case map[string]interface{}, []interface{}:
-				s.analyzeJSONObject(val, fullKey, location, result)
+				s.analyzeJSONObject(val, fullKey, location, decodes, result)
 			}
 		}
 	case []interface{}:
+		// Strings in an array, such as "emails": ["a@b.com", ...], are
+		// matched against the key the array sits under.
+		key := arrayItemKey(prefix)
 		for i, item := range v {
-			s.analyzeJSONObject(item, fmt.Sprintf("%s[%d]", prefix, i), location, result)
+			itemPath := fmt.Sprintf("%s[%d]", prefix, i)
+			if val, ok := item.(string); ok {
+				s.analyzeJSONString(key, itemPath, val, location, decodes, result)
+				continue
+			}
+			s.analyzeJSONObject(item, itemPath, location, decodes, result)
+		}
+	}
+}
+
+// analyzeJSONString scans a JSON string value, decoding it first when it
+// holds embedded JSON. It is matched on the bare key, or the field behind it
+// for a GraphQL alias, but reported under the full JSON path.
+func (s *PIIService) analyzeJSONString(key, path, value, location string, decodes int, result *PIIAnalysisResult) {
+	key = result.responseFieldKey(location, key)
+	if decodes < maxEmbeddedJSONDepth {
+		if embedded, ok := decodeEmbeddedJSON(value); ok {
+			s.analyzeJSONObject(embedded, path, location, decodes+1, result)
+			return
 		}
 	}
+	findings := s.detectGuarded(result, key, value, location)
+	for _, finding := range findings {
+		finding.FieldName = path
+		result.Findings = append(result.Findings, finding)
+	}
+	s.analyzeDataURIs(value, key, path, location, result)
+}
+
+// arrayItemKey returns the key of the JSON path an array is at, e.g.
+// "emails" for "user.emails" or "matrix[0]", or "" for a top-level array.
+func arrayItemKey(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		path = path[i+1:]
+	}
+	key, _, _ := strings.Cut(path, "[")
+	return key
+}
+
+// decodeEmbeddedJSON decodes a string holding a JSON object or array.
+func decodeEmbeddedJSON(value string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) < 2 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return nil, false
+	}
+	switch decoded.(type) {
+	case map[string]interface{}, []interface{}:
+		return decoded, true
+	}
+	return nil, false
 }
 
 func (s *PIIService) maskSensitiveValue(value string) string {
-	if len(value) <= 4 {
-		return strings.Repeat("*", len(value))
+	if s.maskingDisabled {
+		return value
 	}
-	return value[:2] + strings.Repeat("*", len(value)-4) + value[len(value)-2:]
+	return maskRevealing(value, s.config.MaskRevealPrefix, s.config.MaskRevealSuffix)
 }
 
 func (s *PIIService) calculateRiskMetrics(findings []PIIDetectionResult) (int, string) {
 	if len(findings) == 0 {
-		return 0, "NONE"
+		return 0, noRiskLevel
 	}
 	totalScore := 0
-	highestRisk := "LOW"
+	highestRisk := lowestRiskLevel(s.config.RiskLevels)
 	maxRiskValue := 0
 	for _, finding := range findings {
 		if riskValue, exists := s.config.RiskLevels[finding.RiskLevel]; exists {
@@ -416,9 +1189,15 @@ func (s *PIIService) calculateRiskMetrics(findings []PIIDetectionResult) (int, s
 	return totalScore, highestRisk
 }
 
+// RiskLevelValue returns the configured weight of a risk level, or 0 if unknown.
+func (s *PIIService) RiskLevelValue(level string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.RiskLevels[level]
+}
+
 func (s *PIIService) isJSON(str string) bool {
-	var js interface{}
-	return json.Unmarshal([]byte(str), &js) == nil
+	return json.Valid([]byte(str))
 }
 
 func (s *PIIService) ProcessAllAPIDataForPII() ([]PIIAnalysisResult, error) {
@@ -429,11 +1208,15 @@ func (s *PIIService) ProcessAllAPIDataForPII() ([]PIIAnalysisResult, error) {
 	var results []PIIAnalysisResult
 	log.Printf("Starting PII analysis for %d API entries", len(apiDataList))
 	for _, apiData := range apiDataList {
-		result := s.AnalyzePIIInAPIData(apiData)
+		if err := apiData.DecompressBodies(); err != nil {
+			log.Printf("Skipping %s %s: %v", apiData.Method, sanitizeForLog(apiData.APIEndpoint), err)
+			continue
+		}
+		result := s.AnalyzePIIInAPIData(context.Background(), apiData)
 		if result.TotalCount > 0 {
 			results = append(results, result)
 			log.Printf("Found %d PII findings in %s %s (Risk: %s, Score: %d)",
-				result.TotalCount, result.Method, result.APIEndpoint,
+				result.TotalCount, result.Method, sanitizeForLog(result.APIEndpoint),
 				result.HighestRisk, result.RiskScore)
 		}
 	}
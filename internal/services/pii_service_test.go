@@ -0,0 +1,283 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// countFindings counts the findings of piiType.
+func countFindings(findings []PIIDetectionResult, piiType string) int {
+	n := 0
+	for _, f := range findings {
+		if f.PIIType == piiType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTrimTruncatedTail(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+		want interface{}
+	}{
+		{name: "cut mid-token", body: "a jane@example.com b john@exam", want: "a jane@example.com b "},
+		{name: "cut mid-JSON value", body: `{"email":"jane@example.com","phone":"+1 41`, want: `{"email":"jane@example.com","phone":"+1 `},
+		{name: "ends on delimiter", body: `{"a":1}`, want: `{"a":1}`},
+		{name: "single token", body: "abcdef", want: ""},
+		{name: "not a string", body: 42, want: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimTruncatedTail(tt.body); got != tt.want {
+				t.Errorf("trimTruncatedTail(%v) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTruncatedResponseBody(t *testing.T) {
+	s := newTestPIIService(t)
+	// The second address is cut off mid-domain, yet still looks like an
+	// address on its own.
+	body := "Contact jane at example dot com or john at example dot co"
+	tests := []struct {
+		name      string
+		truncated bool
+		want      int
+	}{
+		{name: "complete body", truncated: false, want: 2},
+		{name: "truncated body", truncated: true, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:     "/api/contact",
+				Method:          "GET",
+				URL:             "https://api.example.com/api/contact",
+				ResponseHeaders: map[string]string{"Content-Type": "text/plain"},
+				ResponseBody:    body,
+				BodyTruncated:   tt.truncated,
+			})
+			if got := countFindings(result.Findings, "OBFUSCATED_EMAIL"); got != tt.want {
+				t.Errorf("OBFUSCATED_EMAIL findings = %d, want %d", got, tt.want)
+			}
+			if result.BodyTruncated != tt.truncated {
+				t.Errorf("BodyTruncated = %t, want %t", result.BodyTruncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestMissingPIIConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		missing     bool
+		strict      string
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "missing falls back", missing: true, wantWarning: true},
+		{name: "missing falls back when not strict", missing: true, strict: "false", wantWarning: true},
+		{name: "missing fails when strict", missing: true, strict: "true", wantErr: true},
+		{name: "present when strict", strict: "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigCopy(t)
+			if tt.missing {
+				if err := os.Remove(filepath.Join("config", "regexpii.json")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PII_CONFIG_STRICT", tt.strict)
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(io.Discard)
+
+			s, err := NewPIIService(db.MongoInstance{})
+			if tt.wantErr {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("NewPIIService error = %v, want a missing file error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPIIService: %v", err)
+			}
+			if warned := strings.Contains(logs.String(), "built-in default PII config"); warned != tt.wantWarning {
+				t.Errorf("fallback warning logged = %t, want %t", warned, tt.wantWarning)
+			}
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/users",
+				Method:      "POST",
+				RequestBody: map[string]interface{}{"email": "john.doe@example.com"},
+			})
+			if countFindings(result.Findings, "EMAIL") != 1 {
+				t.Errorf("findings = %+v, want the email detected", result.Findings)
+			}
+		})
+	}
+}
+
+func TestPatternLimitsConcurrentMatches(t *testing.T) {
+	s := newTestPIIService(t)
+	const pattern, sample = `\d{3}-\d{2}-\d{4}`, "ssn 123-45-6789"
+
+	// Matches that outlived their request hold every slot.
+	for i := 0; i < maxConcurrentPatternTests; i++ {
+		patternTestSlots <- struct{}{}
+	}
+	_, err := s.TestPattern(context.Background(), pattern, "value_only", sample)
+	if !errors.Is(err, ErrPatternTestBusy) {
+		t.Errorf("TestPattern with every slot taken: error = %v, want ErrPatternTestBusy", err)
+	}
+
+	// Releasing one slot lets a test run, and it gives the slot back.
+	<-patternTestSlots
+	result, err := s.TestPattern(context.Background(), pattern, "value_only", sample)
+	if err != nil {
+		t.Fatalf("TestPattern: %v", err)
+	}
+	if result.Count != 1 {
+		t.Errorf("Count = %d, want 1", result.Count)
+	}
+	for i := 1; i < maxConcurrentPatternTests; i++ {
+		<-patternTestSlots
+	}
+	if n := len(patternTestSlots); n != 0 {
+		t.Errorf("%d slots still held after the tests finished", n)
+	}
+}
+
+func TestBodyFindingFieldPath(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "top-level field", body: `{"email":"jane.doe@example.com"}`, want: "email"},
+		{name: "nested object", body: `{"user":{"profile":{"email":"jane.doe@example.com"}}}`, want: "user.profile.email"},
+		{name: "object in an array", body: `{"user":{"contacts":[{"phone":"x"},{"email":"jane.doe@example.com"}]}}`, want: "user.contacts[1].email"},
+		{name: "first array element", body: `{"user":{"contacts":[{"email":"jane.doe@example.com"}]}}`, want: "user.contacts[0].email"},
+		{name: "string in an array", body: `{"emails":["x","jane.doe@example.com"]}`, want: "emails[1]"},
+		{name: "top-level array", body: `[{"email":"jane.doe@example.com"}]`, want: "[0].email"},
+	}
+	s := newTestPIIService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/users",
+				Method:      "POST",
+				RequestBody: tt.body,
+			})
+			var fields []string
+			for _, f := range result.Findings {
+				if f.PIIType == "EMAIL" {
+					fields = append(fields, f.FieldName)
+				}
+			}
+			if len(fields) != 1 || fields[0] != tt.want {
+				t.Errorf("EMAIL field names = %q, want [%q]", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsedResponsePayloads(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+		want string
+	}{
+		{name: "string", body: `{"user":{"email":"jane.doe@example.com"}}`, want: "user.email"},
+		{name: "map", body: map[string]interface{}{"user": map[string]interface{}{"email": "jane.doe@example.com"}}, want: "user.email"},
+		{name: "array", body: []interface{}{map[string]interface{}{"email": "jane.doe@example.com"}}, want: "[0].email"},
+		{name: "BSON document", body: bson.D{{Key: "user", Value: bson.D{{Key: "email", Value: "jane.doe@example.com"}}}}, want: "user.email"},
+		{name: "BSON array", body: bson.A{bson.M{"email": "jane.doe@example.com"}}, want: "[0].email"},
+	}
+	s := newTestPIIService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:  "/api/users",
+				Method:       "GET",
+				ResponseBody: tt.body,
+			})
+			var fields []string
+			for _, f := range result.Findings {
+				if f.PIIType == "EMAIL" && f.Location == "response_body" {
+					fields = append(fields, f.FieldName)
+				}
+			}
+			if len(fields) != 1 || fields[0] != tt.want {
+				t.Errorf("EMAIL field names = %q, want [%q]", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedJSONBodies(t *testing.T) {
+	// embed nests value under keys, encoding each layer below the first as a
+	// JSON string, so embed(v, "a", "b") is {"a":"{\"b\":v}"}.
+	embed := func(value interface{}, keys ...string) string {
+		for i := len(keys) - 1; i >= 0; i-- {
+			data, err := json.Marshal(map[string]interface{}{keys[i]: value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			value = string(data)
+		}
+		return value.(string)
+	}
+	tests := []struct {
+		name     string
+		body     string
+		piiType  string
+		wantPath string
+	}{
+		{name: "double-encoded email", body: embed("jane.doe@example.com", "payload", "email"), piiType: "EMAIL", wantPath: "payload.email"},
+		{name: "encoded array", body: `{"payload":"[{\"email\":\"jane.doe@example.com\"}]"}`, piiType: "EMAIL", wantPath: "payload[0].email"},
+		{name: "three embedded layers", body: embed("female", "a", "b", "c", "gender"), piiType: "GENDER", wantPath: "a.b.c.gender"},
+		// The fourth layer is scanned as plain text, where a field-name
+		// match can't happen.
+		{name: "past the depth cap", body: embed("female", "a", "b", "c", "d", "gender"), piiType: "GENDER"},
+	}
+	s := newTestPIIService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/events",
+				Method:      "POST",
+				RequestBody: tt.body,
+			})
+			var paths []string
+			for _, f := range result.Findings {
+				if f.PIIType == tt.piiType {
+					paths = append(paths, f.FieldName)
+				}
+			}
+			if tt.wantPath == "" {
+				if len(paths) != 0 {
+					t.Errorf("%s findings at %q, want none", tt.piiType, paths)
+				}
+				return
+			}
+			if len(paths) != 1 || paths[0] != tt.wantPath {
+				t.Errorf("%s field names = %q, want [%q]", tt.piiType, paths, tt.wantPath)
+			}
+		})
+	}
+}
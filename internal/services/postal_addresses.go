@@ -0,0 +1,85 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PostalAddressConfig drives street address detection in free text. An
+// address needs a house number followed by a street name ending in one of
+// StreetSuffixes; a trailing city, state and ZIP or postal code is optional
+// but raises the confidence from Confidence to PostalCodeConfidence.
+type PostalAddressConfig struct {
+	MaskOptions
+
+	Enabled              bool     `json:"enabled"`
+	StreetSuffixes       []string `json:"streetSuffixes"`
+	RiskLevel            string   `json:"riskLevel"`
+	Category             string   `json:"category"`
+	Tags                 []string `json:"tags"`
+	Frameworks           []string `json:"frameworks,omitempty"`
+	Confidence           float64  `json:"confidence"`
+	PostalCodeConfidence float64  `json:"postalCodeConfidence"`
+}
+
+const postalAddressType = "POSTAL_ADDRESS"
+
+// compilePostalAddressPattern builds the address regex from the configured
+// street suffixes. Street name words must be capitalized, which keeps prose
+// such as "3 big st" from matching.
+func (s *PIIService) compilePostalAddressPattern() {
+	cfg := s.config.PostalAddresses
+	s.addressRegex = nil
+	if !cfg.Enabled || len(cfg.StreetSuffixes) == 0 {
+		return
+	}
+	suffixes := make([]string, 0, len(cfg.StreetSuffixes))
+	for _, suffix := range cfg.StreetSuffixes {
+		suffixes = append(suffixes, regexp.QuoteMeta(suffix))
+	}
+	expr := `\b\d{1,6}[A-Za-z]?` +
+		`(?:\s+(?:[NSEW]\.?|North|South|East|West))?` +
+		`(?:\s+[A-Z0-9][A-Za-z0-9'-]*){1,4}?` +
+		`\s+(?:` + strings.Join(suffixes, "|") + `)(?:\.|\b)` +
+		`(?:\s+(?:[NS][EW]|[NSEW])\b\.?)?` +
+		`(?:,?\s+(?:Apt|Apartment|Suite|Ste|Unit|#)\.?\s*[A-Za-z0-9-]+)?` +
+		`(?:,?\s+(?:[A-Z][A-Za-z.'-]*(?:\s+[A-Z][A-Za-z.'-]*){0,3},?\s+)?(?:[A-Z]{2}\s+)?(\d{5}(?:-\d{4})?))?`
+	regex, err := compilePattern(expr)
+	if err != nil {
+		log.Printf("Warning: Failed to compile postal address pattern: %v", err)
+		return
+	}
+	s.addressRegex = regex
+}
+
+// detectPostalAddresses reports street addresses in text.
+func (s *PIIService) detectPostalAddresses(text, location string) []PIIDetectionResult {
+	if s.addressRegex == nil {
+		return nil
+	}
+	cfg := s.config.PostalAddresses
+	var findings []PIIDetectionResult
+	for _, match := range s.addressRegex.FindAllStringSubmatch(text, -1) {
+		confidence := cfg.Confidence
+		if match[1] != "" && cfg.PostalCodeConfidence > 0 {
+			confidence = cfg.PostalCodeConfidence
+		}
+		s.stats.record("value_only", postalAddressType)
+		findings = append(findings, PIIDetectionResult{
+			PIIType:       postalAddressType,
+			DetectedValue: s.maskValue(match[0], cfg.MaskOptions),
+			rawValue:      match[0],
+			Location:      location,
+			DetectionMode: "value_only",
+			RiskLevel:     cfg.RiskLevel,
+			Category:      cfg.Category,
+			Tags:          cfg.Tags,
+			Frameworks:    cfg.Frameworks,
+			Confidence:    confidence,
+			Timestamp:     time.Now(),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,47 @@
+package services
+
+import "testing"
+
+func TestDetectPostalAddresses(t *testing.T) {
+	s := newTestPIIService(t)
+	cfg := s.config.PostalAddresses
+	tests := []struct {
+		name           string
+		text           string
+		want           string
+		withPostalCode bool
+	}{
+		{name: "street only", text: "Ship to 1600 Pennsylvania Ave today", want: "1600 Pennsylvania Ave"},
+		{name: "city, state and ZIP", text: "Mail it to 350 Fifth Avenue, New York, NY 10118 please.", want: "350 Fifth Avenue, New York, NY 10118", withPostalCode: true},
+		{name: "ZIP+4 and a unit", text: "Office: 1 Hacker Way, Suite 200, Menlo Park, CA 94025-1456", want: "1 Hacker Way, Suite 200, Menlo Park, CA 94025-1456", withPostalCode: true},
+		{name: "directional and abbreviated suffix", text: "Billing: 42 N Main St. Apt 4B", want: "42 N Main St. Apt 4B"},
+		{name: "post-directional", text: "at 1200 Pennsylvania Ave NW, Washington, DC 20500", want: "1200 Pennsylvania Ave NW, Washington, DC 20500", withPostalCode: true},
+		{name: "lowercase prose", text: "there were 3 big st signs on the road", want: ""},
+		{name: "number without a street", text: "order 12345 ships in 3 days", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := s.detectPostalAddresses(tt.text, "response_body")
+			if tt.want == "" {
+				if len(findings) != 0 {
+					t.Errorf("found %q, want no address", findings[0].rawValue)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].rawValue != tt.want {
+				var got []string
+				for _, f := range findings {
+					got = append(got, f.rawValue)
+				}
+				t.Fatalf("addresses = %q, want [%q]", got, tt.want)
+			}
+			wantConfidence := cfg.Confidence
+			if tt.withPostalCode {
+				wantConfidence = cfg.PostalCodeConfidence
+			}
+			if f := findings[0]; f.PIIType != postalAddressType || f.Confidence != wantConfidence {
+				t.Errorf("finding %s confidence %v, want %s confidence %v", f.PIIType, f.Confidence, postalAddressType, wantConfidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+// QuasiIdentifierConfig escalates findings of quasi-identifiers, such as age,
+// gender or postal code, that are harmless alone but together can re-identify
+// a person. When at least Threshold distinct Types occur in one document,
+// their findings are raised to EscalateTo.
+type QuasiIdentifierConfig struct {
+	Types      []string `json:"types"`
+	Threshold  int      `json:"threshold"`
+	EscalateTo string   `json:"escalateTo"`
+}
+
+const coOccurrenceTag = "CO_OCCURRENCE"
+
+// escalateQuasiIdentifiers raises the risk of co-occurring quasi-identifier
+// findings in place. Findings already at or above the escalated level keep theirs.
+func (s *PIIService) escalateQuasiIdentifiers(findings []PIIDetectionResult) {
+	cfg := s.config.QuasiIdentifiers
+	if cfg.Threshold <= 0 || len(cfg.Types) == 0 || cfg.EscalateTo == "" {
+		return
+	}
+	quasi := make(map[string]bool, len(cfg.Types))
+	for _, t := range cfg.Types {
+		quasi[t] = true
+	}
+	present := map[string]bool{}
+	for _, f := range findings {
+		if quasi[f.PIIType] {
+			present[f.PIIType] = true
+		}
+	}
+	if len(present) < cfg.Threshold {
+		return
+	}
+	escalated := s.config.RiskLevels[cfg.EscalateTo]
+	for i := range findings {
+		f := &findings[i]
+		if !quasi[f.PIIType] || s.config.RiskLevels[f.RiskLevel] >= escalated {
+			continue
+		}
+		f.RiskLevel = cfg.EscalateTo
+		f.Tags = append(append([]string{}, f.Tags...), coOccurrenceTag)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestQuasiIdentifierEscalation(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name      string
+		body      string
+		quasi     int
+		escalated bool
+	}{
+		{name: "zip, age and gender", body: `{"zip":"94103","age":"42","gender":"female"}`, quasi: 3, escalated: true},
+		{name: "zip and age", body: `{"zip":"94103","age":"42"}`, quasi: 2},
+		{name: "the same type three times", body: `{"items":[{"zip":"94103"},{"zip":"10001"},{"zip":"60601"}]}`, quasi: 3},
+		{name: "look-alike fields", body: `{"zip":"94103","page":"42","usage":"3","gender":"m"}`, quasi: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/profile",
+				Method:      "POST",
+				RequestBody: tt.body,
+			})
+			quasi := 0
+			for _, f := range result.Findings {
+				if f.Category != "QUASI_IDENTIFIER" {
+					continue
+				}
+				quasi++
+				wantRisk := "LOW"
+				if tt.escalated {
+					wantRisk = "HIGH"
+				}
+				if f.RiskLevel != wantRisk {
+					t.Errorf("%s risk = %s, want %s", f.PIIType, f.RiskLevel, wantRisk)
+				}
+				if tagged := slices.Contains(f.Tags, coOccurrenceTag); tagged != tt.escalated {
+					t.Errorf("%s tagged %s = %v, want %v", f.PIIType, coOccurrenceTag, tagged, tt.escalated)
+				}
+			}
+			if quasi != tt.quasi {
+				t.Errorf("got %d quasi-identifier findings, want %d: %+v", quasi, tt.quasi, result.Findings)
+			}
+			if tt.escalated && result.HighestRisk != "HIGH" {
+				t.Errorf("highest risk = %s, want HIGH", result.HighestRisk)
+			}
+		})
+	}
+}
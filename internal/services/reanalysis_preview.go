@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// FindingChange is a finding kept by a re-analysis whose attributes differ.
+type FindingChange struct {
+	Before PIIDetectionResult `json:"before"`
+	After  PIIDetectionResult `json:"after"`
+	Fields []string           `json:"fields"`
+}
+
+// ReanalysisPreview compares a document's stored findings with a fresh
+// analysis of it. Findings are matched by id.
+type ReanalysisPreview struct {
+	DocumentID   string               `json:"document_id"`
+	CurrentRisk  string               `json:"current_risk"`
+	ProposedRisk string               `json:"proposed_risk"`
+	Added        []PIIDetectionResult `json:"added"`
+	Removed      []PIIDetectionResult `json:"removed"`
+	Changed      []FindingChange      `json:"changed"`
+	Unchanged    int                  `json:"unchanged"`
+}
+
+// PreviewReanalysis analyzes a stored document under the running config and
+// reports how its findings would change, without writing anything. Kept
+// findings are merged the way a stored re-analysis merges them, so review
+// state such as labels is not reported as a change.
+func (s *PIIService) PreviewReanalysis(ctx context.Context, doc db.UserAPIData) (ReanalysisPreview, error) {
+	if err := doc.DecompressBodies(); err != nil {
+		return ReanalysisPreview{}, err
+	}
+	analysis := s.AnalyzePIIInAPIData(ctx, doc)
+	fresh := make([]db.PIIFinding, len(analysis.Findings))
+	for i, f := range analysis.Findings {
+		fresh[i] = db.PIIFinding{
+			ID:            f.ID,
+			PIIType:       f.PIIType,
+			DetectedValue: f.DetectedValue,
+			FieldName:     f.FieldName,
+			Location:      f.Location,
+			DetectionMode: f.DetectionMode,
+			RiskLevel:     f.RiskLevel,
+			Category:      f.Category,
+			Tags:          f.Tags,
+			Frameworks:    f.Frameworks,
+			Confidence:    f.Confidence,
+			Timestamp:     f.Timestamp,
+		}
+	}
+	merged := db.MergePIIFindings(doc.PIIFindings, fresh)
+
+	preview := ReanalysisPreview{
+		DocumentID:   doc.ID.Hex(),
+		CurrentRisk:  doc.HighestRisk,
+		ProposedRisk: analysis.HighestRisk,
+		Added:        []PIIDetectionResult{},
+		Removed:      []PIIDetectionResult{},
+		Changed:      []FindingChange{},
+	}
+	stored := make(map[string]db.PIIFinding, len(doc.PIIFindings))
+	for _, f := range doc.PIIFindings {
+		stored[f.ID] = f
+	}
+	kept := make(map[string]bool, len(merged))
+	for _, after := range merged {
+		before, ok := stored[after.ID]
+		if !ok {
+			preview.Added = append(preview.Added, detectionResult(after))
+			continue
+		}
+		kept[after.ID] = true
+		if fields := changedFindingFields(before, after); len(fields) > 0 {
+			preview.Changed = append(preview.Changed, FindingChange{Before: detectionResult(before), After: detectionResult(after), Fields: fields})
+		} else {
+			preview.Unchanged++
+		}
+	}
+	for _, f := range doc.PIIFindings {
+		if !kept[f.ID] {
+			preview.Removed = append(preview.Removed, detectionResult(f))
+		}
+	}
+	for _, findings := range [][]PIIDetectionResult{preview.Added, preview.Removed} {
+		sort.Slice(findings, func(i, j int) bool { return findings[i].ID < findings[j].ID })
+	}
+	sort.Slice(preview.Changed, func(i, j int) bool { return preview.Changed[i].After.ID < preview.Changed[j].After.ID })
+	return preview, nil
+}
+
+// changedFindingFields lists the attributes analysis sets that differ
+// between two findings with the same id.
+func changedFindingFields(before, after db.PIIFinding) []string {
+	var fields []string
+	for _, f := range []struct {
+		name          string
+		before, after interface{}
+	}{
+		{"detection_mode", before.DetectionMode, after.DetectionMode},
+		{"risk_level", before.RiskLevel, after.RiskLevel},
+		{"category", before.Category, after.Category},
+		{"tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ",")},
+		{"frameworks", strings.Join(before.Frameworks, ","), strings.Join(after.Frameworks, ",")},
+		{"confidence", before.Confidence, after.Confidence},
+	} {
+		if f.before != f.after {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
+
+func detectionResult(f db.PIIFinding) PIIDetectionResult {
+	return PIIDetectionResult{
+		ID:            f.ID,
+		PIIType:       f.PIIType,
+		DetectedValue: f.DetectedValue,
+		FieldName:     f.FieldName,
+		Location:      f.Location,
+		DetectionMode: f.DetectionMode,
+		RiskLevel:     f.RiskLevel,
+		Category:      f.Category,
+		Tags:          f.Tags,
+		Frameworks:    f.Frameworks,
+		Confidence:    f.Confidence,
+		Timestamp:     f.Timestamp,
+	}
+}
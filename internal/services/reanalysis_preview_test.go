@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPreviewReanalysis(t *testing.T) {
+	current := newTestPIIService(t)
+	doc := db.UserAPIData{
+		ID:           primitive.NewObjectID(),
+		APIEndpoint:  "/staff",
+		Method:       "GET",
+		URL:          "https://api.example.com/staff",
+		ResponseBody: `{"badge":"EMP-123456","email":"jane@example.com"}`,
+	}
+	analysis := current.AnalyzePIIInAPIData(context.Background(), doc)
+	if analysis.TotalCount == 0 {
+		t.Fatal("no findings under the current config")
+	}
+	for _, f := range analysis.Findings {
+		doc.PIIFindings = append(doc.PIIFindings, db.PIIFinding{
+			ID:            f.ID,
+			PIIType:       f.PIIType,
+			DetectedValue: f.DetectedValue,
+			FieldName:     f.FieldName,
+			Location:      f.Location,
+			DetectionMode: f.DetectionMode,
+			RiskLevel:     f.RiskLevel,
+			Category:      f.Category,
+			Tags:          f.Tags,
+			Frameworks:    f.Frameworks,
+			Confidence:    f.Confidence,
+			Timestamp:     f.Timestamp,
+			// Review state is kept by a re-analysis, so it is no change.
+			Labels: []string{"triaged"},
+		})
+	}
+	doc.HighestRisk = analysis.HighestRisk
+	stale := db.PIIFinding{ID: "stale", PIIType: "PHONE", DetectedValue: "+1 *** *** 0100", FieldName: "phone", Location: "response_body", RiskLevel: "MEDIUM"}
+	doc.PIIFindings = append(doc.PIIFindings, stale)
+
+	proposed, err := current.newSimulatedService(json.RawMessage(`{"detection_modes":{"value_only":{"patterns":{"EMPLOYEE_ID":{
+		"regexPattern":"\\bEMP-\\d{6}\\b","riskLevel":"HIGH","category":"IDENTITY"}}}}}`), false)
+	if err != nil {
+		t.Fatalf("newSimulatedService: %v", err)
+	}
+	preview, err := proposed.PreviewReanalysis(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("PreviewReanalysis: %v", err)
+	}
+
+	if preview.DocumentID != doc.ID.Hex() {
+		t.Errorf("document id = %s, want %s", preview.DocumentID, doc.ID.Hex())
+	}
+	if len(preview.Added) != 1 || preview.Added[0].PIIType != "EMPLOYEE_ID" || preview.Added[0].RiskLevel != "HIGH" {
+		t.Errorf("added = %+v, want one HIGH EMPLOYEE_ID", preview.Added)
+	}
+	if len(preview.Removed) != 1 || preview.Removed[0].ID != stale.ID {
+		t.Errorf("removed = %+v, want the stale finding", preview.Removed)
+	}
+	if len(preview.Changed) != 0 || preview.Unchanged != analysis.TotalCount {
+		t.Errorf("changed = %+v, unchanged = %d, want %d unchanged", preview.Changed, preview.Unchanged, analysis.TotalCount)
+	}
+	if preview.CurrentRisk != analysis.HighestRisk || preview.ProposedRisk != "HIGH" {
+		t.Errorf("risk %s -> %s, want %s -> HIGH", preview.CurrentRisk, preview.ProposedRisk, analysis.HighestRisk)
+	}
+}
+
+func TestChangedFindingFields(t *testing.T) {
+	before := db.PIIFinding{ID: "a", RiskLevel: "MEDIUM", Tags: []string{"x"}, Confidence: 0.8}
+	after := before
+	after.RiskLevel = "HIGH"
+	after.Tags = []string{"x", "ERROR_LEAK"}
+	after.Labels = []string{"ignored"}
+	got := changedFindingFields(before, after)
+	if len(got) != 2 || got[0] != "risk_level" || got[1] != "tags" {
+		t.Errorf("changed fields = %v, want [risk_level tags]", got)
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"github.com/RavenSec10/Raven_Backend/internal/nationalid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const remaskBatchSize = 200
+
+type RemaskResult struct {
+	Documents int `json:"documents"`
+	Remasked  int `json:"remasked"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// RemaskFindings re-applies the current masking settings to the detected
+// value of every stored finding, without re-running detection. Raw values are
+// not stored, so each one is recovered from the document's headers, bodies or
+// URL: it must be the only value there that the old mask could have come from.
+// Findings whose value can't be recovered unambiguously are skipped.
+func (s *PIIService) RemaskFindings(ctx context.Context) (RemaskResult, error) {
+	var result RemaskResult
+	afterID := primitive.NilObjectID
+	for {
+		docs, err := s.db.FindAPIDataWithFindingsAfter(ctx, afterID, remaskBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(docs) == 0 {
+			return result, nil
+		}
+		for _, doc := range docs {
+			afterID = doc.ID
+			if err := doc.DecompressBodies(); err != nil {
+				log.Printf("Skipping remask of %s: %v", doc.ID.Hex(), err)
+				result.Skipped += len(doc.PIIFindings)
+				continue
+			}
+			result.Documents++
+			if s.remaskDocument(&doc, &result) {
+				if err := s.db.UpdatePIIFindings(ctx, doc.ID, doc.PIIFindings); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+}
+
+// remaskDocument remasks the findings of doc in place and reports whether any changed.
+func (s *PIIService) remaskDocument(doc *db.UserAPIData, result *RemaskResult) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	changed := false
+	for i := range doc.PIIFindings {
+		finding := &doc.PIIFindings[i]
+		opts, ok := s.maskOptionsFor(finding.PIIType, finding.DetectionMode)
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		raw, ok := recoverRawValue(*doc, *finding)
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		masked := s.maskValue(raw, opts)
+		if masked == finding.DetectedValue {
+			result.Unchanged++
+			continue
+		}
+		finding.DetectedValue = masked
+		// The id is rederived from the raw value, as re-analysis would, in
+		// case it was stored before ids were keyed on it.
+		finding.ID = findingID(PIIDetectionResult{
+			PIIType:       finding.PIIType,
+			Location:      finding.Location,
+			FieldName:     finding.FieldName,
+			DetectedValue: masked,
+			rawValue:      raw,
+		})
+		result.Remasked++
+		changed = true
+	}
+	return changed
+}
+
+// maskOptionsFor returns the mask options of the pattern that produces
+// findings of piiType in the given detection mode.
+func (s *PIIService) maskOptionsFor(piiType, mode string) (MaskOptions, bool) {
+	modes := s.config.DetectionModes
+	switch mode {
+	case "field_based":
+		if pattern, ok := modes.FieldBased.Patterns[piiType]; ok {
+			return pattern.MaskOptions, true
+		}
+		if doc, ok := s.config.IdentityDocuments[piiType]; ok {
+			return doc.MaskOptions, true
+		}
+		if piiType == "SESSION_ID" {
+			return s.config.SessionIDs.MaskOptions, true
+		}
+	case "value_only":
+		if pattern, ok := modes.ValueOnly.Patterns[piiType]; ok {
+			return pattern.MaskOptions, true
+		}
+		if nationalid.IsIDType(piiType) {
+			return s.config.NationalIDs.MaskOptions, true
+		}
+		if piiType == postalAddressType {
+			return s.config.PostalAddresses.MaskOptions, true
+		}
+	case "keyword_based":
+		if pattern, ok := modes.KeywordBased.Patterns[piiType]; ok {
+			return pattern.MaskOptions, true
+		}
+	}
+	return MaskOptions{}, false
+}
+
+// recoverRawValue finds the raw value behind a finding's masked value. It
+// succeeds only if exactly one distinct value fits the mask.
+func recoverRawValue(doc db.UserAPIData, finding db.PIIFinding) (string, bool) {
+	candidates := rawCandidates(doc, finding)
+	if len(candidates) != 1 {
+		return "", false
+	}
+	return candidates[0], true
+}
+
+// rawCandidates returns the distinct values that a finding's masked value
+// could have been produced from. It looks in the finding's field, or the whole
+// location when the finding has no field.
+func rawCandidates(doc db.UserAPIData, finding db.PIIFinding) []string {
+	masked := []rune(finding.DetectedValue)
+	if len(masked) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	var candidates []string
+	for _, text := range locationValues(doc, finding.Location, finding.FieldName) {
+		runes := []rune(text)
+		for start := 0; start+len(masked) <= len(runes); start++ {
+			window := runes[start : start+len(masked)]
+			if !fitsMask(masked, window) || seen[string(window)] {
+				continue
+			}
+			seen[string(window)] = true
+			candidates = append(candidates, string(window))
+		}
+	}
+	return candidates
+}
+
+// fitsMask reports whether masking raw could have produced masked under any
+// strategy: '*' hides any character, '#' a digit and 'x' a letter, and every
+// other character is revealed as is.
+func fitsMask(masked, raw []rune) bool {
+	for i, m := range masked {
+		r := raw[i]
+		switch {
+		case m == r, m == '*':
+		case m == '#' && unicode.IsDigit(r):
+		case m == 'x' && unicode.IsLetter(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// locationValues returns the strings stored at a finding location, restricted
+// to fieldName when it is set.
+func locationValues(doc db.UserAPIData, location, fieldName string) []string {
+	switch location {
+	case "request_headers", "response_headers", "request_trailers", "response_trailers":
+		headers := doc.RequestHeaders
+		switch location {
+		case "response_headers":
+			headers = doc.ResponseHeaders
+		case "request_trailers":
+			headers = doc.RequestTrailers
+		case "response_trailers":
+			headers = doc.ResponseTrailers
+		}
+		var values []string
+		for name, value := range headers {
+			switch {
+			case fieldName == contentDispositionField:
+				if strings.EqualFold(name, "Content-Disposition") {
+					values = append(values, filenameSeparators.Replace(contentDispositionFilename(value)))
+				}
+			case fieldName == "" || strings.EqualFold(name, fieldName):
+				values = append(values, value)
+			case strings.EqualFold(name, "cookie") || strings.EqualFold(name, "set-cookie"):
+				// Session ids are reported under the cookie's name.
+				values = append(values, value)
+			}
+		}
+		return values
+	case "request_body", "response_body":
+		body := doc.RequestBody
+		if location == "response_body" {
+			body = doc.ResponseBody
+		}
+		var values []string
+		collectBodyValues(body, "", func(path, value string) {
+			if fieldName == "" || path == fieldName {
+				values = append(values, value)
+			}
+		})
+		return values
+	case "url_path", "query_params", "url_fragment", "matrix_param":
+		return []string{decodedURL(doc.URL)}
+	case "pseudo_path":
+		if pseudoURL := pseudoHeaderURL(doc.RequestHeaders); pseudoURL != "" {
+			return []string{decodedURL(pseudoURL)}
+		}
+		return nil
+	case "referer", "redirect_url":
+		headers, headerName := doc.RequestHeaders, "Referer"
+		if location == "redirect_url" {
+			headers, headerName = doc.ResponseHeaders, "Location"
+		}
+		var values []string
+		for name, value := range headers {
+			if strings.EqualFold(name, headerName) {
+				values = append(values, decodedURL(value))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func decodedURL(rawURL string) string {
+	decoded, err := url.QueryUnescape(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return decoded
+}
+
+// collectBodyValues calls visit with each string in a stored body and its
+// JSON path, as reported in finding field names. Strings holding JSON are
+// decoded like analysis does; other top-level strings are visited whole
+// under an empty path.
+func collectBodyValues(body interface{}, prefix string, visit func(path, value string)) {
+	// A string body holding JSON doesn't count toward the embedded depth.
+	if text, ok := body.(string); ok {
+		if decoded, ok := decodeEmbeddedJSON(text); ok {
+			body = decoded
+		}
+	}
+	collectBodyValuesDepth(body, prefix, 0, visit)
+}
+
+func collectBodyValuesDepth(body interface{}, prefix string, decodes int, visit func(path, value string)) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := body.(type) {
+	case string:
+		if decodes < maxEmbeddedJSONDepth {
+			if decoded, ok := decodeEmbeddedJSON(v); ok {
+				collectBodyValuesDepth(decoded, prefix, decodes+1, visit)
+				return
+			}
+		}
+		visit(prefix, v)
+	case map[string]interface{}:
+		for key, item := range v {
+			collectBodyValuesDepth(item, join(key), decodes, visit)
+		}
+	case primitive.D:
+		for _, e := range v {
+			collectBodyValuesDepth(e.Value, join(e.Key), decodes, visit)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectBodyValuesDepth(item, fmt.Sprintf("%s[%d]", prefix, i), decodes, visit)
+		}
+	case primitive.A:
+		for i, item := range v {
+			collectBodyValuesDepth(item, fmt.Sprintf("%s[%d]", prefix, i), decodes, visit)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestRemaskDocument(t *testing.T) {
+	const email = "jane.doe@example.com"
+	tests := []struct {
+		name string
+		// strategy is the EMAIL mask strategy switched to after analysis.
+		strategy string
+		edit     func(*db.UserAPIData)
+		want     RemaskResult
+	}{
+		{
+			name:     "raw value retained",
+			strategy: maskStrategyFormatPreserving,
+			want:     RemaskResult{Remasked: 1},
+		},
+		{
+			name:     "raw value not retained",
+			strategy: maskStrategyFormatPreserving,
+			edit:     func(doc *db.UserAPIData) { doc.RequestBody = nil },
+			want:     RemaskResult{Skipped: 1},
+		},
+		{
+			name:     "raw value ambiguous",
+			strategy: maskStrategyFormatPreserving,
+			// Two raw values now fit the old mask of the field.
+			edit: func(doc *db.UserAPIData) { doc.RequestBody = `{"email":"` + email + ` jane.dof@example.com"}` },
+			want: RemaskResult{Skipped: 1},
+		},
+		{
+			name: "mask unchanged",
+			want: RemaskResult{Unchanged: 1},
+		},
+		{
+			name:     "pattern no longer configured",
+			strategy: maskStrategyFormatPreserving,
+			edit:     func(doc *db.UserAPIData) { doc.PIIFindings[0].PIIType = "RETIRED_TYPE" },
+			want:     RemaskResult{Skipped: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			doc := db.UserAPIData{
+				APIEndpoint: "/api/users",
+				Method:      "POST",
+				URL:         "https://api.example.com/api/users",
+				RequestBody: `{"email":"` + email + `"}`,
+			}
+			(&IngestPipeline{piiService: s}).enrichUserAPIData(&doc, s.AnalyzePIIInAPIData(context.Background(), doc))
+			if len(doc.PIIFindings) != 1 || doc.PIIFindings[0].PIIType != "EMAIL" {
+				t.Fatalf("findings = %+v, want one EMAIL", doc.PIIFindings)
+			}
+			if tt.edit != nil {
+				tt.edit(&doc)
+			}
+			if tt.strategy != "" {
+				pattern := s.config.DetectionModes.FieldBased.Patterns["EMAIL"]
+				pattern.MaskStrategy = tt.strategy
+				s.config.DetectionModes.FieldBased.Patterns["EMAIL"] = pattern
+			}
+			before := doc.PIIFindings[0]
+
+			var result RemaskResult
+			changed := s.remaskDocument(&doc, &result)
+			if result != tt.want {
+				t.Fatalf("result = %+v, want %+v", result, tt.want)
+			}
+			after := doc.PIIFindings[0]
+			if changed != (tt.want.Remasked > 0) {
+				t.Errorf("changed = %v, want %v", changed, tt.want.Remasked > 0)
+			}
+			if tt.want.Remasked == 0 {
+				if after.DetectedValue != before.DetectedValue || after.ID != before.ID {
+					t.Errorf("finding changed from %+v to %+v", before, after)
+				}
+				return
+			}
+			wantValue := s.maskValue(email, MaskOptions{MaskStrategy: tt.strategy})
+			if after.DetectedValue != wantValue {
+				t.Errorf("DetectedValue = %q, want %q", after.DetectedValue, wantValue)
+			}
+			// Ids are keyed on the raw value, so a new mask keeps them.
+			if after.ID != before.ID {
+				t.Errorf("finding id changed from %s to %s with the mask", before.ID, after.ID)
+			}
+		})
+	}
+}
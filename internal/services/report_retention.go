@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	reportPeriodWeek  = "week"
+	reportPeriodMonth = "month"
+)
+
+// ReportRetentionPolicy keeps daily reports for DailyDays, then rolls them
+// up into weekly summaries; weekly summaries are kept for WeeklyDays, then
+// rolled up into monthly ones, which are kept.
+type ReportRetentionPolicy struct {
+	DailyDays  int `json:"daily_days"`
+	WeeklyDays int `json:"weekly_days"`
+}
+
+// ReportRetentionPolicyFromEnv reads REPORT_DAILY_RETENTION_DAYS (default 30)
+// and REPORT_WEEKLY_RETENTION_DAYS (default 180).
+func ReportRetentionPolicyFromEnv() ReportRetentionPolicy {
+	policy := ReportRetentionPolicy{
+		DailyDays:  envInt("REPORT_DAILY_RETENTION_DAYS", 30),
+		WeeklyDays: envInt("REPORT_WEEKLY_RETENTION_DAYS", 180),
+	}
+	policy.WeeklyDays = max(policy.WeeklyDays, policy.DailyDays)
+	return policy
+}
+
+// ReportRetentionResult counts the rollups written, the reports they
+// summarize, and the stored reports and older rollups they replaced.
+type ReportRetentionResult struct {
+	Policy   ReportRetentionPolicy `json:"policy"`
+	Weekly   int                   `json:"weekly"`
+	Monthly  int                   `json:"monthly"`
+	RolledUp int                   `json:"rolled_up"`
+	Deleted  int64                 `json:"deleted"`
+}
+
+type reportRollup struct {
+	period  string
+	start   time.Time
+	members []db.PIIAnalysisReport
+}
+
+// ApplyReportRetention rolls up the stored reports older than the policy
+// allows. Reports hold point-in-time totals, so a rollup carries the figures
+// of the newest report in its period, with RolledUp counting the reports it
+// stands for. The latest report is never rolled up.
+func (s *PIIService) ApplyReportRetention(ctx context.Context, policy ReportRetentionPolicy) (ReportRetentionResult, error) {
+	result := ReportRetentionResult{Policy: policy}
+	now := time.Now()
+	reports, err := s.db.FindPIIAnalysisReportsBefore(ctx, now.AddDate(0, 0, -policy.DailyDays))
+	if err != nil {
+		return result, err
+	}
+	latest, err := s.db.FindLatestPIIAnalysisReport()
+	if err != nil {
+		return result, err
+	}
+	latestID := primitive.NilObjectID
+	if latest != nil {
+		latestID = latest.ID
+	}
+
+	for _, rollup := range planReportRollups(reports, latestID, policy, now) {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		newest := rollup.members[len(rollup.members)-1]
+		summary := newest
+		summary.ID = primitive.NewObjectID()
+		summary.ReportDate = rollup.start
+		summary.Period = rollup.period
+		summary.RolledUp = 0
+		ids := make([]primitive.ObjectID, len(rollup.members))
+		for i, member := range rollup.members {
+			summary.RolledUp += max(member.RolledUp, 1)
+			ids[i] = member.ID
+		}
+		deleted, err := s.db.ReplacePIIAnalysisReports(ctx, summary, ids)
+		if err != nil {
+			return result, err
+		}
+		if rollup.period == reportPeriodWeek {
+			result.Weekly++
+		} else {
+			result.Monthly++
+		}
+		result.RolledUp += summary.RolledUp
+		result.Deleted += deleted
+	}
+	return result, nil
+}
+
+// planReportRollups groups the reports due for a rollup by the week or month
+// they move into. An existing rollup of that period joins its group, so
+// running the retention again extends it instead of adding a second one.
+// Members are ordered by creation, so the newest report comes last.
+func planReportRollups(reports []db.PIIAnalysisReport, latestID primitive.ObjectID, policy ReportRetentionPolicy, now time.Time) []reportRollup {
+	dailyCutoff := now.AddDate(0, 0, -policy.DailyDays)
+	weeklyCutoff := now.AddDate(0, 0, -policy.WeeklyDays)
+	loc := ReportLocation()
+
+	type key struct {
+		period string
+		start  int64
+	}
+	groups := map[key]*reportRollup{}
+	existing := map[key]db.PIIAnalysisReport{}
+	for _, report := range reports {
+		if report.ID == latestID {
+			continue
+		}
+		period := ""
+		switch {
+		case report.Period == reportPeriodMonth:
+		case report.ReportDate.Before(weeklyCutoff):
+			period = reportPeriodMonth
+		case report.Period == "" && report.ReportDate.Before(dailyCutoff):
+			period = reportPeriodWeek
+		}
+		if period == "" || period == report.Period {
+			if report.Period != "" {
+				existing[key{report.Period, report.ReportDate.Unix()}] = report
+			}
+			continue
+		}
+		start := reportPeriodStart(period, report.ReportDate.In(loc))
+		k := key{period, start.Unix()}
+		if groups[k] == nil {
+			groups[k] = &reportRollup{period: period, start: start}
+		}
+		groups[k].members = append(groups[k].members, report)
+	}
+
+	rollups := make([]reportRollup, 0, len(groups))
+	for k, group := range groups {
+		if report, ok := existing[k]; ok {
+			group.members = append(group.members, report)
+		}
+		sort.Slice(group.members, func(i, j int) bool {
+			return group.members[i].CreatedAt.Before(group.members[j].CreatedAt)
+		})
+		rollups = append(rollups, *group)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].start.Before(rollups[j].start) })
+	return rollups
+}
+
+// reportPeriodStart returns the local midnight starting the week (from
+// Monday) or month of t.
+func reportPeriodStart(period string, t time.Time) time.Time {
+	if period == reportPeriodMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// dailyReports returns a report at noon UTC of each day from first to
+// last, oldest first.
+func dailyReports(first, last time.Time) []db.PIIAnalysisReport {
+	var reports []db.PIIAnalysisReport
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		noon := day.Add(12 * time.Hour)
+		reports = append(reports, db.PIIAnalysisReport{ID: primitive.NewObjectID(), ReportDate: noon, CreatedAt: noon, TotalPIIFindings: day.YearDay()})
+	}
+	return reports
+}
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPlanReportRollups(t *testing.T) {
+	now := utcDate(2024, time.July, 1)
+	policy := ReportRetentionPolicy{DailyDays: 30, WeeklyDays: 180}
+	existingWeek := db.PIIAnalysisReport{ID: primitive.NewObjectID(), ReportDate: utcDate(2024, time.May, 20), CreatedAt: utcDate(2024, time.May, 27), Period: reportPeriodWeek, RolledUp: 5}
+	tests := []struct {
+		name         string
+		reports      []db.PIIAnalysisReport
+		wantWeekly   int
+		wantMonthly  int
+		wantRolledUp int
+		wantLeft     int
+	}{
+		{
+			// January 1 and 2 are past the weekly cutoff of January 3 and
+			// move into a monthly rollup. January 3 to May 31 fall in the
+			// 22 weeks starting on the Mondays from January 1 to May 27.
+			// June is kept daily.
+			name:         "six months of daily reports",
+			reports:      dailyReports(utcDate(2024, time.January, 1), utcDate(2024, time.June, 30)),
+			wantWeekly:   22,
+			wantMonthly:  1,
+			wantRolledUp: 152,
+			wantLeft:     30 + 22 + 1,
+		},
+		{
+			name:         "latest report is kept",
+			reports:      dailyReports(utcDate(2024, time.January, 1), utcDate(2024, time.January, 14)),
+			wantWeekly:   2,
+			wantMonthly:  1,
+			wantRolledUp: 13,
+			wantLeft:     3 + 1,
+		},
+		{
+			// May 25 and 26 join the rollup of the week of May 20, and May
+			// 27 to 31 start the week of May 27.
+			name:         "existing weekly rollup is extended",
+			reports:      append([]db.PIIAnalysisReport{existingWeek}, dailyReports(utcDate(2024, time.May, 25), utcDate(2024, time.June, 30))...),
+			wantWeekly:   2,
+			wantRolledUp: 5 + 2 + 5,
+			wantLeft:     30 + 2,
+		},
+		{
+			name:    "recent reports stay daily",
+			reports: dailyReports(utcDate(2024, time.June, 1), utcDate(2024, time.June, 30)),
+			// Nothing is old enough to roll up.
+			wantLeft: 30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latestID := tt.reports[len(tt.reports)-1].ID
+			rollups := planReportRollups(tt.reports, latestID, policy, now)
+
+			weekly, monthly, rolledUp, members := 0, 0, 0, 0
+			type periodStart struct {
+				period string
+				start  time.Time
+			}
+			seen := map[periodStart]bool{}
+			for _, rollup := range rollups {
+				if rollup.period == reportPeriodWeek {
+					weekly++
+				} else {
+					monthly++
+				}
+				k := periodStart{rollup.period, rollup.start}
+				if seen[k] {
+					t.Errorf("two %s rollups start on %v", rollup.period, rollup.start)
+				}
+				seen[k] = true
+				for _, member := range rollup.members {
+					if member.ID == latestID {
+						t.Errorf("latest report rolled up into the %s of %v", rollup.period, rollup.start)
+					}
+					rolledUp += max(member.RolledUp, 1)
+				}
+				members += len(rollup.members)
+			}
+			if weekly != tt.wantWeekly || monthly != tt.wantMonthly {
+				t.Errorf("planned %d weekly and %d monthly rollups, want %d and %d", weekly, monthly, tt.wantWeekly, tt.wantMonthly)
+			}
+			if rolledUp != tt.wantRolledUp {
+				t.Errorf("rollups stand for %d reports, want %d", rolledUp, tt.wantRolledUp)
+			}
+			if left := len(tt.reports) - members + len(rollups); left != tt.wantLeft {
+				t.Errorf("%d reports left after the rollup, want %d", left, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestApplyReportRetention(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("daily reports past the policy", func(mt *mtest.T) {
+		policy := ReportRetentionPolicy{DailyDays: 30, WeeklyDays: 180}
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		old := dailyReports(today.AddDate(0, 0, -60), today.AddDate(0, 0, -40))
+		latest := dailyReports(today, today)[0]
+
+		// The old reports fall into the weeks they started in.
+		weeks := map[time.Time]int{}
+		for _, report := range old {
+			weeks[reportPeriodStart(reportPeriodWeek, report.ReportDate.In(ReportLocation()))]++
+		}
+		docs := make([]bson.D, len(old))
+		for i, report := range old {
+			docs[i] = toBSONDoc(mt.T, report)
+		}
+		responses := []bson.D{
+			mtest.CreateCursorResponse(0, "raven.pii_analysis_reports", mtest.FirstBatch, docs...),
+			mtest.CreateCursorResponse(0, "raven.pii_analysis_reports", mtest.FirstBatch, toBSONDoc(mt.T, latest)),
+		}
+		for range weeks {
+			responses = append(responses,
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 7}),
+			)
+		}
+		mt.AddMockResponses(responses...)
+		s := newTestPIIService(mt)
+		s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+		result, err := s.ApplyReportRetention(context.Background(), policy)
+		if err != nil {
+			mt.Fatalf("ApplyReportRetention: %v", err)
+		}
+		if result.Weekly != len(weeks) || result.Monthly != 0 {
+			mt.Errorf("wrote %d weekly and %d monthly rollups, want %d weekly", result.Weekly, result.Monthly, len(weeks))
+		}
+		if result.RolledUp != len(old) {
+			mt.Errorf("rolled up %d reports, want %d", result.RolledUp, len(old))
+		}
+		if want := int64(7 * len(weeks)); result.Deleted != want {
+			mt.Errorf("deleted %d reports, want %d", result.Deleted, want)
+		}
+
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName != "insert" {
+				continue
+			}
+			var rollup db.PIIAnalysisReport
+			if err := bson.Unmarshal(event.Command.Lookup("documents").Array().Index(0).Value().Document(), &rollup); err != nil {
+				mt.Fatalf("decoding rollup: %v", err)
+			}
+			members, ok := weeks[rollup.ReportDate]
+			if !ok || rollup.Period != reportPeriodWeek {
+				mt.Errorf("rollup %s of %v, want a week starting %v", rollup.Period, rollup.ReportDate, weeks)
+				continue
+			}
+			if rollup.RolledUp != members {
+				mt.Errorf("rollup of %v stands for %d reports, want %d", rollup.ReportDate, rollup.RolledUp, members)
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"strings"
+	"sync"
+)
+
+// ResponseSamplingRule analyzes 1 in Rate response bodies of the endpoints
+// it matches.
+type ResponseSamplingRule struct {
+	EndpointRule
+	Rate int `json:"rate"`
+}
+
+// responseSamplingRule returns the first response_sampling rule matching an
+// endpoint, if any.
+func (s *PIIService) responseSamplingRule(apiEndpoint, method string) (ResponseSamplingRule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rule := range s.config.ResponseSampling {
+		if rule.matches(apiEndpoint, method) {
+			return rule, true
+		}
+	}
+	return ResponseSamplingRule{}, false
+}
+
+// responseSampler decides which response bodies of sampled endpoints are
+// analyzed. Documents are counted per rule, not per endpoint: a rule such as
+// /users/* covers one endpoint per id, and counting each of those apart
+// would analyze every first, and so nearly every, response.
+type responseSampler struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func newResponseSampler() *responseSampler {
+	return &responseSampler{counts: make(map[string]uint64)}
+}
+
+// analyze reports whether this document's response body is analyzed under
+// rule: the first of every rate documents the rule matches is, starting with
+// the first one seen.
+func (r *responseSampler) analyze(rule ResponseSamplingRule) bool {
+	if rule.Rate <= 1 {
+		return true
+	}
+	key := strings.ToUpper(rule.Method) + " " + rule.Endpoint
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.counts[key]
+	r.counts[key] = n + 1
+	return n%uint64(rule.Rate) == 0
+}
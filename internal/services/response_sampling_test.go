@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestSampleResponse(t *testing.T) {
+	rules := []ResponseSamplingRule{
+		{EndpointRule: EndpointRule{Endpoint: "/users/*", Method: "GET"}, Rate: 10},
+		{EndpointRule: EndpointRule{Endpoint: "/orders/*"}, Rate: 4},
+		{EndpointRule: EndpointRule{Endpoint: "/health"}, Rate: 1},
+	}
+	tests := []struct {
+		name      string
+		endpoints func(i int) (endpoint, method string)
+		documents int
+		want      int
+	}{
+		{
+			name:      "1 in 10 across ids",
+			endpoints: func(i int) (string, string) { return fmt.Sprintf("/users/%d", i), "GET" },
+			documents: 100,
+			want:      10,
+		},
+		{
+			name:      "1 in 10 on one endpoint",
+			endpoints: func(int) (string, string) { return "/users/42", "GET" },
+			documents: 100,
+			want:      10,
+		},
+		{
+			name:      "rule covers every method",
+			endpoints: func(i int) (string, string) { return fmt.Sprintf("/orders/%d", i), []string{"GET", "POST"}[i%2] },
+			documents: 40,
+			want:      10,
+		},
+		{
+			name:      "rate 1",
+			endpoints: func(int) (string, string) { return "/health", "GET" },
+			documents: 20,
+			want:      20,
+		},
+		{
+			name:      "method not matched",
+			endpoints: func(i int) (string, string) { return fmt.Sprintf("/users/%d", i), "POST" },
+			documents: 20,
+			want:      20,
+		},
+		{
+			name:      "no rule",
+			endpoints: func(i int) (string, string) { return fmt.Sprintf("/accounts/%d", i), "GET" },
+			documents: 20,
+			want:      20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.config.ResponseSampling = rules
+			p := &IngestPipeline{piiService: s, sampler: newResponseSampler()}
+			analyzed := 0
+			for i := 0; i < tt.documents; i++ {
+				endpoint, method := tt.endpoints(i)
+				if p.sampleResponse(db.UserAPIData{APIEndpoint: endpoint, Method: method}) {
+					analyzed++
+				}
+			}
+			if analyzed != tt.want {
+				t.Errorf("analyzed %d of %d response bodies, want %d", analyzed, tt.documents, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import "sort"
+
+// noRiskLevel is the highest risk of a document without findings. It is not
+// a configurable level.
+const noRiskLevel = "NONE"
+
+// RiskLevelsByWeight returns the configured risk levels from the highest
+// weight to the lowest. Levels of equal weight are ordered by name.
+func (s *PIIService) RiskLevelsByWeight() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return rankedRiskLevels(s.config.RiskLevels)
+}
+
+func rankedRiskLevels(levels map[string]int) []string {
+	ranked := make([]string, 0, len(levels))
+	for level := range levels {
+		ranked = append(ranked, level)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if levels[ranked[i]] != levels[ranked[j]] {
+			return levels[ranked[i]] > levels[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	return ranked
+}
+
+// lowestRiskLevel returns the configured level with the lowest weight, the
+// highest risk reported for findings whose own level isn't configured.
+func lowestRiskLevel(levels map[string]int) string {
+	lowest := ""
+	for level, weight := range levels {
+		if lowest == "" || weight < levels[lowest] || (weight == levels[lowest] && level < lowest) {
+			lowest = level
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCustomRiskLevels(t *testing.T) {
+	levels := map[string]int{"SEVERE": 20, "ELEVATED": 8, "NOTICE": 3, "INFO": 1}
+	s := newTestPIIService(t)
+	s.config.RiskLevels = levels
+
+	if got, want := s.RiskLevelsByWeight(), []string{"SEVERE", "ELEVATED", "NOTICE", "INFO"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RiskLevelsByWeight() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name        string
+		findings    []string // risk levels
+		wantScore   int
+		wantHighest string
+	}{
+		{name: "no findings", wantHighest: noRiskLevel},
+		{name: "highest custom level", findings: []string{"INFO", "SEVERE", "ELEVATED"}, wantScore: 29, wantHighest: "SEVERE"},
+		{name: "lowest custom level", findings: []string{"INFO", "INFO"}, wantScore: 2, wantHighest: "INFO"},
+		{name: "default names aren't configured", findings: []string{"CRITICAL", "LOW"}, wantHighest: "INFO"},
+		{name: "unknown level among known", findings: []string{"CRITICAL", "NOTICE"}, wantScore: 3, wantHighest: "NOTICE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := make([]PIIDetectionResult, len(tt.findings))
+			for i, level := range tt.findings {
+				findings[i] = PIIDetectionResult{PIIType: "TEST", RiskLevel: level}
+			}
+			score, highest := s.calculateRiskMetrics(findings)
+			if score != tt.wantScore || highest != tt.wantHighest {
+				t.Errorf("calculateRiskMetrics() = %d, %q, want %d, %q", score, highest, tt.wantScore, tt.wantHighest)
+			}
+		})
+	}
+}
+
+func TestImportedRiskLevels(t *testing.T) {
+	s := newTestPIIService(t)
+	exported, err := s.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rename the shipped levels to a custom set.
+	renamed := strings.NewReplacer(`"CRITICAL"`, `"SEVERE"`, `"HIGH"`, `"ELEVATED"`, `"MEDIUM"`, `"NOTICE"`, `"LOW"`, `"INFO"`).Replace(string(exported))
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "custom set", config: renamed},
+		{name: "pattern of an unknown level", config: strings.Replace(renamed, `"SEVERE": `, `"EXTREME": `, 1), wantErr: "unknown risk level 'SEVERE'"},
+		{name: "reserved name", config: strings.Replace(renamed, `"INFO": `, `"NONE": `, 1), wantErr: "reserved"},
+		{name: "zero weight", config: strings.Replace(renamed, `"ELEVATED": 3`, `"ELEVATED": 0`, 1), wantErr: "positive weight"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseImportedConfig([]byte(tt.config))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parseImportedConfig: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseImportedConfig error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+const sampleReanalysisBatchSize = 200
+
+// SampleReanalysisResult reports how re-analysis changed a random sample of
+// stored documents. Before and After total the sampled documents' findings
+// and risk; Population is the number of documents sampled from.
+type SampleReanalysisResult struct {
+	Percent       float64          `json:"percent"`
+	Population    int64            `json:"population"`
+	Sampled       int              `json:"sampled"`
+	Updated       int              `json:"updated"`
+	Failed        int              `json:"failed"`
+	RiskChanged   int              `json:"risk_changed"`
+	Before        SimulationTotals `json:"before"`
+	After         SimulationTotals `json:"after"`
+	FindingsDelta int              `json:"findings_delta"`
+	Added         int              `json:"added"`
+	Removed       int              `json:"removed"`
+}
+
+// ReanalyzeSample re-analyzes a random percent of the stored documents under
+// the running config and stores the results, to check a config change on
+// real data before re-analyzing everything. Batches run on the worker pool.
+func (p *IngestPipeline) ReanalyzeSample(ctx context.Context, percent float64) (SampleReanalysisResult, error) {
+	result := SampleReanalysisResult{
+		Percent: percent,
+		Before:  SimulationTotals{RiskLevels: map[string]int{}},
+		After:   SimulationTotals{RiskLevels: map[string]int{}},
+	}
+	population, err := p.mongo.SampleAPIData(ctx, percent, sampleReanalysisBatchSize, func(docs []db.UserAPIData) error {
+		updated := make([]db.UserAPIData, len(docs))
+		errs := make([]error, len(docs))
+		parallelEach(len(docs), func(i int) {
+			if errs[i] = ctx.Err(); errs[i] != nil {
+				return
+			}
+			updated[i], errs[i] = p.reanalyze(ctx, docs[i])
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for i, doc := range docs {
+			result.Sampled++
+			if errs[i] != nil {
+				log.Printf("Skipping sampled re-analysis of %s: %v", doc.ID.Hex(), errs[i])
+				result.Failed++
+				continue
+			}
+			result.Updated++
+			result.Before.addStored(doc)
+			result.After.addStored(updated[i])
+			if doc.HighestRisk != updated[i].HighestRisk {
+				result.RiskChanged++
+			}
+			result.Added += len(newFindings(doc.PIIFindings, updated[i].PIIFindings))
+			result.Removed += len(newFindings(updated[i].PIIFindings, doc.PIIFindings))
+		}
+		return nil
+	})
+	result.Population = population
+	result.FindingsDelta = result.After.Findings - result.Before.Findings
+	return result, err
+}
+
+func (t *SimulationTotals) addStored(doc db.UserAPIData) {
+	t.Findings += doc.PIICount
+	t.RiskScore += doc.RiskScore
+	t.RiskLevels[doc.HighestRisk]++
+}
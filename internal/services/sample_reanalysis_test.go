@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestReanalyzeSample(t *testing.T) {
+	const population = 400
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tests := []struct {
+		percent float64
+		want    int
+	}{
+		{percent: 10, want: 40},
+		{percent: 25, want: 100},
+		{percent: 2.5, want: 10},
+		{percent: 0.1, want: 1},
+		{percent: 100, want: population},
+	}
+	for _, tt := range tests {
+		mt.Run(fmt.Sprintf("%v percent", tt.percent), func(mt *mtest.T) {
+			// The database returns as many documents as $sample asks for;
+			// the test checks the size asked for.
+			sampled := make([]bson.D, tt.want)
+			for i := range sampled {
+				sampled[i] = toBSONDoc(mt.T, db.UserAPIData{ID: primitive.NewObjectID(), APIEndpoint: fmt.Sprintf("/items/%d", i), Method: "GET"})
+			}
+			responses := []bson.D{
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(population)}}),
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, sampled...),
+			}
+			for range sampled {
+				responses = append(responses, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+			}
+			mt.AddMockResponses(responses...)
+			p := &IngestPipeline{
+				piiService: newTestPIIService(mt),
+				mongo:      db.MongoInstance{Client: mt.Client, DB: mt.DB},
+				owners:     &OwnerResolver{},
+				sampler:    newResponseSampler(),
+			}
+
+			result, err := p.ReanalyzeSample(context.Background(), tt.percent)
+			if err != nil {
+				mt.Fatalf("ReanalyzeSample: %v", err)
+			}
+
+			sampleSize, updates := -1, 0
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				switch event.CommandName {
+				case "aggregate":
+					stages, _ := event.Command.Lookup("pipeline").Array().Values()
+					for _, stage := range stages {
+						if size, ok := stage.Document().Lookup("$sample", "size").AsInt64OK(); ok {
+							sampleSize = int(size)
+						}
+					}
+				case "update":
+					updates++
+				}
+			}
+			if sampleSize != tt.want {
+				mt.Errorf("$sample size = %d, want %d", sampleSize, tt.want)
+			}
+			if exact := population * tt.percent / 100; math.Abs(float64(sampleSize)-exact) >= 1 {
+				mt.Errorf("sampled %d of %d documents, not about %v%%", sampleSize, population, tt.percent)
+			}
+			if result.Population != population || result.Sampled != tt.want || result.Updated != tt.want || result.Failed != 0 {
+				mt.Errorf("result = %+v, want %d of %d sampled and updated", result, tt.want, population)
+			}
+			if updates != tt.want {
+				mt.Errorf("%d documents written, want %d", updates, tt.want)
+			}
+		})
+	}
+}
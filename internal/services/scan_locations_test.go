@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestScanLocations(t *testing.T) {
+	const email = "jane@example.com"
+	base := db.UserAPIData{APIEndpoint: "/api/users", Method: "POST", URL: "https://api.example.com/api/users"}
+	tests := []struct {
+		location string
+		apiData  func(db.UserAPIData) db.UserAPIData
+		// shared is set when another location scans the same data, so
+		// only findings under location are checked.
+		shared bool
+	}{
+		{location: "request_headers", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.RequestHeaders = map[string]string{"X-Email": email}
+			return d
+		}},
+		{location: "request_body", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.RequestBody = `{"email":"` + email + `"}`
+			return d
+		}},
+		{location: "response_body", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.ResponseBody = `{"email":"` + email + `"}`
+			return d
+		}},
+		{location: "query_params", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.URL = "https://api.example.com/api/users?email=" + email
+			return d
+		}},
+		{location: "url_path", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.URL = "https://api.example.com/api/users/123-45-6789"
+			return d
+		}},
+		{location: "referer", shared: true, apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.RequestHeaders = map[string]string{"Referer": "https://shop.example.com/checkout?email=" + email}
+			return d
+		}},
+		{location: "pseudo_path", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.RequestHeaders = map[string]string{":path": "/api/users?email=" + email}
+			return d
+		}},
+		{location: "request_trailers", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.RequestTrailers = map[string]string{"X-Email": email}
+			return d
+		}},
+		{location: "response_trailers", apiData: func(d db.UserAPIData) db.UserAPIData {
+			d.ResponseTrailers = map[string]string{"X-Email": email}
+			return d
+		}},
+	}
+	for _, tt := range tests {
+		for _, enabled := range []bool{true, false} {
+			name := tt.location + "/disabled"
+			if enabled {
+				name = tt.location + "/enabled"
+			}
+			t.Run(name, func(t *testing.T) {
+				s := newTestPIIService(t)
+				s.config.ScanLocations = map[string]bool{tt.location: enabled}
+				result := s.AnalyzePIIInAPIData(context.Background(), tt.apiData(base))
+				atLocation := 0
+				for _, f := range result.Findings {
+					if f.Location == tt.location {
+						atLocation++
+					}
+				}
+				if enabled {
+					if atLocation == 0 {
+						t.Fatalf("no findings at %s while enabled: %+v", tt.location, result.Findings)
+					}
+					return
+				}
+				if atLocation != 0 {
+					t.Errorf("%d findings at disabled location %s", atLocation, tt.location)
+				}
+				if tt.shared {
+					return
+				}
+				// Nothing else carries PII, so a scan of the disabled
+				// location would have recorded a match.
+				if len(result.Findings) != 0 {
+					t.Errorf("findings = %+v, want none", result.Findings)
+				}
+				if matches := s.PatternStats().Patterns; len(matches) != 0 {
+					t.Errorf("patterns matched at a disabled location: %+v", matches)
+				}
+			})
+		}
+	}
+}
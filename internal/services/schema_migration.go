@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const schemaMigrationBatchSize = 200
+
+// schemaMigration upgrades a document from the version before to version.
+type schemaMigration struct {
+	version int
+	migrate func(doc *db.UserAPIData)
+}
+
+// schemaMigrations are applied in order. Documents stored before versions
+// were stamped count as version 1. A new migration is appended here, and
+// SaveSchemaMigration stores any field it rewrites.
+var schemaMigrations = []schemaMigration{
+	{version: 2, migrate: migrateFindingIdentity},
+}
+
+// CurrentSchemaVersion is the schema version newly ingested documents are
+// stamped with.
+var CurrentSchemaVersion = schemaMigrations[len(schemaMigrations)-1].version
+
+// migrateFindingIdentity gives findings stored before ids and first-seen
+// times existed theirs.
+func migrateFindingIdentity(doc *db.UserAPIData) {
+	for i := range doc.PIIFindings {
+		f := &doc.PIIFindings[i]
+		if f.ID == "" {
+			f.ID = storedFindingID(*doc, *f)
+		}
+		if f.FirstSeen.IsZero() {
+			f.FirstSeen = f.Timestamp
+			if f.FirstSeen.IsZero() {
+				f.FirstSeen = doc.Timestamp
+			}
+		}
+	}
+}
+
+// migrateSchema applies the migrations a document is missing and reports
+// the version it started from.
+func migrateSchema(doc *db.UserAPIData) int {
+	from := max(doc.SchemaVersion, 1)
+	for _, m := range schemaMigrations {
+		if m.version > from {
+			m.migrate(doc)
+		}
+	}
+	doc.SchemaVersion = CurrentSchemaVersion
+	return from
+}
+
+// SchemaMigrationResult counts migrated documents by the version they were
+// migrated from.
+type SchemaMigrationResult struct {
+	Version  int         `json:"version"`
+	Migrated int         `json:"migrated"`
+	From     map[int]int `json:"from"`
+}
+
+// MigrateSchema upgrades every document below CurrentSchemaVersion in
+// batches on the worker pool. Documents migrate independently, so an
+// interrupted run is finished by running it again.
+func (s *PIIService) MigrateSchema(ctx context.Context) (SchemaMigrationResult, error) {
+	result := SchemaMigrationResult{Version: CurrentSchemaVersion, From: map[int]int{}}
+	afterID := primitive.NilObjectID
+	for {
+		docs, err := s.db.FindAPIDataBelowSchemaVersion(ctx, CurrentSchemaVersion, afterID, schemaMigrationBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(docs) == 0 {
+			return result, nil
+		}
+		afterID = docs[len(docs)-1].ID
+
+		froms := make([]int, len(docs))
+		errs := make([]error, len(docs))
+		parallelEach(len(docs), func(i int) {
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			froms[i] = migrateSchema(&docs[i])
+			errs[i] = s.db.SaveSchemaMigration(ctx, docs[i])
+		})
+		for i, err := range errs {
+			if err != nil {
+				return result, err
+			}
+			result.Migrated++
+			result.From[froms[i]]++
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMigrateSchema(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ingested := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	found := ingested.Add(-time.Hour)
+	tests := []struct {
+		name          string
+		finding       db.PIIFinding
+		wantFirstSeen time.Time
+	}{
+		{
+			name:          "finding with a timestamp",
+			finding:       db.PIIFinding{PIIType: "email", DetectedValue: "jo****@example.com", FieldName: "email", Location: "request_body", Timestamp: found},
+			wantFirstSeen: found,
+		},
+		{
+			name:          "finding without a timestamp",
+			finding:       db.PIIFinding{PIIType: "phone", DetectedValue: "******1234", FieldName: "phone", Location: "response_body"},
+			wantFirstSeen: ingested,
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			// A version 1 document: no schema version, and findings
+			// without ids or first-seen times.
+			v1 := db.UserAPIData{
+				ID:          primitive.NewObjectID(),
+				APIEndpoint: "/users",
+				Method:      "POST",
+				Timestamp:   ingested,
+				PIIFindings: []db.PIIFinding{tt.finding},
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, toBSONDoc(mt.T, v1)),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+				mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch),
+			)
+			s := newTestPIIService(mt)
+			s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+			result, err := s.MigrateSchema(context.Background())
+			if err != nil {
+				mt.Fatalf("MigrateSchema: %v", err)
+			}
+			if result.Version != CurrentSchemaVersion || result.Migrated != 1 || result.From[1] != 1 {
+				mt.Errorf("result = %+v, want one document migrated from version 1 to %d", result, CurrentSchemaVersion)
+			}
+
+			var set struct {
+				SchemaVersion int             `bson:"schema_version"`
+				PIIFindings   []db.PIIFinding `bson:"pii_findings"`
+			}
+			saved := false
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				if event.CommandName != "update" {
+					continue
+				}
+				update := event.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set")
+				if err := bson.Unmarshal(update.Document(), &set); err != nil {
+					mt.Fatalf("decoding $set: %v", err)
+				}
+				saved = true
+			}
+			if !saved {
+				mt.Fatal("migrated document was not saved")
+			}
+			if set.SchemaVersion != CurrentSchemaVersion {
+				mt.Errorf("saved schema_version = %d, want %d", set.SchemaVersion, CurrentSchemaVersion)
+			}
+			if len(set.PIIFindings) != 1 {
+				mt.Fatalf("saved %d findings, want 1", len(set.PIIFindings))
+			}
+			got := set.PIIFindings[0]
+			wantID := findingID(PIIDetectionResult{PIIType: tt.finding.PIIType, Location: tt.finding.Location, FieldName: tt.finding.FieldName, DetectedValue: tt.finding.DetectedValue})
+			if got.ID != wantID {
+				mt.Errorf("finding id = %q, want %q", got.ID, wantID)
+			}
+			if !got.FirstSeen.Equal(tt.wantFirstSeen) {
+				mt.Errorf("first seen = %v, want %v", got.FirstSeen, tt.wantFirstSeen)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionIDConfig drives session identifier detection in cookies and headers.
+// Known names are always flagged; other cookies are flagged only when their
+// value is long and random enough to be an opaque token.
+type SessionIDConfig struct {
+	MaskOptions
+
+	Names            []string `json:"names"`
+	MinLength        int      `json:"minLength"`
+	MinEntropy       float64  `json:"minEntropy"`
+	HeuristicEnabled bool     `json:"heuristicEnabled"`
+	RiskLevel        string   `json:"riskLevel"`
+	Category         string   `json:"category"`
+	Tags             []string `json:"tags"`
+	Frameworks       []string `json:"frameworks,omitempty"`
+}
+
+const heuristicSessionConfidence = 0.5
+
+// detectSessionIDs checks a header for session identifiers: Cookie and
+// Set-Cookie headers are split into their cookies, other headers are checked
+// by name only.
+func (s *PIIService) detectSessionIDs(headerName, headerValue, location string) []PIIDetectionResult {
+	cfg := s.config.SessionIDs
+	if len(cfg.Names) == 0 && !cfg.HeuristicEnabled {
+		return nil
+	}
+	var findings []PIIDetectionResult
+	switch strings.ToLower(headerName) {
+	case "cookie":
+		cookies, err := http.ParseCookie(headerValue)
+		if err != nil {
+			return nil
+		}
+		for _, cookie := range cookies {
+			findings = append(findings, s.checkSessionValue(cookie.Name, cookie.Value, location, true)...)
+		}
+	case "set-cookie":
+		cookie, err := http.ParseSetCookie(headerValue)
+		if err != nil {
+			return nil
+		}
+		findings = append(findings, s.checkSessionValue(cookie.Name, cookie.Value, location, true)...)
+	default:
+		findings = append(findings, s.checkSessionValue(headerName, headerValue, location, false)...)
+	}
+	return findings
+}
+
+func (s *PIIService) checkSessionValue(name, value, location string, isCookie bool) []PIIDetectionResult {
+	cfg := s.config.SessionIDs
+	if value == "" {
+		return nil
+	}
+	confidence := 0.0
+	tags := cfg.Tags
+	switch {
+	case isKnownSessionName(name, cfg.Names):
+	case isCookie && cfg.HeuristicEnabled && looksLikeOpaqueToken(value, cfg.MinLength, cfg.MinEntropy):
+		confidence = heuristicSessionConfidence
+		tags = append(append([]string{}, cfg.Tags...), "HEURISTIC")
+	default:
+		return nil
+	}
+	s.stats.record("field_based", "SESSION_ID")
+	return []PIIDetectionResult{{
+		PIIType:       "SESSION_ID",
+		DetectedValue: s.maskValue(value, cfg.MaskOptions),
+		rawValue:      value,
+		FieldName:     name,
+		Location:      location,
+		DetectionMode: "field_based",
+		RiskLevel:     cfg.RiskLevel,
+		Category:      cfg.Category,
+		Tags:          tags,
+		Frameworks:    cfg.Frameworks,
+		Confidence:    confidence,
+		Timestamp:     time.Now(),
+	}}
+}
+
+func isKnownSessionName(name string, names []string) bool {
+	for _, known := range names {
+		if strings.EqualFold(name, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeOpaqueToken reports whether a value is long, made only of token
+// characters, and random enough (Shannon entropy in bits per character).
+func looksLikeOpaqueToken(value string, minLength int, minEntropy float64) bool {
+	if len(value) < minLength {
+		return false
+	}
+	counts := make(map[rune]int)
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("+/=_-.%", r)) {
+			return false
+		}
+		counts[r]++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(value))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectSessionIDs(t *testing.T) {
+	s := newTestPIIService(t)
+	const opaque = "Qm9vM2tYc1pQd2x4R2hOa0V6cVJ2VDU"
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		want      []string
+		heuristic bool
+	}{
+		{name: "named cookie", header: "Cookie", value: "theme=dark; JSESSIONID=ABC123", want: []string{"JSESSIONID"}},
+		{name: "named cookie in any case", header: "Cookie", value: "phpsessid=r2t5uvjq435r4q7ib3vtdjq120", want: []string{"phpsessid"}},
+		{name: "named set-cookie", header: "Set-Cookie", value: "connect.sid=s%3Aabc.def; Path=/; HttpOnly", want: []string{"connect.sid"}},
+		{name: "named header", header: "X-Session-Token", value: "abc123", want: []string{"X-Session-Token"}},
+		{name: "opaque cookie", header: "Cookie", value: "theme=dark; sid_v2=" + opaque, want: []string{"sid_v2"}, heuristic: true},
+		{name: "short cookie", header: "Cookie", value: "theme=dark; lang=en-US", want: nil},
+		{name: "long low-entropy cookie", header: "Cookie", value: "pref=aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", want: nil},
+		{name: "opaque value in another header", header: "X-Request-Id", value: opaque, want: nil},
+		{name: "empty named cookie", header: "Cookie", value: "JSESSIONID=", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := s.detectSessionIDs(tt.header, tt.value, "request_headers")
+			var names []string
+			for _, f := range findings {
+				names = append(names, f.FieldName)
+				if f.PIIType != "SESSION_ID" {
+					t.Errorf("finding type %s, want SESSION_ID", f.PIIType)
+				}
+				if heuristic := slices.Contains(f.Tags, "HEURISTIC"); heuristic != tt.heuristic {
+					t.Errorf("HEURISTIC tag = %v, want %v", heuristic, tt.heuristic)
+				}
+				if tt.heuristic && f.Confidence != heuristicSessionConfidence {
+					t.Errorf("confidence = %v, want %v", f.Confidence, heuristicSessionConfidence)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("session ids in %q = %v, want %v", tt.value, names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultSimulationSample = 200
+	MaxSimulationSample     = 1000
+
+	// maxSimulationChanges caps the documents listed in a simulation result.
+	maxSimulationChanges = 100
+)
+
+// SimulationTotals summarizes the analysis of the sampled documents under
+// one config. RiskLevels counts documents by their highest risk.
+type SimulationTotals struct {
+	Findings   int            `json:"findings"`
+	RiskScore  int            `json:"risk_score"`
+	RiskLevels map[string]int `json:"risk_levels"`
+}
+
+// SimulatedFinding identifies a finding gained or lost under the proposed config.
+type SimulatedFinding struct {
+	PIIType   string `json:"pii_type"`
+	Location  string `json:"location"`
+	FieldName string `json:"field_name,omitempty"`
+	RiskLevel string `json:"risk_level"`
+}
+
+// SimulatedDocument is a sampled document whose findings change.
+type SimulatedDocument struct {
+	ID               string             `json:"id"`
+	APIEndpoint      string             `json:"api_endpoint"`
+	Method           string             `json:"method"`
+	CurrentRisk      string             `json:"current_risk"`
+	ProposedRisk     string             `json:"proposed_risk"`
+	CurrentFindings  int                `json:"current_findings"`
+	ProposedFindings int                `json:"proposed_findings"`
+	Gained           []SimulatedFinding `json:"gained,omitempty"`
+	Lost             []SimulatedFinding `json:"lost,omitempty"`
+}
+
+type SimulationResult struct {
+	Documents        int                 `json:"documents"`
+	Current          SimulationTotals    `json:"current"`
+	Proposed         SimulationTotals    `json:"proposed"`
+	FindingsDelta    int                 `json:"findings_delta"`
+	ChangedCount     int                 `json:"changed_count"`
+	Changed          []SimulatedDocument `json:"changed"`
+	ChangedTruncated bool                `json:"changed_truncated,omitempty"`
+}
+
+// Simulate analyzes the sampleSize most recent stored documents under both
+// the running config and a proposed one, and reports how the results differ.
+// The proposed config is JSON merged over the config file, so it can be a
+// whole config or just the patterns to add or replace; with replace it is
+// used on its own. Nothing is written.
+func (s *PIIService) Simulate(ctx context.Context, proposed json.RawMessage, replace bool, sampleSize int) (SimulationResult, error) {
+	simulated, err := s.newSimulatedService(proposed, replace)
+	if err != nil {
+		return SimulationResult{}, err
+	}
+	return compareServices(ctx, s, simulated, sampleSize)
+}
+
+// compareServices analyzes the sampleSize most recent stored documents under
+// the current and the proposed service and reports how the results differ.
+func compareServices(ctx context.Context, current, proposed *PIIService, sampleSize int) (SimulationResult, error) {
+	docs, err := current.db.FindRecentAPIData(ctx, sampleSize)
+	if err != nil {
+		return SimulationResult{}, err
+	}
+
+	type outcome struct {
+		current, proposed PIIAnalysisResult
+		ok                bool
+	}
+	outcomes := make([]outcome, len(docs))
+	parallelEach(len(docs), func(i int) {
+		doc := docs[i]
+		if ctx.Err() != nil {
+			return
+		}
+		if err := doc.DecompressBodies(); err != nil {
+			log.Printf("Skipping %s in simulation: %v", doc.ID.Hex(), err)
+			return
+		}
+		outcomes[i] = outcome{
+			current:  current.AnalyzePIIInAPIData(ctx, doc),
+			proposed: proposed.AnalyzePIIInAPIData(ctx, doc),
+			ok:       true,
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return SimulationResult{}, err
+	}
+
+	result := SimulationResult{
+		Current:  SimulationTotals{RiskLevels: map[string]int{}},
+		Proposed: SimulationTotals{RiskLevels: map[string]int{}},
+		Changed:  []SimulatedDocument{},
+	}
+	for i, o := range outcomes {
+		if !o.ok {
+			continue
+		}
+		result.Documents++
+		result.Current.add(o.current)
+		result.Proposed.add(o.proposed)
+		gained, lost := diffFindings(o.current.Findings, o.proposed.Findings)
+		if len(gained) == 0 && len(lost) == 0 {
+			continue
+		}
+		result.ChangedCount++
+		if len(result.Changed) >= maxSimulationChanges {
+			result.ChangedTruncated = true
+			continue
+		}
+		result.Changed = append(result.Changed, SimulatedDocument{
+			ID:               docs[i].ID.Hex(),
+			APIEndpoint:      docs[i].APIEndpoint,
+			Method:           docs[i].Method,
+			CurrentRisk:      o.current.HighestRisk,
+			ProposedRisk:     o.proposed.HighestRisk,
+			CurrentFindings:  o.current.TotalCount,
+			ProposedFindings: o.proposed.TotalCount,
+			Gained:           gained,
+			Lost:             lost,
+		})
+	}
+	result.FindingsDelta = result.Proposed.Findings - result.Current.Findings
+	return result, nil
+}
+
+func (t *SimulationTotals) add(analysis PIIAnalysisResult) {
+	t.Findings += analysis.TotalCount
+	t.RiskScore += analysis.RiskScore
+	t.RiskLevels[analysis.HighestRisk]++
+}
+
+// newSimulatedService builds a detached service running the proposed config.
+// It shares the stored patterns and suppression rules of the running one but
+// records no pattern statistics.
+func (s *PIIService) newSimulatedService(proposed json.RawMessage, replace bool) (*PIIService, error) {
+	simulated := newUnloadedPIIService(s.db)
+	if !replace {
+		data, err := readPIIConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &simulated.config); err != nil {
+			return nil, fmt.Errorf("failed to parse PII config JSON: %w", err)
+		}
+	}
+	if err := json.Unmarshal(proposed, &simulated.config); err != nil {
+		return nil, fmt.Errorf("invalid proposed config: %w", err)
+	}
+	if err := validatePatternRegexes(simulated.config); err != nil {
+		return nil, err
+	}
+	if err := simulated.resolveProfile(); err != nil {
+		return nil, err
+	}
+	simulated.mergeStoredPatterns()
+	if err := simulated.compileRegexPatterns(); err != nil {
+		return nil, err
+	}
+	if err := simulated.loadSuppressionRules(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	simulated.confidenceFactors = s.confidenceFactors
+	simulated.maskingDisabled = s.maskingDisabled
+	simulated.jsonStreamThreshold = s.jsonStreamThreshold
+	simulated.analysisTimeout = s.analysisTimeout
+	s.mu.RUnlock()
+	return simulated, nil
+}
+
+// validatePatternRegexes rejects a config with a pattern that doesn't
+// compile, which loading would only log and skip.
+func validatePatternRegexes(config PIIConfig) error {
+	modes := config.DetectionModes
+	for mode, patterns := range map[string]map[string]PIIPattern{
+		"field_based":   modes.FieldBased.Patterns,
+		"value_only":    modes.ValueOnly.Patterns,
+		"keyword_based": modes.KeywordBased.Patterns,
+	} {
+		for name, pattern := range patterns {
+			for _, expr := range []string{pattern.ValuePattern, pattern.RegexPattern} {
+				if expr == "" {
+					continue
+				}
+				if _, err := compilePattern(expr); err != nil {
+					return fmt.Errorf("%s pattern '%s' is invalid: %w", mode, name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// diffFindings returns the findings only in proposed and only in current,
+// matched by finding id.
+func diffFindings(current, proposed []PIIDetectionResult) (gained, lost []SimulatedFinding) {
+	ids := func(findings []PIIDetectionResult) map[string]PIIDetectionResult {
+		m := make(map[string]PIIDetectionResult, len(findings))
+		for _, f := range findings {
+			m[f.ID] = f
+		}
+		return m
+	}
+	summarize := func(f PIIDetectionResult) SimulatedFinding {
+		return SimulatedFinding{PIIType: f.PIIType, Location: f.Location, FieldName: f.FieldName, RiskLevel: f.RiskLevel}
+	}
+	currentIDs, proposedIDs := ids(current), ids(proposed)
+	for id, f := range proposedIDs {
+		if _, ok := currentIDs[id]; !ok {
+			gained = append(gained, summarize(f))
+		}
+	}
+	for id, f := range currentIDs {
+		if _, ok := proposedIDs[id]; !ok {
+			lost = append(lost, summarize(f))
+		}
+	}
+	sortSimulatedFindings(gained)
+	sortSimulatedFindings(lost)
+	return gained, lost
+}
+
+func sortSimulatedFindings(findings []SimulatedFinding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.PIIType != b.PIIType {
+			return a.PIIType < b.PIIType
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.FieldName < b.FieldName
+	})
+}
+
+// parallelEach calls fn for 0..n-1 on a pool of one worker per CPU.
+func parallelEach(n int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
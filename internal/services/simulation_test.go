@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSimulate(t *testing.T) {
+	docs := []db.UserAPIData{
+		{ID: primitive.NewObjectID(), APIEndpoint: "/staff", Method: "GET", ResponseBody: `{"badge":"EMP-123456","email":"jane@example.com"}`},
+		{ID: primitive.NewObjectID(), APIEndpoint: "/staff", Method: "GET", ResponseBody: `{"email":"john@example.com"}`},
+		{ID: primitive.NewObjectID(), APIEndpoint: "/health", Method: "GET", ResponseBody: `{"status":"ok"}`},
+	}
+	// Only the pattern to add: it is merged over the config file.
+	proposed := json.RawMessage(`{"detection_modes":{"value_only":{"patterns":{"EMPLOYEE_ID":{
+		"regexPattern":"\\bEMP-\\d{6}\\b","riskLevel":"HIGH","category":"IDENTITY"}}}}}`)
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("merged pattern", func(mt *mtest.T) {
+		var stored []bson.D
+		for _, doc := range docs {
+			stored = append(stored, toBSONDoc(mt.T, doc))
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "raven.pii_patterns", mtest.FirstBatch), // no stored patterns
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch, stored...),
+		)
+		s := newTestPIIService(mt)
+		s.db = db.MongoInstance{Client: mt.Client, DB: mt.DB}
+
+		result, err := s.Simulate(context.Background(), proposed, false, len(docs))
+		if err != nil {
+			mt.Fatalf("Simulate: %v", err)
+		}
+		if result.Documents != len(docs) || result.ChangedCount != 1 || result.FindingsDelta != 1 {
+			mt.Fatalf("result = %+v, want one of %d documents to gain a finding", result, len(docs))
+		}
+		changed := result.Changed[0]
+		if changed.ID != docs[0].ID.Hex() || len(changed.Gained) != 1 || len(changed.Lost) != 0 {
+			mt.Fatalf("changed = %+v, want %s to gain one finding", changed, docs[0].ID.Hex())
+		}
+		if g := changed.Gained[0]; g.PIIType != "EMPLOYEE_ID" || g.Location != "response_body" || g.RiskLevel != "HIGH" {
+			mt.Errorf("gained = %+v, want a HIGH EMPLOYEE_ID in the response body", g)
+		}
+		if changed.ProposedRisk != "HIGH" || result.Proposed.RiskLevels["HIGH"] != result.Current.RiskLevels["HIGH"]+1 {
+			mt.Errorf("risk %s -> %s, totals %v -> %v, want the document raised to HIGH",
+				changed.CurrentRisk, changed.ProposedRisk, result.Current.RiskLevels, result.Proposed.RiskLevels)
+		}
+		if _, ok := s.config.DetectionModes.ValueOnly.Patterns["EMPLOYEE_ID"]; ok {
+			mt.Error("the running config picked up the simulated pattern")
+		}
+	})
+}
+
+func TestSimulateRejectsInvalidConfig(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name     string
+		proposed string
+	}{
+		{name: "not JSON", proposed: `{"detection_modes":`},
+		{name: "bad regex", proposed: `{"detection_modes":{"value_only":{"patterns":{"BROKEN":{"regexPattern":"(unclosed"}}}}}`},
+	}
+	for _, tt := range tests {
+		if _, err := s.Simulate(context.Background(), json.RawMessage(tt.proposed), false, 10); err == nil {
+			t.Errorf("%s: Simulate succeeded, want an error", tt.name)
+		}
+	}
+}
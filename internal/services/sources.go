@@ -0,0 +1,32 @@
+package services
+
+import "sync"
+
+// UnknownSource stands in for documents ingested without a source.
+const UnknownSource = "unknown"
+
+// maxSourceLabels bounds the metric series per-source counters can create,
+// since the source comes from the log message itself.
+const maxSourceLabels = 50
+
+var (
+	sourceLabelsMu sync.Mutex
+	sourceLabels   = map[string]bool{}
+)
+
+// sourceLabel returns the metric label for an ingest source. Sources beyond
+// the first maxSourceLabels seen are counted under "other".
+func sourceLabel(source string) string {
+	if source == "" {
+		return UnknownSource
+	}
+	sourceLabelsMu.Lock()
+	defer sourceLabelsMu.Unlock()
+	if !sourceLabels[source] {
+		if len(sourceLabels) >= maxSourceLabels {
+			return "other"
+		}
+		sourceLabels[source] = true
+	}
+	return source
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestSourceDetectionModes(t *testing.T) {
+	s := newTestPIIService(t)
+	s.config.SourceDetectionModes = map[string][]string{
+		"edge":     {"field_based"},
+		"batch":    {"value_only", "keyword_based"},
+		"disabled": {},
+	}
+	body := `{"email":"jane@example.com","note":"ssn 123-45-6789","secret":"abc"}`
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{source: "", want: []string{"field_based", "keyword_based", "value_only"}},
+		{source: "unlisted", want: []string{"field_based", "keyword_based", "value_only"}},
+		{source: "edge", want: []string{"field_based"}},
+		{source: "batch", want: []string{"keyword_based", "value_only"}},
+		{source: "disabled", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint: "/api/users",
+				Method:      "POST",
+				URL:         "https://api.example.com/api/users",
+				RequestBody: body,
+				Source:      tt.source,
+			})
+			seen := map[string]bool{}
+			for _, f := range result.Findings {
+				seen[f.DetectionMode] = true
+			}
+			var got []string
+			for mode := range seen {
+				got = append(got, mode)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("modes with findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLabelCap(t *testing.T) {
+	sourceLabelsMu.Lock()
+	saved := sourceLabels
+	sourceLabels = map[string]bool{}
+	sourceLabelsMu.Unlock()
+	t.Cleanup(func() {
+		sourceLabelsMu.Lock()
+		sourceLabels = saved
+		sourceLabelsMu.Unlock()
+	})
+
+	if got := sourceLabel(""); got != UnknownSource {
+		t.Errorf("sourceLabel(\"\") = %q, want %q", got, UnknownSource)
+	}
+	for i := 0; i < maxSourceLabels; i++ {
+		source := fmt.Sprintf("source-%d", i)
+		if got := sourceLabel(source); got != source {
+			t.Fatalf("sourceLabel(%q) = %q before the cap", source, got)
+		}
+	}
+	if got := sourceLabel("one-too-many"); got != "other" {
+		t.Errorf("sourceLabel past the cap = %q, want other", got)
+	}
+	if got := sourceLabel("source-0"); got != "source-0" {
+		t.Errorf("a source seen before the cap = %q, want its own label", got)
+	}
+}
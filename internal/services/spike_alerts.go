@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+const (
+	// spikeBaselineWeight is the weight of the latest interval in an
+	// endpoint's rolling baseline.
+	spikeBaselineWeight = 0.2
+
+	// spikeWarmupIntervals is how many intervals a baseline must average
+	// over before it can raise alerts.
+	spikeWarmupIntervals = 3
+)
+
+// SpikeAlert reports an endpoint whose findings in the last interval
+// exceeded its baseline times the configured multiplier.
+type SpikeAlert struct {
+	APIEndpoint string    `json:"api_endpoint"`
+	Method      string    `json:"method"`
+	Findings    int       `json:"findings"`
+	Baseline    float64   `json:"baseline"`
+	Multiplier  float64   `json:"multiplier"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+}
+
+func (a SpikeAlert) message() string {
+	return fmt.Sprintf("RAVEN: PII findings spiked on %s %s: %d findings between %s and %s, against a baseline of %.1f",
+		a.Method, a.APIEndpoint, a.Findings, a.From.Format(time.RFC3339), a.To.Format(time.RFC3339), a.Baseline)
+}
+
+// SpikeDetector periodically compares each endpoint's findings in the last
+// interval with its rolling baseline, kept in the finding_baselines
+// collection, and alerts on spikes. A nil *SpikeDetector is valid and
+// behaves as a no-op.
+type SpikeDetector struct {
+	mongo       db.MongoInstance
+	notifier    *AlertNotifier
+	interval    time.Duration
+	multiplier  float64
+	minFindings int
+}
+
+// NewSpikeDetectorFromEnv returns a detector checking every
+// SPIKE_CHECK_INTERVAL (default 15 minutes), or nil when the interval is
+// zero. An endpoint spikes when its findings reach SPIKE_MIN_FINDINGS
+// (default 10) and exceed SPIKE_MULTIPLIER (default 3) times its baseline.
+// Without a webhook configured, spikes are only logged.
+func NewSpikeDetectorFromEnv(mongoInstance db.MongoInstance) *SpikeDetector {
+	interval := envDuration("SPIKE_CHECK_INTERVAL", 15*time.Minute)
+	if interval <= 0 {
+		return nil
+	}
+	return &SpikeDetector{
+		mongo:       mongoInstance,
+		notifier:    NewAlertNotifierFromEnv(),
+		interval:    interval,
+		multiplier:  envFloat("SPIKE_MULTIPLIER", 3),
+		minFindings: envInt("SPIKE_MIN_FINDINGS", 10),
+	}
+}
+
+// Start runs a check every interval until the context is canceled.
+func (d *SpikeDetector) Start(ctx context.Context) {
+	if d == nil || d.mongo.DB == nil {
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := d.Check(ctx, now); err != nil {
+				log.Printf("Error checking for finding spikes: %v", err)
+			}
+		}
+	}
+}
+
+// Check counts the findings of the interval ending at now, alerts on the
+// endpoints that spiked and folds the counts into the baselines.
+func (d *SpikeDetector) Check(ctx context.Context, now time.Time) ([]SpikeAlert, error) {
+	from := now.Add(-d.interval)
+	counts, err := d.mongo.CountFindingsByEndpoint(ctx, from, now)
+	if err != nil {
+		return nil, err
+	}
+	baselines, err := d.mongo.ListFindingBaselines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alerts, updated := detectSpikes(counts, baselines, d.multiplier, d.minFindings, now)
+	for i := range alerts {
+		alerts[i].From = from
+		findingSpikes.Inc()
+		log.Print(alerts[i].message())
+		if err := d.notifier.Notify(ctx, "finding_spike", alerts[i].message(), alerts[i]); err != nil {
+			log.Printf("Error sending finding spike alert: %v", err)
+		}
+	}
+	if err := d.mongo.SaveFindingBaselines(ctx, updated); err != nil {
+		return alerts, err
+	}
+	return alerts, nil
+}
+
+// detectSpikes compares one interval's finding counts with the baselines
+// and returns the spikes along with the baselines updated by the counts.
+// Endpoints missing from counts had no findings. An endpoint without a
+// baseline starts one at its count and can't spike until the baseline has
+// warmed up. A spike still updates the baseline, so a sustained new level
+// stops alerting.
+func detectSpikes(counts []db.EndpointFindingCount, baselines []db.FindingBaseline, multiplier float64, minFindings int, now time.Time) ([]SpikeAlert, []db.FindingBaseline) {
+	key := func(endpoint, method string) string {
+		return strings.ToUpper(method) + " " + strings.ToLower(endpoint)
+	}
+	byKey := make(map[string]db.FindingBaseline, len(baselines)+len(counts))
+	for _, b := range baselines {
+		byKey[key(b.APIEndpoint, b.Method)] = b
+	}
+	findings := make(map[string]int, len(counts))
+	for _, c := range counts {
+		k := key(c.APIEndpoint, c.Method)
+		findings[k] += c.Findings
+		if _, ok := byKey[k]; !ok {
+			byKey[k] = db.FindingBaseline{APIEndpoint: c.APIEndpoint, Method: c.Method}
+		}
+	}
+
+	var alerts []SpikeAlert
+	updated := make([]db.FindingBaseline, 0, len(byKey))
+	for k, b := range byKey {
+		count := findings[k]
+		if b.Intervals >= spikeWarmupIntervals && count >= minFindings && float64(count) > multiplier*b.Rate {
+			alerts = append(alerts, SpikeAlert{
+				APIEndpoint: b.APIEndpoint,
+				Method:      b.Method,
+				Findings:    count,
+				Baseline:    b.Rate,
+				Multiplier:  multiplier,
+				To:          now,
+			})
+		}
+		if b.Intervals == 0 {
+			b.Rate = float64(count)
+		} else {
+			b.Rate = (1-spikeBaselineWeight)*b.Rate + spikeBaselineWeight*float64(count)
+		}
+		b.Intervals++
+		b.UpdatedAt = now
+		updated = append(updated, b)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Findings != alerts[j].Findings {
+			return alerts[i].Findings > alerts[j].Findings
+		}
+		return key(alerts[i].APIEndpoint, alerts[i].Method) < key(alerts[j].APIEndpoint, alerts[j].Method)
+	})
+	return alerts, updated
+}
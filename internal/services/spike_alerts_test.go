@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDetectSpikes(t *testing.T) {
+	const multiplier, minFindings = 3, 10
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// series holds each endpoint's findings per interval.
+		series map[string][]int
+		// want holds, per interval, the endpoints alerted on.
+		want [][]string
+	}{
+		{
+			name:   "synthetic spike",
+			series: map[string][]int{"/users": {5, 6, 4, 5, 5, 40, 6}},
+			want:   [][]string{nil, nil, nil, nil, nil, {"/users"}, nil},
+		},
+		{
+			name:   "spike during warm-up",
+			series: map[string][]int{"/users": {5, 40, 5, 5}},
+			want:   [][]string{nil, nil, nil, nil},
+		},
+		{
+			name:   "below the minimum",
+			series: map[string][]int{"/orders": {1, 2, 1, 2, 9}},
+			want:   [][]string{nil, nil, nil, nil, nil},
+		},
+		{
+			name:   "sustained new level alerts until the baseline catches up",
+			series: map[string][]int{"/users": {5, 5, 5, 5, 40, 40, 40, 40, 40}},
+			want:   [][]string{nil, nil, nil, nil, {"/users"}, {"/users"}, nil, nil, nil},
+		},
+		{
+			name:   "findings after quiet intervals",
+			series: map[string][]int{"/users": {3, 0, 0, 0, 12}},
+			want:   [][]string{nil, nil, nil, nil, {"/users"}},
+		},
+		{
+			name: "only the spiking endpoint",
+			series: map[string][]int{
+				"/users":  {5, 5, 5, 5, 60},
+				"/orders": {20, 20, 20, 20, 25},
+			},
+			want: [][]string{nil, nil, nil, nil, {"/users"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var baselines []db.FindingBaseline
+			for i, want := range tt.want {
+				var counts []db.EndpointFindingCount
+				for endpoint, series := range tt.series {
+					if series[i] > 0 {
+						counts = append(counts, db.EndpointFindingCount{APIEndpoint: endpoint, Method: "GET", Findings: series[i]})
+					}
+				}
+				alerts, updated := detectSpikes(counts, baselines, multiplier, minFindings, start.Add(time.Duration(i)*time.Hour))
+				var got []string
+				for _, alert := range alerts {
+					got = append(got, alert.APIEndpoint)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("interval %d: alerts on %v, want %v", i, got, want)
+				}
+				baselines = updated
+			}
+		})
+	}
+}
+
+func TestSpikeDetectorCheck(t *testing.T) {
+	var received []webhookAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert webhookAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		received = append(received, alert)
+	}))
+	defer server.Close()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("synthetic spike", func(mt *mtest.T) {
+		now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "raven.user_api_data", mtest.FirstBatch,
+				bson.D{{Key: "api_endpoint", Value: "/users"}, {Key: "method", Value: "GET"}, {Key: "findings", Value: 45}},
+				bson.D{{Key: "api_endpoint", Value: "/orders"}, {Key: "method", Value: "GET"}, {Key: "findings", Value: 12}},
+			),
+			mtest.CreateCursorResponse(0, "raven.finding_baselines", mtest.FirstBatch,
+				toBSONDoc(mt.T, db.FindingBaseline{APIEndpoint: "/users", Method: "GET", Rate: 5, Intervals: 8}),
+				toBSONDoc(mt.T, db.FindingBaseline{APIEndpoint: "/orders", Method: "GET", Rate: 10, Intervals: 8}),
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}),
+		)
+		d := &SpikeDetector{
+			mongo:       db.MongoInstance{Client: mt.Client, DB: mt.DB},
+			notifier:    &AlertNotifier{webhookURL: server.URL, client: server.Client()},
+			interval:    15 * time.Minute,
+			multiplier:  3,
+			minFindings: 10,
+		}
+
+		alerts, err := d.Check(context.Background(), now)
+		if err != nil {
+			mt.Fatalf("Check: %v", err)
+		}
+		want := []SpikeAlert{{APIEndpoint: "/users", Method: "GET", Findings: 45, Baseline: 5, Multiplier: 3, From: now.Add(-15 * time.Minute), To: now}}
+		if !reflect.DeepEqual(alerts, want) {
+			mt.Errorf("alerts = %+v, want %+v", alerts, want)
+		}
+		if len(received) != 1 || received[0].Type != "finding_spike" || received[0].Message != want[0].message() {
+			mt.Errorf("webhook received %+v, want one finding_spike alert", received)
+		}
+	})
+}
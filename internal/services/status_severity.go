@@ -0,0 +1,43 @@
+package services
+
+import "strings"
+
+// StatusSeverityRule adjusts the findings in responses whose status code is
+// between MinStatus and MaxStatus inclusive. A 5xx response carrying PII is
+// usually a stack trace or error dump echoing data back, which is both a
+// leak and an error-handling bug, so its response findings are raised to
+// EscalateTo and tagged with Tag to surface separately.
+type StatusSeverityRule struct {
+	MinStatus  int    `json:"minStatus"`
+	MaxStatus  int    `json:"maxStatus"`
+	EscalateTo string `json:"escalateTo"`
+	Tag        string `json:"tag"`
+}
+
+// applyStatusSeverity adjusts response findings in place under the first
+// rule matching statusCode. Findings already at or above the escalated level
+// keep theirs but are still tagged. An unknown status (0) matches no rule.
+func (s *PIIService) applyStatusSeverity(findings []PIIDetectionResult, statusCode int) {
+	if statusCode == 0 {
+		return
+	}
+	for _, rule := range s.config.StatusSeverity {
+		if statusCode < rule.MinStatus || statusCode > rule.MaxStatus {
+			continue
+		}
+		escalated, escalate := s.config.RiskLevels[rule.EscalateTo]
+		for i := range findings {
+			f := &findings[i]
+			if !strings.HasPrefix(f.Location, "response_") {
+				continue
+			}
+			if escalate && s.config.RiskLevels[f.RiskLevel] < escalated {
+				f.RiskLevel = rule.EscalateTo
+			}
+			if rule.Tag != "" {
+				f.Tags = append(append([]string{}, f.Tags...), rule.Tag)
+			}
+		}
+		return
+	}
+}
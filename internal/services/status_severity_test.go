@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestStatusSeverity(t *testing.T) {
+	tests := []struct {
+		status int
+		risk   string
+		tagged bool
+	}{
+		{status: 200, risk: "MEDIUM"},
+		{status: 500, risk: "HIGH", tagged: true},
+		{status: 503, risk: "HIGH", tagged: true},
+		{status: 404, risk: "MEDIUM"},
+		{status: 0, risk: "MEDIUM"},
+	}
+	for _, tt := range tests {
+		s := newTestPIIService(t)
+		result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+			APIEndpoint:  "/api/users",
+			Method:       "POST",
+			URL:          "https://api.example.com/api/users",
+			StatusCode:   tt.status,
+			RequestBody:  `{"email":"john@example.com"}`,
+			ResponseBody: `{"error":"duplicate user","email":"jane@example.com"}`,
+		})
+		var response, request *PIIDetectionResult
+		for i, f := range result.Findings {
+			if f.PIIType != "EMAIL" {
+				continue
+			}
+			switch f.Location {
+			case "response_body":
+				response = &result.Findings[i]
+			case "request_body":
+				request = &result.Findings[i]
+			}
+		}
+		if response == nil || request == nil {
+			t.Fatalf("status %d: findings = %+v, want an email in the request and the response", tt.status, result.Findings)
+		}
+		if response.RiskLevel != tt.risk || slices.Contains(response.Tags, "ERROR_LEAK") != tt.tagged {
+			t.Errorf("status %d: response email %s with tags %v, want %s, tagged %v", tt.status, response.RiskLevel, response.Tags, tt.risk, tt.tagged)
+		}
+		if request.RiskLevel != "MEDIUM" || slices.Contains(request.Tags, "ERROR_LEAK") {
+			t.Errorf("status %d: request email %s with tags %v, want MEDIUM and untagged", tt.status, request.RiskLevel, request.Tags)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// StorePolicy decides which analyzed documents are persisted.
+// The zero value stores everything.
+type StorePolicy struct {
+	OnlyPII bool
+	MinRisk string
+}
+
+// NewStorePolicyFromEnv reads STORE_ONLY_PII and MIN_STORE_RISK.
+func NewStorePolicyFromEnv() StorePolicy {
+	policy := StorePolicy{
+		OnlyPII: envBool("STORE_ONLY_PII", false),
+		MinRisk: strings.ToUpper(strings.TrimSpace(os.Getenv("MIN_STORE_RISK"))),
+	}
+	if policy.OnlyPII || policy.MinRisk != "" {
+		log.Printf("Store policy enabled: only_pii=%t, min_risk=%q", policy.OnlyPII, policy.MinRisk)
+	}
+	return policy
+}
+
+// Validate checks MinRisk names a configured risk level. An unknown level
+// would weigh nothing and let every document through.
+func (p StorePolicy) Validate(piiService *PIIService) error {
+	if p.MinRisk == "" {
+		return nil
+	}
+	piiService.mu.RLock()
+	defer piiService.mu.RUnlock()
+	if _, ok := piiService.config.RiskLevels[p.MinRisk]; !ok {
+		return fmt.Errorf("MIN_STORE_RISK '%s' is not a configured risk level", p.MinRisk)
+	}
+	return nil
+}
+
+// ShouldStore reports whether an analysis result passes the policy, and if not, why.
+func (p StorePolicy) ShouldStore(result PIIAnalysisResult, piiService *PIIService) (bool, string) {
+	if p.OnlyPII && result.TotalCount == 0 {
+		return false, "no_pii"
+	}
+	if p.MinRisk != "" && piiService.RiskLevelValue(result.HighestRisk) < piiService.RiskLevelValue(p.MinRisk) {
+		return false, "below_min_risk"
+	}
+	return true, ""
+}
@@ -0,0 +1,49 @@
+package services
+
+import "testing"
+
+func TestStorePolicyValidate(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name    string
+		minRisk string
+		wantErr bool
+	}{
+		{name: "unset", minRisk: "", wantErr: false},
+		{name: "configured level", minRisk: "HIGH", wantErr: false},
+		{name: "unknown level", minRisk: "SEVERE", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StorePolicy{MinRisk: tt.minRisk}.Validate(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorePolicyShouldStore(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name       string
+		policy     StorePolicy
+		result     PIIAnalysisResult
+		wantStore  bool
+		wantReason string
+	}{
+		{name: "zero value stores all", policy: StorePolicy{}, result: PIIAnalysisResult{}, wantStore: true},
+		{name: "only PII without findings", policy: StorePolicy{OnlyPII: true}, result: PIIAnalysisResult{}, wantReason: "no_pii"},
+		{name: "only PII with findings", policy: StorePolicy{OnlyPII: true}, result: PIIAnalysisResult{TotalCount: 1, HighestRisk: "LOW"}, wantStore: true},
+		{name: "below min risk", policy: StorePolicy{MinRisk: "HIGH"}, result: PIIAnalysisResult{TotalCount: 1, HighestRisk: "MEDIUM"}, wantReason: "below_min_risk"},
+		{name: "at min risk", policy: StorePolicy{MinRisk: "HIGH"}, result: PIIAnalysisResult{TotalCount: 1, HighestRisk: "HIGH"}, wantStore: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, reason := tt.policy.ShouldStore(tt.result, s)
+			if store != tt.wantStore || reason != tt.wantReason {
+				t.Errorf("ShouldStore() = %t, %q, want %t, %q", store, reason, tt.wantStore, tt.wantReason)
+			}
+		})
+	}
+}
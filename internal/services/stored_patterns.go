@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// ValidateStoredPattern checks a user-defined pattern before it is written,
+// so a pattern that would be skipped at load time is rejected up front.
+func (s *PIIService) ValidateStoredPattern(pattern db.StoredPIIPattern) error {
+	if pattern.Name == "" {
+		return fmt.Errorf("pattern name is required")
+	}
+	switch pattern.Mode {
+	case "field_based":
+		if len(pattern.FieldNames) == 0 {
+			return fmt.Errorf("field_based patterns require at least one field name")
+		}
+	case "value_only", "keyword_based":
+	default:
+		return fmt.Errorf("unknown detection mode '%s'", pattern.Mode)
+	}
+	if _, err := compilePattern(pattern.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if pattern.Validate != "" {
+		if _, ok := validators[pattern.Validate]; !ok {
+			return fmt.Errorf("unknown validator '%s'", pattern.Validate)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.config.RiskLevels[pattern.RiskLevel]; !ok {
+		return fmt.Errorf("unknown risk level '%s'", pattern.RiskLevel)
+	}
+	return nil
+}
+
+// mergeStoredPatterns adds the patterns stored in MongoDB to the file-based
+// config. A stored pattern replaces a file pattern of the same mode and name.
+// Failing to read them is logged rather than fatal, so detection keeps
+// running on the file config.
+func (s *PIIService) mergeStoredPatterns() {
+	if s.db.DB == nil {
+		return
+	}
+	stored, err := s.db.ListPIIPatterns(context.Background())
+	if err != nil {
+		log.Printf("Warning: Failed to load stored PII patterns: %v", err)
+		return
+	}
+	s.addStoredPatterns(stored)
+	log.Printf("Merged %d stored PII patterns", len(stored))
+}
+
+// addStoredPatterns adds stored patterns to the detection modes of the
+// config. Patterns of an unknown mode are skipped.
+func (s *PIIService) addStoredPatterns(stored []db.StoredPIIPattern) {
+	modes := &s.config.DetectionModes
+	for _, p := range stored {
+		pattern := PIIPattern{
+			FieldNames:  p.FieldNames,
+			Name:        p.Name,
+			RiskLevel:   p.RiskLevel,
+			Category:    p.Category,
+			Tags:        p.Tags,
+			Frameworks:  p.Frameworks,
+			MaskOptions: MaskOptions{MaskStrategy: p.MaskStrategy},
+			Validate:    p.Validate,
+			Stored:      true,
+		}
+		var target *map[string]PIIPattern
+		switch p.Mode {
+		case "field_based":
+			pattern.ValuePattern = p.Pattern
+			target = &modes.FieldBased.Patterns
+		case "value_only":
+			pattern.RegexPattern = p.Pattern
+			target = &modes.ValueOnly.Patterns
+		case "keyword_based":
+			pattern.RegexPattern = p.Pattern
+			target = &modes.KeywordBased.Patterns
+		default:
+			log.Printf("Warning: Skipping stored PII pattern %s with unknown mode '%s'", p.Name, p.Mode)
+			continue
+		}
+		if *target == nil {
+			*target = make(map[string]PIIPattern)
+		}
+		(*target)[p.Name] = pattern
+	}
+}
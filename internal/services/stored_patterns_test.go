@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestValidateStoredPattern(t *testing.T) {
+	valid := db.StoredPIIPattern{Name: "EMPLOYEE_ID", Mode: "value_only", Pattern: `\bEMP-[0-9]{6}\b`, RiskLevel: "HIGH", Category: "PII"}
+	tests := []struct {
+		name    string
+		edit    func(*db.StoredPIIPattern)
+		wantErr string
+	}{
+		{name: "valid", edit: func(*db.StoredPIIPattern) {}},
+		{name: "missing name", edit: func(p *db.StoredPIIPattern) { p.Name = "" }, wantErr: "name is required"},
+		{name: "unknown mode", edit: func(p *db.StoredPIIPattern) { p.Mode = "fuzzy" }, wantErr: "unknown detection mode"},
+		{name: "field based without fields", edit: func(p *db.StoredPIIPattern) { p.Mode = "field_based" }, wantErr: "at least one field name"},
+		{name: "invalid regex", edit: func(p *db.StoredPIIPattern) { p.Pattern = `EMP-[0-9` }, wantErr: "invalid pattern"},
+		{name: "oversized regex", edit: func(p *db.StoredPIIPattern) { p.Pattern = strings.Repeat("a", maxPatternLength+1) }, wantErr: "maximum length"},
+		{name: "unknown validator", edit: func(p *db.StoredPIIPattern) { p.Validate = "crc32" }, wantErr: "unknown validator"},
+		{name: "unknown risk level", edit: func(p *db.StoredPIIPattern) { p.RiskLevel = "SEVERE" }, wantErr: "unknown risk level"},
+	}
+	s := newTestPIIService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := valid
+			tt.edit(&pattern)
+			err := s.ValidateStoredPattern(pattern)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStoredPattern() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateStoredPattern() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStoredPatternLifecycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern db.StoredPIIPattern
+		apiData db.UserAPIData
+	}{
+		{
+			name:    "value only",
+			pattern: db.StoredPIIPattern{Name: "EMPLOYEE_ID", Mode: "value_only", Pattern: `\bEMP-[0-9]{6}\b`, RiskLevel: "HIGH", Category: "PII"},
+			apiData: db.UserAPIData{RequestBody: `{"note":"assigned to EMP-123456 today"}`},
+		},
+		{
+			name:    "field based",
+			pattern: db.StoredPIIPattern{Name: "EMPLOYEE_ID", Mode: "field_based", Pattern: `^EMP-[0-9]{6}$`, FieldNames: []string{"employeeid"}, RiskLevel: "HIGH", Category: "PII"},
+			apiData: db.UserAPIData{RequestBody: `{"employeeId":"EMP-123456"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			tt.apiData.APIEndpoint, tt.apiData.Method, tt.apiData.URL = "/api/staff", "POST", "https://api.example.com/api/staff"
+			analyze := func() int {
+				result := s.AnalyzePIIInAPIData(context.Background(), tt.apiData)
+				return countFindings(result.Findings, tt.pattern.Name)
+			}
+			if n := analyze(); n != 0 {
+				t.Fatalf("%d %s findings before the pattern was created", n, tt.pattern.Name)
+			}
+
+			// Create: the pattern is validated, stored and merged on reload.
+			if err := s.ValidateStoredPattern(tt.pattern); err != nil {
+				t.Fatalf("ValidateStoredPattern() = %v", err)
+			}
+			s.addStoredPatterns([]db.StoredPIIPattern{tt.pattern})
+			if err := s.compileRegexPatterns(); err != nil {
+				t.Fatalf("compileRegexPatterns() = %v", err)
+			}
+			if n := analyze(); n != 1 {
+				t.Fatalf("%d %s findings after the pattern was created, want 1", n, tt.pattern.Name)
+			}
+
+			// Delete: reloading without the stored pattern drops it.
+			if err := s.Reload(); err != nil {
+				t.Fatalf("Reload() = %v", err)
+			}
+			if n := analyze(); n != 0 {
+				t.Fatalf("%d %s findings after the pattern was deleted", n, tt.pattern.Name)
+			}
+		})
+	}
+}
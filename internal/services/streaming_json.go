@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultJSONStreamThreshold is the body size from which JSON bodies are
+// walked token by token instead of decoded into a tree.
+const defaultJSONStreamThreshold = 1 << 20
+
+// analyzeJSONStream scans a JSON document with a token decoder, so a large
+// body is never held as a decoded tree. It reports the same findings and
+// paths as analyzeJSONObject on the decoded document.
+func (s *PIIService) analyzeJSONStream(jsonStr, location string, result *PIIAnalysisResult) error {
+	dec := json.NewDecoder(strings.NewReader(jsonStr))
+	dec.UseNumber()
+	if err := s.walkJSONValue(dec, "", "", location, result); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level JSON value")
+	}
+	return nil
+}
+
+// walkJSONValue consumes the next value from dec. key is the object key the
+// value belongs to, or for array items the key of the array; path is the
+// value's full JSON path, empty for the top-level value, which is not
+// scanned when it is a string.
+func (s *PIIService) walkJSONValue(dec *json.Decoder, key, path, location string, result *PIIAnalysisResult) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		if v == '{' {
+			return s.walkJSONObject(dec, path, location, result)
+		}
+		if v == '[' {
+			return s.walkJSONArray(dec, path, location, result)
+		}
+		return fmt.Errorf("unexpected delimiter %q", v)
+	case string:
+		if path == "" {
+			return nil
+		}
+		s.analyzeJSONString(key, path, v, location, 0, result)
+	}
+	return nil
+}
+
+func (s *PIIService) walkJSONObject(dec *json.Decoder, prefix, location string, result *PIIAnalysisResult) error {
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected object key %v", token)
+		}
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		if err := s.walkJSONValue(dec, key, fullKey, location, result); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // closing '}'
+	return err
+}
+
+func (s *PIIService) walkJSONArray(dec *json.Decoder, prefix, location string, result *PIIAnalysisResult) error {
+	key := arrayItemKey(prefix)
+	for i := 0; dec.More(); i++ {
+		if err := s.walkJSONValue(dec, key, fmt.Sprintf("%s[%d]", prefix, i), location, result); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // closing ']'
+	return err
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// largeJSONArrayBody returns a JSON array of n user records, one in ten
+// holding an email address.
+func largeJSONArrayBody(n int) string {
+	var b strings.Builder
+	b.WriteString(`{"users":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		contact := fmt.Sprintf(`"status":"active","notes":"record %d"`, i)
+		if i%10 == 0 {
+			contact = fmt.Sprintf(`"email":"user%d@example.com"`, i)
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"user %d",%s,"tags":["a","b"],"nested":{"score":%d.5}}`, i, i, contact, i)
+	}
+	b.WriteString(`]}`)
+	return b.String()
+}
+
+// findingKeys summarizes findings for comparing two analyses of one body.
+func findingKeys(findings []PIIDetectionResult) []string {
+	keys := make([]string, len(findings))
+	for i, f := range findings {
+		keys[i] = strings.Join([]string{f.PIIType, f.Location, f.FieldName, f.DetectedValue, f.DetectionMode}, "|")
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestAnalyzeJSONStreamMatchesTree(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "object", body: `{"email":"jane@example.com","profile":{"ssn":"123-45-6789","phone":"+1 415 555 0100"}}`},
+		{name: "array of records", body: largeJSONArrayBody(50)},
+		{name: "top-level array", body: `[{"email":"jane@example.com"},{"emails":["john@example.com","amy@example.com"]}]`},
+		{name: "embedded json string", body: `{"payload":"{\"email\":\"jane@example.com\"}"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			analyze := func(threshold int) []string {
+				s.jsonStreamThreshold = threshold
+				result := PIIAnalysisResult{modes: s.detectionModesForSource(""), Findings: []PIIDetectionResult{}}
+				s.analyzeJSONForPII(tt.body, "response_body", &result)
+				return findingKeys(result.Findings)
+			}
+			tree, stream := analyze(0), analyze(1)
+			if len(tree) == 0 {
+				t.Fatal("no findings in the tree analysis")
+			}
+			if !reflect.DeepEqual(stream, tree) {
+				t.Errorf("streaming findings differ from the tree walk:\n stream %v\n tree   %v", stream, tree)
+			}
+		})
+	}
+}
+
+func TestJSONArrayStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string // type|field|value
+	}{
+		{
+			name: "array under a key",
+			body: `{"user":{"emails":["jane@example.com","john@example.com"]}}`,
+			want: []string{"EMAIL|user.emails[0]|jane@example.com", "EMAIL|user.emails[1]|john@example.com"},
+		},
+		{
+			name: "nested arrays",
+			body: `{"emails":[["jane@example.com"],["amy@example.com"]]}`,
+			want: []string{"EMAIL|emails[0][0]|jane@example.com", "EMAIL|emails[1][0]|amy@example.com"},
+		},
+		{
+			name: "embedded json in an array",
+			body: `{"events":["{\"email\":\"jane@example.com\"}"]}`,
+			want: []string{"EMAIL|events[0].email|jane@example.com"},
+		},
+		{
+			name: "top-level array",
+			body: `["123-45-6789"]`,
+			want: []string{"US_SSN|[0]|123-45-6789"},
+		},
+	}
+	for _, tt := range tests {
+		for _, threshold := range []int{0, 1} {
+			t.Run(fmt.Sprintf("%s/threshold %d", tt.name, threshold), func(t *testing.T) {
+				s := newTestPIIService(t)
+				s.maskingDisabled = true
+				s.jsonStreamThreshold = threshold
+				result := PIIAnalysisResult{modes: s.detectionModesForSource(""), Findings: []PIIDetectionResult{}}
+				s.analyzeJSONForPII(tt.body, "response_body", &result)
+				var got []string
+				for _, f := range result.Findings {
+					got = append(got, f.PIIType+"|"+f.FieldName+"|"+f.DetectedValue)
+				}
+				sort.Strings(got)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("findings = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkAnalyzeJSONBody compares the tree and streaming walks of a large
+// array body. The walk-only runs enable no detection mode, so B/op shows what
+// each walk costs apart from the detectors, which allocate the same either way.
+func BenchmarkAnalyzeJSONBody(b *testing.B) {
+	body := largeJSONArrayBody(2000)
+	for _, bm := range []struct {
+		name      string
+		threshold int
+		walkOnly  bool
+	}{
+		{name: "tree", threshold: 0},
+		{name: "stream", threshold: 1},
+		{name: "tree walk only", threshold: 0, walkOnly: true},
+		{name: "stream walk only", threshold: 1, walkOnly: true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			s := newTestPIIService(b)
+			s.jsonStreamThreshold = bm.threshold
+			modes := s.detectionModesForSource("")
+			if bm.walkOnly {
+				modes = detectionModes{}
+			}
+			b.SetBytes(int64(len(body)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result := PIIAnalysisResult{modes: modes, Findings: []PIIDetectionResult{}}
+				s.analyzeJSONForPII(body, "response_body", &result)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+// SuppressionRule downgrades or drops findings of the given categories for
+// traffic matching all of its non-empty selectors (source, host and endpoint globs).
+type SuppressionRule struct {
+	Name       string   `json:"name"`
+	Source     string   `json:"source,omitempty"`
+	Host       string   `json:"host,omitempty"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Action     string   `json:"action"`
+	RiskLevel  string   `json:"riskLevel,omitempty"`
+}
+
+const (
+	suppressionActionSuppress  = "suppress"
+	suppressionActionDowngrade = "downgrade"
+)
+
+func (s *PIIService) loadSuppressionRules() error {
+	configPath := filepath.Join("config", "suppression_rules.json")
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read suppression rules file: %w", err)
+	}
+	var rules struct {
+		Rules []SuppressionRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("failed to parse suppression rules JSON: %w", err)
+	}
+	for _, rule := range rules.Rules {
+		if err := s.validateSuppressionRule(rule); err != nil {
+			return err
+		}
+	}
+	s.suppressionRules = rules.Rules
+	log.Printf("Loaded %d suppression rules", len(s.suppressionRules))
+	return nil
+}
+
+// validateSuppressionRule rejects rules that could never take effect, such
+// as one naming a category no finding carries.
+func (s *PIIService) validateSuppressionRule(rule SuppressionRule) error {
+	if rule.Action != suppressionActionSuppress && rule.Action != suppressionActionDowngrade {
+		return fmt.Errorf("suppression rule '%s' has unknown action '%s'", rule.Name, rule.Action)
+	}
+	if rule.Action == suppressionActionDowngrade && s.config.RiskLevels[rule.RiskLevel] == 0 {
+		return fmt.Errorf("suppression rule '%s' downgrades to unknown risk level '%s'", rule.Name, rule.RiskLevel)
+	}
+	for _, category := range rule.Categories {
+		if !slices.Contains(s.config.Categories, category) {
+			return fmt.Errorf("suppression rule '%s' has unknown category '%s'", rule.Name, category)
+		}
+	}
+	return nil
+}
+
+func (r SuppressionRule) matchesTraffic(apiData db.UserAPIData, host string) bool {
+	return globMatch(r.Source, apiData.Source) &&
+		globMatch(r.Host, host) &&
+		globMatch(r.Endpoint, apiData.APIEndpoint)
+}
+
+func (r SuppressionRule) coversCategory(category string) bool {
+	if len(r.Categories) == 0 {
+		return true
+	}
+	for _, c := range r.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// applySuppressionRules drops or downgrades findings covered by a rule matching
+// the document's traffic. The first matching rule for a finding wins.
+func (s *PIIService) applySuppressionRules(apiData db.UserAPIData, findings []PIIDetectionResult) []PIIDetectionResult {
+	if len(s.suppressionRules) == 0 || len(findings) == 0 {
+		return findings
+	}
+	host := ""
+	if parsed, err := url.Parse(apiData.URL); err == nil {
+		host = parsed.Hostname()
+	}
+	var matching []SuppressionRule
+	for _, rule := range s.suppressionRules {
+		if rule.matchesTraffic(apiData, host) {
+			matching = append(matching, rule)
+		}
+	}
+	if len(matching) == 0 {
+		return findings
+	}
+
+	kept := findings[:0]
+	for _, finding := range findings {
+		suppressed := false
+		for _, rule := range matching {
+			if !rule.coversCategory(finding.Category) {
+				continue
+			}
+			findingsSuppressed.WithLabelValues(rule.Action, finding.Category).Inc()
+			if rule.Action == suppressionActionSuppress {
+				suppressed = true
+			} else if s.config.RiskLevels[rule.RiskLevel] < s.config.RiskLevels[finding.RiskLevel] {
+				finding.RiskLevel = rule.RiskLevel
+			}
+			break
+		}
+		if !suppressed {
+			kept = append(kept, finding)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestApplySuppressionRules(t *testing.T) {
+	s := newTestPIIService(t)
+	s.suppressionRules = []SuppressionRule{
+		{Name: "internal-device-noise", Source: "internal", Categories: []string{"DEVICE"}, Action: suppressionActionSuppress},
+		{Name: "health-checks", Endpoint: "/health*", Action: suppressionActionDowngrade, RiskLevel: "LOW"},
+	}
+	device := PIIDetectionResult{PIIType: "MAC_ADDRESS", Category: "DEVICE", RiskLevel: "MEDIUM"}
+	email := PIIDetectionResult{PIIType: "EMAIL", Category: "PII", RiskLevel: "HIGH"}
+	tests := []struct {
+		name     string
+		apiData  db.UserAPIData
+		findings []PIIDetectionResult
+		want     []string // PIIType:RiskLevel of the kept findings
+	}{
+		{
+			name:     "internal device finding suppressed",
+			apiData:  db.UserAPIData{Source: "internal", APIEndpoint: "/api/devices"},
+			findings: []PIIDetectionResult{device, email},
+			want:     []string{"EMAIL:HIGH"},
+		},
+		{
+			name:     "external traffic untouched",
+			apiData:  db.UserAPIData{Source: "edge", APIEndpoint: "/api/devices"},
+			findings: []PIIDetectionResult{device, email},
+			want:     []string{"MAC_ADDRESS:MEDIUM", "EMAIL:HIGH"},
+		},
+		{
+			name:     "downgraded on matching endpoint",
+			apiData:  db.UserAPIData{Source: "edge", APIEndpoint: "/healthz"},
+			findings: []PIIDetectionResult{email},
+			want:     []string{"EMAIL:LOW"},
+		},
+		{
+			name:     "downgrade never raises risk",
+			apiData:  db.UserAPIData{Source: "edge", APIEndpoint: "/healthz"},
+			findings: []PIIDetectionResult{{PIIType: "AGE", Category: "QUASI_IDENTIFIER", RiskLevel: "LOW"}},
+			want:     []string{"AGE:LOW"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := append([]PIIDetectionResult{}, tt.findings...)
+			kept := s.applySuppressionRules(tt.apiData, findings)
+			var got []string
+			for _, f := range kept {
+				got = append(got, f.PIIType+":"+f.RiskLevel)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("kept %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("kept %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSuppressionRule(t *testing.T) {
+	s := newTestPIIService(t)
+	tests := []struct {
+		name    string
+		rule    SuppressionRule
+		wantErr bool
+	}{
+		{name: "suppress known category", rule: SuppressionRule{Name: "r", Categories: []string{"DEVICE"}, Action: suppressionActionSuppress}},
+		{name: "downgrade to known level", rule: SuppressionRule{Name: "r", Action: suppressionActionDowngrade, RiskLevel: "LOW"}},
+		{name: "unknown category", rule: SuppressionRule{Name: "r", Categories: []string{"NETWORK"}, Action: suppressionActionSuppress}, wantErr: true},
+		{name: "unknown action", rule: SuppressionRule{Name: "r", Action: "ignore"}, wantErr: true},
+		{name: "downgrade to unknown level", rule: SuppressionRule{Name: "r", Action: suppressionActionDowngrade, RiskLevel: "TRIVIAL"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateSuppressionRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSuppressionRule() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShippedSuppressionRulesLoad(t *testing.T) {
+	s := newTestPIIService(t)
+	if len(s.suppressionRules) == 0 {
+		t.Fatal("config/suppression_rules.json loaded no rules")
+	}
+}
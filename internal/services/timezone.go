@@ -0,0 +1,36 @@
+package services
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	reportLocationOnce sync.Once
+	reportLocation     *time.Location
+)
+
+// ReportLocation is the display timezone for reports, from REPORT_TIMEZONE
+// (an IANA name such as "Asia/Kolkata"), defaulting to UTC.
+//
+// Timestamps are always stored in UTC; ingest converts NJS unix seconds and
+// Filebeat's zoned @timestamp alike. Only presentation and day bucketing use
+// this location, so a day boundary falls at local midnight, DST included.
+func ReportLocation() *time.Location {
+	reportLocationOnce.Do(func() {
+		reportLocation = time.UTC
+		name := os.Getenv("REPORT_TIMEZONE")
+		if name == "" {
+			return
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Warning: Invalid REPORT_TIMEZONE '%s', using UTC: %v", name, err)
+			return
+		}
+		reportLocation = loc
+	})
+	return reportLocation
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingServiceName names RAVEN in traces unless OTEL_SERVICE_NAME is set.
+const TracingServiceName = "raven-backend"
+
+// tracer records the ingest spans. It follows the global provider, so spans
+// are dropped until SetupTracing installs an exporting one.
+var tracer = otel.Tracer("github.com/RavenSec10/Raven_Backend/internal/services")
+
+// SetupTracing exports traces over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set; the exporter reads the other
+// OTEL_EXPORTER_OTLP_* variables itself. Otherwise tracing stays a no-op.
+// The returned function flushes and stops the exporter.
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	provider, err := newTracerProvider(ctx)
+	if err != nil || provider == nil {
+		return func(context.Context) error { return nil }, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	log.Println("Exporting traces over OTLP")
+	return provider.Shutdown, nil
+}
+
+// newTracerProvider returns an OTLP-exporting provider, or nil when no
+// endpoint is configured.
+func newTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return nil, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(TracingServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// Span attributes. Spans carry the endpoint, method and finding count, never
+// detected values.
+const (
+	attrEndpoint = attribute.Key("raven.api.endpoint")
+	attrMethod   = attribute.Key("http.request.method")
+	attrPIICount = attribute.Key("raven.pii_count")
+)
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorderOnce sync.Once
+	spanRecorder     *tracetest.SpanRecorder
+)
+
+// recordSpans starts a root span on a recording provider and returns its
+// context and a function listing the spans ended in its trace. The global
+// provider can only be replaced once, so all tests share the recorder.
+func recordSpans(t *testing.T) (context.Context, func() []sdktrace.ReadOnlySpan) {
+	t.Helper()
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	ctx, root := otel.Tracer("test").Start(context.Background(), t.Name())
+	t.Cleanup(func() { root.End() })
+	traceID := root.SpanContext().TraceID()
+	return ctx, func() []sdktrace.ReadOnlySpan {
+		var spans []sdktrace.ReadOnlySpan
+		for _, span := range spanRecorder.Ended() {
+			if span.SpanContext().TraceID() == traceID {
+				spans = append(spans, span)
+			}
+		}
+		return spans
+	}
+}
+
+func spanNamed(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		tracesURL    string
+		wantProvider bool
+	}{
+		{name: "unconfigured", wantProvider: false},
+		{name: "endpoint", endpoint: "http://127.0.0.1:4318", wantProvider: true},
+		{name: "traces endpoint", tracesURL: "http://127.0.0.1:4318/v1/traces", wantProvider: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.endpoint)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", tt.tracesURL)
+			provider, err := newTracerProvider(context.Background())
+			if err != nil {
+				t.Fatalf("newTracerProvider: %v", err)
+			}
+			if got := provider != nil; got != tt.wantProvider {
+				t.Fatalf("provider created = %t, want %t", got, tt.wantProvider)
+			}
+			if provider != nil {
+				if err := provider.Shutdown(context.Background()); err != nil {
+					t.Errorf("Shutdown: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupTracingUnconfiguredIsNoop(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	before := otel.GetTracerProvider()
+	shutdown, err := SetupTracing(context.Background())
+	if err != nil {
+		t.Fatalf("SetupTracing: %v", err)
+	}
+	if otel.GetTracerProvider() != before {
+		t.Error("SetupTracing replaced the global provider without an endpoint")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
+
+func TestIngestSpans(t *testing.T) {
+	const email = "jane.doe@example.com"
+	s := newTestPIIService(t)
+	pipeline := &IngestPipeline{
+		piiService: s,
+		owners:     &OwnerResolver{},
+		sampler:    newResponseSampler(),
+		// Storing nothing keeps the ingest off the database.
+		policy: StorePolicy{MinRisk: "CRITICAL"},
+	}
+	ctx, ended := recordSpans(t)
+	result, err := pipeline.Ingest(ctx, KafkaLogMessage{
+		Method:         "POST",
+		Path:           "/api/users",
+		Host:           "api.example.com",
+		StatusCode:     "201",
+		RequestPayload: `{"email":"` + email + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if result.Stored || !result.HasPII {
+		t.Fatalf("Ingest result = %+v, want PII found and not stored", result)
+	}
+
+	spans := ended()
+	for _, name := range []string{"ingest", "ingest.map", "pii.analyze"} {
+		if spanNamed(spans, name) == nil {
+			t.Errorf("no %q span recorded", name)
+		}
+	}
+	for _, name := range []string{"ingest", "pii.analyze"} {
+		span := spanNamed(spans, name)
+		if span == nil {
+			continue
+		}
+		if v, ok := spanAttribute(span, attrEndpoint); !ok || v.AsString() != "/api/users" {
+			t.Errorf("%s endpoint = %v, want /api/users", name, v.Emit())
+		}
+		if v, ok := spanAttribute(span, attrMethod); !ok || v.AsString() != "POST" {
+			t.Errorf("%s method = %v, want POST", name, v.Emit())
+		}
+		if v, ok := spanAttribute(span, attrPIICount); !ok || v.AsInt64() == 0 {
+			t.Errorf("%s pii_count = %v, want findings", name, v.Emit())
+		}
+	}
+	for _, span := range spans {
+		for _, kv := range span.Attributes() {
+			if strings.Contains(kv.Value.Emit(), email) {
+				t.Errorf("span %s attribute %s carries the raw value", span.Name(), kv.Key)
+			}
+		}
+	}
+}
+
+func TestEndSpanRecordsError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantError bool
+	}{
+		{name: "success", err: nil, wantError: false},
+		{name: "failure", err: ErrMalformedLog, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ended := recordSpans(t)
+			_, span := tracer.Start(ctx, "op")
+			endSpan(span, tt.err)
+			got := spanNamed(ended(), "op")
+			if got == nil {
+				t.Fatal("span was not ended")
+			}
+			if isError := got.Status().Code == codes.Error; isError != tt.wantError {
+				t.Errorf("error status = %t, want %t", isError, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RavenSec10/Raven_Backend/db"
+)
+
+func TestAnalyzeURLDecoding(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want []string // type|location|field|value
+	}{
+		{
+			name: "encoded plus in a query value",
+			url:  "https://api.example.com/search?email=jane%2Btag@example.com&q=a+b",
+			want: []string{"EMAIL|query_params|email|jane+tag@example.com"},
+		},
+		{
+			name: "plus as space in a query value",
+			url:  "https://api.example.com/search?phone=%2B1+415+555+0100",
+			want: []string{"PHONE|query_params|phone|+1 415 555 0100"},
+		},
+		{
+			name: "plus in the host",
+			url:  "https://a+b.example.com/email/jane@example.com",
+			want: []string{"EMAIL|url_path|email|jane@example.com"},
+		},
+		{
+			name: "encoded slash stays in its segment",
+			url:  "https://api.example.com/docs/a%2Fb/123-45-6789",
+			want: []string{
+				"US_SSN|url_path|url_path_segment|123-45-6789",
+				"US_SSN|url_path|url_segment_3|123-45-6789",
+			},
+		},
+		{
+			name: "encoded slash doesn't split a field name segment",
+			url:  "https://api.example.com/ssn%2Fv2/123-45-6789",
+			want: []string{
+				"US_SSN|url_path|url_path_segment|123-45-6789",
+				"US_SSN|url_path|url_segment_2|123-45-6789",
+			},
+		},
+		{
+			name: "escaped path segment decoded once",
+			url:  "https://api.example.com/email/jane%40example.com?ref=jane%2540example.com",
+			want: []string{"EMAIL|url_path|email|jane@example.com"},
+		},
+		{
+			name: "token in the fragment",
+			url:  "https://app.example.com/callback#access_token=eyJhbGciOiJIUzI1NiJ9.e30.sig&state=xyz",
+			want: []string{"CREDENTIAL_KEYWORDS|url_fragment|access_token|eyJhbGciOiJIUzI1NiJ9.e30.sig"},
+		},
+		{
+			name: "session id in a matrix parameter",
+			url:  "https://shop.example.com/cart;jsessionid=r2t5uvjq435r4q7ib3vtdjq120/items?page=2",
+			want: []string{"SESSION_ID|matrix_param|jsessionid|r2t5uvjq435r4q7ib3vtdjq120"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			result := PIIAnalysisResult{modes: s.detectionModesForSource(""), Findings: []PIIDetectionResult{}}
+			s.analyzeURL(tt.url, &result)
+			var got []string
+			for _, f := range result.Findings {
+				got = append(got, f.PIIType+"|"+f.Location+"|"+f.FieldName+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHeaderURLs(t *testing.T) {
+	s := newTestPIIService(t)
+	s.maskingDisabled = true
+	result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+		APIEndpoint: "/login",
+		Method:      "POST",
+		URL:         "https://api.example.com/login",
+		RequestHeaders: map[string]string{
+			"Referer": "https://app.example.com/reset?access_token=eyJhbGciOiJIUzI1NiJ9.e30.sig&step=2",
+			// A Location request header is not a redirect.
+			"Location": "https://app.example.com/next?email=john@example.com",
+		},
+		ResponseHeaders: map[string]string{"Location": "https://app.example.com/welcome?email=jane@example.com"},
+	})
+	var got []string
+	for _, f := range result.Findings {
+		if f.Location == "referer" || f.Location == "redirect_url" {
+			got = append(got, f.PIIType+"|"+f.Location+"|"+f.FieldName+"|"+f.DetectedValue)
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"CREDENTIAL_KEYWORDS|referer|access_token|eyJhbGciOiJIUzI1NiJ9.e30.sig",
+		"EMAIL|redirect_url|email|jane@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("header URL findings = %q, want %q", got, want)
+	}
+}
+
+func TestAnalyzePseudoHeaderURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    []string // type|location|field|value
+	}{
+		{
+			name:    "token in the :path query",
+			headers: map[string]string{":method": "GET", ":path": "/v1/orders?access_token=eyJhbGciOiJIUzI1NiJ9.e30.sig&page=2"},
+			want:    []string{"CREDENTIAL_KEYWORDS|pseudo_path|access_token|eyJhbGciOiJIUzI1NiJ9.e30.sig"},
+		},
+		{
+			name: "credentials in the :authority userinfo",
+			headers: map[string]string{
+				":scheme":    "https",
+				":authority": "jane:hunter2@api.example.com",
+				":path":      "/v1/orders",
+			},
+			want: []string{
+				"PASSWORD|pseudo_path|password|hunter2",
+				"USERNAME|pseudo_path|username|jane",
+			},
+		},
+		{
+			name:    "email in the :authority userinfo without a :path",
+			headers: map[string]string{":authority": "jane%40example.com@api.example.com"},
+			want:    []string{"CREDENTIAL_KEYWORDS|pseudo_path|username|jane@example.com"},
+		},
+		{
+			name:    "plain :authority",
+			headers: map[string]string{":authority": "api.example.com", ":path": "*"},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPIIService(t)
+			s.maskingDisabled = true
+			result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+				APIEndpoint:    "/v1/orders",
+				Method:         "GET",
+				URL:            "https://api.example.com/v1/orders",
+				RequestHeaders: tt.headers,
+			})
+			var got []string
+			for _, f := range result.Findings {
+				got = append(got, f.PIIType+"|"+f.Location+"|"+f.FieldName+"|"+f.DetectedValue)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTrailers(t *testing.T) {
+	s := newTestPIIService(t)
+	s.maskingDisabled = true
+	result := s.AnalyzePIIInAPIData(context.Background(), db.UserAPIData{
+		APIEndpoint:      "/grpc.Users/Get",
+		Method:           "POST",
+		URL:              "https://api.example.com/grpc.Users/Get",
+		StatusCode:       500,
+		RequestTrailers:  map[string]string{"X-User-Email": "jane@example.com"},
+		ResponseTrailers: map[string]string{"Grpc-Message": "no account for jane@example.com", "X-Owner-Email": "john@example.com"},
+	})
+	var got []string
+	for _, f := range result.Findings {
+		got = append(got, f.PIIType+"|"+f.Location+"|"+f.FieldName+"|"+f.DetectedValue)
+	}
+	sort.Strings(got)
+	want := []string{
+		"EMAIL|request_trailers|X-User-Email|jane@example.com",
+		"EMAIL|response_trailers|X-Owner-Email|john@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trailer findings = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// validators holds checksum checks that patterns opt into via "validate" in
+// regexpii.json. A regex match is only reported when its validator accepts it.
+var validators = map[string]func(string) bool{
+	"aba_routing":      validateABARouting,
+	"btc_base58check":  validateBase58Check,
+	"btc_bech32":       validateBech32,
+	"eip55":            validateEIP55,
+	"luhn":             validateLuhn,
+	"obfuscated_email": validateObfuscatedEmail,
+}
+
+// passesValidation reports whether a match satisfies the pattern's validator.
+// Patterns without a validator, or naming an unknown one, always pass.
+func passesValidation(validatorName, value string) bool {
+	if validatorName == "" {
+		return true
+	}
+	validate, ok := validators[validatorName]
+	if !ok {
+		return true
+	}
+	return validate(value)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// validateBase58Check decodes a base58 string and verifies its trailing
+// 4-byte double-SHA256 checksum, as used by legacy Bitcoin addresses.
+func validateBase58Check(value string) bool {
+	num := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range value {
+		idx := strings.IndexRune(base58Alphabet, r)
+		if idx == -1 {
+			return false
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(idx)))
+	}
+	decoded := num.Bytes()
+	for _, r := range value {
+		if r != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 5 {
+		return false
+	}
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return bytes.Equal(second[:4], checksum)
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// validateBech32 verifies a bech32 or bech32m checksum (BIP-173 / BIP-350).
+func validateBech32(value string) bool {
+	if strings.ToLower(value) != value && strings.ToUpper(value) != value {
+		return false
+	}
+	value = strings.ToLower(value)
+	sep := strings.LastIndexByte(value, '1')
+	if sep < 1 || sep+7 > len(value) {
+		return false
+	}
+	hrp, data := value[:sep], value[sep+1:]
+
+	values := make([]int, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, int(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, int(c)&31)
+	}
+	for _, c := range data {
+		idx := strings.IndexRune(bech32Charset, c)
+		if idx == -1 {
+			return false
+		}
+		values = append(values, idx)
+	}
+
+	generator := []int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	const bech32Const, bech32mConst = 1, 0x2bc830a3
+	return chk == bech32Const || chk == bech32mConst
+}
+
+// validateEIP55 accepts all-lowercase or all-uppercase Ethereum addresses, and
+// mixed-case addresses only when their capitalization matches the EIP-55 checksum.
+func validateEIP55(value string) bool {
+	addr := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if len(addr) != 40 {
+		return false
+	}
+	if _, err := hex.DecodeString(addr); err != nil {
+		return false
+	}
+	if addr == strings.ToLower(addr) || addr == strings.ToUpper(addr) {
+		return true
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(strings.ToLower(addr)))
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	for i, c := range addr {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		upper := hash[i] >= '8'
+		if upper != (c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateABARouting verifies the check digit of a 9-digit US ABA routing
+// number: weights 3, 7, 1 repeat across the digits and the sum is a multiple of 10.
+func validateABARouting(value string) bool {
+	if len(value) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// validateLuhn verifies the Luhn check digit of a digit string, ignoring
+// spaces and dashes. It is used for IMEIs.
+func validateLuhn(value string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if len(digits) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+var (
+	emailObfuscationAt  = regexp.MustCompile(`(?i)\s*(?:\[at\]|\(at\)|\{at\}|\sat\s)\s*`)
+	emailObfuscationDot = regexp.MustCompile(`(?i)\s*(?:\[dot\]|\(dot\)|\{dot\}|\sdot\s)\s*`)
+	plainEmail          = regexp.MustCompile(`(?i)^[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}$`)
+)
+
+// validateObfuscatedEmail rewrites "[at]"/"(at)"/" at " and the matching "dot"
+// forms back to '@' and '.', then requires a well-formed email. Plain emails
+// are rejected so they are only reported by the regular EMAIL pattern. A bare
+// " at " is ordinary English ("look at example.com"), so it only counts when
+// the dots are obfuscated too.
+func validateObfuscatedEmail(value string) bool {
+	if strings.Contains(value, "@") {
+		return false
+	}
+	lower := strings.ToLower(value)
+	bracketedAt := strings.Contains(lower, "[at]") || strings.Contains(lower, "(at)") || strings.Contains(lower, "{at}")
+	if !bracketedAt && !emailObfuscationDot.MatchString(value) {
+		return false
+	}
+	normalized := emailObfuscationAt.ReplaceAllString(value, "@")
+	normalized = emailObfuscationDot.ReplaceAllString(normalized, ".")
+	return plainEmail.MatchString(normalized)
+}
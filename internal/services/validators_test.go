@@ -0,0 +1,75 @@
+package services
+
+import "testing"
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		validator string
+		value     string
+		want      bool
+	}{
+		{validator: "btc_base58check", value: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", want: true},
+		{validator: "btc_base58check", value: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", want: true},
+		{validator: "btc_base58check", value: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", want: false},
+		{validator: "btc_base58check", value: "1A1zP1eP5QGefi2DMPTfTL5SLmv7Div0Na", want: false},
+		{validator: "btc_bech32", value: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", want: true},
+		{validator: "btc_bech32", value: "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", want: true},
+		{validator: "btc_bech32", value: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", want: true},
+		{validator: "btc_bech32", value: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdp", want: false},
+		{validator: "btc_bech32", value: "bc1qAR0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", want: false},
+		{validator: "eip55", value: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", want: true},
+		{validator: "eip55", value: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", want: true},
+		{validator: "eip55", value: "0x5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAed", want: false},
+		{validator: "eip55", value: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", want: false},
+		{validator: "luhn", value: "4111 1111 1111 1111", want: true},
+		{validator: "luhn", value: "490154203237518", want: true},
+		{validator: "luhn", value: "4111111111111112", want: false},
+		{validator: "aba_routing", value: "021000021", want: true},
+		{validator: "aba_routing", value: "021000022", want: false},
+		{validator: "aba_routing", value: "02100002", want: false},
+		{validator: "obfuscated_email", value: "john [at] example [dot] com", want: true},
+		{validator: "obfuscated_email", value: "john at example dot com", want: true},
+		{validator: "obfuscated_email", value: "john@example.com", want: false},
+		{validator: "obfuscated_email", value: "look at example.com", want: false},
+		{validator: "", value: "anything", want: true},
+		{validator: "unknown", value: "anything", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.validator+"/"+tt.value, func(t *testing.T) {
+			if got := passesValidation(tt.validator, tt.value); got != tt.want {
+				t.Errorf("passesValidation(%q, %q) = %t, want %t", tt.validator, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectWalletAddresses(t *testing.T) {
+	s := newTestPIIService(t)
+	modes := detectionModes{valueOnly: true}
+	tests := []struct {
+		name     string
+		text     string
+		wantType string
+	}{
+		{name: "legacy bitcoin", text: "pay 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa now", wantType: "BTC_ADDRESS"},
+		{name: "corrupted legacy bitcoin", text: "pay 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb now", wantType: ""},
+		{name: "bech32 bitcoin", text: "pay bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq now", wantType: "BTC_BECH32_ADDRESS"},
+		{name: "corrupted bech32 bitcoin", text: "pay bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdp now", wantType: ""},
+		{name: "ethereum", text: "pay 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed now", wantType: "ETH_ADDRESS"},
+		{name: "corrupted ethereum", text: "pay 0x5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAed now", wantType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := s.detectPIIInText(modes, "", tt.text, "request_body")
+			for _, piiType := range []string{"BTC_ADDRESS", "BTC_BECH32_ADDRESS", "ETH_ADDRESS"} {
+				want := 0
+				if piiType == tt.wantType {
+					want = 1
+				}
+				if got := countFindings(findings, piiType); got != want {
+					t.Errorf("%s findings = %d, want %d", piiType, got, want)
+				}
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/RavenSec10/Raven_Backend/db"
 	"github.com/RavenSec10/Raven_Backend/internal/routes"
 	"github.com/RavenSec10/Raven_Backend/internal/services"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
@@ -26,6 +27,12 @@ func main() {
 
 	defer mongoInstance.CloseDB(ctx)
 
+	// Tracing is set up after ConnectDB has loaded .env.
+	shutdownTracing, err := services.SetupTracing(ctx)
+	if err != nil {
+		log.Printf("Error setting up tracing, traces are not exported: %v", err)
+	}
+
 	piiService, err := services.NewPIIService(mongoInstance)
 	if err != nil {
 		log.Fatalf("Failed to initialize PII service: %v", err)
@@ -34,13 +41,25 @@ func main() {
 	kafkaBrokerAddress := "localhost:9093"
 	kafkaTopic := "api_logs"
 	kafkaGroupID := "raven-backend-consumer-group"
-	kafkaConsumerService := services.NewKafkaConsumerService(kafkaBrokerAddress, kafkaTopic, kafkaGroupID, piiService, mongoInstance)
+	esSink := services.NewElasticsearchSinkFromEnv()
+	go esSink.Start(ctx)
+
+	storePolicy := services.NewStorePolicyFromEnv()
+	if err := storePolicy.Validate(piiService); err != nil {
+		log.Fatalf("Invalid store policy: %v", err)
+	}
+	ingestPipeline := services.NewIngestPipeline(piiService, mongoInstance, esSink, storePolicy)
+	kafkaConsumerService := services.NewKafkaConsumerService(kafkaBrokerAddress, kafkaTopic, kafkaGroupID, ingestPipeline)
+	go ingestPipeline.StartBackfill(ctx)
+	go piiService.StartFeedbackCalibration(ctx)
+	go services.NewSpikeDetectorFromEnv(mongoInstance).Start(ctx)
 
 	go kafkaConsumerService.Start(ctx)
 
 	router := gin.Default()
+	router.Use(otelgin.Middleware(services.TracingServiceName))
 
-	routes.SetupRoutes(router, mongoInstance)
+	routes.SetupRoutes(router, mongoInstance, piiService, kafkaConsumerService, ingestPipeline)
 
 	srv := &http.Server{
 		Addr:    ":7000",
@@ -68,6 +87,10 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	esSink.Wait(shutdownCtx)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Error flushing traces: %v", err)
+	}
 
 	log.Println("Server and Kafka consumer exited properly.")
 }
\ No newline at end of file